@@ -0,0 +1,189 @@
+// Package broker interacts with a Pact Broker (or PactFlow), e.g. to compare
+// locally generated contracts with those already published.
+package broker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+)
+
+// Config identifies a contract and the broker it is published to
+type Config struct {
+	Consumer string
+	Provider string
+
+	// PactDir is the directory the local pact file was written to
+	PactDir string
+
+	// URL of the broker. Defaults to the PACT_BROKER_URL environment variable
+	BrokerURL string
+
+	// Token used to authenticate with the broker. Defaults to PACT_BROKER_TOKEN
+	BrokerToken string
+
+	// Username and password used to authenticate with the broker, if no token is set.
+	// Default to PACT_BROKER_USERNAME and PACT_BROKER_PASSWORD
+	BrokerUsername string
+	BrokerPassword string
+
+	// BrokerHTTPClient is used for requests to the broker, e.g. to configure TLS,
+	// proxies or timeouts. Defaults to http.DefaultClient
+	BrokerHTTPClient *http.Client
+
+	// RegistryCacheDir is a directory in which fetched contracts are cached, as
+	// <consumer>-<provider>.json, so they can be reused when the broker can't be
+	// reached. The directory may also be given to provider verification as a pact dir
+	RegistryCacheDir string
+
+	// RegistryCacheTTL is how long a cached contract is used without contacting the
+	// broker, an older contract is refreshed but still used if the broker can't be
+	// reached. Defaults to 24 hours
+	RegistryCacheTTL time.Duration
+
+	// ForceRefresh fetches the contract from the broker even if the cached copy is fresh,
+	// the cached copy is still used if the broker can't be reached
+	ForceRefresh bool
+}
+
+func (c *Config) validate() error {
+	c.BrokerURL = valueOrFromEnvironment(c.BrokerURL, "PACT_BROKER_URL")
+	c.BrokerToken = valueOrFromEnvironment(c.BrokerToken, "PACT_BROKER_TOKEN")
+	c.BrokerUsername = valueOrFromEnvironment(c.BrokerUsername, "PACT_BROKER_USERNAME")
+	c.BrokerPassword = valueOrFromEnvironment(c.BrokerPassword, "PACT_BROKER_PASSWORD")
+
+	if c.Consumer == "" || c.Provider == "" {
+		return fmt.Errorf("a consumer and provider must be specified")
+	}
+	if c.BrokerURL == "" {
+		return fmt.Errorf("a broker URL must be specified, or set with PACT_BROKER_URL")
+	}
+	if c.BrokerHTTPClient == nil {
+		c.BrokerHTTPClient = http.DefaultClient
+	}
+	if c.RegistryCacheTTL == 0 {
+		c.RegistryCacheTTL = defaultRegistryCacheTTL
+	}
+
+	return nil
+}
+
+// pactFile is the path of the pact file written by the consumer DSLs
+func (c Config) pactFile() string {
+	return filepath.Join(c.PactDir, fmt.Sprintf("%s-%s.json", c.Consumer, c.Provider))
+}
+
+// FetchLatest retrieves the latest version of the pact between the consumer and
+// provider from the broker. A nil pact is returned if none has been published.
+//
+// If a RegistryCacheDir is configured, a cached contract younger than RegistryCacheTTL
+// is returned without contacting the broker (unless ForceRefresh is set), and fetched
+// contracts are written to the cache. If the broker can't be reached, the cached contract
+// is returned however old it is, and an error only if there is no cached contract.
+func FetchLatest(config Config) (*pactfile.Pact, error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	var cached *pactfile.Pact
+	var age time.Duration
+	if config.RegistryCacheDir != "" {
+		var err error
+		cached, age, err = readCache(config)
+		if err != nil {
+			log.Println("[DEBUG] not using the registry cache:", err)
+		} else if age <= config.RegistryCacheTTL && !config.ForceRefresh {
+			log.Println("[DEBUG] using the cached contract", cacheFile(config))
+			return cached, nil
+		}
+	}
+
+	body, err := fetchLatest(config)
+	if err != nil {
+		if cached != nil {
+			log.Printf("[WARN] %v, using the cached contract %s fetched %s ago", err, cacheFile(config), age)
+			return cached, nil
+		}
+		if config.RegistryCacheDir != "" {
+			return nil, fmt.Errorf("%v (and no contract is available in the registry cache)", err)
+		}
+		return nil, err
+	}
+	if body == nil {
+		return nil, nil
+	}
+
+	p, err := pactfile.Parse(body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the published pact: %v", err)
+	}
+
+	if config.RegistryCacheDir != "" {
+		if err = writeCache(config, body); err != nil {
+			log.Println("[WARN] unable to write the registry cache:", err)
+		}
+	}
+
+	return p, nil
+}
+
+// fetchLatest retrieves the body of the latest pact, or nil if none has been published
+func fetchLatest(config Config) ([]byte, error) {
+	u := fmt.Sprintf("%s/pacts/provider/%s/consumer/%s/latest",
+		strings.TrimSuffix(config.BrokerURL, "/"), url.PathEscape(config.Provider), url.PathEscape(config.Consumer))
+	log.Println("[DEBUG] fetching published pact from", u)
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/hal+json, application/json")
+	authorize(req, config.BrokerToken, config.BrokerUsername, config.BrokerPassword)
+
+	res, err := config.BrokerHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to fetch the published pact: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the published pact: %v", err)
+	}
+
+	switch {
+	case res.StatusCode == http.StatusNotFound:
+		log.Printf("[DEBUG] no pact has been published between %s and %s", config.Consumer, config.Provider)
+		return nil, nil
+	case res.StatusCode >= 300:
+		return nil, fmt.Errorf("unable to fetch the published pact, the broker responded with %d: %s", res.StatusCode, body)
+	}
+
+	return body, nil
+}
+
+// authorize authenticates a request to the broker with the token, or else the username
+// and password
+func authorize(req *http.Request, token, username, password string) {
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	} else if username != "" {
+		req.SetBasicAuth(username, password)
+	}
+}
+
+func valueOrFromEnvironment(value string, envKey string) string {
+	if value != "" {
+		return value
+	}
+
+	return os.Getenv(envKey)
+}
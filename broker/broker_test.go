@@ -0,0 +1,30 @@
+package broker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchLatest_BrokerHTTPClient(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(publishedPact))
+	}))
+	defer server.Close()
+
+	config := Config{
+		Consumer:  "consumer",
+		Provider:  "provider",
+		BrokerURL: server.URL,
+	}
+
+	_, err := FetchLatest(config)
+	assert.Error(t, err)
+
+	config.BrokerHTTPClient = server.Client()
+	p, err := FetchLatest(config)
+	assert.NoError(t, err)
+	assert.Len(t, p.Messages, 2)
+}
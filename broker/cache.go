@@ -0,0 +1,42 @@
+package broker
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+)
+
+const defaultRegistryCacheTTL = 24 * time.Hour
+
+func cacheFile(config Config) string {
+	return filepath.Join(config.RegistryCacheDir, fmt.Sprintf("%s-%s.json", config.Consumer, config.Provider))
+}
+
+// readCache returns the cached contract, and how long ago it was fetched
+func readCache(config Config) (*pactfile.Pact, time.Duration, error) {
+	file := cacheFile(config)
+
+	info, err := os.Stat(file)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	p, err := pactfile.Read(file)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return p, time.Since(info.ModTime()).Round(time.Second), nil
+}
+
+func writeCache(config Config, body []byte) error {
+	if err := os.MkdirAll(config.RegistryCacheDir, os.ModePerm); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(cacheFile(config), body, 0644)
+}
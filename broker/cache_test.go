@@ -0,0 +1,94 @@
+package broker
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFetchLatest_RegistryCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "registry")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var requests int
+	online := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if !online {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(publishedPact))
+	}))
+	defer server.Close()
+
+	config := Config{
+		Consumer:         "consumer",
+		Provider:         "provider",
+		BrokerURL:        server.URL,
+		RegistryCacheDir: dir,
+	}
+
+	t.Run("fetched contracts are cached", func(t *testing.T) {
+		p, err := FetchLatest(config)
+		assert.NoError(t, err)
+		assert.Len(t, p.Messages, 2)
+		assert.Equal(t, 1, requests)
+		assert.FileExists(t, filepath.Join(dir, "consumer-provider.json"))
+	})
+
+	t.Run("fresh contracts are reused offline", func(t *testing.T) {
+		online = false
+		p, err := FetchLatest(config)
+		assert.NoError(t, err)
+		assert.Len(t, p.Messages, 2)
+		assert.Equal(t, 1, requests)
+	})
+
+	t.Run("force refresh contacts the broker", func(t *testing.T) {
+		forced := config
+		forced.ForceRefresh = true
+		p, err := FetchLatest(forced)
+		assert.NoError(t, err)
+		assert.Len(t, p.Messages, 2)
+		assert.Equal(t, 2, requests)
+	})
+
+	t.Run("stale contracts are used when the broker is down", func(t *testing.T) {
+		old := time.Now().Add(-48 * time.Hour)
+		assert.NoError(t, os.Chtimes(filepath.Join(dir, "consumer-provider.json"), old, old))
+
+		p, err := FetchLatest(config)
+		assert.NoError(t, err)
+		assert.Len(t, p.Messages, 2)
+		assert.Equal(t, 3, requests)
+	})
+
+	t.Run("stale contracts are refreshed", func(t *testing.T) {
+		online = true
+		p, err := FetchLatest(config)
+		assert.NoError(t, err)
+		assert.NotNil(t, p)
+		assert.Equal(t, 4, requests)
+
+		info, err := os.Stat(filepath.Join(dir, "consumer-provider.json"))
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), info.ModTime(), time.Minute)
+	})
+
+	t.Run("an error is returned without a cached contract", func(t *testing.T) {
+		online = false
+		empty := config
+		empty.RegistryCacheDir = filepath.Join(dir, "empty")
+		_, err := FetchLatest(empty)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "no contract is available in the registry cache")
+	})
+}
@@ -0,0 +1,228 @@
+package broker
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// CanIDeployRequest asks the broker whether a version of a pacticipant can be deployed
+// to an environment
+type CanIDeployRequest struct {
+	// Pacticipant is the name of the consumer or provider to deploy. Required
+	Pacticipant string
+
+	// Version of the pacticipant to deploy, e.g. a git sha. Required
+	Version string
+
+	// Environment to deploy to, e.g. "production". Required
+	Environment string
+
+	// URL of the broker. Defaults to the PACT_BROKER_URL environment variable
+	BrokerURL string
+
+	// Token used to authenticate with the broker. Defaults to PACT_BROKER_TOKEN
+	BrokerToken string
+
+	// Username and password used to authenticate with the broker, if no token is set.
+	// Default to PACT_BROKER_USERNAME and PACT_BROKER_PASSWORD
+	BrokerUsername string
+	BrokerPassword string
+
+	// BrokerHTTPClient is used for requests to the broker. Defaults to http.DefaultClient
+	BrokerHTTPClient *http.Client
+
+	// RetryWhileUnknown asks the broker again, up to this many times, while the result
+	// of a verification is still unknown, e.g. while the provider build is running
+	RetryWhileUnknown int
+
+	// RetryInterval is the time between retries. Defaults to 10 seconds
+	RetryInterval time.Duration
+}
+
+func (r *CanIDeployRequest) validate() error {
+	r.BrokerURL = valueOrFromEnvironment(r.BrokerURL, "PACT_BROKER_URL")
+	r.BrokerToken = valueOrFromEnvironment(r.BrokerToken, "PACT_BROKER_TOKEN")
+	r.BrokerUsername = valueOrFromEnvironment(r.BrokerUsername, "PACT_BROKER_USERNAME")
+	r.BrokerPassword = valueOrFromEnvironment(r.BrokerPassword, "PACT_BROKER_PASSWORD")
+
+	if r.Pacticipant == "" || r.Version == "" || r.Environment == "" {
+		return fmt.Errorf("a pacticipant, version and environment must be specified")
+	}
+	if r.BrokerURL == "" {
+		return fmt.Errorf("a broker URL must be specified, or set with PACT_BROKER_URL")
+	}
+	if r.BrokerHTTPClient == nil {
+		r.BrokerHTTPClient = http.DefaultClient
+	}
+	if r.RetryInterval == 0 {
+		r.RetryInterval = 10 * time.Second
+	}
+
+	return nil
+}
+
+// Notice is a message from the broker, e.g. explaining why a version can't be deployed
+type Notice struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// VerificationSummary counts the verifications the result of can-i-deploy is based on
+type VerificationSummary struct {
+	Success int `json:"success"`
+	Failed  int `json:"failed"`
+	Unknown int `json:"unknown"`
+}
+
+// MatrixRow is a pact between versions of a consumer and provider that affects the
+// deployment, with the result of its verification
+type MatrixRow struct {
+	Consumer        string
+	ConsumerVersion string
+	Provider        string
+	ProviderVersion string
+
+	// Verified is the result of the verification, or nil if the pact hasn't been verified
+	Verified *bool
+}
+
+// CanIDeployResult is the answer of the broker to can-i-deploy
+type CanIDeployResult struct {
+	// Deployable is true if the version may be deployed
+	Deployable bool
+
+	// Reason the version is, or isn't, deployable
+	Reason string
+
+	// Summary of the verifications
+	Summary VerificationSummary
+
+	// Notices give more detail, e.g. which verifications failed
+	Notices []Notice
+
+	// Matrix of the pacts considered
+	Matrix []MatrixRow
+}
+
+type canIDeployBody struct {
+	Summary struct {
+		Deployable *bool  `json:"deployable"`
+		Reason     string `json:"reason"`
+		VerificationSummary
+	} `json:"summary"`
+	Notices []Notice `json:"notices"`
+	Matrix  []struct {
+		Consumer struct {
+			Name    string `json:"name"`
+			Version struct {
+				Number string `json:"number"`
+			} `json:"version"`
+		} `json:"consumer"`
+		Provider struct {
+			Name    string `json:"name"`
+			Version struct {
+				Number string `json:"number"`
+			} `json:"version"`
+		} `json:"provider"`
+		VerificationResult *struct {
+			Success bool `json:"success"`
+		} `json:"verificationResult"`
+	} `json:"matrix"`
+}
+
+// CanIDeploy asks the broker whether the version of the pacticipant is compatible with
+// the versions of the other pacticipants deployed to the environment, e.g. to gate a
+// release pipeline:
+//
+//	res, err := broker.CanIDeploy(broker.CanIDeployRequest{
+//		Pacticipant: "OrderService",
+//		Version:     sha,
+//		Environment: "production",
+//	})
+//	if err == nil && !res.Deployable {
+//		log.Fatal(res.Reason)
+//	}
+//
+// An error is only returned if the broker could not be asked, not if the version isn't
+// deployable.
+func CanIDeploy(request CanIDeployRequest) (*CanIDeployResult, error) {
+	if err := request.validate(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		res, err := canIDeploy(request)
+		if err != nil || res.Summary.Unknown == 0 || attempt >= request.RetryWhileUnknown {
+			return res, err
+		}
+
+		log.Printf("[INFO] %d verification result(s) unknown, asking again in %s", res.Summary.Unknown, request.RetryInterval)
+		time.Sleep(request.RetryInterval)
+	}
+}
+
+// canIDeploy asks the broker once
+func canIDeploy(request CanIDeployRequest) (*CanIDeployResult, error) {
+	query := url.Values{
+		"pacticipant": {request.Pacticipant},
+		"version":     {request.Version},
+		"environment": {request.Environment},
+	}
+	u := strings.TrimSuffix(request.BrokerURL, "/") + "/can-i-deploy?" + query.Encode()
+	log.Println("[DEBUG] asking the broker", u)
+
+	req, err := http.NewRequest(http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/hal+json, application/json")
+	authorize(req, request.BrokerToken, request.BrokerUsername, request.BrokerPassword)
+
+	res, err := request.BrokerHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("unable to ask the broker: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the broker's response: %v", err)
+	}
+	if res.StatusCode >= 300 {
+		return nil, fmt.Errorf("unable to ask the broker, it responded with %d: %s", res.StatusCode, body)
+	}
+
+	var parsed canIDeployBody
+	if err = json.Unmarshal(body, &parsed); err != nil {
+		return nil, fmt.Errorf("unable to parse the broker's response: %v", err)
+	}
+
+	result := &CanIDeployResult{
+		// the broker gives no answer while verification results are unknown
+		Deployable: parsed.Summary.Deployable != nil && *parsed.Summary.Deployable,
+		Reason:     parsed.Summary.Reason,
+		Summary:    parsed.Summary.VerificationSummary,
+		Notices:    parsed.Notices,
+	}
+	for _, row := range parsed.Matrix {
+		r := MatrixRow{
+			Consumer:        row.Consumer.Name,
+			ConsumerVersion: row.Consumer.Version.Number,
+			Provider:        row.Provider.Name,
+			ProviderVersion: row.Provider.Version.Number,
+		}
+		if row.VerificationResult != nil {
+			success := row.VerificationResult.Success
+			r.Verified = &success
+		}
+		result.Matrix = append(result.Matrix, r)
+	}
+
+	return result, nil
+}
@@ -0,0 +1,110 @@
+package broker
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var deployable = `{
+  "summary": {"deployable": true, "reason": "All required verification results are present and successful", "success": 1, "failed": 0, "unknown": 0},
+  "notices": [{"type": "success", "text": "OrderService 1.0.0 can be deployed to production"}],
+  "matrix": [
+    {
+      "consumer": {"name": "OrderService", "version": {"number": "1.0.0"}},
+      "provider": {"name": "UserService", "version": {"number": "2.0.0"}},
+      "verificationResult": {"success": true}
+    }
+  ]
+}`
+
+var unknown = `{
+  "summary": {"deployable": null, "reason": "There is no verified pact", "success": 0, "failed": 0, "unknown": 1},
+  "matrix": [
+    {
+      "consumer": {"name": "OrderService", "version": {"number": "1.0.0"}},
+      "provider": {"name": "UserService", "version": {"number": "2.0.0"}},
+      "verificationResult": null
+    }
+  ]
+}`
+
+func TestCanIDeploy(t *testing.T) {
+	var query map[string][]string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/can-i-deploy", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		query = r.URL.Query()
+		w.Write([]byte(deployable))
+	}))
+	defer server.Close()
+
+	res, err := CanIDeploy(CanIDeployRequest{
+		Pacticipant: "OrderService",
+		Version:     "1.0.0",
+		Environment: "production",
+		BrokerURL:   server.URL,
+		BrokerToken: "token",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string][]string{"pacticipant": {"OrderService"}, "version": {"1.0.0"}, "environment": {"production"}}, query)
+	assert.True(t, res.Deployable)
+	assert.Equal(t, "All required verification results are present and successful", res.Reason)
+	assert.Equal(t, VerificationSummary{Success: 1}, res.Summary)
+	assert.Equal(t, []Notice{{Type: "success", Text: "OrderService 1.0.0 can be deployed to production"}}, res.Notices)
+	assert.Len(t, res.Matrix, 1)
+	assert.Equal(t, "UserService", res.Matrix[0].Provider)
+	assert.Equal(t, "2.0.0", res.Matrix[0].ProviderVersion)
+	assert.True(t, *res.Matrix[0].Verified)
+
+	t.Run("unknown results are retried", func(t *testing.T) {
+		calls := 0
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+			if calls < 3 {
+				w.Write([]byte(unknown))
+			} else {
+				w.Write([]byte(deployable))
+			}
+		}))
+		defer server.Close()
+
+		request := CanIDeployRequest{
+			Pacticipant:   "OrderService",
+			Version:       "1.0.0",
+			Environment:   "production",
+			BrokerURL:     server.URL,
+			RetryInterval: time.Millisecond,
+		}
+		res, err := CanIDeploy(request)
+		assert.NoError(t, err)
+		assert.False(t, res.Deployable)
+		assert.Equal(t, 1, res.Summary.Unknown)
+		assert.Nil(t, res.Matrix[0].Verified)
+
+		request.RetryWhileUnknown = 5
+		res, err = CanIDeploy(request)
+		assert.NoError(t, err)
+		assert.True(t, res.Deployable)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("broker errors", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("unknown pacticipant"))
+		}))
+		defer failing.Close()
+
+		_, err := CanIDeploy(CanIDeployRequest{Pacticipant: "OrderService", Version: "1.0.0", Environment: "production", BrokerURL: failing.URL})
+		assert.EqualError(t, err, "unable to ask the broker, it responded with 404: unknown pacticipant")
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		_, err := CanIDeploy(CanIDeployRequest{Pacticipant: "OrderService", Version: "1.0.0", BrokerURL: server.URL})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,87 @@
+package broker
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+)
+
+// DiffAgainstPublished compares the locally generated pact in config.PactDir with the
+// latest version published to the broker, returning a markdown summary of the changes
+// suitable for a pull request comment, e.g.
+//
+//	### Contract changes between consumer and provider
+//
+//	**Added interactions**
+//	- a user deleted event
+//
+//	**Changed interactions**
+//	- a user created event
+//	  - `contents $.email`: added
+func DiffAgainstPublished(config Config) (string, error) {
+	local, err := pactfile.Read(config.pactFile())
+	if err != nil {
+		return "", err
+	}
+
+	published, err := FetchLatest(config)
+	if err != nil {
+		return "", err
+	}
+	if published == nil {
+		published = &pactfile.Pact{}
+	}
+
+	return MarkdownDiff(config.Consumer, config.Provider, pactfile.Diff(published, local)), nil
+}
+
+// MarkdownDiff renders the changes to the contract between consumer and provider as markdown
+func MarkdownDiff(consumer, provider string, changes []pactfile.Change) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "### Contract changes between %s and %s\n", consumer, provider)
+
+	if len(changes) == 0 {
+		b.WriteString("\nNo changes.\n")
+		return b.String()
+	}
+
+	var added, removed []string
+	var changed []string
+	details := make(map[string][]string)
+	for _, c := range changes {
+		switch {
+		case c.Path == "" && c.Kind == pactfile.Added:
+			added = append(added, c.Interaction)
+		case c.Path == "" && c.Kind == pactfile.Removed:
+			removed = append(removed, c.Interaction)
+		default:
+			if _, ok := details[c.Interaction]; !ok {
+				changed = append(changed, c.Interaction)
+			}
+			detail := fmt.Sprintf("`%s`: %s", c.Path, c.Kind)
+			if c.Detail != "" {
+				detail = fmt.Sprintf("`%s`: %s", c.Path, c.Detail)
+			}
+			details[c.Interaction] = append(details[c.Interaction], detail)
+		}
+	}
+
+	list := func(title string, items []string) {
+		if len(items) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n**%s**\n", title)
+		for _, i := range items {
+			fmt.Fprintf(&b, "- %s\n", i)
+			for _, d := range details[i] {
+				fmt.Fprintf(&b, "  - %s\n", d)
+			}
+		}
+	}
+	list("Added interactions", added)
+	list("Removed interactions", removed)
+	list("Changed interactions", changed)
+
+	return b.String()
+}
@@ -0,0 +1,103 @@
+package broker
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const publishedPact = `{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "messages": [
+    {"description": "a user created event", "contents": {"id": 1, "name": "billy"}},
+    {"description": "a user renamed event", "contents": {"id": 1}}
+  ]
+}`
+
+const localPact = `{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "messages": [
+    {
+      "description": "a user created event",
+      "contents": {"id": 1, "name": "billy", "email": "billy@example.com"},
+      "matchingRules": {"body": {"$.name": {"combine": "AND", "matchers": [{"match": "type"}]}}}
+    },
+    {"description": "a user deleted event", "contents": {"id": 1}}
+  ]
+}`
+
+func TestDiffAgainstPublished(t *testing.T) {
+	dir, err := ioutil.TempDir("", "broker")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = ioutil.WriteFile(filepath.Join(dir, "consumer-provider.json"), []byte(localPact), 0644)
+	assert.NoError(t, err)
+
+	var published string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/pacts/provider/provider/consumer/consumer/latest", r.URL.Path)
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if published == "" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(published))
+	}))
+	defer server.Close()
+
+	config := Config{
+		Consumer:    "consumer",
+		Provider:    "provider",
+		PactDir:     dir,
+		BrokerURL:   server.URL,
+		BrokerToken: "token",
+	}
+
+	t.Run("changes are summarised", func(t *testing.T) {
+		published = publishedPact
+		diff, err := DiffAgainstPublished(config)
+		assert.NoError(t, err)
+		assert.Equal(t, `### Contract changes between consumer and provider
+
+**Added interactions**
+- a user deleted event
+
+**Removed interactions**
+- a user renamed event
+
+**Changed interactions**
+- a user created event
+  - `+"`contents $.email`"+`: added
+  - `+"`contents $.name`"+`: matcher changed from an exact value to type
+`, diff)
+	})
+
+	t.Run("unchanged contracts", func(t *testing.T) {
+		published = localPact
+		diff, err := DiffAgainstPublished(config)
+		assert.NoError(t, err)
+		assert.Contains(t, diff, "No changes.")
+	})
+
+	t.Run("unpublished contracts", func(t *testing.T) {
+		published = ""
+		diff, err := DiffAgainstPublished(config)
+		assert.NoError(t, err)
+		assert.Contains(t, diff, "- a user created event")
+		assert.Contains(t, diff, "- a user deleted event")
+	})
+
+	t.Run("a broker URL is required", func(t *testing.T) {
+		os.Unsetenv("PACT_BROKER_URL")
+		_, err := DiffAgainstPublished(Config{Consumer: "consumer", Provider: "provider", PactDir: dir})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,213 @@
+package broker
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+)
+
+// PublishRequest describes the pacts to publish to a broker, and the consumer version
+// they were generated by
+type PublishRequest struct {
+	// PactDirs are directories whose *.json pact files are published
+	PactDirs []string
+
+	// PactFiles are individual pact files to publish
+	PactFiles []string
+
+	// URL of the broker. Defaults to the PACT_BROKER_URL environment variable
+	BrokerURL string
+
+	// Token used to authenticate with the broker. Defaults to PACT_BROKER_TOKEN
+	BrokerToken string
+
+	// Username and password used to authenticate with the broker, if no token is set.
+	// Default to PACT_BROKER_USERNAME and PACT_BROKER_PASSWORD
+	BrokerUsername string
+	BrokerPassword string
+
+	// BrokerHTTPClient is used for requests to the broker. Defaults to http.DefaultClient
+	BrokerHTTPClient *http.Client
+
+	// ConsumerVersion is the version of the consumer the pacts were generated by,
+	// e.g. a git sha. Required
+	ConsumerVersion string
+
+	// Branch of the consumer version. Optional
+	Branch string
+
+	// Tags to apply to the consumer version. Optional
+	Tags []string
+
+	// BuildURL links the consumer version to the build that published it. Optional
+	BuildURL string
+}
+
+func (r *PublishRequest) validate() error {
+	r.BrokerURL = valueOrFromEnvironment(r.BrokerURL, "PACT_BROKER_URL")
+	r.BrokerToken = valueOrFromEnvironment(r.BrokerToken, "PACT_BROKER_TOKEN")
+	r.BrokerUsername = valueOrFromEnvironment(r.BrokerUsername, "PACT_BROKER_USERNAME")
+	r.BrokerPassword = valueOrFromEnvironment(r.BrokerPassword, "PACT_BROKER_PASSWORD")
+
+	if len(r.PactDirs) == 0 && len(r.PactFiles) == 0 {
+		return fmt.Errorf("no pacts to publish, PactDirs or PactFiles must be specified")
+	}
+	if r.BrokerURL == "" {
+		return fmt.Errorf("a broker URL must be specified, or set with PACT_BROKER_URL")
+	}
+	if r.ConsumerVersion == "" {
+		return fmt.Errorf("a consumer version must be specified")
+	}
+	if r.BrokerHTTPClient == nil {
+		r.BrokerHTTPClient = http.DefaultClient
+	}
+
+	return nil
+}
+
+// files returns the pact files to publish
+func (r PublishRequest) files() ([]string, error) {
+	files := append([]string{}, r.PactFiles...)
+	for _, dir := range r.PactDirs {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no pact files found in %s", dir)
+		}
+		files = append(files, matches...)
+	}
+
+	return files, nil
+}
+
+type publishContract struct {
+	ConsumerName  string `json:"consumerName"`
+	ProviderName  string `json:"providerName"`
+	Specification string `json:"specification"`
+	ContentType   string `json:"contentType"`
+	Content       string `json:"content"`
+}
+
+type publishBody struct {
+	PacticipantName          string            `json:"pacticipantName"`
+	PacticipantVersionNumber string            `json:"pacticipantVersionNumber"`
+	Branch                   string            `json:"branch,omitempty"`
+	Tags                     []string          `json:"tags,omitempty"`
+	BuildURL                 string            `json:"buildUrl,omitempty"`
+	Contracts                []publishContract `json:"contracts"`
+}
+
+// PublishPacts publishes the pact files to the broker as contracts of the consumer
+// version, e.g. from TestMain once the consumer tests have written them. The pacts of
+// each consumer are published together, with a request to the broker's
+// /contracts/publish endpoint.
+func PublishPacts(request PublishRequest) error {
+	if err := request.validate(); err != nil {
+		return err
+	}
+
+	files, err := request.files()
+	if err != nil {
+		return err
+	}
+
+	bodies := make(map[string]*publishBody)
+	for _, file := range files {
+		content, err := ioutil.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("unable to read pact file: %v", err)
+		}
+		p, err := pactfile.Parse(content)
+		if err != nil {
+			return fmt.Errorf("unable to parse pact file %s: %v", file, err)
+		}
+
+		b, ok := bodies[p.Consumer.Name]
+		if !ok {
+			b = &publishBody{
+				PacticipantName:          p.Consumer.Name,
+				PacticipantVersionNumber: request.ConsumerVersion,
+				Branch:                   request.Branch,
+				Tags:                     request.Tags,
+				BuildURL:                 request.BuildURL,
+			}
+			bodies[p.Consumer.Name] = b
+		}
+		b.Contracts = append(b.Contracts, publishContract{
+			ConsumerName:  p.Consumer.Name,
+			ProviderName:  p.Provider.Name,
+			Specification: "pact",
+			ContentType:   "application/json",
+			Content:       base64.StdEncoding.EncodeToString(content),
+		})
+	}
+
+	consumers := make([]string, 0, len(bodies))
+	for c := range bodies {
+		consumers = append(consumers, c)
+	}
+	sort.Strings(consumers)
+
+	for _, c := range consumers {
+		if err = publish(request, bodies[c]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// publish sends the contracts of a consumer version to the broker
+func publish(request PublishRequest, body *publishBody) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	u := strings.TrimSuffix(request.BrokerURL, "/") + "/contracts/publish"
+	log.Printf("[DEBUG] publishing %d pact(s) for %s version %s to %s", len(body.Contracts), body.PacticipantName, body.PacticipantVersionNumber, u)
+
+	req, err := http.NewRequest(http.MethodPost, u, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/hal+json, application/json")
+	authorize(req, request.BrokerToken, request.BrokerUsername, request.BrokerPassword)
+
+	res, err := request.BrokerHTTPClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to publish the pacts: %v", err)
+	}
+	defer res.Body.Close()
+
+	resBody, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read the broker's response: %v", err)
+	}
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("unable to publish the pacts, the broker responded with %d: %s", res.StatusCode, resBody)
+	}
+
+	var published struct {
+		Notices []Notice `json:"notices"`
+	}
+	if err = json.Unmarshal(resBody, &published); err == nil {
+		for _, n := range published.Notices {
+			log.Printf("[INFO] %s: %s", n.Type, n.Text)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,67 @@
+package broker
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPublishPacts(t *testing.T) {
+	var received publishBody
+	var auth string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/contracts/publish", r.URL.Path)
+		auth = r.Header.Get("Authorization")
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &received))
+		w.Write([]byte(`{"notices": [{"type": "success", "text": "published"}]}`))
+	}))
+	defer server.Close()
+
+	dir, _ := ioutil.TempDir("", "publish")
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "consumer-provider.json")
+	assert.NoError(t, ioutil.WriteFile(file, []byte(publishedPact), 0644))
+
+	err := PublishPacts(PublishRequest{
+		PactDirs:        []string{dir},
+		BrokerURL:       server.URL + "/",
+		BrokerToken:     "token",
+		ConsumerVersion: "1.0.0",
+		Branch:          "main",
+		Tags:            []string{"prod"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer token", auth)
+	assert.Equal(t, "consumer", received.PacticipantName)
+	assert.Equal(t, "1.0.0", received.PacticipantVersionNumber)
+	assert.Equal(t, "main", received.Branch)
+	assert.Equal(t, []string{"prod"}, received.Tags)
+	assert.Len(t, received.Contracts, 1)
+	assert.Equal(t, "provider", received.Contracts[0].ProviderName)
+	content, _ := base64.StdEncoding.DecodeString(received.Contracts[0].Content)
+	assert.Equal(t, publishedPact, string(content))
+
+	t.Run("broker errors", func(t *testing.T) {
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("bad version"))
+		}))
+		defer failing.Close()
+
+		err := PublishPacts(PublishRequest{PactFiles: []string{file}, BrokerURL: failing.URL, ConsumerVersion: "1.0.0"})
+		assert.EqualError(t, err, "unable to publish the pacts, the broker responded with 400: bad version")
+	})
+
+	t.Run("invalid requests", func(t *testing.T) {
+		assert.Error(t, PublishPacts(PublishRequest{BrokerURL: server.URL, ConsumerVersion: "1.0.0"}))
+		assert.Error(t, PublishPacts(PublishRequest{PactFiles: []string{file}, BrokerURL: server.URL}))
+	})
+}
@@ -0,0 +1,108 @@
+// Package codecs provides a registry of decoders used to turn message content
+// of a given content type (e.g. protobuf or MessagePack) into a value to pass
+// to message consumers.
+package codecs
+
+import (
+	"fmt"
+	"mime"
+	"strings"
+	"sync"
+)
+
+// Decoder converts the raw bytes of a message into a value
+type Decoder func(body []byte) (interface{}, error)
+
+// Registry maps content types to decoders. It is safe for concurrent use.
+type Registry struct {
+	mu       sync.RWMutex
+	decoders map[string]Decoder
+}
+
+// Default is the registry used for content types without a decoder in the registry
+// given to a pact, see Register
+var Default = NewRegistry()
+
+// Register sets the decoder for a content type in the Default registry, for all pacts
+func Register(contentType string, decoder Decoder) {
+	Default.Register(contentType, decoder)
+}
+
+// Lookup returns the decoder for the content type in r, or else in the Default registry
+func Lookup(r *Registry, contentType string) (Decoder, bool) {
+	if d, ok := r.Decoder(contentType); ok {
+		return d, true
+	}
+
+	return Default.Decoder(contentType)
+}
+
+// NewRegistry creates an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{
+		decoders: make(map[string]Decoder),
+	}
+}
+
+// Register sets the decoder for a content type, e.g. "application/x-protobuf".
+// Parameters such as charset are ignored.
+func (r *Registry) Register(contentType string, decoder Decoder) *Registry {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.decoders == nil {
+		r.decoders = make(map[string]Decoder)
+	}
+	r.decoders[normalise(contentType)] = decoder
+
+	return r
+}
+
+// Decoder returns the decoder registered for the content type. A structured syntax
+// suffix falls back to its base type, e.g. "application/vnd.user+json" uses the
+// decoder for "application/json" if it has none of its own.
+func (r *Registry) Decoder(contentType string) (Decoder, bool) {
+	if r == nil {
+		return nil, false
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	t := normalise(contentType)
+	if d, ok := r.decoders[t]; ok {
+		return d, true
+	}
+
+	if i := strings.LastIndex(t, "+"); i >= 0 {
+		if slash := strings.Index(t, "/"); slash >= 0 && slash < i {
+			d, ok := r.decoders[t[:slash+1]+t[i+1:]]
+			return d, ok
+		}
+	}
+
+	return nil, false
+}
+
+// Decode decodes the body with the decoder registered for the content type
+func (r *Registry) Decode(contentType string, body []byte) (interface{}, error) {
+	d, ok := r.Decoder(contentType)
+	if !ok {
+		return nil, fmt.Errorf("no decoder registered for content type '%s'", contentType)
+	}
+
+	v, err := d(body)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode %s content: %v", contentType, err)
+	}
+
+	return v, nil
+}
+
+func normalise(contentType string) string {
+	if t, _, err := mime.ParseMediaType(contentType); err == nil {
+		return t
+	}
+
+	return strings.ToLower(strings.TrimSpace(contentType))
+}
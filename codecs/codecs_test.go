@@ -0,0 +1,76 @@
+package codecs
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry().
+		Register("application/json", func(body []byte) (interface{}, error) {
+			var v interface{}
+			err := json.Unmarshal(body, &v)
+			return v, err
+		}).
+		Register("application/x-msgpack", func(body []byte) (interface{}, error) {
+			return nil, errors.New("bad msgpack")
+		})
+
+	t.Run("decodes by content type", func(t *testing.T) {
+		v, err := r.Decode("application/json; charset=utf-8", []byte(`{"id": 1}`))
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"id": float64(1)}, v)
+	})
+
+	t.Run("falls back from a structured syntax suffix", func(t *testing.T) {
+		_, ok := r.Decoder("application/vnd.user+json")
+		assert.True(t, ok)
+	})
+
+	t.Run("unregistered content types", func(t *testing.T) {
+		_, ok := r.Decoder("application/x-protobuf")
+		assert.False(t, ok)
+
+		_, err := r.Decode("application/x-protobuf", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("decoder errors", func(t *testing.T) {
+		_, err := r.Decode("application/x-msgpack", nil)
+		assert.EqualError(t, err, "unable to decode application/x-msgpack content: bad msgpack")
+	})
+
+	t.Run("nil registry", func(t *testing.T) {
+		var nilRegistry *Registry
+		_, ok := nilRegistry.Decoder("application/json")
+		assert.False(t, ok)
+	})
+}
+
+func TestLookup(t *testing.T) {
+	defer func(d *Registry) { Default = d }(Default)
+	Default = NewRegistry()
+
+	Register("application/avro", func(body []byte) (interface{}, error) {
+		return "default", nil
+	})
+	r := NewRegistry().Register("application/avro", func(body []byte) (interface{}, error) {
+		return "pact", nil
+	})
+
+	d, ok := Lookup(r, "application/avro")
+	assert.True(t, ok)
+	v, _ := d(nil)
+	assert.Equal(t, "pact", v)
+
+	d, ok = Lookup(nil, "application/avro")
+	assert.True(t, ok)
+	v, _ = d(nil)
+	assert.Equal(t, "default", v)
+
+	_, ok = Lookup(r, "text/csv")
+	assert.False(t, ok)
+}
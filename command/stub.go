@@ -0,0 +1,57 @@
+package command
+
+import (
+	"log"
+	"os"
+	"os/signal"
+
+	"github.com/pact-foundation/pact-go/v2/broker"
+	"github.com/pact-foundation/pact-go/v2/stub"
+
+	"github.com/spf13/cobra"
+)
+
+var stubConfig stub.Config
+var stubBrokerURL string
+var stubConsumer string
+var stubProvider string
+var stubCmd = &cobra.Command{
+	Use:   "stub",
+	Short: "Serve pact files as a stub API",
+	Long:  "Serve the interactions and messages of pact files as a stub API, until interrupted",
+	Run: func(cmd *cobra.Command, args []string) {
+		setLogLevel(verbose, logLevel)
+
+		if stubConsumer != "" || stubProvider != "" {
+			stubConfig.Brokers = append(stubConfig.Brokers, broker.Config{
+				Consumer:  stubConsumer,
+				Provider:  stubProvider,
+				BrokerURL: stubBrokerURL,
+			})
+		}
+
+		s, err := stub.Start(stubConfig)
+		if err != nil {
+			log.Println("[ERROR] unable to start the stub server:", err)
+			os.Exit(1)
+		}
+
+		interrupt := make(chan os.Signal, 1)
+		signal.Notify(interrupt, os.Interrupt)
+		<-interrupt
+
+		s.Close()
+	},
+}
+
+func init() {
+	stubCmd.Flags().StringSliceVarP(&stubConfig.PactFiles, "file", "f", nil, "Pact file to serve, may be repeated")
+	stubCmd.Flags().StringSliceVarP(&stubConfig.PactDirs, "dir", "d", nil, "Directory of pact files to serve, may be repeated")
+	stubCmd.Flags().StringVar(&stubBrokerURL, "broker-url", "", "URL of the broker to fetch the pact from. Defaults to PACT_BROKER_URL")
+	stubCmd.Flags().StringVar(&stubConsumer, "consumer", "", "Consumer of the pact to fetch from the broker")
+	stubCmd.Flags().StringVar(&stubProvider, "provider", "", "Provider of the pact to fetch from the broker")
+	stubCmd.Flags().StringVar(&stubConfig.Host, "host", "127.0.0.1", "Host to listen on")
+	stubCmd.Flags().IntVarP(&stubConfig.Port, "port", "p", 8080, "Port to listen on")
+	stubCmd.Flags().BoolVar(&stubConfig.CORS, "cors", false, "Allow the stub to be called from browsers on any origin")
+	RootCmd.AddCommand(stubCmd)
+}
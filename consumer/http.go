@@ -72,6 +72,27 @@ type MockHTTPProviderConfig struct {
 	// TLS enables a mock service behind a self-signed certificate
 	// TODO: document and test this
 	TLS bool
+
+	// TLSServerConfig serves the mock service with the given TLS configuration, e.g. a
+	// certificate issued by a custom CA, or ClientAuth to require client certificates,
+	// so that clients enforcing certificate pinning or mutual TLS can be tested
+	// unmodified. The configuration must provide a certificate. Can't be used with TLS.
+	// Optional
+	TLSServerConfig *tls.Config
+
+	// UnixSocketPath also serves the mock service on a Unix domain socket at this path,
+	// for clients that only speak over sockets, e.g. in containers. Any socket left at
+	// the path by an earlier run is replaced. Can be used with TLSServerConfig, but not TLS.
+	// Optional
+	UnixSocketPath string
+
+	// Environment the pact is generated in (e.g. "staging"), recorded in the pact file metadata.
+	// Optional
+	Environment string
+
+	// VerificationResultsFile is a file the VerificationResult of each test is appended to,
+	// as a line of JSON, so CI tooling can report the mismatches of a run. Optional
+	VerificationResultsFile string
 }
 
 // httpMockProvider is the entrypoint for http consumer tests
@@ -80,14 +101,20 @@ type httpMockProvider struct {
 	specificationVersion models.SpecificationVersion
 	config               MockHTTPProviderConfig
 	mockserver           *native.MockServer
+	tlsServer            *mockServerProxy
+	socketServer         *mockServerProxy
 }
 
 // MockServerConfig stores the address configuration details of the server for the current executing test
-// This is most useful for the use of OS assigned, dynamic ports and parallel tests
+// This is most useful for the use of OS assigned, dynamic ports and parallel tests.
+// TLSConfig trusts the self-signed certificate of a TLS mock server, and is nil when
+// the mock server is given a TLSServerConfig.
+// UnixSocketPath is the socket the mock server is also served on, if one was configured.
 type MockServerConfig struct {
-	Port      int
-	Host      string
-	TLSConfig *tls.Config
+	Port           int
+	Host           string
+	TLSConfig      *tls.Config
+	UnixSocketPath string
 }
 
 // configure validates the configuration for the consumer test
@@ -107,6 +134,14 @@ func (p *httpMockProvider) configure() error {
 		p.config.PactDir = filepath.Join(dir, "pacts")
 	}
 
+	if p.config.TLS && p.config.TLSServerConfig != nil {
+		return fmt.Errorf("TLS and TLSServerConfig can't both be set")
+	}
+
+	if p.config.TLS && p.config.UnixSocketPath != "" {
+		return fmt.Errorf("TLS can't be used with UnixSocketPath, use TLSServerConfig")
+	}
+
 	if p.config.ClientTimeout == 0 {
 		p.config.ClientTimeout = 10 * time.Second
 	}
@@ -120,6 +155,9 @@ func (p *httpMockProvider) configure() error {
 	case models.V4:
 		p.mockserver.WithSpecificationVersion(native.SPECIFICATION_VERSION_V4)
 	}
+	if p.config.Environment != "" {
+		p.mockserver.WithMetadata(models.MetadataNamespace, models.EnvironmentMetadataKey, p.config.Environment)
+	}
 	native.Init(string(logging.LogLevel()))
 
 	return nil
@@ -142,32 +180,59 @@ func (p *httpMockProvider) ExecuteTest(t *testing.T, integrationTest func(MockSe
 		return fmt.Errorf("error: unable to find free port, mock server will fail to start")
 	}
 
+	serverConfig := MockServerConfig{
+		Port: p.config.Port,
+		Host: p.config.Host,
+	}
+	if p.config.TLSServerConfig != nil {
+		// The mock server listens on a port of its own, behind the TLS server on the configured port
+		p.config.Port = 0
+	}
+
 	p.config.Port, err = p.mockserver.Start(fmt.Sprintf("%s:%d", p.config.Host, p.config.Port), p.config.TLS)
 	defer p.reset()
 	if err != nil {
 		return err
 	}
 
+	if p.config.TLSServerConfig != nil {
+		p.tlsServer, err = startTLSMockServer(fmt.Sprintf("%s:%d", p.config.Host, serverConfig.Port), fmt.Sprintf("%s:%d", p.config.Host, p.config.Port), p.config.TLSServerConfig)
+		if err != nil {
+			return err
+		}
+		serverConfig.Port = p.tlsServer.port
+	} else {
+		serverConfig.Port = p.config.Port
+		serverConfig.TLSConfig = GetTLSConfigForTLSMockServer()
+	}
+
+	if p.config.UnixSocketPath != "" {
+		p.socketServer, err = startUnixSocketMockServer(p.config.UnixSocketPath, fmt.Sprintf("%s:%d", p.config.Host, p.config.Port), p.config.TLSServerConfig)
+		if err != nil {
+			return err
+		}
+		serverConfig.UnixSocketPath = p.config.UnixSocketPath
+	}
+
 	// Run the integration test
-	err = integrationTest(MockServerConfig{
-		Port:      p.config.Port,
-		Host:      p.config.Host,
-		TLSConfig: GetTLSConfigForTLSMockServer(),
-	})
+	err = integrationTest(serverConfig)
 
 	res, mismatches := p.mockserver.Verify(p.config.Port, p.config.PactDir)
 	p.displayMismatches(t, mismatches)
 
-	if err != nil {
-		return err
+	result := newVerificationResult(t.Name(), mismatches)
+	if p.config.VerificationResultsFile != "" {
+		if writeErr := writeVerificationResult(p.config.VerificationResultsFile, result); writeErr != nil {
+			log.Println("[WARN] unable to write the verification result:", writeErr)
+		}
 	}
 
-	if !res {
-		return fmt.Errorf("pact validation failed: %+v %+v", res, mismatches)
+	if err != nil {
+		return err
 	}
 
-	if len(mismatches) > 0 {
-		return fmt.Errorf("pact validation failed: %+v", mismatches)
+	if !res || len(mismatches) > 0 {
+		return &VerificationError{Result: result}
 	}
 
 	p.mockserver.CleanupPlugins()
@@ -177,6 +242,14 @@ func (p *httpMockProvider) ExecuteTest(t *testing.T, integrationTest func(MockSe
 
 // Clear state between tests
 func (p *httpMockProvider) reset() {
+	if p.tlsServer != nil {
+		p.tlsServer.close()
+		p.tlsServer = nil
+	}
+	if p.socketServer != nil {
+		p.socketServer.close()
+		p.socketServer = nil
+	}
 	p.mockserver.CleanupMockServer(p.config.Port)
 	p.config.Port = 0
 	p.configure()
@@ -178,6 +178,15 @@ func (i *V2RequestBuilder) BinaryBody(body []byte) *V2RequestBuilder {
 	return i
 }
 
+// FormURLEncodedBody adds an application/x-www-form-urlencoded body to the expected request,
+// e.g. FormURLEncodedBody(map[string][]matchers.Matcher{"name": {matchers.S("billy")}}).
+// The fields are encoded with the example values of any matchers.
+func (i *V2RequestBuilder) FormURLEncodedBody(form map[string][]matchers.Matcher) *V2RequestBuilder {
+	i.interaction.interaction.WithRequestBody(formContentType, formURLEncoded(form))
+
+	return i
+}
+
 // MultipartBody adds a multipart  body to the expected request
 func (i *V2RequestBuilder) MultipartBody(contentType string, filename string, mimePartName string) *V2RequestBuilder {
 	i.interaction.interaction.WithRequestMultipartFile(contentType, filename, mimePartName)
@@ -281,6 +290,15 @@ func (i *V2ResponseBuilder) BinaryBody(body []byte) *V2ResponseBuilder {
 	return i
 }
 
+// FormURLEncodedBody adds an application/x-www-form-urlencoded body to the expected response,
+// e.g. FormURLEncodedBody(map[string][]matchers.Matcher{"name": {matchers.S("billy")}}).
+// The fields are encoded with the example values of any matchers.
+func (i *V2ResponseBuilder) FormURLEncodedBody(form map[string][]matchers.Matcher) *V2ResponseBuilder {
+	i.interaction.interaction.WithResponseBody(formContentType, formURLEncoded(form))
+
+	return i
+}
+
 // MultipartBody adds a multipart  body to the expected response
 func (i *V2ResponseBuilder) MultipartBody(contentType string, filename string, mimePartName string) *V2ResponseBuilder {
 	i.interaction.interaction.WithResponseMultipartFile(contentType, filename, mimePartName)
@@ -189,6 +189,23 @@ func (i *V3RequestBuilder) BinaryBody(body []byte) *V3RequestBuilder {
 	return i
 }
 
+// XMLBody adds an XML body to the expected request, whose elements, attributes and text
+// may be matched. See matchers.XMLDocument
+func (i *V3RequestBuilder) XMLBody(body matchers.XMLBody) *V3RequestBuilder {
+	i.interaction.interaction.WithRequestBody(matchers.XMLContentType, xmlBody(body))
+
+	return i
+}
+
+// FormURLEncodedBody adds an application/x-www-form-urlencoded body to the expected request,
+// e.g. FormURLEncodedBody(map[string][]matchers.Matcher{"name": {matchers.S("billy")}}).
+// The fields are encoded with the example values of any matchers.
+func (i *V3RequestBuilder) FormURLEncodedBody(form map[string][]matchers.Matcher) *V3RequestBuilder {
+	i.interaction.interaction.WithRequestBody(formContentType, formURLEncoded(form))
+
+	return i
+}
+
 // MultipartBody adds a multipart  body to the expected request
 func (i *V3RequestBuilder) MultipartBody(contentType string, filename string, mimePartName string) *V3RequestBuilder {
 	i.interaction.interaction.WithRequestMultipartFile(contentType, filename, mimePartName)
@@ -292,6 +309,23 @@ func (i *V3ResponseBuilder) BinaryBody(body []byte) *V3ResponseBuilder {
 	return i
 }
 
+// XMLBody adds an XML body to the expected response, whose elements, attributes and text
+// may be matched. See matchers.XMLDocument
+func (i *V3ResponseBuilder) XMLBody(body matchers.XMLBody) *V3ResponseBuilder {
+	i.interaction.interaction.WithResponseBody(matchers.XMLContentType, xmlBody(body))
+
+	return i
+}
+
+// FormURLEncodedBody adds an application/x-www-form-urlencoded body to the expected response,
+// e.g. FormURLEncodedBody(map[string][]matchers.Matcher{"name": {matchers.S("billy")}}).
+// The fields are encoded with the example values of any matchers.
+func (i *V3ResponseBuilder) FormURLEncodedBody(form map[string][]matchers.Matcher) *V3ResponseBuilder {
+	i.interaction.interaction.WithResponseBody(formContentType, formURLEncoded(form))
+
+	return i
+}
+
 // MultipartBody adds a multipart  body to the expected response
 func (i *V3ResponseBuilder) MultipartBody(contentType string, filename string, mimePartName string) *V3ResponseBuilder {
 	i.interaction.interaction.WithResponseMultipartFile(contentType, filename, mimePartName)
@@ -1,6 +1,7 @@
 package consumer
 
 import (
+	"fmt"
 	"log"
 	"testing"
 
@@ -190,6 +191,23 @@ func (i *V4RequestBuilder) BinaryBody(body []byte) *V4RequestBuilder {
 	return i
 }
 
+// XMLBody adds an XML body to the expected request, whose elements, attributes and text
+// may be matched. See matchers.XMLDocument
+func (i *V4RequestBuilder) XMLBody(body matchers.XMLBody) *V4RequestBuilder {
+	i.interaction.interaction.WithRequestBody(matchers.XMLContentType, xmlBody(body))
+
+	return i
+}
+
+// FormURLEncodedBody adds an application/x-www-form-urlencoded body to the expected request,
+// e.g. FormURLEncodedBody(map[string][]matchers.Matcher{"name": {matchers.S("billy")}}).
+// The fields are encoded with the example values of any matchers.
+func (i *V4RequestBuilder) FormURLEncodedBody(form map[string][]matchers.Matcher) *V4RequestBuilder {
+	i.interaction.interaction.WithRequestBody(formContentType, formURLEncoded(form))
+
+	return i
+}
+
 // MultipartBody adds a multipart  body to the expected request
 func (i *V4RequestBuilder) MultipartBody(contentType string, filename string, mimePartName string) *V4RequestBuilder {
 	i.interaction.interaction.WithRequestMultipartFile(contentType, filename, mimePartName)
@@ -293,6 +311,23 @@ func (i *V4ResponseBuilder) BinaryBody(body []byte) *V4ResponseBuilder {
 	return i
 }
 
+// XMLBody adds an XML body to the expected response, whose elements, attributes and text
+// may be matched. See matchers.XMLDocument
+func (i *V4ResponseBuilder) XMLBody(body matchers.XMLBody) *V4ResponseBuilder {
+	i.interaction.interaction.WithResponseBody(matchers.XMLContentType, xmlBody(body))
+
+	return i
+}
+
+// FormURLEncodedBody adds an application/x-www-form-urlencoded body to the expected response,
+// e.g. FormURLEncodedBody(map[string][]matchers.Matcher{"name": {matchers.S("billy")}}).
+// The fields are encoded with the example values of any matchers.
+func (i *V4ResponseBuilder) FormURLEncodedBody(form map[string][]matchers.Matcher) *V4ResponseBuilder {
+	i.interaction.interaction.WithResponseBody(formContentType, formURLEncoded(form))
+
+	return i
+}
+
 // MultipartBody adds a multipart  body to the expected response
 func (i *V4ResponseBuilder) MultipartBody(contentType string, filename string, mimePartName string) *V4ResponseBuilder {
 	i.interaction.interaction.WithResponseMultipartFile(contentType, filename, mimePartName)
@@ -334,25 +369,21 @@ type V4InteractionWithPlugin struct {
 }
 
 // UsingPlugin specifies the current interaction relies on one or more plugins for operation
-// If the plugin is not correctly installed, this method will terminate the test immediately with a non-zero status
+// If the plugin is not correctly installed, ExecuteTest returns the error
 func (i *V4UnconfiguredInteraction) UsingPlugin(config PluginConfig) *V4InteractionWithPlugin {
-	res := i.provider.mockserver.UsingPlugin(config.Plugin, config.Version)
-	if res != nil {
-		log.Fatal("pact setup failed:", res)
-	}
-
-	return &V4InteractionWithPlugin{
+	s := &V4InteractionWithPlugin{
 		interaction: i.interaction,
 		provider:    i.provider,
 	}
+
+	return s.UsingPlugin(config)
 }
 
 // UsingPlugin specifies the current interaction relies on one or more plugins for operation
-// If the plugin is not correctly installed, this method will terminate the test immediately with a non-zero status
+// If the plugin is not correctly installed, ExecuteTest returns the error
 func (i *V4InteractionWithPlugin) UsingPlugin(config PluginConfig) *V4InteractionWithPlugin {
-	res := i.provider.mockserver.UsingPlugin(config.Plugin, config.Version)
-	if res != nil {
-		log.Fatal("pact setup failed:", res)
+	if err := i.provider.mockserver.UsingPlugin(config.Plugin, config.Version); err != nil {
+		i.interaction.setErr(fmt.Errorf("unable to load plugin %s %s: %v", config.Plugin, config.Version, err))
 	}
 
 	return i
@@ -431,7 +462,15 @@ type V4InteractionWithPluginResponse struct {
 }
 
 // ExecuteTest runs the current test case against a Mock Service.
+// It returns the first error loading the plugins or setting the plugin contents without
+// running the test
 func (m *V4InteractionWithPluginResponse) ExecuteTest(t *testing.T, integrationTest func(MockServerConfig) error) error {
+	if err := m.interaction.err; err != nil {
+		m.provider.mockserver.CleanupPlugins()
+		m.provider.reset()
+		return err
+	}
+
 	return m.provider.ExecuteTest(t, integrationTest)
 }
 
@@ -458,11 +497,25 @@ func (i *V4InteractionWithPluginRequestBuilder) Headers(headers matchers.Headers
 
 // PluginContents configures a plugin. This may be called once per plugin registered.
 func (i *V4InteractionWithPluginRequestBuilder) PluginContents(contentType string, contents string) *V4InteractionWithPluginRequestBuilder {
-	i.interaction.interaction.WithPluginInteractionContents(native.INTERACTION_PART_REQUEST, contentType, contents)
+	if err := i.interaction.interaction.WithPluginInteractionContents(native.INTERACTION_PART_REQUEST, contentType, contents); err != nil {
+		i.interaction.setErr(fmt.Errorf("invalid plugin contents: %v", err))
+	}
 
 	return i
 }
 
+// WithContentsFromPlugin configures a plugin with its configuration for the content type,
+// as a JSON string or a value marshalled to JSON
+func (i *V4InteractionWithPluginRequestBuilder) WithContentsFromPlugin(contentType string, config interface{}) *V4InteractionWithPluginRequestBuilder {
+	contents, err := pluginContents(config)
+	if err != nil {
+		i.interaction.setErr(fmt.Errorf("invalid plugin contents: %v", err))
+		return i
+	}
+
+	return i.PluginContents(contentType, contents)
+}
+
 // JSONBody adds a JSON body to the expected request
 func (i *V4InteractionWithPluginRequestBuilder) JSONBody(body interface{}) *V4InteractionWithPluginRequestBuilder {
 	// TODO: Don't like panic, but not sure if there is a better builder experience?
@@ -539,11 +592,25 @@ func (i *V4InteractionWithPluginResponseBuilder) Headers(headers matchers.Header
 
 // PluginContents configures a plugin. This may be called once per plugin registered.
 func (i *V4InteractionWithPluginResponseBuilder) PluginContents(contentType string, contents string) *V4InteractionWithPluginResponseBuilder {
-	i.interaction.interaction.WithPluginInteractionContents(native.INTERACTION_PART_RESPONSE, contentType, contents)
+	if err := i.interaction.interaction.WithPluginInteractionContents(native.INTERACTION_PART_RESPONSE, contentType, contents); err != nil {
+		i.interaction.setErr(fmt.Errorf("invalid plugin contents: %v", err))
+	}
 
 	return i
 }
 
+// WithContentsFromPlugin configures a plugin with its configuration for the content type,
+// as a JSON string or a value marshalled to JSON
+func (i *V4InteractionWithPluginResponseBuilder) WithContentsFromPlugin(contentType string, config interface{}) *V4InteractionWithPluginResponseBuilder {
+	contents, err := pluginContents(config)
+	if err != nil {
+		i.interaction.setErr(fmt.Errorf("invalid plugin contents: %v", err))
+		return i
+	}
+
+	return i.PluginContents(contentType, contents)
+}
+
 // JSONBody adds a JSON body to the expected response
 func (i *V4InteractionWithPluginResponseBuilder) JSONBody(body interface{}) *V4InteractionWithPluginResponseBuilder {
 	// TODO: Don't like panic, how to build a better builder here - nil return + log?
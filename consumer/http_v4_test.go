@@ -92,3 +92,44 @@ type S = matchers.String
 var ArrayMinLike = matchers.ArrayMinLike
 
 type Map = matchers.Map
+
+func TestHttpV4UsingPlugins(t *testing.T) {
+	p, err := NewV4Pact(MockHTTPProviderConfig{
+		Consumer: "consumer",
+		Provider: "provider",
+		PactDir:  t.TempDir(),
+	})
+	assert.NoError(t, err)
+
+	called := false
+	err = p.AddInteraction().
+		UponReceiving("a request of plugins that aren't installed").
+		UsingPlugin(PluginConfig{Plugin: "not-a-plugin", Version: "0.0.1"}).
+		UsingPlugin(PluginConfig{Plugin: "nor-a-plugin", Version: "0.0.2"}).
+		WithRequest("POST", "/features", func(b *V4InteractionWithPluginRequestBuilder) {
+			b.WithContentsFromPlugin("application/protobuf", map[string]interface{}{"pact:proto": "plugin.proto"})
+		}).
+		WillRespondWith(200, func(b *V4InteractionWithPluginResponseBuilder) {
+			b.WithContentsFromPlugin("application/protobuf", map[string]interface{}{"invalid": func() {}})
+		}).
+		ExecuteTest(t, func(config MockServerConfig) error {
+			called = true
+			return nil
+		})
+
+	assert.ErrorContains(t, err, "unable to load plugin not-a-plugin 0.0.1")
+	assert.False(t, called)
+}
+
+func TestPluginContents(t *testing.T) {
+	contents, err := pluginContents(`{"pact:proto": "plugin.proto"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"pact:proto": "plugin.proto"}`, contents)
+
+	contents, err = pluginContents(map[string]interface{}{"pact:proto": "plugin.proto"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"pact:proto": "plugin.proto"}`, contents)
+
+	_, err = pluginContents(map[string]interface{}{"invalid": func() {}})
+	assert.EqualError(t, err, "json: unsupported type: func()")
+}
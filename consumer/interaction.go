@@ -3,6 +3,7 @@ package consumer
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 
 	mockserver "github.com/pact-foundation/pact-go/v2/internal/native"
@@ -15,6 +16,17 @@ type Interaction struct {
 	// Reference to the native rust handle
 	interaction          *mockserver.Interaction
 	specificationVersion models.SpecificationVersion
+
+	// err is the first error encountered while building the interaction, e.g. loading a
+	// plugin, returned by ExecuteTest
+	err error
+}
+
+// setErr records the first error encountered while building the interaction
+func (i *Interaction) setErr(err error) {
+	if i.err == nil {
+		i.err = err
+	}
 }
 
 // WithCompleteRequest specifies the details of the HTTP request that will be used to
@@ -105,6 +117,45 @@ func keyValuesToMapStringArrayInterface(key string, values ...matchers.Matcher)
 	return q
 }
 
+// formContentType is the content type of form bodies
+const formContentType = "application/x-www-form-urlencoded"
+
+// formURLEncoded encodes the fields of a form body, with the example values of any matchers
+func formURLEncoded(form map[string][]matchers.Matcher) []byte {
+	values := url.Values{}
+	for k, v := range form {
+		for _, m := range v {
+			values.Add(k, fmt.Sprint(m.GetValue()))
+		}
+	}
+
+	return []byte(values.Encode())
+}
+
+// pluginContents returns the configuration of a plugin as the JSON string given to the plugin
+func pluginContents(config interface{}) (string, error) {
+	if contents, ok := config.(string); ok {
+		return contents, nil
+	}
+	b, err := json.Marshal(config)
+
+	return string(b), err
+}
+
+// xmlBody serialises an XML body to the XML DSL of the core
+func xmlBody(body matchers.XMLBody) []byte {
+	// TODO: Don't like panic, but not sure if there is a better builder experience?
+	if body.Root == nil {
+		panic("an XML body requires a root element")
+	}
+	dsl, err := json.Marshal(body)
+	if err != nil {
+		panic(fmt.Sprintln("unable to marshal the XML body:", err))
+	}
+
+	return dsl
+}
+
 func headersMatcherToNativeHeaders(headers matchers.HeadersMatcher) map[string][]interface{} {
 	h := make(map[string][]interface{})
 
@@ -67,3 +67,14 @@ func TestInteraction(t *testing.T) {
 		}
 	})
 }
+
+func TestFormURLEncoded(t *testing.T) {
+	body := formURLEncoded(map[string][]matchers.Matcher{
+		"name":  {matchers.S("billy bob")},
+		"age":   {matchers.Like(27)},
+		"roles": {matchers.S("admin"), matchers.S("user&co")},
+	})
+
+	assert.Equal(t, "age=27&name=billy+bob&roles=admin&roles=user%26co", string(body))
+	assert.Empty(t, formURLEncoded(nil))
+}
@@ -0,0 +1,53 @@
+package consumer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// mockServerProxy serves the mock server on a listener of its own, e.g. terminating TLS
+// with a user supplied configuration, or on a Unix domain socket, proxying requests to
+// the mock server so that they are matched as usual
+type mockServerProxy struct {
+	server *http.Server
+	port   int
+}
+
+// startMockServerProxy serves the mock server at target on the listener
+func startMockServerProxy(listener net.Listener, target string) *mockServerProxy {
+	proxy := httputil.NewSingleHostReverseProxy(&url.URL{
+		Scheme: "http",
+		Host:   target,
+	})
+	s := &mockServerProxy{
+		server: &http.Server{Handler: proxy},
+	}
+	if addr, ok := listener.Addr().(*net.TCPAddr); ok {
+		s.port = addr.Port
+	}
+
+	log.Println("[DEBUG] starting mock server proxy on", listener.Addr())
+	go s.server.Serve(listener)
+
+	return s
+}
+
+// startTLSMockServer serves the mock server at target on address, with the TLS configuration
+func startTLSMockServer(address string, target string, config *tls.Config) (*mockServerProxy, error) {
+	listener, err := tls.Listen("tcp", address, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start the TLS mock server: %v", err)
+	}
+
+	return startMockServerProxy(listener, target), nil
+}
+
+// close stops the server, and closes any open connections
+func (s *mockServerProxy) close() error {
+	return s.server.Close()
+}
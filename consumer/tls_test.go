@@ -0,0 +1,90 @@
+package consumer
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// issue creates a certificate for 127.0.0.1 signed by the CA, or self-signed if ca is nil
+func issue(t *testing.T, ca *tls.Certificate, isCA bool) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "pact-go test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IsCA:                  isCA,
+		BasicConstraintsValid: true,
+	}
+	parent, signer := template, interface{}(key)
+	if ca != nil {
+		parent = ca.Leaf
+		signer = ca.PrivateKey
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, signer)
+	assert.NoError(t, err)
+	leaf, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestStartTLSMockServer(t *testing.T) {
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "hello from %s", r.URL.Path)
+	}))
+	defer mock.Close()
+
+	ca := issue(t, nil, true)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.Leaf)
+
+	s, err := startTLSMockServer("127.0.0.1:0", mock.Listener.Addr().String(), &tls.Config{
+		Certificates: []tls.Certificate{issue(t, &ca, false)},
+		ClientCAs:    pool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	assert.NoError(t, err)
+	defer s.close()
+
+	u := fmt.Sprintf("https://127.0.0.1:%d/users", s.port)
+
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      pool,
+		Certificates: []tls.Certificate{issue(t, &ca, false)},
+	}}}
+	res, err := client.Get(u)
+	assert.NoError(t, err)
+	body, _ := ioutil.ReadAll(res.Body)
+	res.Body.Close()
+	assert.Equal(t, "hello from /users", string(body))
+
+	noClientCert := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pool}}}
+	_, err = noClientCert.Get(u)
+	assert.Error(t, err)
+}
+
+func TestStartTLSMockServerWithoutCertificate(t *testing.T) {
+	_, err := startTLSMockServer("127.0.0.1:0", "127.0.0.1:1234", &tls.Config{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,32 @@
+package consumer
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+)
+
+// startUnixSocketMockServer serves the mock server at target on a Unix domain socket at
+// path, with TLS if a configuration is given. A socket left at path by an earlier run is
+// replaced, but any other file is not.
+func startUnixSocketMockServer(path string, target string, config *tls.Config) (*mockServerProxy, error) {
+	if info, err := os.Lstat(path); err == nil {
+		if info.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("unable to start the mock server on %s: the file exists and is not a socket", path)
+		}
+		if err = os.Remove(path); err != nil {
+			return nil, fmt.Errorf("unable to remove the socket %s: %v", path, err)
+		}
+	}
+
+	listener, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to start the mock server on %s: %v", path, err)
+	}
+	if config != nil {
+		listener = tls.NewListener(listener, config)
+	}
+
+	return startMockServerProxy(listener, target), nil
+}
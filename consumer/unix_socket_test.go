@@ -0,0 +1,74 @@
+package consumer
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartUnixSocketMockServer(t *testing.T) {
+	mock := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "hello from %s", r.URL.Path)
+	}))
+	defer mock.Close()
+
+	// socket paths are limited in length, so the test directory may be too long
+	dir, err := os.MkdirTemp("", "pact")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "mock.sock")
+
+	get := func() (string, error) {
+		client := &http.Client{Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return (&net.Dialer{}).DialContext(ctx, "unix", path)
+			},
+		}}
+		res, err := client.Get("http://mock/users")
+		if err != nil {
+			return "", err
+		}
+		defer res.Body.Close()
+		body, err := ioutil.ReadAll(res.Body)
+
+		return string(body), err
+	}
+
+	s, err := startUnixSocketMockServer(path, mock.Listener.Addr().String(), nil)
+	assert.NoError(t, err)
+	body, err := get()
+	assert.NoError(t, err)
+	assert.Equal(t, "hello from /users", body)
+	s.close()
+
+	t.Run("a socket left by an earlier run is replaced", func(t *testing.T) {
+		stale, err := net.Listen("unix", path)
+		assert.NoError(t, err)
+		stale.(*net.UnixListener).SetUnlinkOnClose(false)
+		stale.Close()
+
+		s, err := startUnixSocketMockServer(path, mock.Listener.Addr().String(), nil)
+		assert.NoError(t, err)
+		defer s.close()
+
+		body, err := get()
+		assert.NoError(t, err)
+		assert.Equal(t, "hello from /users", body)
+	})
+
+	t.Run("other files are not replaced", func(t *testing.T) {
+		file := filepath.Join(dir, "file")
+		assert.NoError(t, ioutil.WriteFile(file, []byte("data"), 0644))
+
+		_, err := startUnixSocketMockServer(file, mock.Listener.Addr().String(), nil)
+		assert.Error(t, err)
+	})
+}
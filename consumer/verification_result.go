@@ -0,0 +1,167 @@
+package consumer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/v2/internal/native"
+)
+
+// VerificationResult is the machine-readable result of a consumer test, describing each
+// request the mock server did not receive, did not expect, or could not match
+type VerificationResult struct {
+	// Test is the name of the test
+	Test string `json:"test"`
+
+	// Passed is true if every interaction was matched
+	Passed bool `json:"passed"`
+
+	// Interactions that failed
+	Interactions []InteractionResult `json:"interactions"`
+}
+
+// InteractionResult describes a request that failed verification
+type InteractionResult struct {
+	// Type of the failure: "missing-request" (an expected request was not received),
+	// "request-not-found" (an unexpected request was received) or "request-mismatch"
+	Type string `json:"type"`
+
+	Method string `json:"method"`
+	Path   string `json:"path"`
+
+	// Mismatches between the request received and the expected request
+	Mismatches []Mismatch `json:"mismatches,omitempty"`
+}
+
+// Mismatch is a difference between a request and the expected request
+type Mismatch struct {
+	// Type of the mismatch, e.g. "BodyMismatch", "HeaderMismatch" or "QueryMismatch"
+	Type string `json:"type"`
+
+	// Path of the mismatched value: the JSON path of a body value, or the name of a
+	// header or query parameter
+	Path string `json:"path,omitempty"`
+
+	Expected string `json:"expected"`
+	Actual   string `json:"actual"`
+
+	// Matcher that failed, e.g. "regex" or "type", or "equality" when values were
+	// compared exactly. Empty when it can't be determined from the mismatch
+	Matcher string `json:"matcher,omitempty"`
+
+	// Description of the mismatch given by the mock server
+	Description string `json:"description"`
+}
+
+// VerificationError is returned by ExecuteTest when the mock server did not receive the
+// expected requests. Use errors.As to access the result.
+type VerificationError struct {
+	Result VerificationResult
+}
+
+func (e *VerificationError) Error() string {
+	failures := make([]string, 0, len(e.Result.Interactions))
+	for _, i := range e.Result.Interactions {
+		failure := fmt.Sprintf("%s %s (%s)", i.Method, i.Path, i.Type)
+		for _, m := range i.Mismatches {
+			failure += ": " + m.Description
+		}
+		failures = append(failures, failure)
+	}
+
+	return fmt.Sprintf("pact validation failed: %s", strings.Join(failures, "; "))
+}
+
+// matcherPatterns recognise the matcher that failed from the descriptions of the core
+var matcherPatterns = []struct {
+	pattern *regexp.Regexp
+	matcher string
+}{
+	{regexp.MustCompile(`to match '.*'`), "regex"},
+	{regexp.MustCompile(`to be the same type as`), "type"},
+	{regexp.MustCompile(`to include`), "include"},
+	{regexp.MustCompile(`to be a (boolean|number|integer|decimal)`), "type"},
+	{regexp.MustCompile(`to match a (date|time|datetime)`), "timestamp"},
+	{regexp.MustCompile(`to have (at least|at most) \d+`), "type"},
+	{regexp.MustCompile(`to be equal to|to equal`), "equality"},
+}
+
+// newVerificationResult builds the result of the test from the mismatches of the mock server
+func newVerificationResult(test string, mismatches []native.MismatchedRequest) VerificationResult {
+	res := VerificationResult{
+		Test:         test,
+		Passed:       len(mismatches) == 0,
+		Interactions: []InteractionResult{},
+	}
+
+	for _, m := range mismatches {
+		i := InteractionResult{
+			Type:   m.Type,
+			Method: m.Method,
+			Path:   m.Path,
+		}
+		for _, detail := range m.Mismatches {
+			i.Mismatches = append(i.Mismatches, Mismatch{
+				Type:        detail.Type,
+				Path:        mismatchPath(detail),
+				Expected:    detail.Expected,
+				Actual:      detail.Actual,
+				Matcher:     failedMatcher(detail.Mismatch),
+				Description: detail.Mismatch,
+			})
+		}
+		res.Interactions = append(res.Interactions, i)
+	}
+
+	return res
+}
+
+// mismatchPath returns the path of the mismatched value, which depends on its type
+func mismatchPath(detail native.MismatchDetail) string {
+	switch {
+	case detail.Path != "":
+		return detail.Path
+	case detail.Key != "":
+		return detail.Key
+	default:
+		return detail.Parameter
+	}
+}
+
+// failedMatcher returns the matcher that failed, recognised from the description of the mismatch
+func failedMatcher(description string) string {
+	for _, p := range matcherPatterns {
+		if p.pattern.MatchString(description) {
+			return p.matcher
+		}
+	}
+
+	return ""
+}
+
+// writeVerificationResult appends the result to the file as a line of JSON, so that the
+// results of every test in the run are collected
+func writeVerificationResult(file string, result VerificationResult) error {
+	if err := os.MkdirAll(filepath.Dir(file), 0755); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = f.Write(append(b, '\n'))
+
+	return err
+}
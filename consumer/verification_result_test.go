@@ -0,0 +1,109 @@
+package consumer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/internal/native"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerificationResult(t *testing.T) {
+	mismatches := []native.MismatchedRequest{
+		{
+			Request: native.Request{Method: "GET", Path: "/users/1"},
+			Type:    "request-mismatch",
+			Mismatches: []native.MismatchDetail{
+				{
+					Type:     "BodyMismatch",
+					Path:     "$.name",
+					Expected: `"billy"`,
+					Actual:   `"bob"`,
+					Mismatch: "Expected 'bob' to match '\\w{5}'",
+				},
+				{
+					Type:     "HeaderMismatch",
+					Key:      "Authorization",
+					Expected: `"Bearer 1234"`,
+					Mismatch: "Expected header 'Authorization' but was missing",
+				},
+			},
+		},
+		{
+			Request: native.Request{Method: "POST", Path: "/users"},
+			Type:    "missing-request",
+		},
+	}
+
+	result := newVerificationResult("TestUserClient", mismatches)
+
+	assert.Equal(t, "TestUserClient", result.Test)
+	assert.False(t, result.Passed)
+	assert.Len(t, result.Interactions, 2)
+	assert.Equal(t, InteractionResult{
+		Type:   "request-mismatch",
+		Method: "GET",
+		Path:   "/users/1",
+		Mismatches: []Mismatch{
+			{
+				Type:        "BodyMismatch",
+				Path:        "$.name",
+				Expected:    `"billy"`,
+				Actual:      `"bob"`,
+				Matcher:     "regex",
+				Description: "Expected 'bob' to match '\\w{5}'",
+			},
+			{
+				Type:        "HeaderMismatch",
+				Path:        "Authorization",
+				Expected:    `"Bearer 1234"`,
+				Description: "Expected header 'Authorization' but was missing",
+			},
+		},
+	}, result.Interactions[0])
+	assert.Equal(t, "missing-request", result.Interactions[1].Type)
+
+	var err error = &VerificationError{Result: result}
+	var verificationErr *VerificationError
+	assert.True(t, errors.As(err, &verificationErr))
+	assert.Contains(t, err.Error(), "GET /users/1 (request-mismatch): Expected 'bob' to match")
+	assert.Contains(t, err.Error(), "POST /users (missing-request)")
+
+	t.Run("passed", func(t *testing.T) {
+		result := newVerificationResult("TestUserClient", nil)
+
+		assert.True(t, result.Passed)
+		assert.Empty(t, result.Interactions)
+	})
+}
+
+func TestFailedMatcher(t *testing.T) {
+	assert.Equal(t, "type", failedMatcher("Expected 'a' to be the same type as 1"))
+	assert.Equal(t, "equality", failedMatcher("Expected 'a' to be equal to 'b'"))
+	assert.Equal(t, "include", failedMatcher("Expected 'abc' to include 'd'"))
+	assert.Equal(t, "", failedMatcher("Expected header 'Authorization' but was missing"))
+}
+
+func TestWriteVerificationResult(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "results", "pact.jsonl")
+
+	assert.NoError(t, writeVerificationResult(file, VerificationResult{Test: "TestOne", Passed: true}))
+	assert.NoError(t, writeVerificationResult(file, VerificationResult{Test: "TestTwo"}))
+
+	f, err := os.Open(file)
+	assert.NoError(t, err)
+	defer f.Close()
+
+	var tests []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var result VerificationResult
+		assert.NoError(t, json.Unmarshal(scanner.Bytes(), &result))
+		tests = append(tests, result.Test)
+	}
+	assert.Equal(t, []string{"TestOne", "TestTwo"}, tests)
+}
@@ -0,0 +1,304 @@
+// Package generator produces concrete examples of the requests, responses and messages
+// of pact files, reified as the native core does during verification, without running a
+// consumer test. The examples may be used to seed local fixtures, produce sample events
+// or drive golden file tests.
+//
+// The generators of bodies, message contents and metadata are applied, e.g. RandomInt
+// or DateTime. Values from provider states, and generators that can only be applied
+// during verification (e.g. MockServerURL), keep their example value.
+package generator
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/pact-foundation/pact-go/v2/matchers"
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+)
+
+// Interaction types of the examples
+const (
+	HTTP                = "Synchronous/HTTP"
+	AsynchronousMessage = "Asynchronous/Messages"
+	SynchronousMessage  = "Synchronous/Messages"
+)
+
+// Example is a concrete instance of an interaction of a pact
+type Example struct {
+	Description    string
+	ProviderStates []models.ProviderState
+
+	// Type of the interaction, HTTP, AsynchronousMessage or SynchronousMessage
+	Type string
+
+	// Request and Response of an HTTP interaction
+	Request  *Request
+	Response *Response
+
+	// Message is an asynchronous message, or the request of a synchronous message
+	Message *Message
+
+	// Responses of a synchronous message
+	Responses []Message
+}
+
+// Request is an example HTTP request
+type Request struct {
+	Method  string
+	Path    string
+	Query   url.Values
+	Headers http.Header
+	Body    []byte
+}
+
+// Response is an example HTTP response
+type Response struct {
+	Status  int
+	Headers http.Header
+	Body    []byte
+}
+
+// Message is an example message
+type Message struct {
+	Contents    []byte
+	ContentType string
+	Metadata    map[string]interface{}
+}
+
+// ExamplesFromPact reads the pact file, and returns an example of each of its interactions
+func ExamplesFromPact(path string) ([]Example, error) {
+	p, err := pactfile.Read(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return Examples(p, rand.New(rand.NewSource(time.Now().UnixNano())))
+}
+
+// Examples returns an example of each interaction of the pact. Random generators take
+// their values from r, so a seeded source gives repeatable examples.
+func Examples(p *pactfile.Pact, r *rand.Rand) ([]Example, error) {
+	var examples []Example
+
+	for _, i := range p.AllInteractions() {
+		e, err := example(p, i, r)
+		if err != nil {
+			return nil, fmt.Errorf("unable to generate an example of '%s': %v", i.Description, err)
+		}
+		examples = append(examples, e)
+	}
+
+	return examples, nil
+}
+
+// example reifies a single interaction
+func example(p *pactfile.Pact, i *pactfile.Interaction, r *rand.Rand) (Example, error) {
+	e := Example{
+		Description:    i.Description,
+		ProviderStates: i.ProviderStates,
+		Type:           interactionType(p, i),
+	}
+
+	parts := make(map[string]pactfile.Part)
+	for _, part := range i.Parts() {
+		parts[part.Name] = part
+	}
+
+	var err error
+	switch e.Type {
+	case HTTP:
+		request, _ := i.Raw["request"].(map[string]interface{})
+		response, _ := i.Raw["response"].(map[string]interface{})
+		if e.Request, err = httpRequest(request, parts["request.body"], r); err != nil {
+			return e, err
+		}
+		e.Response, err = httpResponse(response, parts["response.body"], r)
+	case AsynchronousMessage:
+		e.Message, err = message(i.Raw["contents"], parts["contents"], parts["metadata"], r)
+	case SynchronousMessage:
+		request, _ := i.Raw["request"].(map[string]interface{})
+		if e.Message, err = message(request["contents"], parts["request.contents"], parts["request.metadata"], r); err != nil {
+			return e, err
+		}
+		responses, _ := i.Raw["response"].([]interface{})
+		for n, res := range responses {
+			res, _ := res.(map[string]interface{})
+			name := fmt.Sprintf("response[%d]", n)
+			m, err := message(res["contents"], parts[name+".contents"], parts[name+".metadata"], r)
+			if err != nil {
+				return e, err
+			}
+			e.Responses = append(e.Responses, *m)
+		}
+	default:
+		err = fmt.Errorf("interactions of type %s are not supported", e.Type)
+	}
+
+	return e, err
+}
+
+// interactionType returns the type of the interaction, given explicitly by V4 pacts
+func interactionType(p *pactfile.Pact, i *pactfile.Interaction) string {
+	if i.Type != "" {
+		return i.Type
+	}
+	for _, m := range p.Messages {
+		if m == i {
+			return AsynchronousMessage
+		}
+	}
+
+	return HTTP
+}
+
+func httpRequest(request map[string]interface{}, body pactfile.Part, r *rand.Rand) (*Request, error) {
+	req := &Request{
+		Headers: headers(request["headers"]),
+		Query:   query(request["query"]),
+	}
+	req.Method, _ = request["method"].(string)
+	req.Path, _ = request["path"].(string)
+
+	var err error
+	req.Body, _, err = content(request["body"], body, req.Headers.Get("Content-Type"), r)
+
+	return req, err
+}
+
+func httpResponse(response map[string]interface{}, body pactfile.Part, r *rand.Rand) (*Response, error) {
+	res := &Response{
+		Status:  http.StatusOK,
+		Headers: headers(response["headers"]),
+	}
+	if s, ok := response["status"].(float64); ok {
+		res.Status = int(s)
+	}
+
+	var err error
+	res.Body, _, err = content(response["body"], body, res.Headers.Get("Content-Type"), r)
+
+	return res, err
+}
+
+func message(contents interface{}, part pactfile.Part, metadataPart pactfile.Part, r *rand.Rand) (*Message, error) {
+	m := &Message{
+		Metadata: map[string]interface{}{},
+	}
+
+	if metadataPart.Content != nil {
+		metadata, err := matchers.Generate(metadataPart.GeneratorTemplate(), r)
+		if err != nil {
+			return nil, err
+		}
+		if md, ok := metadata.(map[string]interface{}); ok {
+			m.Metadata = md
+		}
+	}
+
+	contentType, _ := m.Metadata["contentType"].(string)
+	if contentType == "" {
+		contentType, _ = m.Metadata["content-type"].(string)
+	}
+
+	var err error
+	m.Contents, m.ContentType, err = content(contents, part, contentType, r)
+
+	return m, err
+}
+
+// content returns the bytes and content type of a body or message contents, applying its
+// generators. V4 pacts wrap their bodies with their content type and encoding.
+func content(body interface{}, part pactfile.Part, contentType string, r *rand.Rand) ([]byte, string, error) {
+	if body == nil {
+		return nil, contentType, nil
+	}
+
+	encoded := interface{}(false)
+	if wrapped, ok := body.(map[string]interface{}); ok {
+		if c, ok := wrapped["content"]; ok {
+			if ct, ok := wrapped["contentType"].(string); ok {
+				body, contentType, encoded = c, ct, wrapped["encoded"]
+			}
+		}
+	}
+
+	text, isText := body.(string)
+	switch {
+	case isText && encoded == "base64":
+		b, err := base64.StdEncoding.DecodeString(text)
+		return b, contentType, err
+	case isText && (encoded == "json" || !isJSON(contentType)):
+		return []byte(text), contentType, nil
+	}
+
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	generated, err := matchers.Generate(part.GeneratorTemplate(), r)
+	if err != nil {
+		return nil, contentType, err
+	}
+	b, err := json.Marshal(generated)
+
+	return b, contentType, err
+}
+
+// isJSON reports whether the content type is JSON, or unknown
+func isJSON(contentType string) bool {
+	return contentType == "" || strings.Contains(contentType, "json")
+}
+
+// headers returns the headers of a request or response, whose values may be a single
+// value or a list
+func headers(h interface{}) http.Header {
+	res := http.Header{}
+
+	if m, ok := h.(map[string]interface{}); ok {
+		for name, v := range m {
+			for _, value := range values(v) {
+				res.Add(name, value)
+			}
+		}
+	}
+
+	return res
+}
+
+// query returns the query parameters of a request, given as a string by V2 pacts
+func query(q interface{}) url.Values {
+	res := url.Values{}
+
+	switch t := q.(type) {
+	case string:
+		res, _ = url.ParseQuery(t)
+	case map[string]interface{}:
+		for name, v := range t {
+			res[name] = values(v)
+		}
+	}
+
+	return res
+}
+
+// values returns a header or query value, which may be a single value or a list
+func values(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		res := make([]string, 0, len(t))
+		for _, item := range t {
+			res = append(res, fmt.Sprint(item))
+		}
+		return res
+	default:
+		return []string{fmt.Sprint(t)}
+	}
+}
@@ -0,0 +1,188 @@
+package generator
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+	"github.com/stretchr/testify/assert"
+)
+
+const v3Pact = `{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "interactions": [
+    {
+      "description": "a request for a user",
+      "providerStates": [{"name": "user 1 exists"}],
+      "request": {"method": "GET", "path": "/users/1", "query": {"fields": ["name", "id"]}},
+      "response": {
+        "status": 200,
+        "headers": {"Content-Type": "application/json"},
+        "body": {"id": 1, "name": "billy", "tags": [{"id": 5}]},
+        "generators": {"body": {"$.id": {"type": "RandomInt", "min": 100, "max": 200}, "$.tags[*].id": {"type": "RandomInt", "min": 1, "max": 1}}}
+      }
+    }
+  ],
+  "metadata": {"pactSpecification": {"version": "3.0.0"}}
+}`
+
+const v3MessagePact = `{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "messages": [
+    {
+      "description": "a user created event",
+      "contents": {"id": "abc", "name": "billy"},
+      "metadata": {"contentType": "application/json", "topic": "users"},
+      "generators": {"body": {"$.id": {"type": "RandomString", "size": 6}}}
+    }
+  ],
+  "metadata": {"pactSpecification": {"version": "3.0.0"}}
+}`
+
+const v4Pact = `{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "interactions": [
+    {
+      "type": "Asynchronous/Messages",
+      "description": "an image",
+      "contents": {"content": "aGVsbG8=", "contentType": "image/png", "encoded": "base64"},
+      "metadata": {"topic": "images"}
+    },
+    {
+      "type": "Synchronous/Messages",
+      "description": "a greeting",
+      "request": {"contents": {"content": "hello", "contentType": "text/plain", "encoded": false}},
+      "response": [
+        {"contents": {"content": {"greeting": "hi"}, "contentType": "application/json", "encoded": false}}
+      ]
+    },
+    {
+      "type": "Synchronous/HTTP",
+      "description": "a form post",
+      "request": {
+        "method": "POST",
+        "path": "/users",
+        "headers": {"Content-Type": ["application/x-www-form-urlencoded"]},
+        "body": {"content": "name=billy", "contentType": "application/x-www-form-urlencoded", "encoded": false}
+      },
+      "response": {"status": 201}
+    },
+    {
+      "type": "Synchronous/Plugin",
+      "description": "a plugin interaction"
+    }
+  ],
+  "metadata": {"pactSpecification": {"version": "4.0"}}
+}`
+
+func parse(t *testing.T, pact string) *pactfile.Pact {
+	p, err := pactfile.Parse([]byte(pact))
+	assert.NoError(t, err)
+
+	return p
+}
+
+func TestExamples(t *testing.T) {
+	t.Run("http interactions", func(t *testing.T) {
+		examples, err := Examples(parse(t, v3Pact), rand.New(rand.NewSource(1)))
+		assert.NoError(t, err)
+		assert.Len(t, examples, 1)
+
+		e := examples[0]
+		assert.Equal(t, HTTP, e.Type)
+		assert.Equal(t, "a request for a user", e.Description)
+		assert.Equal(t, "user 1 exists", e.ProviderStates[0].Name)
+		assert.Equal(t, "GET", e.Request.Method)
+		assert.Equal(t, "/users/1", e.Request.Path)
+		assert.Equal(t, []string{"name", "id"}, e.Request.Query["fields"])
+		assert.Nil(t, e.Request.Body)
+		assert.Equal(t, 200, e.Response.Status)
+		assert.Equal(t, "application/json", e.Response.Headers.Get("Content-Type"))
+
+		var body struct {
+			ID   int    `json:"id"`
+			Name string `json:"name"`
+			Tags []struct {
+				ID int `json:"id"`
+			} `json:"tags"`
+		}
+		assert.NoError(t, json.Unmarshal(e.Response.Body, &body))
+		assert.True(t, body.ID >= 100 && body.ID <= 200, "generated id %d is out of range", body.ID)
+		assert.Equal(t, "billy", body.Name)
+		assert.Equal(t, 1, body.Tags[0].ID)
+	})
+
+	t.Run("messages", func(t *testing.T) {
+		examples, err := Examples(parse(t, v3MessagePact), rand.New(rand.NewSource(1)))
+		assert.NoError(t, err)
+		assert.Len(t, examples, 1)
+
+		e := examples[0]
+		assert.Equal(t, AsynchronousMessage, e.Type)
+		assert.Equal(t, "application/json", e.Message.ContentType)
+		assert.Equal(t, "users", e.Message.Metadata["topic"])
+
+		var contents map[string]string
+		assert.NoError(t, json.Unmarshal(e.Message.Contents, &contents))
+		assert.Len(t, contents["id"], 6)
+		assert.NotEqual(t, "abc", contents["id"])
+		assert.Equal(t, "billy", contents["name"])
+	})
+
+	t.Run("a seeded source gives repeatable examples", func(t *testing.T) {
+		first, err := Examples(parse(t, v3MessagePact), rand.New(rand.NewSource(7)))
+		assert.NoError(t, err)
+		second, err := Examples(parse(t, v3MessagePact), rand.New(rand.NewSource(7)))
+		assert.NoError(t, err)
+
+		assert.Equal(t, first[0].Message.Contents, second[0].Message.Contents)
+	})
+
+	t.Run("v4 interactions", func(t *testing.T) {
+		_, err := Examples(parse(t, v4Pact), rand.New(rand.NewSource(1)))
+		assert.EqualError(t, err, "unable to generate an example of 'a plugin interaction': interactions of type Synchronous/Plugin are not supported")
+
+		p := parse(t, v4Pact)
+		p.Interactions = p.Interactions[:3]
+		examples, err := Examples(p, rand.New(rand.NewSource(1)))
+		assert.NoError(t, err)
+		assert.Len(t, examples, 3)
+
+		image := examples[0]
+		assert.Equal(t, AsynchronousMessage, image.Type)
+		assert.Equal(t, []byte("hello"), image.Message.Contents)
+		assert.Equal(t, "image/png", image.Message.ContentType)
+		assert.Equal(t, "images", image.Message.Metadata["topic"])
+
+		greeting := examples[1]
+		assert.Equal(t, SynchronousMessage, greeting.Type)
+		assert.Equal(t, []byte("hello"), greeting.Message.Contents)
+		assert.Equal(t, "text/plain", greeting.Message.ContentType)
+		assert.Len(t, greeting.Responses, 1)
+		assert.JSONEq(t, `{"greeting": "hi"}`, string(greeting.Responses[0].Contents))
+
+		form := examples[2]
+		assert.Equal(t, HTTP, form.Type)
+		assert.Equal(t, []byte("name=billy"), form.Request.Body)
+		assert.Equal(t, 201, form.Response.Status)
+		assert.Nil(t, form.Response.Body)
+	})
+}
+
+func TestExamplesFromPact(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pact.json")
+	assert.NoError(t, os.WriteFile(file, []byte(v3Pact), 0644))
+
+	examples, err := ExamplesFromPact(file)
+	assert.NoError(t, err)
+	assert.Len(t, examples, 1)
+
+	_, err = ExamplesFromPact(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
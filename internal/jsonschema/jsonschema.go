@@ -0,0 +1,221 @@
+// Package jsonschema validates values against the commonly used subset of JSON Schema:
+// type, enum, const, properties, required, additionalProperties, items, minItems,
+// maxItems, minimum, maximum, minLength, maxLength and pattern. Other keywords are ignored.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError lists every way in which a value fails to satisfy a schema
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("the value does not match the schema: %s", strings.Join(e.Problems, "; "))
+}
+
+// Validate checks the value (anything that can be marshalled to JSON) against the schema.
+// A *ValidationError is returned describing every problem found.
+func Validate(schema []byte, value interface{}) error {
+	var s interface{}
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return fmt.Errorf("unable to parse the schema: %v", err)
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("unable to serialise the value: %v", err)
+	}
+	var v interface{}
+	if err = json.Unmarshal(b, &v); err != nil {
+		return fmt.Errorf("unable to serialise the value: %v", err)
+	}
+
+	if problems := validate("$", s, v); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+func validate(path string, schema interface{}, v interface{}) []string {
+	s, ok := schema.(map[string]interface{})
+	if !ok {
+		// The boolean schemas, true accepts any value and false none
+		if schema == false {
+			return []string{fmt.Sprintf("%s: no value is allowed", path)}
+		}
+		return nil
+	}
+
+	if t, ok := s["type"]; ok && !matchesType(t, v) {
+		return []string{fmt.Sprintf("%s: expected %s but got %s", path, describeType(t), kind(v))}
+	}
+
+	var problems []string
+	if enum, ok := s["enum"].([]interface{}); ok && !contains(enum, v) {
+		problems = append(problems, fmt.Sprintf("%s: %s is not one of %s", path, format(v), format(enum)))
+	}
+	if c, ok := s["const"]; ok && !equal(c, v) {
+		problems = append(problems, fmt.Sprintf("%s: expected %s but got %s", path, format(c), format(v)))
+	}
+
+	switch val := v.(type) {
+	case map[string]interface{}:
+		problems = append(problems, validateObject(path, s, val)...)
+	case []interface{}:
+		if min, ok := s["minItems"].(float64); ok && float64(len(val)) < min {
+			problems = append(problems, fmt.Sprintf("%s: expected at least %v items but got %d", path, min, len(val)))
+		}
+		if max, ok := s["maxItems"].(float64); ok && float64(len(val)) > max {
+			problems = append(problems, fmt.Sprintf("%s: expected at most %v items but got %d", path, max, len(val)))
+		}
+		if items, ok := s["items"]; ok {
+			for i, item := range val {
+				problems = append(problems, validate(fmt.Sprintf("%s[%d]", path, i), items, item)...)
+			}
+		}
+	case float64:
+		if min, ok := s["minimum"].(float64); ok && val < min {
+			problems = append(problems, fmt.Sprintf("%s: %v is less than the minimum of %v", path, val, min))
+		}
+		if max, ok := s["maximum"].(float64); ok && val > max {
+			problems = append(problems, fmt.Sprintf("%s: %v is greater than the maximum of %v", path, val, max))
+		}
+	case string:
+		length := float64(utf8.RuneCountInString(val))
+		if min, ok := s["minLength"].(float64); ok && length < min {
+			problems = append(problems, fmt.Sprintf("%s: expected at least %v characters but got %v", path, min, length))
+		}
+		if max, ok := s["maxLength"].(float64); ok && length > max {
+			problems = append(problems, fmt.Sprintf("%s: expected at most %v characters but got %v", path, max, length))
+		}
+		if pattern, ok := s["pattern"].(string); ok {
+			r, err := regexp.Compile(pattern)
+			switch {
+			case err != nil:
+				problems = append(problems, fmt.Sprintf("%s: invalid pattern '%s': %v", path, pattern, err))
+			case !r.MatchString(val):
+				problems = append(problems, fmt.Sprintf("%s: '%s' does not match the pattern '%s'", path, val, pattern))
+			}
+		}
+	}
+
+	return problems
+}
+
+func validateObject(path string, s map[string]interface{}, v map[string]interface{}) []string {
+	var problems []string
+
+	if required, ok := s["required"].([]interface{}); ok {
+		for _, r := range required {
+			if name, ok := r.(string); ok {
+				if _, present := v[name]; !present {
+					problems = append(problems, fmt.Sprintf("%s: the required property '%s' is missing", path, name))
+				}
+			}
+		}
+	}
+
+	properties, _ := s["properties"].(map[string]interface{})
+	keys := make([]string, 0, len(v))
+	for k := range v {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		childPath := path + "." + k
+		if property, ok := properties[k]; ok {
+			problems = append(problems, validate(childPath, property, v[k])...)
+			continue
+		}
+		if additional, ok := s["additionalProperties"]; ok {
+			if additional == false {
+				problems = append(problems, fmt.Sprintf("%s: the property is not allowed", childPath))
+			} else {
+				problems = append(problems, validate(childPath, additional, v[k])...)
+			}
+		}
+	}
+
+	return problems
+}
+
+func matchesType(t interface{}, v interface{}) bool {
+	switch t := t.(type) {
+	case string:
+		if t == "integer" {
+			f, ok := v.(float64)
+			return ok && f == math.Trunc(f)
+		}
+		return kind(v) == t
+	case []interface{}:
+		for _, alternative := range t {
+			if matchesType(alternative, v) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return true
+}
+
+func describeType(t interface{}) string {
+	if alternatives, ok := t.([]interface{}); ok {
+		names := make([]string, len(alternatives))
+		for i, a := range alternatives {
+			names[i] = fmt.Sprint(a)
+		}
+		return "one of " + strings.Join(names, ", ")
+	}
+
+	return fmt.Sprintf("%v", t)
+}
+
+// kind returns the JSON Schema type name of a decoded JSON value
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+		return "object"
+	}
+}
+
+func contains(values []interface{}, v interface{}) bool {
+	for _, e := range values {
+		if equal(e, v) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func equal(a, b interface{}) bool {
+	return format(a) == format(b)
+}
+
+func format(v interface{}) string {
+	b, _ := json.Marshal(v)
+
+	return string(b)
+}
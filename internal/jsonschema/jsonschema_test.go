@@ -0,0 +1,61 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const userSchema = `{
+  "type": "object",
+  "required": ["id", "name"],
+  "additionalProperties": false,
+  "properties": {
+    "id": {"type": "integer", "minimum": 1},
+    "name": {"type": "string", "minLength": 1},
+    "role": {"enum": ["admin", "user"]},
+    "email": {"type": "string", "pattern": "^[^@]+@[^@]+$"},
+    "tags": {"type": "array", "items": {"type": "string"}, "maxItems": 2},
+    "manager": {"type": ["integer", "null"]}
+  }
+}`
+
+func TestValidate(t *testing.T) {
+	t.Run("valid values", func(t *testing.T) {
+		err := Validate([]byte(userSchema), map[string]interface{}{
+			"id":      1,
+			"name":    "billy",
+			"role":    "admin",
+			"email":   "billy@example.com",
+			"tags":    []string{"a", "b"},
+			"manager": nil,
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid values", func(t *testing.T) {
+		err := Validate([]byte(userSchema), map[string]interface{}{
+			"id":      "1",
+			"role":    "owner",
+			"email":   "billy",
+			"tags":    []interface{}{"a", 2, "c"},
+			"manager": 1.5,
+			"age":     30,
+		})
+		assert.Error(t, err)
+		assert.ElementsMatch(t, []string{
+			"$: the required property 'name' is missing",
+			"$.age: the property is not allowed",
+			"$.email: 'billy' does not match the pattern '^[^@]+@[^@]+$'",
+			"$.id: expected integer but got string",
+			"$.manager: expected one of integer, null but got number",
+			`$.role: "owner" is not one of ["admin","user"]`,
+			"$.tags: expected at most 2 items but got 3",
+			"$.tags[1]: expected string but got number",
+		}, err.(*ValidationError).Problems)
+	})
+
+	t.Run("invalid schemas", func(t *testing.T) {
+		assert.Error(t, Validate([]byte(`{`), nil))
+	})
+}
@@ -35,12 +35,18 @@ void pactffi_message_given(InteractionHandle message, const char *description);
 void pactffi_message_given_with_param(InteractionHandle message, const char *description, const char *name, const char *value);
 void pactffi_message_with_contents(InteractionHandle message, const char *content_type, const char *body, int size);
 void pactffi_message_with_metadata(InteractionHandle message, const char *key, const char *value);
+// Adds expected metadata to the message, where the value may be a matcher in the integration JSON format
+void pactffi_message_with_metadata_v2(InteractionHandle message, const char *key, const char *value);
 int pactffi_write_message_pact_file(PactHandle pact, const char *directory, bool overwrite);
 void pactffi_with_message_pact_metadata(PactHandle pact, const char *namespace, const char *name, const char *value);
 int pactffi_write_pact_file(int mock_server_port, const char *directory, bool overwrite);
 bool pactffi_given(InteractionHandle interaction, const char *description);
 bool pactffi_given_with_param(InteractionHandle interaction, const char *description, const char *name, const char *value);
+// Adds a provider state with parameters given as a JSON object, as a new state even if one has the same name
+int pactffi_given_with_params(InteractionHandle interaction, const char *description, const char *params);
+bool pactffi_set_pending(InteractionHandle interaction, bool pending);
 void pactffi_with_specification(PactHandle pact, int specification_version);
+unsigned int pactffi_free_pact_handle(PactHandle pact);
 
 int pactffi_using_plugin(PactHandle pact, const char *plugin_name, const char *plugin_version);
 void pactffi_cleanup_plugins(PactHandle pact);
@@ -103,6 +109,8 @@ import (
 	"fmt"
 	"log"
 	"unsafe"
+
+	"github.com/pact-foundation/pact-go/v2/models"
 )
 
 type MessagePact struct {
@@ -122,6 +130,23 @@ type Message struct {
 	pact        *MessagePact
 	index       int
 	server      *MessageServer
+
+	// err is the first error returned by the native core while building the message.
+	// Once set, further builder calls are not sent to the native core
+	err error
+}
+
+// Err returns the first error the native core returned while building the message
+func (m *Message) Err() error {
+	return m.err
+}
+
+// fail records the error of a builder call, unless an earlier call already failed
+func (m *Message) fail(call string, format string, args ...interface{}) {
+	if m.err == nil {
+		m.err = fmt.Errorf("%s: %s", call, fmt.Sprintf(format, args...))
+		log.Println("[ERROR]", m.err)
+	}
 }
 
 // MessageServer is the public interface for managing the message based interface
@@ -195,45 +220,76 @@ func (m *MessageServer) NewAsyncMessageInteraction(description string) *Message
 	return i
 }
 
+// Close frees the native pact handle. The server must not be used once closed
+func (m *MessageServer) Close() error {
+	res := int(C.pactffi_free_pact_handle(m.messagePact.handle))
+
+	// | Error | Description |
+	// |-------|-------------|
+	// | 1 | The handle is not valid or does not refer to a valid Pact. Could be that it was previously deleted. |
+	switch res {
+	case 0:
+		return nil
+	case 1:
+		return ErrHandleNotFound
+	default:
+		return fmt.Errorf("an unknown error (code: %v) occurred when freeing the pact handle", res)
+	}
+}
+
 func (m *MessageServer) WithSpecificationVersion(version specificationVersion) {
 	C.pactffi_with_specification(m.messagePact.handle, C.int(version))
 }
 
 func (m *Message) Given(state string) *Message {
+	if m.err != nil {
+		return m
+	}
+
 	cState := C.CString(state)
 	defer free(cState)
 
-	C.pactffi_given(m.handle, cState)
+	if int(C.pactffi_given(m.handle, cState)) == 0 {
+		m.fail("Given", "unable to add provider state '%s'", state)
+	}
 
 	return m
 }
 
+// GivenWithParameter adds a provider state with parameters to the message. Each call adds a
+// new state, so states with the same name and different parameters are all recorded
 func (m *Message) GivenWithParameter(state string, params map[string]interface{}) *Message {
-	cState := C.CString(state)
-	defer free(cState)
+	if m.err != nil {
+		return m
+	}
 
 	if len(params) == 0 {
-		cState := C.CString(state)
-		defer free(cState)
+		return m.Given(state)
+	}
 
-		C.pactffi_given(m.handle, cState)
-	} else {
-		for k, v := range params {
-			cKey := C.CString(k)
-			defer free(cKey)
-			param := stringFromInterface(v)
-			cValue := C.CString(param)
-			defer free(cValue)
+	data, err := json.Marshal(params)
+	if err != nil {
+		m.fail("GivenWithParameter", "unable to serialise the parameters of provider state '%s': %v", state, err)
+		return m
+	}
 
-			C.pactffi_given_with_param(m.handle, cState, cKey, cValue)
+	cState := C.CString(state)
+	defer free(cState)
+	cParams := C.CString(string(data))
+	defer free(cParams)
 
-		}
+	if res := int(C.pactffi_given_with_params(m.handle, cState, cParams)); res != 0 {
+		m.fail("GivenWithParameter", "unable to add provider state '%s' with parameters (code: %v)", state, res)
 	}
 
 	return m
 }
 
 func (m *Message) ExpectsToReceive(description string) *Message {
+	if m.err != nil {
+		return m
+	}
+
 	cDescription := C.CString(description)
 	defer free(cDescription)
 
@@ -242,7 +298,25 @@ func (m *Message) ExpectsToReceive(description string) *Message {
 	return m
 }
 
+// SetPending marks the interaction as pending, so that verification failures are
+// reported without failing the verification
+func (m *Message) SetPending(pending bool) *Message {
+	if m.err != nil {
+		return m
+	}
+
+	if int(C.pactffi_set_pending(m.handle, boolToCInt(pending))) == 0 {
+		m.fail("SetPending", "unable to mark the message as pending")
+	}
+
+	return m
+}
+
 func (m *Message) WithMetadata(valueOrMatcher map[string]string) *Message {
+	if m.err != nil {
+		return m
+	}
+
 	for k, v := range valueOrMatcher {
 
 		cName := C.CString(k)
@@ -261,25 +335,47 @@ func (m *Message) WithMetadata(valueOrMatcher map[string]string) *Message {
 	return m
 }
 
-func (m *Message) WithRequestBinaryContents(body []byte) *Message {
-	cHeader := C.CString("application/octet-stream")
-	defer free(cHeader)
+// WithMetadataMatcher adds metadata whose value is a matcher, e.g. {"pact:matcher:type": "regex", ...}
+func (m *Message) WithMetadataMatcher(key string, matcher interface{}) *Message {
+	if m.err != nil {
+		return m
+	}
 
-	// TODO: handle response
-	res := C.pactffi_with_binary_file(m.handle, C.int(INTERACTION_PART_REQUEST), cHeader, (*C.char)(unsafe.Pointer(&body[0])), C.int(len(body)))
+	cName := C.CString(key)
+	defer free(cName)
+	cValue := C.CString(stringFromInterface(matcher))
+	defer free(cValue)
 
-	log.Println("[DEBUG] WithRequestBinaryContents - pactffi_with_binary_file returned", int(res))
+	C.pactffi_message_with_metadata_v2(m.handle, cName, cValue)
 
 	return m
 }
+
+func (m *Message) WithRequestBinaryContents(body []byte) *Message {
+	return m.withBinaryContents("WithRequestBinaryContents", INTERACTION_PART_REQUEST, "application/octet-stream", body)
+}
+
 func (m *Message) WithRequestBinaryContentType(contentType string, body []byte) *Message {
+	return m.withBinaryContents("WithRequestBinaryContentType", INTERACTION_PART_REQUEST, contentType, body)
+}
+
+func (m *Message) withBinaryContents(call string, part interactionPart, contentType string, body []byte) *Message {
+	if m.err != nil {
+		return m
+	}
+	if len(body) == 0 {
+		m.fail(call, "the %s contents are empty", contentType)
+		return m
+	}
+
 	cHeader := C.CString(contentType)
 	defer free(cHeader)
 
-	// TODO: handle response
-	res := C.pactffi_with_binary_file(m.handle, C.int(INTERACTION_PART_REQUEST), cHeader, (*C.char)(unsafe.Pointer(&body[0])), C.int(len(body)))
-
-	log.Println("[DEBUG] WithRequestBinaryContents - pactffi_with_binary_file returned", int(res))
+	res := C.pactffi_with_binary_file(m.handle, C.int(part), cHeader, (*C.char)(unsafe.Pointer(&body[0])), C.int(len(body)))
+	log.Printf("[DEBUG] %s - pactffi_with_binary_file returned %v", call, int(res) == 1)
+	if int(res) == 0 {
+		m.fail(call, "the native core rejected the %s contents", contentType)
+	}
 
 	return m
 }
@@ -293,13 +389,7 @@ func (m *Message) WithRequestJSONContents(body interface{}) *Message {
 }
 
 func (m *Message) WithResponseBinaryContents(body []byte) *Message {
-	cHeader := C.CString("application/octet-stream")
-	defer free(cHeader)
-
-	// TODO: handle response
-	C.pactffi_with_binary_file(m.handle, C.int(INTERACTION_PART_RESPONSE), cHeader, (*C.char)(unsafe.Pointer(&body[0])), C.int(len(body)))
-
-	return m
+	return m.withBinaryContents("WithResponseBinaryContents", INTERACTION_PART_RESPONSE, "application/octet-stream", body)
 }
 
 func (m *Message) WithResponseJSONContents(body interface{}) *Message {
@@ -313,11 +403,22 @@ func (m *Message) WithResponseJSONContents(body interface{}) *Message {
 // TODO: note that string values here must be NUL terminated.
 // Only accepts JSON
 func (m *Message) WithContents(part interactionPart, contentType string, body []byte) *Message {
+	if m.err != nil {
+		return m
+	}
+	if len(body) == 0 {
+		m.fail("WithContents", "the %s contents are empty", contentType)
+		return m
+	}
+
 	cHeader := C.CString(contentType)
 	defer free(cHeader)
 
 	res := C.pactffi_with_body(m.handle, C.int(part), cHeader, (*C.char)(unsafe.Pointer(&body[0])))
 	log.Println("[DEBUG] response from pactffi_interaction_contents", (int(res) == 1))
+	if int(res) == 0 {
+		m.fail("WithContents", "the native core rejected the %s contents, check the content type and any matchers: %s", contentType, body)
+	}
 
 	return m
 }
@@ -620,8 +721,11 @@ func (m *MessageServer) MockServerMatched(port int) bool {
 // WritePactFile writes the Pact to file.
 func (m *MessageServer) WritePactFile(dir string, overwrite bool) error {
 	log.Println("[DEBUG] writing pact file for message pact at dir:", dir)
+	m.WithMetadata(models.MetadataNamespace, models.NativeVersionMetadataKey, Version())
 	cDir := C.CString(dir)
 	defer free(cDir)
+	defer LockPactDir(dir)()
+	defer FlushLogs()
 
 	overwritePact := 0
 	if overwrite {
@@ -649,8 +753,11 @@ func (m *MessageServer) WritePactFile(dir string, overwrite bool) error {
 // WritePactFile writes the Pact to file.
 func (m *MessageServer) WritePactFileForServer(port int, dir string, overwrite bool) error {
 	log.Println("[DEBUG] writing pact file for message pact at dir:", dir)
+	m.WithMetadata(models.MetadataNamespace, models.NativeVersionMetadataKey, Version())
 	cDir := C.CString(dir)
 	defer free(cDir)
+	defer LockPactDir(dir)()
+	defer FlushLogs()
 
 	overwritePact := 0
 	if overwrite {
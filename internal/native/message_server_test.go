@@ -9,10 +9,12 @@ import (
 	"io/ioutil"
 	l "log"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/pact-foundation/pact-go/v2/log"
+	"github.com/pact-foundation/pact-go/v2/models"
 	"google.golang.org/protobuf/proto"
 
 	"github.com/stretchr/testify/assert"
@@ -47,6 +49,81 @@ func TestHandleBasedMessageTestsWithString(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestWritePactFileRecordsNativeVersion(t *testing.T) {
+	tmpPactFolder, err := ioutil.TempDir("", "pact-go")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpPactFolder)
+
+	s := NewMessageServer("test-version-consumer", "test-version-provider")
+	s.NewMessage().
+		ExpectsToReceive("some message").
+		WithContents(INTERACTION_PART_REQUEST, "text/plain", []byte("some string"))
+
+	err = s.WritePactFile(tmpPactFolder, false)
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpPactFolder, "test-version-consumer-test-version-provider.json"))
+	assert.NoError(t, err)
+
+	var pact struct {
+		Metadata map[string]map[string]interface{} `json:"metadata"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &pact))
+	assert.NotEmpty(t, Version())
+	assert.Equal(t, Version(), pact.Metadata[models.MetadataNamespace][models.NativeVersionMetadataKey])
+}
+
+func TestMessageRecordsEveryProviderState(t *testing.T) {
+	tmpPactFolder, err := ioutil.TempDir("", "pact-go")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpPactFolder)
+
+	s := NewMessageServer("test-states-consumer", "test-states-provider")
+	m := s.NewMessage().
+		Given("a user exists").
+		GivenWithParameter("an account exists", map[string]interface{}{"id": 1}).
+		GivenWithParameter("an account exists", map[string]interface{}{"id": 2}).
+		ExpectsToReceive("some message").
+		WithContents(INTERACTION_PART_REQUEST, "text/plain", []byte("some string"))
+	assert.NoError(t, m.Err())
+
+	err = s.WritePactFile(tmpPactFolder, false)
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(tmpPactFolder, "test-states-consumer-test-states-provider.json"))
+	assert.NoError(t, err)
+
+	var pact struct {
+		Messages []struct {
+			ProviderStates []models.ProviderState `json:"providerStates"`
+		} `json:"messages"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &pact))
+	assert.Len(t, pact.Messages, 1)
+	assert.Equal(t, []models.ProviderState{
+		{Name: "a user exists"},
+		{Name: "an account exists", Parameters: map[string]interface{}{"id": float64(1)}},
+		{Name: "an account exists", Parameters: map[string]interface{}{"id": float64(2)}},
+	}, pact.Messages[0].ProviderStates)
+}
+
+func TestMessageRecordsFirstNativeError(t *testing.T) {
+	s := NewMessageServer("test-error-consumer", "test-error-provider")
+	m := s.NewMessage().
+		ExpectsToReceive("some message").
+		WithContents(INTERACTION_PART_REQUEST, "application/json", []byte{}).
+		WithRequestBinaryContents([]byte{})
+
+	assert.Error(t, m.Err())
+	assert.Contains(t, m.Err().Error(), "WithContents:")
+
+	ok := s.NewMessage().
+		Given("some state").
+		ExpectsToReceive("another message").
+		WithContents(INTERACTION_PART_REQUEST, "text/plain", []byte("some string"))
+	assert.NoError(t, ok.Err())
+}
+
 func TestHandleBasedMessageTestsWithJSON(t *testing.T) {
 	tmpPactFolder, err := ioutil.TempDir("", "pact-go")
 	assert.NoError(t, err)
@@ -126,6 +203,22 @@ func TestHandleBasedMessageTestsWithBinary(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestBinaryContentsRoundTrip(t *testing.T) {
+	s := NewMessageServer("test-binarymessage-consumer", "test-binarymessage-provider")
+
+	// the PNG signature and the start of an IHDR chunk, with NUL bytes
+	png := []byte{0x89, 'P', 'N', 'G', '\r', '\n', 0x1a, '\n', 0x00, 0x00, 0x00, 0x0d, 'I', 'H', 'D', 'R', 0xff}
+
+	m := s.NewMessage().
+		ExpectsToReceive("an image").
+		WithRequestBinaryContentType("image/png", png)
+	assert.NoError(t, m.Err())
+
+	body, err := m.GetMessageRequestContents()
+	assert.NoError(t, err)
+	assert.Equal(t, png, body)
+}
+
 func TestGetAsyncMessageContentsAsBytes(t *testing.T) {
 	s := NewMessageServer("test-message-consumer", "test-message-provider")
 
@@ -28,11 +28,13 @@ type Request struct {
 
 // MismatchDetail contains the specific assertions that failed during the verification
 type MismatchDetail struct {
-	Actual   string
-	Expected string
-	Key      string
-	Mismatch string
-	Type     string
+	Actual    string
+	Expected  string
+	Key       string
+	Path      string
+	Parameter string
+	Mismatch  string
+	Type      string
 }
 
 // MismatchedRequest contains details of any request mismatches during pact verification
@@ -62,10 +62,10 @@ int pactffi_create_mock_server_for_pact(PactHandle pact, const char *addr_str, b
 void pactffi_with_specification(PactHandle pact, int specification_version);
 
 /// Adds a provider state to the Interaction
-void pactffi_given(InteractionHandle interaction, const char *description);
+bool pactffi_given(InteractionHandle interaction, const char *description);
 
 /// Adds a provider state with params to the Interaction
-void pactffi_given_with_param(InteractionHandle interaction, const char *description, const char *name, const char *value);
+bool pactffi_given_with_param(InteractionHandle interaction, const char *description, const char *name, const char *value);
 
 /// Get self signed certificate for TLS mode
 char* pactffi_get_tls_ca_certificate();
@@ -148,10 +148,10 @@ void pactffi_with_pact_metadata(PactHandle pact, const char *namespace, const ch
 
 // Additional global logging functions
 //void pactffi_log_message(const char *source, const char *log_level, const char *message);
-//int pactffi_log_to_buffer(int level);
+int pactffi_log_to_buffer(int level);
 int pactffi_log_to_stdout(int level);
 int pactffi_log_to_file(const char *file_name, int level_filter);
-//char* pactffi_fetch_log_buffer();
+char* pactffi_fetch_log_buffer(const char *log_id);
 
 int pactffi_using_plugin(PactHandle pact, const char *plugin_name, const char *plugin_version);
 void pactffi_cleanup_plugins(PactHandle pact);
@@ -171,7 +171,11 @@ import (
 	"log"
 	"os"
 	"strings"
+	"sync"
 	"unsafe"
+
+	logging "github.com/pact-foundation/pact-go/v2/log"
+	"github.com/pact-foundation/pact-go/v2/models"
 )
 
 type interactionPart int
@@ -236,28 +240,116 @@ func Version() string {
 
 var loggingInitialised string
 
-// Init initialises the library
+// references counts the callers of Init that have not yet called Shutdown
+var references refCount
+
+// refCount is a reference count, running a function as the first reference is acquired
+// and as the last is released
+type refCount struct {
+	mu    sync.Mutex
+	count int
+}
+
+// acquire adds a reference, running first if there were none, and returns the number of
+// references
+func (r *refCount) acquire(first func()) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.count++
+	if r.count == 1 {
+		first()
+	}
+
+	return r.count
+}
+
+// release removes a reference, running last if it was the last, and returns the number of
+// references left. It returns false if there were no references to release
+func (r *refCount) release(last func()) (int, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.count == 0 {
+		return 0, false
+	}
+	r.count--
+	if r.count == 0 {
+		last()
+	}
+
+	return r.count, true
+}
+
+// Init initialises the library, and must be balanced with a call to Shutdown once the
+// caller no longer needs the native interface. Calls are reference counted, so it is
+// safe for independent pacts to initialise and shutdown concurrently.
+//
+// NOTE: the native logger is a process wide singleton, so the log level is fixed by the first call
 func Init(logLevel string) {
-	log.Println("[DEBUG] initialising native interface")
-	logLevel = strings.ToUpper(logLevel)
+	count := references.acquire(func() {
+		initLogging(strings.ToUpper(logLevel))
+	})
+	log.Println("[DEBUG] initialised native interface, active references:", count)
+}
 
+// initLogging directs the native logs, once a first reference to the native interface is
+// acquired. The native logger can only be set up once, so later calls only attach the
+// logger set with log.SetNativeLogger again.
+func initLogging(logLevel string) {
 	if loggingInitialised != "" {
-		log.Printf("log level ('%s') cannot be set to '%s' after initialisation\n", loggingInitialised, logLevel)
-	} else {
-		l, ok := logLevelStringToInt[logLevel]
-		if !ok {
-			l = LOG_LEVEL_INFO
+		if loggingInitialised != logLevel {
+			log.Printf("log level ('%s') cannot be set to '%s' after initialisation\n", loggingInitialised, logLevel)
 		}
-		log.Printf("[DEBUG] initialised native log level to %s (%d)", logLevel, l)
-
-		if os.Getenv("PACT_LOG_PATH") != "" {
-			log.Println("[DEBUG] initialised native log to log to file:", os.Getenv("PACT_LOG_PATH"))
-			logToFile(os.Getenv("PACT_LOG_PATH"), l)
-		} else {
-			log.Println("[DEBUG] initialised native log to log to stdout")
-			logToStdout(l)
+		if loggingToBuffer {
+			attachNativeLogger(logging.NativeLogger())
 		}
+		return
 	}
+
+	l, ok := logLevelStringToInt[logLevel]
+	if !ok {
+		l = LOG_LEVEL_INFO
+	}
+	log.Printf("[DEBUG] initialised native log level to %s (%d)", logLevel, l)
+
+	if os.Getenv("PACT_LOG_PATH") != "" {
+		log.Println("[DEBUG] initialised native log to log to file:", os.Getenv("PACT_LOG_PATH"))
+		logToFile(os.Getenv("PACT_LOG_PATH"), l)
+	} else if logging.NativeLogger() != nil {
+		log.Println("[DEBUG] initialised native log to log to the native logger")
+		if logToBuffer(l) == nil {
+			loggingToBuffer = true
+			attachNativeLogger(logging.NativeLogger())
+		}
+	} else {
+		log.Println("[DEBUG] initialised native log to log to stdout")
+		logToStdout(l)
+	}
+	loggingInitialised = logLevel
+}
+
+// Shutdown releases a reference obtained from Init, returning the number of references
+// still active. Calls without a matching Init are ignored.
+//
+// Once the last reference is released, the native logs are flushed and the logger set with
+// log.SetNativeLogger is detached, until the next call to Init. The native logger itself is
+// process wide, and can't be released by the native interface.
+func Shutdown() int {
+	count, ok := references.release(func() {
+		FlushLogs()
+		attachNativeLogger(nil)
+	})
+	if !ok {
+		log.Println("[WARN] native interface shutdown called without a matching call to Init")
+		return 0
+	}
+	log.Println("[DEBUG] released native interface, active references:", count)
+	if count > 0 {
+		FlushLogs()
+	}
+
+	return count
 }
 
 // MockServer is the public interface for managing the HTTP mock server
@@ -376,8 +468,11 @@ func (m *MockServer) CleanupMockServer(port int) bool {
 // TODO: expose overwrite
 func (m *MockServer) WritePactFile(port int, dir string) error {
 	log.Println("[DEBUG] writing pact file for mock server on port:", port, ", dir:", dir)
+	m.WithMetadata(models.MetadataNamespace, models.NativeVersionMetadataKey, Version())
 	cDir := C.CString(dir)
 	defer free(cDir)
+	defer LockPactDir(dir)()
+	defer FlushLogs()
 
 	// overwritePact := 0
 	// if overwrite {
@@ -848,12 +943,12 @@ func quotedString(s string) string {
 // 	log.Println("[DEBUG] log_to_buffer res", res)
 // }
 
-// func logToBuffer(level logLevel) error {
-// 	res := C.pactffi_log_to_buffer(C.int(level))
-// 	log.Println("[DEBUG] log_to_buffer res", res)
+func logToBuffer(level logLevel) error {
+	res := C.pactffi_log_to_buffer(C.int(level))
+	log.Println("[DEBUG] log_to_buffer res", res)
 
-// 	return logResultToError(int(res))
-// }
+	return logResultToError(int(res))
+}
 
 func logToStdout(level logLevel) error {
 	res := C.pactffi_log_to_stdout(C.int(level))
@@ -872,12 +967,65 @@ func logToFile(file string, level logLevel) error {
 	return logResultToError(int(res))
 }
 
-// func getLogBuffer() string {
-// 	buf := C.pactffi_fetch_log_buffer()
-// 	defer free(buf)
+// nativeLogs tracks the native log lines forwarded to the native logger
+var nativeLogs struct {
+	sync.Mutex
+	logger    logging.Logger
+	forwarded int
+}
 
-// 	return C.GoString(buf)
-// }
+// loggingToBuffer is set if the native logs are written to the buffer forwarded to the
+// native logger
+var loggingToBuffer bool
+
+// attachNativeLogger sets the logger the native logs are forwarded to, or stops forwarding
+// them if it is nil
+func attachNativeLogger(logger logging.Logger) {
+	nativeLogs.Lock()
+	defer nativeLogs.Unlock()
+
+	nativeLogs.logger = logger
+}
+
+// FlushLogs forwards the native log lines written since the last flush to the logger set
+// with log.SetNativeLogger, at the level of each line
+func FlushLogs() {
+	nativeLogs.Lock()
+	defer nativeLogs.Unlock()
+
+	if nativeLogs.logger == nil {
+		return
+	}
+
+	buf := C.pactffi_fetch_log_buffer(nil)
+	if buf == nil {
+		return
+	}
+	defer libRustFree(buf)
+	contents := C.GoString(buf)
+
+	// The buffer may or may not be cleared once fetched
+	if len(contents) < nativeLogs.forwarded {
+		nativeLogs.forwarded = 0
+	}
+	for _, line := range strings.Split(contents[nativeLogs.forwarded:], "\n") {
+		if strings.TrimSpace(line) != "" {
+			nativeLogs.logger.Log(nativeLogLevel(line), "%s", line)
+		}
+	}
+	nativeLogs.forwarded = len(contents)
+}
+
+// nativeLogLevel is the level of a native log line, e.g. "2023-01-01T00:00:00Z DEBUG ..."
+func nativeLogLevel(line string) string {
+	for _, field := range strings.Fields(line) {
+		if _, ok := logLevelStringToInt[field]; ok && field != "OFF" {
+			return field
+		}
+	}
+
+	return "INFO"
+}
 
 func logResultToError(res int) error {
 	switch res {
@@ -5,6 +5,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
+	"sync"
+	"sync/atomic"
 	"testing"
 
 	"github.com/pact-foundation/pact-go/v2/log"
@@ -16,6 +18,68 @@ func init() {
 	Init("")
 }
 
+func TestInit_ReferenceCounting(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			Init("INFO")
+			Shutdown()
+		}()
+	}
+	wg.Wait()
+
+	// The reference from init() must still be held
+	Init("")
+	assert.Equal(t, 1, Shutdown())
+}
+
+func TestRefCount(t *testing.T) {
+	var r refCount
+	var calls []string
+	first := func() { calls = append(calls, "first") }
+	last := func() { calls = append(calls, "last") }
+
+	assert.Equal(t, 1, r.acquire(first))
+	assert.Equal(t, 2, r.acquire(first))
+	count, ok := r.release(last)
+	assert.Equal(t, 1, count)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"first"}, calls)
+
+	count, ok = r.release(last)
+	assert.Equal(t, 0, count)
+	assert.True(t, ok)
+	assert.Equal(t, []string{"first", "last"}, calls)
+
+	count, ok = r.release(last)
+	assert.Equal(t, 0, count)
+	assert.False(t, ok, "a release without an acquire")
+	assert.Equal(t, []string{"first", "last"}, calls)
+
+	assert.Equal(t, 1, r.acquire(first))
+	assert.Equal(t, []string{"first", "last", "first"}, calls)
+}
+
+func TestRefCount_Concurrent(t *testing.T) {
+	var r refCount
+	var firsts, lasts int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.acquire(func() { atomic.AddInt32(&firsts, 1) })
+			r.release(func() { atomic.AddInt32(&lasts, 1) })
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 0, r.count)
+	assert.Equal(t, firsts, lasts, "each first reference is released")
+}
+
 func TestMockServer_CreateAndCleanupMockServer(t *testing.T) {
 	m := MockServer{}
 	port, _ := m.CreateMockServer(pactComplex, "0.0.0.0:0", false)
@@ -290,3 +354,9 @@ var pactComplex = `{
     }
   }]
 }`
+
+func TestNativeLogLevel(t *testing.T) {
+	assert.Equal(t, "DEBUG", nativeLogLevel("2023-01-01T00:00:00.000Z DEBUG ThreadId(01) pact_ffi::mock_server: started"))
+	assert.Equal(t, "WARN", nativeLogLevel(" WARN pact_plugin_driver: plugin not found"))
+	assert.Equal(t, "INFO", nativeLogLevel("an unlevelled line"))
+}
@@ -0,0 +1,23 @@
+package native
+
+import (
+	"path/filepath"
+	"sync"
+)
+
+// pactDirLocks holds a mutex for each directory pact files are written to, see LockPactDir
+var pactDirLocks sync.Map
+
+// LockPactDir serialises writes of pact files to dir within the process, returning the
+// function to release it. Pact files are merged with any existing file, so pacts sharing
+// a directory (e.g. tests run with t.Parallel) must not write to it concurrently.
+func LockPactDir(dir string) func() {
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+
+	mu, _ := pactDirLocks.LoadOrStore(filepath.Clean(dir), &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+
+	return mu.(*sync.Mutex).Unlock
+}
@@ -0,0 +1,154 @@
+package schemaregistry
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// validateAvro checks a JSON value against an Avro schema. Unions are matched by any
+// of their branches, i.e. as plain JSON rather than the Avro JSON encoding
+func validateAvro(schema string, value interface{}) error {
+	var s interface{}
+	if err := json.Unmarshal([]byte(schema), &s); err != nil {
+		return fmt.Errorf("unable to parse the Avro schema: %v", err)
+	}
+
+	v := avroValidator{named: make(map[string]interface{})}
+	if problems := v.validate("$", s, value); len(problems) > 0 {
+		return fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+
+	return nil
+}
+
+type avroValidator struct {
+	// named types (records, enums and fixed) by name, for references
+	named map[string]interface{}
+}
+
+func (v avroValidator) validate(path string, schema interface{}, value interface{}) []string {
+	switch s := schema.(type) {
+	case string:
+		if named, ok := v.named[s]; ok {
+			return v.validate(path, named, value)
+		}
+		return v.validatePrimitive(path, s, value)
+	case []interface{}:
+		for _, branch := range s {
+			if len(v.validate(path, branch, value)) == 0 {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s: %s does not match any type of the union", path, describe(value))}
+	case map[string]interface{}:
+		return v.validateComplex(path, s, value)
+	}
+
+	return []string{fmt.Sprintf("%s: invalid Avro schema", path)}
+}
+
+func (v avroValidator) validatePrimitive(path string, t string, value interface{}) []string {
+	ok := false
+	switch t {
+	case "null":
+		ok = value == nil
+	case "boolean":
+		_, ok = value.(bool)
+	case "int", "long":
+		f, isNumber := value.(float64)
+		ok = isNumber && f == math.Trunc(f)
+		if ok && t == "int" {
+			ok = f >= math.MinInt32 && f <= math.MaxInt32
+		}
+	case "float", "double":
+		_, ok = value.(float64)
+	case "string", "bytes":
+		_, ok = value.(string)
+	default:
+		return []string{fmt.Sprintf("%s: unknown Avro type '%s'", path, t)}
+	}
+
+	if !ok {
+		return []string{fmt.Sprintf("%s: expected %s but got %s", path, t, describe(value))}
+	}
+
+	return nil
+}
+
+func (v avroValidator) validateComplex(path string, s map[string]interface{}, value interface{}) []string {
+	t, _ := s["type"].(string)
+	if name, ok := s["name"].(string); ok {
+		v.named[name] = s
+		if ns, ok := s["namespace"].(string); ok {
+			v.named[ns+"."+name] = s
+		}
+	}
+
+	switch t {
+	case "record", "error":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected a record but got %s", path, describe(value))}
+		}
+		var problems []string
+		fields, _ := s["fields"].([]interface{})
+		for _, f := range fields {
+			field, _ := f.(map[string]interface{})
+			name, _ := field["name"].(string)
+			fieldValue, present := obj[name]
+			if !present {
+				if _, hasDefault := field["default"]; !hasDefault {
+					problems = append(problems, fmt.Sprintf("%s.%s: the field is missing and has no default", path, name))
+				}
+				continue
+			}
+			problems = append(problems, v.validate(path+"."+name, field["type"], fieldValue)...)
+		}
+		return problems
+	case "enum":
+		symbol, _ := value.(string)
+		symbols, _ := s["symbols"].([]interface{})
+		for _, sym := range symbols {
+			if sym == symbol && symbol != "" {
+				return nil
+			}
+		}
+		return []string{fmt.Sprintf("%s: %s is not a symbol of the enum", path, describe(value))}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected an array but got %s", path, describe(value))}
+		}
+		var problems []string
+		for i, item := range items {
+			problems = append(problems, v.validate(fmt.Sprintf("%s[%d]", path, i), s["items"], item)...)
+		}
+		return problems
+	case "map":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: expected a map but got %s", path, describe(value))}
+		}
+		var problems []string
+		for k, item := range obj {
+			problems = append(problems, v.validate(path+"."+k, s["values"], item)...)
+		}
+		return problems
+	case "fixed":
+		if _, ok := value.(string); !ok {
+			return []string{fmt.Sprintf("%s: expected fixed bytes but got %s", path, describe(value))}
+		}
+		return nil
+	}
+
+	// a primitive type with attributes, e.g. {"type": "long", "logicalType": "timestamp-millis"}
+	return v.validate(path, s["type"], value)
+}
+
+func describe(v interface{}) string {
+	b, _ := json.Marshal(v)
+
+	return string(b)
+}
@@ -0,0 +1,133 @@
+// Package schemaregistry validates message content against the schemas of a
+// Confluent compatible schema registry.
+package schemaregistry
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/v2/internal/jsonschema"
+)
+
+// Schema is a version of a subject registered with the schema registry
+type Schema struct {
+	Subject    string `json:"subject"`
+	ID         int    `json:"id"`
+	Version    int    `json:"version"`
+	SchemaType string `json:"schemaType"`
+	Schema     string `json:"schema"`
+}
+
+// Client queries a schema registry
+type Client struct {
+	URL        string
+	HTTPClient *http.Client
+}
+
+// Latest returns the latest version of the schema registered for the subject
+func (c Client) Latest(subject string) (Schema, error) {
+	var s Schema
+	err := c.get(fmt.Sprintf("/subjects/%s/versions/latest", url.PathEscape(subject)), &s)
+	if s.SchemaType == "" {
+		// The registry omits the type of Avro schemas
+		s.SchemaType = "AVRO"
+	}
+
+	return s, err
+}
+
+// registered reports whether the schema with the given id is a version of the subject
+func (c Client) registered(subject string, id uint32) (bool, error) {
+	var versions []struct {
+		Subject string `json:"subject"`
+	}
+	if err := c.get(fmt.Sprintf("/schemas/ids/%d/versions", id), &versions); err != nil {
+		return false, err
+	}
+
+	for _, v := range versions {
+		if v.Subject == subject {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+func (c Client) get(path string, v interface{}) error {
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	u := strings.TrimSuffix(c.URL, "/") + path
+	log.Println("[DEBUG] fetching schema from", u)
+
+	res, err := client.Get(u)
+	if err != nil {
+		return fmt.Errorf("unable to reach the schema registry: %v", err)
+	}
+	defer res.Body.Close()
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("unable to read the schema registry response: %v", err)
+	}
+	if res.StatusCode >= 300 {
+		return fmt.Errorf("the schema registry responded with %d: %s", res.StatusCode, body)
+	}
+
+	return json.Unmarshal(body, v)
+}
+
+// Validate checks the content of a message against the latest schema of the subject.
+//
+// Content in the Confluent wire format (a zero magic byte followed by a 4 byte schema id)
+// must be encoded with a schema registered for the subject. Otherwise the content must be
+// JSON, and is validated against subjects with JSON or Avro schemas.
+func (c Client) Validate(subject string, contents []byte) error {
+	latest, err := c.Latest(subject)
+	if err != nil {
+		return fmt.Errorf("unable to fetch the schema for subject '%s': %v", subject, err)
+	}
+
+	if len(contents) >= 5 && contents[0] == 0 {
+		id := binary.BigEndian.Uint32(contents[1:5])
+		if int(id) == latest.ID {
+			return nil
+		}
+		ok, err := c.registered(subject, id)
+		if err != nil {
+			return fmt.Errorf("unable to look up schema id %d: %v", id, err)
+		}
+		if !ok {
+			return fmt.Errorf("the content is encoded with schema id %d, which is not registered for subject '%s'", id, subject)
+		}
+		return nil
+	}
+
+	var value interface{}
+	if err = json.Unmarshal(contents, &value); err != nil {
+		return fmt.Errorf("the content is neither in the Confluent wire format nor JSON, and can't be validated against subject '%s'", subject)
+	}
+
+	switch latest.SchemaType {
+	case "JSON":
+		err = jsonschema.Validate([]byte(latest.Schema), value)
+	case "AVRO":
+		err = validateAvro(latest.Schema, value)
+	default:
+		err = fmt.Errorf("%s schemas can only be checked for content in the Confluent wire format", latest.SchemaType)
+	}
+	if err != nil {
+		return fmt.Errorf("the content does not match version %d of subject '%s': %v", latest.Version, subject, err)
+	}
+
+	return nil
+}
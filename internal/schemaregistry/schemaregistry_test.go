@@ -0,0 +1,86 @@
+package schemaregistry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const userAvroSchema = `{
+  "type": "record",
+  "name": "User",
+  "fields": [
+    {"name": "id", "type": "long"},
+    {"name": "name", "type": "string"},
+    {"name": "email", "type": ["null", "string"], "default": null},
+    {"name": "role", "type": {"type": "enum", "name": "Role", "symbols": ["ADMIN", "USER"]}},
+    {"name": "manager", "type": ["null", "User"], "default": null}
+  ]
+}`
+
+func TestClient_Validate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/subjects/users-value/versions/latest":
+			w.Write([]byte(`{"subject": "users-value", "id": 7, "version": 3, "schema": ` + quote(userAvroSchema) + `}`))
+		case "/subjects/orders-value/versions/latest":
+			w.Write([]byte(`{"subject": "orders-value", "id": 9, "version": 1, "schemaType": "JSON", "schema": "{\"type\": \"object\", \"required\": [\"id\"]}"}`))
+		case "/schemas/ids/5/versions":
+			w.Write([]byte(`[{"subject": "users-value", "version": 2}]`))
+		case "/schemas/ids/6/versions":
+			w.Write([]byte(`[{"subject": "accounts-value", "version": 1}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error_code": 40401, "message": "Subject not found"}`))
+		}
+	}))
+	defer server.Close()
+
+	c := Client{URL: server.URL}
+
+	t.Run("avro subjects", func(t *testing.T) {
+		assert.NoError(t, c.Validate("users-value", []byte(`{"id": 1, "name": "billy", "role": "ADMIN", "manager": {"id": 2, "name": "bob", "role": "USER"}}`)))
+
+		err := c.Validate("users-value", []byte(`{"id": 1.5, "role": "OWNER", "email": 1}`))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "$.id: expected long")
+		assert.Contains(t, err.Error(), "$.name: the field is missing")
+		assert.Contains(t, err.Error(), "$.role")
+		assert.Contains(t, err.Error(), "$.email")
+	})
+
+	t.Run("json subjects", func(t *testing.T) {
+		assert.NoError(t, c.Validate("orders-value", []byte(`{"id": 1}`)))
+		assert.Error(t, c.Validate("orders-value", []byte(`{}`)))
+	})
+
+	t.Run("wire format", func(t *testing.T) {
+		assert.NoError(t, c.Validate("users-value", []byte{0, 0, 0, 0, 7, 0x02}))
+		assert.NoError(t, c.Validate("users-value", []byte{0, 0, 0, 0, 5, 0x02}))
+		assert.Error(t, c.Validate("users-value", []byte{0, 0, 0, 0, 6, 0x02}))
+	})
+
+	t.Run("unknown subjects", func(t *testing.T) {
+		err := c.Validate("missing-value", []byte(`{}`))
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "Subject not found")
+	})
+}
+
+func quote(s string) string {
+	b := []byte{'"'}
+	for _, r := range s {
+		switch r {
+		case '"':
+			b = append(b, '\\', '"')
+		case '\n':
+			b = append(b, '\\', 'n')
+		default:
+			b = append(b, string(r)...)
+		}
+	}
+
+	return string(append(b, '"'))
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"github.com/hashicorp/logutils"
 )
@@ -63,6 +64,86 @@ func LogLevel() logutils.LogLevel {
 	return logutils.LogLevel(defaultLogLevel)
 }
 
+// Logger receives the log output of the framework, e.g. to redirect or silence it
+type Logger interface {
+	// Log records a message at a level such as "DEBUG" or "WARN". The message is
+	// formatted with the args as by fmt.Sprintf
+	Log(level, msg string, args ...interface{})
+}
+
+// Logf logs a message with the logger, or if it is nil with the standard logger,
+// filtered by the framework log level (see SetLogLevel)
+func Logf(logger Logger, level, format string, args ...interface{}) {
+	if logger != nil {
+		logger.Log(level, format, args...)
+		return
+	}
+
+	log.Printf("[%s] %s", level, fmt.Sprintf(format, args...))
+}
+
+// WithLevel filters the messages given to logger, dropping those below level, e.g. for a
+// pact with a log level of its own. If logger is nil the messages are written to stderr,
+// independently of the framework log level
+func WithLevel(logger Logger, level logutils.LogLevel) (Logger, error) {
+	min := levelRank(level)
+	if min < 0 {
+		return nil, fmt.Errorf(`invalid logLevel '%s'. Please specify one of "TRACE", "DEBUG", "INFO", "WARN", "ERROR"`, level)
+	}
+	if logger == nil {
+		logger = &stdLogger{log.New(os.Stderr, "", log.LstdFlags)}
+	}
+
+	return &levelLogger{logger: logger, min: min}, nil
+}
+
+type levelLogger struct {
+	logger Logger
+	min    int
+}
+
+func (l *levelLogger) Log(level, msg string, args ...interface{}) {
+	if r := levelRank(logutils.LogLevel(level)); r >= 0 && r < l.min {
+		return
+	}
+
+	l.logger.Log(level, msg, args...)
+}
+
+type stdLogger struct {
+	log *log.Logger
+}
+
+func (l *stdLogger) Log(level, msg string, args ...interface{}) {
+	l.log.Printf("[%s] %s", level, fmt.Sprintf(msg, args...))
+}
+
+// levelRank orders the log levels by severity, or is -1 for an unknown level
+func levelRank(level logutils.LogLevel) int {
+	for i, l := range logFilter.Levels {
+		if l == logutils.LogLevel(strings.ToUpper(string(level))) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+var nativeLogger Logger
+
+// SetNativeLogger routes the log output of the native core to the logger, rather than
+// to stdout, e.g. to capture it in CI. Lines are forwarded as pact files are written
+// and when the native interface is shut down. It must be called before the first pact
+// is created, and is ignored if PACT_LOG_PATH is set
+func SetNativeLogger(logger Logger) {
+	nativeLogger = logger
+}
+
+// NativeLogger returns the logger set with SetNativeLogger
+func NativeLogger() Logger {
+	return nativeLogger
+}
+
 func PactCrash(err error) {
 	log.Panicf(crashMessage, err.Error())
 }
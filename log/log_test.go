@@ -0,0 +1,33 @@
+package log
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Log(level, msg string, args ...interface{}) {
+	l.lines = append(l.lines, fmt.Sprintf("[%s] %s", level, fmt.Sprintf(msg, args...)))
+}
+
+func TestWithLevel(t *testing.T) {
+	recorder := &recordingLogger{}
+	logger, err := WithLevel(recorder, "warn")
+	assert.NoError(t, err)
+
+	logger.Log("DEBUG", "dropped")
+	logger.Log("INFO", "dropped")
+	logger.Log("WARN", "kept %d", 1)
+	logger.Log("ERROR", "kept %d", 2)
+	logger.Log("CUSTOM", "kept %d", 3)
+
+	assert.Equal(t, []string{"[WARN] kept 1", "[ERROR] kept 2", "[CUSTOM] kept 3"}, recorder.lines)
+
+	_, err = WithLevel(recorder, "LOUD")
+	assert.Error(t, err)
+}
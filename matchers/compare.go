@@ -0,0 +1,599 @@
+package matchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Mismatch describes a single expectation that a concrete value failed to meet
+// when compared against a (possibly matcher decorated) template
+type Mismatch struct {
+	// Path is the JSON path of the offending value, e.g. $.items[0].id
+	Path string `json:"path"`
+
+	// Expected is the expected value or matcher example
+	Expected interface{} `json:"expected"`
+
+	// Actual is the value found at the path (nil if it was missing)
+	Actual interface{} `json:"actual"`
+
+	// Mismatch is a human readable description of the problem
+	Mismatch string `json:"mismatch"`
+}
+
+func (m Mismatch) String() string {
+	return fmt.Sprintf("%s: %s", m.Path, m.Mismatch)
+}
+
+type cascade int
+
+const (
+	cascadeEquality cascade = iota
+	cascadeType
+)
+
+// Compare checks a concrete value against a template that may contain matchers,
+// returning every mismatch found. Both values are normalised through their JSON
+// representation, so the template may be any structure accepted by WithJSONContent
+// (Matchers, StructMatcher, maps, structs) and the actual value may be raw JSON bytes.
+//
+// As with the Pact specification, additional keys in actual objects are permitted.
+func Compare(expected interface{}, actual interface{}) ([]Mismatch, error) {
+	e, err := normalise(expected)
+	if err != nil {
+		return nil, fmt.Errorf("unable to normalise the expected value: %v", err)
+	}
+	a, err := normalise(actual)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the actual value as JSON: %v", err)
+	}
+
+	return compareValue("$", e, a, cascadeEquality), nil
+}
+
+// Example returns the example value of a template that may contain matchers, at any
+// depth, i.e. the value a message built from the template would carry
+func Example(template interface{}) (interface{}, error) {
+	t, err := normalise(template)
+	if err != nil {
+		return nil, fmt.Errorf("unable to normalise the template: %v", err)
+	}
+
+	return exampleOf(t), nil
+}
+
+func normalise(obj interface{}) (interface{}, error) {
+	var raw []byte
+	var err error
+
+	switch v := obj.(type) {
+	case []byte:
+		raw = v
+	case json.RawMessage:
+		raw = v
+	default:
+		raw, err = json.Marshal(obj)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var res interface{}
+	err = json.Unmarshal(raw, &res)
+
+	return res, err
+}
+
+func compareValue(path string, expected interface{}, actual interface{}, mode cascade) []Mismatch {
+	switch e := expected.(type) {
+	case map[string]interface{}:
+		if matcherType, ok := e["pact:matcher:type"].(string); ok {
+			return compareMatcher(path, matcherType, e, actual, mode)
+		}
+		return compareObject(path, e, actual, mode)
+	case []interface{}:
+		return compareArray(path, e, actual, mode)
+	default:
+		return comparePrimitive(path, e, actual, mode)
+	}
+}
+
+func compareObject(path string, expected map[string]interface{}, actual interface{}, mode cascade) []Mismatch {
+	a, ok := actual.(map[string]interface{})
+	if !ok {
+		return []Mismatch{mismatch(path, expected, actual, "expected an object but got %s", jsonKind(actual))}
+	}
+
+	var res []Mismatch
+	for _, k := range sortedKeys(expected) {
+		childPath := objectPath(path, k)
+		v, present := a[k]
+		if !present {
+			res = append(res, mismatch(childPath, exampleOf(expected[k]), nil, "expected key '%s' was missing", k))
+			continue
+		}
+		res = append(res, compareValue(childPath, expected[k], v, mode)...)
+	}
+
+	return res
+}
+
+func compareArray(path string, expected []interface{}, actual interface{}, mode cascade) []Mismatch {
+	a, ok := actual.([]interface{})
+	if !ok {
+		return []Mismatch{mismatch(path, exampleOf(expected), actual, "expected an array but got %s", jsonKind(actual))}
+	}
+
+	if mode == cascadeEquality && len(expected) != len(a) {
+		return []Mismatch{mismatch(path, exampleOf(expected), actual, "expected an array with %d element(s) but got %d", len(expected), len(a))}
+	}
+
+	var res []Mismatch
+	for i, v := range a {
+		template := interface{}(nil)
+		if i < len(expected) {
+			template = expected[i]
+		} else if len(expected) > 0 {
+			template = expected[0]
+		}
+		res = append(res, compareValue(fmt.Sprintf("%s[%d]", path, i), template, v, mode)...)
+	}
+
+	return res
+}
+
+func comparePrimitive(path string, expected interface{}, actual interface{}, mode cascade) []Mismatch {
+	if mode == cascadeType {
+		if jsonKind(expected) != jsonKind(actual) {
+			return []Mismatch{mismatch(path, expected, actual, "expected %s but got %s", jsonKind(expected), jsonKind(actual))}
+		}
+		return nil
+	}
+
+	if !reflect.DeepEqual(expected, actual) {
+		return []Mismatch{mismatch(path, expected, actual, "expected %s but got %s", formatValue(expected), formatValue(actual))}
+	}
+
+	return nil
+}
+
+func compareMatcher(path string, matcherType string, m map[string]interface{}, actual interface{}, mode cascade) []Mismatch {
+	value := m["value"]
+
+	switch matcherType {
+	case "type":
+		var res []Mismatch
+		if _, isArray := value.([]interface{}); isArray && (m["min"] != nil || m["max"] != nil) {
+			res = compareMinMax(path, m, actual)
+		} else {
+			res = compareValue(path, value, actual, cascadeType)
+		}
+		if field, ok := m[uniqueByKey].(string); ok {
+			res = append(res, compareUnique(path, field, actual)...)
+		}
+		return res
+	case "regex":
+		regex, _ := m["regex"].(string)
+		return compareRegex(path, value, regex, actual)
+	case "integer":
+		n, ok := actual.(float64)
+		if !ok || n != float64(int64(n)) {
+			return []Mismatch{mismatch(path, value, actual, "expected an integer but got %s", formatValue(actual))}
+		}
+	case "decimal", "number":
+		if _, ok := actual.(float64); !ok {
+			return []Mismatch{mismatch(path, value, actual, "expected a number but got %s", jsonKind(actual))}
+		}
+	case "boolean":
+		if _, ok := actual.(bool); !ok {
+			return []Mismatch{mismatch(path, value, actual, "expected a boolean but got %s", jsonKind(actual))}
+		}
+	case "include":
+		s, ok := actual.(string)
+		include := fmt.Sprintf("%v", value)
+		if !ok || !strings.Contains(s, include) {
+			return []Mismatch{mismatch(path, value, actual, "expected a string including '%s' but got %s", include, formatValue(actual))}
+		}
+	case "equality":
+		return compareValue(path, value, actual, cascadeEquality)
+	case "null":
+		if actual != nil {
+			return []Mismatch{mismatch(path, nil, actual, "expected null but got %s", formatValue(actual))}
+		}
+	case "notEmpty":
+		if isEmpty(actual) {
+			return []Mismatch{mismatch(path, value, actual, "expected a non-empty value but got %s", formatValue(actual))}
+		}
+		return compareValue(path, value, actual, cascadeType)
+	case "values":
+		return compareValues(path, value, actual)
+	case "eachKey", "eachValue":
+		rules, _ := m["rules"].([]interface{})
+		return compareEach(path, matcherType == "eachKey", rules, value, actual)
+	case "arrayContains":
+		variants, _ := m["variants"].([]interface{})
+		res := compareArrayContains(path, variants, actual)
+		if eachOneOf, _ := m["pact:eachOneOf"].(bool); eachOneOf {
+			res = append(res, compareEachOneOf(path, variants, actual)...)
+		}
+		return res
+	case "date", "time", "timestamp", "datetime":
+		format, _ := m["format"].(string)
+		return compareDateTime(path, matcherType, format, value, actual)
+	default:
+		// Unknown (or plugin provided) matchers fall back to matching on type
+		return compareValue(path, value, actual, cascadeType)
+	}
+
+	return nil
+}
+
+func compareMinMax(path string, m map[string]interface{}, actual interface{}) []Mismatch {
+	value, _ := m["value"].([]interface{})
+	a, ok := actual.([]interface{})
+	if !ok {
+		return []Mismatch{mismatch(path, exampleOf(value), actual, "expected an array but got %s", jsonKind(actual))}
+	}
+
+	var res []Mismatch
+	if min, ok := m["min"].(float64); ok && len(a) < int(min) {
+		res = append(res, mismatch(path, exampleOf(value), actual, "expected an array with at least %d element(s) but got %d", int(min), len(a)))
+	}
+	if max, ok := m["max"].(float64); ok && max > 0 && len(a) > int(max) {
+		res = append(res, mismatch(path, exampleOf(value), actual, "expected an array with at most %d element(s) but got %d", int(max), len(a)))
+	}
+
+	if len(value) == 0 {
+		return res
+	}
+	for i, v := range a {
+		res = append(res, compareValue(fmt.Sprintf("%s[%d]", path, i), value[0], v, cascadeType)...)
+	}
+
+	return res
+}
+
+// compareUnique reports the elements of an array repeating the value of a field of an
+// earlier element, see UniqueBy
+func compareUnique(path string, field string, actual interface{}) []Mismatch {
+	a, ok := actual.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var res []Mismatch
+	seen := make(map[string]int)
+	for i, v := range a {
+		obj, ok := v.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		value, ok := obj[field]
+		if !ok {
+			continue
+		}
+
+		key, _ := json.Marshal(value)
+		if first, ok := seen[string(key)]; ok {
+			res = append(res, mismatch(objectPath(fmt.Sprintf("%s[%d]", path, i), field), nil, value, "expected '%s' to be unique but %s is also the value of %s[%d]", field, formatValue(value), path, first))
+			continue
+		}
+		seen[string(key)] = i
+	}
+
+	return res
+}
+
+func compareRegex(path string, example interface{}, regex string, actual interface{}) []Mismatch {
+	r, err := regexp.Compile(regex)
+	if err != nil {
+		return []Mismatch{mismatch(path, example, actual, "invalid regular expression '%s': %v", regex, err)}
+	}
+
+	s, ok := regexSubject(actual)
+	if !ok {
+		return []Mismatch{mismatch(path, example, actual, "expected a value matching '%s' but got %s", regex, jsonKind(actual))}
+	}
+
+	if !r.MatchString(s) {
+		return []Mismatch{mismatch(path, example, actual, "expected '%s' to match '%s'", s, regex)}
+	}
+
+	return nil
+}
+
+// regexSubject returns the string a regex is matched against. As with the core,
+// numbers and booleans are matched against their string form
+func regexSubject(v interface{}) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case float64, bool:
+		return formatValue(t), true
+	}
+
+	return "", false
+}
+
+func compareValues(path string, template interface{}, actual interface{}) []Mismatch {
+	a, ok := actual.(map[string]interface{})
+	if !ok {
+		return []Mismatch{mismatch(path, exampleOf(template), actual, "expected an object but got %s", jsonKind(actual))}
+	}
+
+	// The template may either be an example object (from which the first value is used)
+	// or the value template itself
+	if t, ok := template.(map[string]interface{}); ok {
+		if _, isMatcher := t["pact:matcher:type"]; !isMatcher {
+			for _, k := range sortedKeys(t) {
+				template = t[k]
+				break
+			}
+		}
+	}
+
+	var res []Mismatch
+	for _, k := range sortedKeys(a) {
+		res = append(res, compareValue(objectPath(path, k), template, a[k], cascadeType)...)
+	}
+
+	return res
+}
+
+// compareEach matches the keys (or values) of an object against the rules of an eachKey
+// (or eachValue) matcher
+func compareEach(path string, keys bool, rules []interface{}, example interface{}, actual interface{}) []Mismatch {
+	a, ok := actual.(map[string]interface{})
+	if !ok {
+		return []Mismatch{mismatch(path, exampleOf(example), actual, "expected an object but got %s", jsonKind(actual))}
+	}
+
+	var res []Mismatch
+	for _, k := range sortedKeys(a) {
+		for _, rule := range rules {
+			if !keys {
+				res = append(res, compareValue(objectPath(path, k), rule, a[k], cascadeType)...)
+				continue
+			}
+			for _, m := range compareValue(objectPath(path, k), rule, k, cascadeType) {
+				m.Mismatch = fmt.Sprintf("key '%s': %s", k, m.Mismatch)
+				res = append(res, m)
+			}
+		}
+	}
+
+	return res
+}
+
+func compareArrayContains(path string, variants []interface{}, actual interface{}) []Mismatch {
+	a, ok := actual.([]interface{})
+	if !ok {
+		return []Mismatch{mismatch(path, exampleOf(variants), actual, "expected an array but got %s", jsonKind(actual))}
+	}
+
+	var res []Mismatch
+	for i, variant := range variants {
+		found := false
+		for j, v := range a {
+			if len(compareValue(fmt.Sprintf("%s[%d]", path, j), variant, v, cascadeEquality)) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, mismatch(path, exampleOf(variant), actual, "expected the array to contain an element matching variant %d", i))
+		}
+	}
+
+	return res
+}
+
+func compareEachOneOf(path string, variants []interface{}, actual interface{}) []Mismatch {
+	a, ok := actual.([]interface{})
+	if !ok {
+		return nil
+	}
+
+	var res []Mismatch
+	for j, v := range a {
+		elementPath := fmt.Sprintf("%s[%d]", path, j)
+		found := false
+		for _, variant := range variants {
+			if len(compareValue(elementPath, variant, v, cascadeEquality)) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			res = append(res, mismatch(elementPath, exampleOf(variants), v, "expected the element to match one of the %d variants", len(variants)))
+		}
+	}
+
+	return res
+}
+
+func compareDateTime(path string, matcherType string, format string, example interface{}, actual interface{}) []Mismatch {
+	s, ok := actual.(string)
+	if !ok {
+		return []Mismatch{mismatch(path, example, actual, "expected a %s string but got %s", matcherType, jsonKind(actual))}
+	}
+
+	layout, ok := javaToGoLayout(format)
+	if !ok {
+		// The format uses symbols that have no Go equivalent, so only the type can be checked
+		return nil
+	}
+
+	if _, err := time.Parse(layout, s); err != nil {
+		return []Mismatch{mismatch(path, example, actual, "expected '%s' to be a %s with format '%s'", s, matcherType, format)}
+	}
+
+	return nil
+}
+
+// javaToGoLayout converts the common subset of Java SimpleDateFormat symbols
+// into a Go time layout. It returns false if the format can't be converted
+func javaToGoLayout(format string) (string, bool) {
+	if format == "" {
+		return "", false
+	}
+
+	tokens := []struct {
+		java   string
+		golang string
+	}{
+		{"yyyy", "2006"}, {"yy", "06"},
+		{"MMMM", "January"}, {"MMM", "Jan"}, {"MM", "01"}, {"M", "1"},
+		{"dd", "02"}, {"d", "2"},
+		{"EEEE", "Monday"}, {"EEE", "Mon"},
+		{"HH", "15"}, {"hh", "03"}, {"h", "3"},
+		{"mm", "04"}, {"ss", "05"},
+		{"SSSSSS", "000000"}, {"SSS", "000"},
+		{"a", "PM"},
+		{"XXX", "Z07:00"}, {"XX", "Z0700"}, {"X", "Z07"}, {"Z", "-0700"}, {"z", "MST"},
+	}
+
+	var b strings.Builder
+	for i := 0; i < len(format); {
+		c := format[i]
+		if c == '\'' {
+			end := strings.IndexByte(format[i+1:], '\'')
+			if end < 0 {
+				return "", false
+			}
+			b.WriteString(format[i+1 : i+1+end])
+			i += end + 2
+			continue
+		}
+
+		matched := false
+		for _, t := range tokens {
+			if strings.HasPrefix(format[i:], t.java) {
+				b.WriteString(t.golang)
+				i += len(t.java)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		if (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') {
+			return "", false
+		}
+		b.WriteByte(c)
+		i++
+	}
+
+	return b.String(), true
+}
+
+func mismatch(path string, expected interface{}, actual interface{}, format string, args ...interface{}) Mismatch {
+	return Mismatch{
+		Path:     path,
+		Expected: expected,
+		Actual:   actual,
+		Mismatch: fmt.Sprintf(format, args...),
+	}
+}
+
+// exampleOf strips any matcher detail from a normalised template, returning the example value
+func exampleOf(template interface{}) interface{} {
+	switch t := template.(type) {
+	case map[string]interface{}:
+		if _, ok := t["pact:matcher:type"]; ok {
+			if variants, ok := t["variants"]; ok {
+				return exampleOf(variants)
+			}
+			return exampleOf(t["value"])
+		}
+		res := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			res[k] = exampleOf(v)
+		}
+		return res
+	case []interface{}:
+		res := make([]interface{}, len(t))
+		for i, v := range t {
+			res[i] = exampleOf(v)
+		}
+		return res
+	default:
+		return t
+	}
+}
+
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case float64:
+		return "a number"
+	case string:
+		return "a string"
+	case []interface{}:
+		return "an array"
+	case map[string]interface{}:
+		return "an object"
+	default:
+		return reflect.TypeOf(v).String()
+	}
+}
+
+func formatValue(v interface{}) string {
+	switch t := v.(type) {
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case string:
+		return fmt.Sprintf("'%s'", t)
+	default:
+		b, _ := json.Marshal(v)
+		return string(b)
+	}
+}
+
+func isEmpty(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == ""
+	case []interface{}:
+		return len(t) == 0
+	case map[string]interface{}:
+		return len(t) == 0
+	}
+	return false
+}
+
+var identifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func objectPath(path string, key string) string {
+	if identifierRegex.MatchString(key) {
+		return path + "." + key
+	}
+	return fmt.Sprintf("%s['%s']", path, key)
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,95 @@
+package matchers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompare(t *testing.T) {
+	template := StructMatcher{
+		"id":       Integer(10),
+		"name":     Like("billy"),
+		"sku":      Regex("ABC-123", `^[A-Z]{3}-\d+$`),
+		"status":   "active",
+		"created":  DateTimeGenerated("2020-01-01T10:00:00", "yyyy-MM-dd'T'HH:mm:ss"),
+		"tags":     EachLike("tag", 1),
+		"optional": Null{},
+		"nested": map[string]interface{}{
+			"price": Decimal(1.5),
+		},
+	}
+
+	testCases := []struct {
+		description string
+		actual      string
+		paths       []string
+	}{
+		{
+			description: "matching payload",
+			actual:      `{"id": 2, "name": "sally", "sku": "XYZ-9", "status": "active", "created": "2021-06-30T23:59:59", "tags": ["a", "b"], "optional": null, "nested": {"price": 27}, "extra": true}`,
+		},
+		{
+			description: "every matcher failing",
+			actual:      `{"id": 2.5, "name": 1, "sku": "xyz", "status": "inactive", "created": "30/06/2021", "tags": [], "optional": "x", "nested": {"price": "1.5"}}`,
+			paths:       []string{"$.created", "$.id", "$.name", "$.nested.price", "$.optional", "$.sku", "$.status", "$.tags"},
+		},
+		{
+			description: "missing keys",
+			actual:      `{"id": 2, "name": "sally", "sku": "XYZ-9", "created": "2021-06-30T23:59:59", "tags": ["a"], "optional": null, "nested": {}}`,
+			paths:       []string{"$.nested.price", "$.status"},
+		},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.description, func(t *testing.T) {
+			mismatches, err := Compare(template, []byte(test.actual))
+			assert.NoError(t, err)
+
+			paths := make([]string, len(mismatches))
+			for i, m := range mismatches {
+				paths[i] = m.Path
+			}
+			assert.ElementsMatch(t, test.paths, paths)
+		})
+	}
+
+	t.Run("invalid actual JSON returns an error", func(t *testing.T) {
+		_, err := Compare(template, []byte(`{`))
+		assert.Error(t, err)
+	})
+
+	t.Run("array type cascades to each element", func(t *testing.T) {
+		mismatches, err := Compare(EachLike(StructMatcher{"id": Like(1)}, 1), []byte(`[{"id": 1}, {"id": "2"}]`))
+		assert.NoError(t, err)
+		assert.Len(t, mismatches, 1)
+		assert.Equal(t, "$[1].id", mismatches[0].Path)
+	})
+}
+
+func TestExample(t *testing.T) {
+	example, err := Example(MapMatcher{
+		"contentType": Regex("application/json", "application/json.*"),
+		"headers": StructMatcher{
+			"partitionKey": Like("orders-1"),
+			"attempts":     EachLike(Integer(1), 2),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"contentType": "application/json",
+		"headers": map[string]interface{}{
+			"partitionKey": "orders-1",
+			"attempts":     []interface{}{float64(1), float64(1)},
+		},
+	}, example)
+}
+
+func TestJavaToGoLayout(t *testing.T) {
+	layout, ok := javaToGoLayout("yyyy-MM-dd'T'HH:mm:ss.SSSXXX")
+	assert.True(t, ok)
+	assert.Equal(t, "2006-01-02T15:04:05.000Z07:00", layout)
+
+	_, ok = javaToGoLayout("yyyy-ww")
+	assert.False(t, ok)
+}
@@ -0,0 +1,108 @@
+package matchers
+
+import (
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/pact-foundation/pact-go/v2/models"
+)
+
+var enums = struct {
+	sync.RWMutex
+	values map[reflect.Type][]interface{}
+}{values: make(map[reflect.Type][]interface{})}
+
+// RegisterEnum records the allowed values of a typed enum, e.g.
+//
+//	type Status string
+//
+//	const (
+//		StatusActive  Status = "active"
+//		StatusRetired Status = "retired"
+//	)
+//
+//	func init() {
+//		matchers.RegisterEnum(StatusActive, StatusRetired)
+//	}
+//
+// Go does not expose the constants declared for a type at runtime, so the values
+// must be registered before EnumFromType is used. All values must be of the same
+// string or integer type; registering a type again replaces its values.
+func RegisterEnum(values ...interface{}) error {
+	if len(values) == 0 {
+		return fmt.Errorf("at least one enum value must be registered")
+	}
+
+	t := reflect.TypeOf(values[0])
+	if !isEnumKind(t) {
+		return fmt.Errorf("enum values must be of a string or integer type, got %v", t)
+	}
+	for _, v := range values[1:] {
+		if reflect.TypeOf(v) != t {
+			return fmt.Errorf("enum values must all be of type %v, got %v", t, reflect.TypeOf(v))
+		}
+	}
+
+	enums.Lock()
+	defer enums.Unlock()
+	enums.values[t] = append([]interface{}(nil), values...)
+
+	return nil
+}
+
+// EnumFromType matches one of the values registered for the type of v with RegisterEnum,
+// using v as the example. If no values are registered for the type, it falls back to
+// matching by type with Like.
+func EnumFromType(v interface{}) Matcher {
+	t := reflect.TypeOf(v)
+
+	enums.RLock()
+	values, ok := enums.values[t]
+	enums.RUnlock()
+
+	if !ok {
+		log.Printf("[WARN] no enum values registered for type %v, matching by type only", t)
+		return Like(v)
+	}
+
+	alternatives := make([]string, len(values))
+	for i, e := range values {
+		alternatives[i] = regexp.QuoteMeta(fmt.Sprint(e))
+	}
+	regex := "^(" + strings.Join(alternatives, "|") + ")$"
+
+	rv := reflect.ValueOf(v)
+	switch t.Kind() {
+	case reflect.String:
+		return Regex(rv.String(), regex)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return numberTerm{
+			Specification: models.V3,
+			Type:          "regex",
+			Value:         float64(rv.Uint()),
+			Regex:         regex,
+		}
+	default:
+		return numberTerm{
+			Specification: models.V3,
+			Type:          "regex",
+			Value:         float64(rv.Int()),
+			Regex:         regex,
+		}
+	}
+}
+
+func isEnumKind(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,68 @@
+package matchers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testStatus string
+
+const (
+	testStatusActive  testStatus = "active"
+	testStatusRetired testStatus = "retired"
+)
+
+type testPriority int
+
+const (
+	testPriorityLow testPriority = iota + 1
+	testPriorityHigh
+)
+
+func TestEnumFromType(t *testing.T) {
+	assert.NoError(t, RegisterEnum(testStatusActive, testStatusRetired))
+	assert.NoError(t, RegisterEnum(testPriorityLow, testPriorityHigh))
+
+	t.Run("string enum", func(t *testing.T) {
+		m := EnumFromType(testStatusActive)
+		assert.NoError(t, Validate(m))
+		assert.Equal(t, "active", m.GetValue())
+
+		mismatches, err := Compare(m, "retired")
+		assert.NoError(t, err)
+		assert.Empty(t, mismatches)
+
+		mismatches, err = Compare(m, "deleted")
+		assert.NoError(t, err)
+		assert.Len(t, mismatches, 1)
+	})
+
+	t.Run("integer enum", func(t *testing.T) {
+		m := EnumFromType(testPriorityHigh)
+		assert.NoError(t, Validate(m))
+
+		mismatches, err := Compare(m, 1)
+		assert.NoError(t, err)
+		assert.Empty(t, mismatches)
+
+		mismatches, err = Compare(m, 3)
+		assert.NoError(t, err)
+		assert.Len(t, mismatches, 1)
+	})
+
+	t.Run("unregistered type", func(t *testing.T) {
+		type colour string
+		m := EnumFromType(colour("red"))
+
+		mismatches, err := Compare(m, "anything")
+		assert.NoError(t, err)
+		assert.Empty(t, mismatches)
+	})
+
+	t.Run("invalid registrations", func(t *testing.T) {
+		assert.Error(t, RegisterEnum())
+		assert.Error(t, RegisterEnum(1.5))
+		assert.Error(t, RegisterEnum(testStatusActive, "retired"))
+	})
+}
@@ -0,0 +1,125 @@
+package matchers
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+)
+
+const alphanumeric = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// Generate produces a concrete value from content that may contain matchers, as the
+// native core does when reifying a message, but applying each generator so that
+// successive calls produce different values. Matchers without a generator produce
+// their example. Randomness is taken from r, so a seeded source gives repeatable values.
+//
+// Values from the provider state, and generators that can't be applied outside of
+// verification (e.g. Regex and MockServerURL), produce their example.
+func Generate(content interface{}, r *rand.Rand) (interface{}, error) {
+	c, err := normalise(content)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialise content: %v", err)
+	}
+
+	return generateValue(c, r), nil
+}
+
+func generateValue(v interface{}, r *rand.Rand) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if _, ok := t["pact:matcher:type"]; !ok {
+			res := make(map[string]interface{}, len(t))
+			for k, child := range t {
+				res[k] = generateValue(child, r)
+			}
+			return res
+		}
+		if generator, ok := t["pact:generator:type"].(string); ok {
+			if g, ok := generate(generator, t, r); ok {
+				return g
+			}
+		}
+		if variants, ok := t["variants"]; ok {
+			return generateValue(variants, r)
+		}
+		return generateValue(t["value"], r)
+	case []interface{}:
+		res := make([]interface{}, len(t))
+		for i, item := range t {
+			res[i] = generateValue(item, r)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+// generate applies a generator, returning false if the example should be used instead
+func generate(generator string, m map[string]interface{}, r *rand.Rand) (interface{}, bool) {
+	intParam := func(key string, def int) int {
+		if f, ok := m[key].(float64); ok {
+			return int(f)
+		}
+		return def
+	}
+
+	switch generator {
+	case "RandomInt":
+		min, max := intParam("min", 0), intParam("max", math.MaxInt32)
+		if max <= min {
+			return min, true
+		}
+		return min + r.Intn(max-min+1), true
+	case "RandomDecimal":
+		digits := intParam("digits", 6)
+		if digits < 2 {
+			digits = 2
+		}
+		s := strconv.Itoa(1+r.Intn(9)) + randomString(r, "0123456789", digits-1)
+		point := 1 + r.Intn(digits-1)
+		f, _ := strconv.ParseFloat(s[:point]+"."+s[point:], 64)
+		return f, true
+	case "RandomBoolean":
+		return r.Intn(2) == 1, true
+	case "RandomString":
+		return randomString(r, alphanumeric, intParam("size", 10)), true
+	case "RandomHexadecimal":
+		return randomString(r, "0123456789abcdef", intParam("digits", 10)), true
+	case "Uuid":
+		b := make([]byte, 16)
+		r.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		id := fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+		if m["format"] == "simple" {
+			id = fmt.Sprintf("%x", b)
+		}
+		return id, true
+	case "Date", "Time", "DateTime":
+		layouts := map[string]string{"Date": "2006-01-02", "Time": "15:04:05", "DateTime": time.RFC3339}
+		layout := layouts[generator]
+		if format, ok := m["format"].(string); ok {
+			if l, ok := javaToGoLayout(format); ok {
+				layout = l
+			} else {
+				log.Printf("[WARN] unable to generate a value for the date format '%s', using the example", format)
+				return nil, false
+			}
+		}
+		return time.Now().Format(layout), true
+	}
+
+	return nil, false
+}
+
+func randomString(r *rand.Rand, chars string, n int) string {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = chars[r.Intn(len(chars))]
+	}
+
+	return string(b)
+}
@@ -0,0 +1,41 @@
+package matchers
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerate(t *testing.T) {
+	content := StructMatcher{
+		"id":      map[string]interface{}{"pact:matcher:type": "integer", "value": 1, "pact:generator:type": "RandomInt", "min": 1, "max": 1000000},
+		"ref":     map[string]interface{}{"pact:matcher:type": "type", "value": "abc", "pact:generator:type": "RandomString", "size": 8},
+		"uuid":    map[string]interface{}{"pact:matcher:type": "regex", "value": "fc763eba-0905-41c5-a27f-3934ab26786c", "regex": uuid, "pact:generator:type": "Uuid"},
+		"created": DateTimeGenerated("2020-01-01T10:00:00", "yyyy-MM-dd'T'HH:mm:ss"),
+		"name":    Like("billy"),
+		"user":    FromProviderState("${name}", "billy"),
+		"tags":    EachLike(Like("a"), 2),
+	}
+
+	r := rand.New(rand.NewSource(1))
+	first, err := Generate(content, r)
+	assert.NoError(t, err)
+	second, err := Generate(content, r)
+	assert.NoError(t, err)
+
+	for _, v := range []interface{}{first, second} {
+		mismatches, err := Compare(content, v)
+		assert.NoError(t, err)
+		assert.Empty(t, mismatches)
+
+		m := v.(map[string]interface{})
+		assert.Len(t, m["ref"], 8)
+		assert.Equal(t, "billy", m["name"])
+		assert.Equal(t, "billy", m["user"])
+		assert.Equal(t, []interface{}{"a", "a"}, m["tags"])
+	}
+
+	assert.NotEqual(t, first.(map[string]interface{})["id"], second.(map[string]interface{})["id"])
+	assert.NotEqual(t, first.(map[string]interface{})["uuid"], second.(map[string]interface{})["uuid"])
+}
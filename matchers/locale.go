@@ -0,0 +1,259 @@
+package matchers
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// numberFormat describes how a locale groups thousands and separates decimals.
+// The first group separator is used in examples, others are also accepted
+type numberFormat struct {
+	group   []string
+	decimal string
+}
+
+// spaces used as group separators, including the (narrow) no-break spaces most formatters emit
+var spaceSeparators = []string{" ", "\u00a0", "\u202f"}
+
+var numberFormats = map[string]numberFormat{
+	"en":    {[]string{","}, "."},
+	"en-IN": {[]string{","}, "."},
+	"ja":    {[]string{","}, "."},
+	"zh":    {[]string{","}, "."},
+	"ko":    {[]string{","}, "."},
+	"th":    {[]string{","}, "."},
+	"de":    {[]string{"."}, ","},
+	"de-CH": {[]string{"'", "’"}, "."},
+	"es":    {[]string{"."}, ","},
+	"it":    {[]string{"."}, ","},
+	"nl":    {[]string{"."}, ","},
+	"pt":    {[]string{"."}, ","},
+	"id":    {[]string{"."}, ","},
+	"tr":    {[]string{"."}, ","},
+	"da":    {[]string{"."}, ","},
+	"fr":    {spaceSeparators, ","},
+	"fr-CH": {spaceSeparators, "."},
+	"ru":    {spaceSeparators, ","},
+	"pl":    {spaceSeparators, ","},
+	"cs":    {spaceSeparators, ","},
+	"sv":    {spaceSeparators, ","},
+	"nb":    {spaceSeparators, ","},
+	"fi":    {spaceSeparators, ","},
+}
+
+// dateNames are the localised month and weekday names of a language
+type dateNames struct {
+	months, shortMonths, days, shortDays []string
+}
+
+var localeDateNames = map[string]dateNames{
+	"en": {
+		months:      []string{"January", "February", "March", "April", "May", "June", "July", "August", "September", "October", "November", "December"},
+		shortMonths: []string{"Jan", "Feb", "Mar", "Apr", "May", "Jun", "Jul", "Aug", "Sep", "Oct", "Nov", "Dec"},
+		days:        []string{"Sunday", "Monday", "Tuesday", "Wednesday", "Thursday", "Friday", "Saturday"},
+		shortDays:   []string{"Sun", "Mon", "Tue", "Wed", "Thu", "Fri", "Sat"},
+	},
+	"de": {
+		months:      []string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"},
+		shortMonths: []string{"Jan.", "Feb.", "März", "Apr.", "Mai", "Juni", "Juli", "Aug.", "Sept.", "Okt.", "Nov.", "Dez."},
+		days:        []string{"Sonntag", "Montag", "Dienstag", "Mittwoch", "Donnerstag", "Freitag", "Samstag"},
+		shortDays:   []string{"So.", "Mo.", "Di.", "Mi.", "Do.", "Fr.", "Sa."},
+	},
+	"fr": {
+		months:      []string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		shortMonths: []string{"janv.", "févr.", "mars", "avr.", "mai", "juin", "juil.", "août", "sept.", "oct.", "nov.", "déc."},
+		days:        []string{"dimanche", "lundi", "mardi", "mercredi", "jeudi", "vendredi", "samedi"},
+		shortDays:   []string{"dim.", "lun.", "mar.", "mer.", "jeu.", "ven.", "sam."},
+	},
+	"es": {
+		months:      []string{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		shortMonths: []string{"ene", "feb", "mar", "abr", "may", "jun", "jul", "ago", "sept", "oct", "nov", "dic"},
+		days:        []string{"domingo", "lunes", "martes", "miércoles", "jueves", "viernes", "sábado"},
+		shortDays:   []string{"dom", "lun", "mar", "mié", "jue", "vie", "sáb"},
+	},
+	"it": {
+		months:      []string{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		shortMonths: []string{"gen", "feb", "mar", "apr", "mag", "giu", "lug", "ago", "set", "ott", "nov", "dic"},
+		days:        []string{"domenica", "lunedì", "martedì", "mercoledì", "giovedì", "venerdì", "sabato"},
+		shortDays:   []string{"dom", "lun", "mar", "mer", "gio", "ven", "sab"},
+	},
+	"nl": {
+		months:      []string{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+		shortMonths: []string{"jan", "feb", "mrt", "apr", "mei", "jun", "jul", "aug", "sep", "okt", "nov", "dec"},
+		days:        []string{"zondag", "maandag", "dinsdag", "woensdag", "donderdag", "vrijdag", "zaterdag"},
+		shortDays:   []string{"zo", "ma", "di", "wo", "do", "vr", "za"},
+	},
+	"pt": {
+		months:      []string{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		shortMonths: []string{"jan", "fev", "mar", "abr", "mai", "jun", "jul", "ago", "set", "out", "nov", "dez"},
+		days:        []string{"domingo", "segunda-feira", "terça-feira", "quarta-feira", "quinta-feira", "sexta-feira", "sábado"},
+		shortDays:   []string{"dom", "seg", "ter", "qua", "qui", "sex", "sáb"},
+	},
+}
+
+// lookupLocale finds the most specific entry for a locale such as "de-CH" or "de_DE",
+// falling back to its language and then to English
+func lookupLocale(locale string, has func(string) bool) string {
+	locale = strings.Replace(locale, "_", "-", -1)
+	parts := strings.SplitN(locale, "-", 2)
+	language := strings.ToLower(parts[0])
+
+	candidates := []string{language}
+	if len(parts) == 2 {
+		candidates = append([]string{language + "-" + strings.ToUpper(parts[1])}, candidates...)
+	}
+
+	for _, c := range candidates {
+		if has(c) {
+			return c
+		}
+	}
+
+	log.Printf("[WARN] locale '%s' is not supported, falling back to 'en'", locale)
+
+	return "en"
+}
+
+// LocalizedNumber matches a number formatted for the given locale (e.g. "en-US" or "de-DE"),
+// accounting for its thousands separator and decimal mark, e.g. 1,234.56 or 1.234,56
+func LocalizedNumber(locale string) Matcher {
+	key := lookupLocale(locale, func(l string) bool {
+		_, ok := numberFormats[l]
+		return ok
+	})
+	f := numberFormats[key]
+
+	separators := make([]string, len(f.group))
+	for i, g := range f.group {
+		separators[i] = regexp.QuoteMeta(g)
+	}
+	group := "(" + strings.Join(separators, "|") + ")"
+	decimal := regexp.QuoteMeta(f.decimal)
+
+	integer := fmt.Sprintf(`\d{1,3}(%s\d{3})*`, group)
+	example := fmt.Sprintf("1%s234%s56", f.group[0], f.decimal)
+	if key == "en-IN" {
+		// Indian numbering groups in twos above the thousands, e.g. 12,34,567.89
+		integer = fmt.Sprintf(`\d{1,2}(%s\d{2})*%s\d{3}|\d{1,3}`, group, group)
+		example = "12,34,567.89"
+	}
+
+	return Regex(example, fmt.Sprintf(`^-?(%s|\d+)(%s\d+)?$`, integer, decimal))
+}
+
+// LocalizedDate matches a date formatted for the given locale, where month and weekday names
+// in the format (MMMM, MMM, EEEE, EEE) are in the locale's language, e.g. "d MMMM yyyy" for
+// "1 février 2000" in "fr-FR". Numeric fields use the SimpleDateFormat symbols y, M, d, H, h, m, s and a.
+func LocalizedDate(locale, format string) Matcher {
+	names := localeDateNames[lookupLocale(locale, func(l string) bool {
+		_, ok := localeDateNames[l]
+		return ok
+	})]
+
+	regex, example := localizedDatePattern(names, format)
+
+	return Regex(example, "^"+regex+"$")
+}
+
+func localizedDatePattern(names dateNames, format string) (string, string) {
+	var regex, example strings.Builder
+
+	for i := 0; i < len(format); {
+		c := format[i]
+
+		// Quoted literals, e.g. 'T' or '' for a single quote
+		if c == '\'' {
+			end := strings.IndexByte(format[i+1:], '\'')
+			if end < 0 {
+				end = len(format) - i - 1
+			}
+			literal := format[i+1 : i+1+end]
+			if literal == "" {
+				literal = "'"
+			}
+			regex.WriteString(regexp.QuoteMeta(literal))
+			example.WriteString(literal)
+			i += end + 2
+			continue
+		}
+
+		n := 1
+		for i+n < len(format) && format[i+n] == c {
+			n++
+		}
+		token := format[i : i+n]
+		i += n
+
+		switch {
+		case c == 'y' && n == 2:
+			regex.WriteString(`\d{2}`)
+			example.WriteString(timeExample.Format("06"))
+		case c == 'y':
+			regex.WriteString(`\d{4}`)
+			example.WriteString(timeExample.Format("2006"))
+		case c == 'M' && n >= 4:
+			regex.WriteString(alternation(names.months))
+			example.WriteString(names.months[timeExample.Month()-1])
+		case c == 'M' && n == 3:
+			regex.WriteString(alternation(names.shortMonths))
+			example.WriteString(names.shortMonths[timeExample.Month()-1])
+		case c == 'E' && n >= 4:
+			regex.WriteString(alternation(names.days))
+			example.WriteString(names.days[timeExample.Weekday()])
+		case c == 'E':
+			regex.WriteString(alternation(names.shortDays))
+			example.WriteString(names.shortDays[timeExample.Weekday()])
+		case c == 'M':
+			numericToken(&regex, &example, n, `(0[1-9]|1[0-2])`, `([1-9]|1[0-2])`, int(timeExample.Month()))
+		case c == 'd':
+			numericToken(&regex, &example, n, `(0[1-9]|[12]\d|3[01])`, `([1-9]|[12]\d|3[01])`, timeExample.Day())
+		case c == 'H':
+			numericToken(&regex, &example, n, `([01]\d|2[0-3])`, `(1?\d|2[0-3])`, timeExample.Hour())
+		case c == 'h':
+			numericToken(&regex, &example, n, `(0[1-9]|1[0-2])`, `([1-9]|1[0-2])`, (timeExample.Hour()+11)%12+1)
+		case c == 'm':
+			numericToken(&regex, &example, n, `[0-5]\d`, `[1-5]?\d`, timeExample.Minute())
+		case c == 's':
+			numericToken(&regex, &example, n, `[0-5]\d`, `[1-5]?\d`, timeExample.Second())
+		case c == 'a':
+			regex.WriteString(`(AM|PM|am|pm)`)
+			example.WriteString(timeExample.Format("PM"))
+		case (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			log.Printf("[WARN] unsupported date format symbol '%s' in LocalizedDate, matching it as any text", token)
+			regex.WriteString(`.+?`)
+			example.WriteString(token)
+		default:
+			regex.WriteString(regexp.QuoteMeta(token))
+			example.WriteString(token)
+		}
+	}
+
+	return regex.String(), example.String()
+}
+
+func numericToken(regex, example *strings.Builder, n int, padded, unpadded string, value int) {
+	if n >= 2 {
+		regex.WriteString(padded)
+		fmt.Fprintf(example, "%02d", value)
+		return
+	}
+	regex.WriteString(unpadded)
+	fmt.Fprintf(example, "%d", value)
+}
+
+// alternation builds a regex matching any of the given names, longest first so
+// that prefixes (e.g. "Juni" and "Jun") don't cut a match short
+func alternation(names []string) string {
+	sorted := append([]string(nil), names...)
+	sort.Slice(sorted, func(i, j int) bool { return len(sorted[i]) > len(sorted[j]) })
+
+	quoted := make([]string, len(sorted))
+	for i, n := range sorted {
+		quoted[i] = regexp.QuoteMeta(n)
+	}
+
+	return "(" + strings.Join(quoted, "|") + ")"
+}
@@ -0,0 +1,60 @@
+package matchers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalizedNumber(t *testing.T) {
+	testCases := []struct {
+		locale  string
+		valid   []string
+		invalid []string
+	}{
+		{"en-US", []string{"1,234.56", "1234.56", "-12", "999,999,999"}, []string{"1.234,56", "1,23,4", "abc"}},
+		{"de_DE", []string{"1.234,56", "1234,5", "12"}, []string{"1,234.56"}},
+		{"fr-FR", []string{"1 234,56", "1 234,56", "1 234"}, []string{"1.234,56"}},
+		{"de-CH", []string{"1'234.56"}, []string{"1.234,56"}},
+		{"en-IN", []string{"12,34,567.89", "1,000", "100"}, []string{"1,234,567.89"}},
+		{"xx-YY", []string{"1,234.56"}, []string{"1.234,56"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.locale, func(t *testing.T) {
+			m := LocalizedNumber(test.locale)
+			assert.NoError(t, Validate(m))
+
+			for _, v := range test.valid {
+				mismatches, err := Compare(m, v)
+				assert.NoError(t, err)
+				assert.Empty(t, mismatches, v)
+			}
+			for _, v := range test.invalid {
+				mismatches, err := Compare(m, v)
+				assert.NoError(t, err)
+				assert.NotEmpty(t, mismatches, v)
+			}
+		})
+	}
+}
+
+func TestLocalizedDate(t *testing.T) {
+	m := LocalizedDate("fr-FR", "EEEE d MMMM yyyy 'à' HH:mm")
+	assert.Equal(t, "mardi 1 février 2000 à 12:30", m.GetValue())
+	assert.NoError(t, Validate(m))
+
+	mismatches, err := Compare(m, "lundi 24 décembre 2018 à 09:05")
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	mismatches, err = Compare(m, "Monday 24 December 2018 à 09:05")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, mismatches)
+
+	m = LocalizedDate("de", "dd. MMM yy")
+	assert.Equal(t, "01. Feb. 00", m.GetValue())
+	mismatches, err = Compare(m, "24. Juni 18")
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches)
+}
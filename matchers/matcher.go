@@ -21,6 +21,9 @@ const (
 	timestamp   = `^([\+-]?\d{4}(?!\d{2}\b))((-?)((0[1-9]|1[0-2])(\3([12]\d|0[1-9]|3[01]))?|W([0-4]\d|5[0-2])(-?[1-7])?|(00[1-9]|0[1-9]\d|[12]\d{2}|3([0-5]\d|6[1-6])))([T\s]((([01]\d|2[0-3])((:?)[0-5]\d)?|24\:?00)([\.,]\d+(?!:))?)?(\17[0-5]\d([\.,]\d+)?)?([zZ]|([\+-])([01]\d|2[0-3]):?([0-5]\d)?)?)?)?$`
 	date        = `^([\+-]?\d{4}(?!\d{2}\b))((-?)((0[1-9]|1[0-2])(\3([12]\d|0[1-9]|3[01]))?|W([0-4]\d|5[0-2])(-?[1-7])?|(00[1-9]|0[1-9]\d|[12]\d{2}|3([0-5]\d|6[1-6])))?)`
 	timeRegex   = `^(T\d\d:\d\d(:\d\d)?(\.\d+)?(([+-]\d\d:\d\d)|Z)?)?$`
+	rfc3339     = `^\d{4}-(0[1-9]|1[0-2])-(0[1-9]|[12]\d|3[01])[Tt]([01]\d|2[0-3]):[0-5]\d:([0-5]\d|60)(\.\d+)?([Zz]|[+-]([01]\d|2[0-3]):[0-5]\d)$`
+	email       = `^[A-Za-z0-9.!#$%&'*+/=?^_{|}~-]+@[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?(\.[A-Za-z0-9]([A-Za-z0-9-]{0,61}[A-Za-z0-9])?)*$`
+	uri         = `^[A-Za-z][A-Za-z0-9+.-]*:[^\s]+$`
 )
 
 var timeExample = time.Date(2000, 2, 1, 12, 30, 0, 0, time.UTC)
@@ -168,6 +171,24 @@ func Time() Matcher {
 	return Regex(timeExample.Format("T15:04:05"), timeRegex)
 }
 
+// RFC3339Time matches a date and time as defined by RFC 3339, e.g. "2000-02-01T12:30:00Z",
+// with optional fractional seconds and a required time zone offset
+func RFC3339Time() Matcher {
+	return Regex(timeExample.Format(time.RFC3339), rfc3339)
+}
+
+// Email matches an email address, i.e. the addr-spec of RFC 5322 without quoted
+// local parts or address literals
+func Email() Matcher {
+	return Regex("billy@example.com", email)
+}
+
+// URI matches an absolute URI as defined by RFC 3986, e.g. "https://example.com/users/1"
+// or "urn:isbn:0451450523"
+func URI() Matcher {
+	return Regex("https://example.com/users/1", uri)
+}
+
 // UUID defines a matcher that accepts UUIDs. Produces a v4 UUID as the example.
 func UUID() Matcher {
 	return Regex("fc763eba-0905-41c5-a27f-3934ab26786c", uuid)
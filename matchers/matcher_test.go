@@ -561,6 +561,218 @@ func ExampleEachLike() {
 	//}
 }
 
+func TestMatcher_Deprecated(t *testing.T) {
+	match := Deprecated(Like("555-1234"), "use email instead")
+	assert.Equal(t, "555-1234", match.GetValue())
+
+	var body map[string]interface{}
+	raw, _ := json.Marshal(match)
+	err := json.Unmarshal(raw, &body)
+	assert.NoError(t, err)
+	assert.Equal(t, "type", body["pact:matcher:type"])
+	assert.Equal(t, "use email instead", body["pact:deprecated"])
+
+	fields, err := DeprecatedFields(StructMatcher{
+		"name": Like("billy"),
+		"fax":  match,
+		"addresses": EachLike(map[string]interface{}{
+			"line2": Deprecated(Like("unit 4"), "merged into line1"),
+		}, 1),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"$.fax":                "use email instead",
+		"$.addresses[0].line2": "merged into line1",
+	}, fields)
+
+	mismatches, err := Compare(StructMatcher{"fax": match}, []byte(`{"fax": 1}`))
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 1)
+}
+
+func TestMatcher_Described(t *testing.T) {
+	match := Described(Regex("2020-01-01", `^\d{4}-\d{2}-\d{2}$`), "the day the order was placed")
+	assert.Equal(t, "2020-01-01", match.GetValue())
+
+	raw, err := json.Marshal(match)
+	assert.NoError(t, err)
+	var body map[string]interface{}
+	err = json.Unmarshal(raw, &body)
+	assert.NoError(t, err)
+	assert.Equal(t, "regex", body["pact:matcher:type"])
+	assert.Equal(t, "the day the order was placed", body["pact:description"])
+
+	fields, err := FieldDescriptions(StructMatcher{
+		"id":       Like(1),
+		"placedOn": match,
+		"items": EachLike(map[string]interface{}{
+			"sku": Described(Deprecated(Like("abc"), "use productId"), "the stock keeping unit"),
+		}, 1),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"$.placedOn":     "the day the order was placed",
+		"$.items[0].sku": "the stock keeping unit",
+	}, fields)
+
+	mismatches, err := Compare(StructMatcher{"placedOn": match}, []byte(`{"placedOn": "yesterday"}`))
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 1)
+}
+
+func TestMatcher_UniqueBy(t *testing.T) {
+	match := UniqueBy("id", EachLike(StructMatcher{"id": Like("sku-1"), "quantity": Integer(1)}, 1))
+
+	raw, err := json.Marshal(match)
+	assert.NoError(t, err)
+	var body map[string]interface{}
+	err = json.Unmarshal(raw, &body)
+	assert.NoError(t, err)
+	assert.Equal(t, "type", body["pact:matcher:type"])
+	assert.Equal(t, "id", body["pact:uniqueBy"])
+
+	template := StructMatcher{"items": match}
+
+	mismatches, err := Compare(template, []byte(`{"items": [{"id": "a", "quantity": 1}, {"id": "b", "quantity": 2}]}`))
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	mismatches, err = Compare(template, []byte(`{"items": [{"id": "a", "quantity": 1}, {"id": "b", "quantity": 2}, {"id": "a", "quantity": 3}]}`))
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, "$.items[2].id", mismatches[0].Path)
+
+	mismatches, err = Compare(UniqueBy("id", ArrayMinMaxLike(StructMatcher{"id": Like(1)}, 1, 3)), []byte(`[{"id": 1}, {"id": 1}]`))
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, "$[1].id", mismatches[0].Path)
+}
+
+func TestMatcher_EachKeyAndValueMatching(t *testing.T) {
+	keys := EachKeyMatching(map[string]interface{}{"order-1": Like("shipped")}, Regex("order-1", `^order-\d+$`))
+
+	raw, err := json.Marshal(keys)
+	assert.NoError(t, err)
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(raw, &body))
+	assert.Equal(t, "eachKey", body["pact:matcher:type"])
+	assert.Equal(t, "4.0.0", body["pact:specification"])
+	assert.Len(t, body["rules"], 1)
+
+	mismatches, err := Compare(keys, []byte(`{"order-2": "created", "order-3": "shipped"}`))
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	mismatches, err = Compare(keys, []byte(`{"order-2": "created", "basket-1": "open"}`))
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, "$['basket-1']", mismatches[0].Path)
+	assert.Contains(t, mismatches[0].Mismatch, "key 'basket-1'")
+
+	values := EachValueMatching(map[string]interface{}{"ABC-1": 2}, Integer(2))
+	mismatches, err = Compare(values, []byte(`{"ABC-1": 2, "XYZ-9": 1.5, "DEF-2": 4}`))
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, "$['XYZ-9']", mismatches[0].Path)
+
+	mismatches, err = Compare(values, []byte(`[2]`))
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 1)
+
+	assert.NoError(t, Validate(values))
+	assert.Error(t, Validate(EachKeyMatching(map[string]interface{}{"basket-1": 1}, Regex("order-1", `^order-\d+$`))))
+}
+
+func TestMatcher_Derived(t *testing.T) {
+	resolved, err := ResolveDerived(StructMatcher{
+		"first":    Like("billy"),
+		"last":     "sampson",
+		"fullName": Derived("${first} ${last}", "first", "last"),
+		"greeting": Derived("hello ${fullName}"),
+		"address": map[string]interface{}{
+			"city":  Like("Melbourne"),
+			"label": Derived("${city} (${postcode.code})"),
+			"postcode": map[string]interface{}{
+				"code": Integer(3000),
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	body := resolved.(map[string]interface{})
+	assert.Equal(t, "billy sampson", body["fullName"].(map[string]interface{})["value"])
+	assert.Equal(t, "hello billy sampson", body["greeting"].(map[string]interface{})["value"])
+	assert.Equal(t, "Melbourne (3000)", body["address"].(map[string]interface{})["label"].(map[string]interface{})["value"])
+	assert.NotContains(t, body["fullName"], "pact:derived")
+
+	_, err = ResolveDerived(StructMatcher{
+		"fullName": Derived("${first} ${last}"),
+	})
+	assert.Error(t, err)
+
+	_, err = ResolveDerived(StructMatcher{
+		"a": Derived("${b}"),
+		"b": Derived("${a}"),
+	})
+	assert.Error(t, err)
+}
+
+func TestMatcher_GeoPoint(t *testing.T) {
+	assert.NoError(t, Validate(StructMatcher{"location": GeoPoint()}))
+
+	for _, valid := range []string{
+		`{"lat": 0, "lng": 0}`,
+		`{"lat": -90, "lng": 180}`,
+		`{"lat": 45.5, "lng": -179.999}`,
+		`{"lat": 90.0, "lng": -180, "alt": 12}`,
+	} {
+		mismatches, err := Compare(GeoPoint(), []byte(valid))
+		assert.NoError(t, err)
+		assert.Empty(t, mismatches, valid)
+	}
+
+	for _, invalid := range []string{
+		`{"lat": 90.5, "lng": 0}`,
+		`{"lat": 0, "lng": 181}`,
+		`{"lat": "0", "lng": -200}`,
+		`{"lat": 0}`,
+	} {
+		mismatches, err := Compare(GeoPoint(), []byte(invalid))
+		assert.NoError(t, err)
+		assert.NotEmpty(t, mismatches, invalid)
+	}
+}
+
+func TestMatcher_EachOneOf(t *testing.T) {
+	items := EachOneOf(
+		StructMatcher{"type": "book", "title": Like("Dune")},
+		StructMatcher{"type": "film", "runtime": Integer(155)},
+	)
+
+	assert.NoError(t, Validate(StructMatcher{"items": items}))
+
+	normalised, err := normalise(items)
+	assert.NoError(t, err)
+	assert.Equal(t, true, normalised.(map[string]interface{})["pact:eachOneOf"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"type": "book", "title": "Dune"},
+		map[string]interface{}{"type": "film", "runtime": float64(155)},
+	}, exampleOf(normalised))
+
+	mismatches, err := Compare(items, []byte(`[{"type": "film", "runtime": 90}, {"type": "book", "title": "Emma"}, {"type": "book", "title": "Ulysses"}]`))
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches)
+
+	mismatches, err = Compare(items, []byte(`[{"type": "film", "runtime": 90}, {"type": "book", "title": "Emma"}, {"type": "song"}]`))
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, "$[2]", mismatches[0].Path)
+
+	mismatches, err = Compare(items, []byte(`[{"type": "film", "runtime": 90}]`))
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 1)
+}
+
 func TestMatch(t *testing.T) {
 	type jsonStruct struct {
 		ValueWithOmitEmpty string `json:"value,omitempty"`
@@ -994,3 +1206,33 @@ func Test_pluckParams(t *testing.T) {
 		})
 	}
 }
+
+func TestMatcher_RFCFormats(t *testing.T) {
+	testCases := []struct {
+		name    string
+		matcher Matcher
+		valid   []string
+		invalid []string
+	}{
+		{"RFC3339Time", RFC3339Time(), []string{"2000-02-01T12:30:00Z", "2000-02-01t12:30:00.123+10:00"}, []string{"2000-02-01T12:30:00", "2000-13-01T12:30:00Z", "2000-02-01 12:30:00Z"}},
+		{"Email", Email(), []string{"billy@example.com", "billy.bob+tag@mail.example.co.uk"}, []string{"billy", "billy@", "@example.com", "billy@-example.com"}},
+		{"URI", URI(), []string{"https://example.com/users/1", "urn:isbn:0451450523", "mailto:billy@example.com"}, []string{"/users/1", "example.com", "https://example.com/a b"}},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			assert.NoError(t, Validate(test.matcher))
+
+			for _, v := range test.valid {
+				mismatches, err := Compare(test.matcher, v)
+				assert.NoError(t, err)
+				assert.Empty(t, mismatches, v)
+			}
+			for _, v := range test.invalid {
+				mismatches, err := Compare(test.matcher, v)
+				assert.NoError(t, err)
+				assert.NotEmpty(t, mismatches, v)
+			}
+		})
+	}
+}
@@ -2,7 +2,10 @@ package matchers
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
+	"regexp"
+	"strings"
 
 	"github.com/pact-foundation/pact-go/v2/models"
 )
@@ -109,6 +112,9 @@ func (s fromProviderState) isMatcher() {}
 // "expression" is used to lookup the dynamic value from the provider state context
 // during verification
 // "example" is the example value to used in the consumer test
+//
+// For messages, the values returned by the provider's state handlers are given to the
+// message handlers as parameters of the provider states, to use in the message
 func FromProviderState(expression, example string) Matcher {
 	return fromProviderState{
 		Specification: models.V3,
@@ -143,10 +149,54 @@ func EachKeyLike(key string, template interface{}) Matcher {
 	}
 }
 
+type eachKeyOrValue struct {
+	Specification models.SpecificationVersion `json:"pact:specification"`
+	Type          string                      `json:"pact:matcher:type"`
+	Contents      interface{}                 `json:"value"`
+	Rules         []Matcher                   `json:"rules"`
+}
+
+func (e eachKeyOrValue) GetValue() interface{} {
+	return e.Contents
+}
+
+func (e eachKeyOrValue) isMatcher() {}
+
+// EachKeyMatching matches an object whose keys each satisfy the matcher, e.g. a map of
+// order ids to orders:
+//
+//	EachKeyMatching(map[string]interface{}{"order-1": order}, Regex("order-1", `order-\d+`))
+//
+// Only the keys are matched. This is a V4 matcher.
+func EachKeyMatching(example interface{}, key Matcher) Matcher {
+	return eachKeyOrValue{
+		Specification: models.V4,
+		Type:          "eachKey",
+		Contents:      example,
+		Rules:         []Matcher{key},
+	}
+}
+
+// EachValueMatching matches an object whose values each satisfy the matcher, whatever
+// their keys, e.g. a map of SKUs to quantities:
+//
+//	EachValueMatching(map[string]interface{}{"ABC-1": 2}, Integer(2))
+//
+// This is a V4 matcher.
+func EachValueMatching(example interface{}, value Matcher) Matcher {
+	return eachKeyOrValue{
+		Specification: models.V4,
+		Type:          "eachValue",
+		Contents:      example,
+		Rules:         []Matcher{value},
+	}
+}
+
 type arrayContaining struct {
 	Specification models.SpecificationVersion `json:"pact:specification"`
 	Type          string                      `json:"pact:matcher:type"`
 	Variants      []interface{}               `json:"variants"`
+	EachOneOf     bool                        `json:"pact:eachOneOf,omitempty"`
 }
 
 func (a arrayContaining) GetValue() interface{} {
@@ -166,6 +216,22 @@ func ArrayContaining(variants []interface{}) Matcher {
 	}
 }
 
+// EachOneOf matches an array whose elements may each be any of several shapes, e.g.
+// a heterogeneous list of items of different types. The example contains one element
+// per variant.
+//
+// The contract is verified as an ArrayContaining matcher, so the provider must send
+// at least one element matching each variant. Compare (and so VerifySample) also
+// checks that every element matches one of the variants.
+func EachOneOf(variants ...interface{}) Matcher {
+	return arrayContaining{
+		Specification: models.V3,
+		Type:          "arrayContains",
+		Variants:      variants,
+		EachOneOf:     true,
+	}
+}
+
 type minMaxLike struct {
 	Specification models.SpecificationVersion `json:"pact:specification"`
 	Type          string                      `json:"pact:matcher:type"`
@@ -269,3 +335,333 @@ func DateTimeGenerated(example string, format string) Matcher {
 		Format:        format,
 	}
 }
+
+// Keys recording notes against a matcher in the contract, see Deprecated, Described and UniqueBy
+const (
+	deprecationKey = "pact:deprecated"
+	descriptionKey = "pact:description"
+	uniqueByKey    = "pact:uniqueBy"
+)
+
+// annotated is a matcher with a note recorded against it in the contract under a key
+type annotated struct {
+	Matcher Matcher
+	Key     string
+	Note    string
+}
+
+func (a annotated) GetValue() interface{} {
+	return a.Matcher.GetValue()
+}
+
+func (a annotated) isMatcher() {}
+
+func (a annotated) MarshalJSON() ([]byte, error) {
+	body, err := json.Marshal(a.Matcher)
+	if err != nil {
+		return nil, err
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(body, &m); err != nil || m["pact:matcher:type"] == nil {
+		log.Printf("[WARN] notes can only be recorded against matchers such as Like or Regex, ignoring %s: %s", a.Key, a.Note)
+		return body, nil
+	}
+	m[a.Key] = a.Note
+
+	return json.Marshal(m)
+}
+
+// Deprecated marks a field as deprecated, recording the note in the contract.
+// The field continues to be matched by the inner matcher during verification.
+func Deprecated(inner Matcher, note string) Matcher {
+	return annotated{
+		Matcher: inner,
+		Key:     deprecationKey,
+		Note:    note,
+	}
+}
+
+// DeprecatedFields returns the JSON path and note of each field marked with
+// Deprecated in the given content, e.g. {"$.user.fax": "use email instead"}
+func DeprecatedFields(content interface{}) (map[string]string, error) {
+	return collectNotes(content, deprecationKey)
+}
+
+// Described documents what a field means, recording the description in the contract
+// alongside the inner matcher, which continues to match the field, e.g.
+//
+//	"placedAt": matchers.Described(matchers.Timestamp(), "when the customer placed the order, in UTC")
+func Described(inner Matcher, description string) Matcher {
+	return annotated{
+		Matcher: inner,
+		Key:     descriptionKey,
+		Note:    description,
+	}
+}
+
+// FieldDescriptions returns the JSON path and description of each field documented
+// with Described in the given content, e.g. {"$.order.placedAt": "when the order was placed"}
+func FieldDescriptions(content interface{}) (map[string]string, error) {
+	return collectNotes(content, descriptionKey)
+}
+
+// UniqueBy requires the named field to be unique across the elements of an array matched
+// by EachLike (or ArrayMinMaxLike), e.g. line items with distinct ids:
+//
+//	"items": matchers.UniqueBy("id", matchers.EachLike(matchers.StructMatcher{"id": matchers.Like("sku-1")}, 1))
+//
+// The field is recorded in the contract and elements repeating a value are reported by Compare.
+func UniqueBy(field string, array Matcher) Matcher {
+	return annotated{
+		Matcher: array,
+		Key:     uniqueByKey,
+		Note:    field,
+	}
+}
+
+func collectNotes(content interface{}, key string) (map[string]string, error) {
+	c, err := normalise(content)
+	if err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string)
+	collectNotesAt("$", c, key, fields)
+
+	return fields, nil
+}
+
+func collectNotesAt(path string, v interface{}, key string, fields map[string]string) {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if _, ok := t["pact:matcher:type"]; ok {
+			if note, ok := t[key].(string); ok {
+				fields[path] = note
+			}
+			switch inner := t["value"].(type) {
+			case []interface{}:
+				for i, item := range inner {
+					collectNotesAt(fmt.Sprintf("%s[%d]", path, i), item, key, fields)
+				}
+			case map[string]interface{}:
+				collectNotesAt(path, inner, key, fields)
+			}
+			return
+		}
+		for k, item := range t {
+			collectNotesAt(objectPath(path, k), item, key, fields)
+		}
+	case []interface{}:
+		for i, item := range t {
+			collectNotesAt(fmt.Sprintf("%s[%d]", path, i), item, key, fields)
+		}
+	}
+}
+
+// derivedKey marks a matcher whose example is computed from other fields
+const derivedKey = "pact:derived"
+
+var derivedReference = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+type derived struct {
+	Expression string
+	DependsOn  []string
+}
+
+func (d derived) GetValue() interface{} {
+	return d.Expression
+}
+
+func (d derived) isMatcher() {}
+
+func (d derived) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"pact:specification": models.V3,
+		"pact:matcher:type":  "type",
+		"value":              d.Expression,
+		derivedKey:           d.DependsOn,
+	})
+}
+
+// Derived matches a string field by type, with an example computed from other
+// fields in the same object so that generated examples remain consistent.
+//
+// expr is a template referencing the fields it depends on, e.g. Derived("${first} ${last}", "first", "last").
+// Nested fields may be referenced with a dotted path relative to the object, e.g. ${name.first}.
+//
+// Derived examples are computed by ResolveDerived, which the message DSLs call automatically.
+func Derived(expr string, dependsOn ...string) Matcher {
+	if len(dependsOn) == 0 {
+		for _, ref := range derivedReference.FindAllStringSubmatch(expr, -1) {
+			dependsOn = append(dependsOn, ref[1])
+		}
+	}
+
+	return derived{
+		Expression: expr,
+		DependsOn:  dependsOn,
+	}
+}
+
+// ResolveDerived computes the example of each Derived matcher in the content
+// from the fields it depends on, returning the content in its JSON form
+func ResolveDerived(content interface{}) (interface{}, error) {
+	c, err := normalise(content)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := resolveDerived("$", c); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func resolveDerived(path string, v interface{}) error {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if _, ok := t["pact:matcher:type"]; !ok {
+			if err := resolveDerivedFields(path, t); err != nil {
+				return err
+			}
+		}
+		for _, k := range sortedKeys(t) {
+			if err := resolveDerived(objectPath(path, k), t[k]); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for i, item := range t {
+			if err := resolveDerived(fmt.Sprintf("%s[%d]", path, i), item); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// resolveDerivedFields resolves the derived fields of an object, repeating until
+// fields derived from other derived fields have been computed
+func resolveDerivedFields(path string, obj map[string]interface{}) error {
+	pending := make(map[string]map[string]interface{})
+	for k, v := range obj {
+		if m, ok := v.(map[string]interface{}); ok && m[derivedKey] != nil {
+			pending[k] = m
+		}
+	}
+
+	for len(pending) > 0 {
+		progress := false
+
+		for _, k := range sortedKeys(obj) {
+			m, ok := pending[k]
+			if !ok {
+				continue
+			}
+
+			expr, _ := m["value"].(string)
+			deps, _ := m[derivedKey].([]interface{})
+			values := make(map[string]string, len(deps))
+			ready := true
+
+			for _, d := range deps {
+				name := fmt.Sprintf("%v", d)
+				if _, isPending := pending[strings.SplitN(name, ".", 2)[0]]; isPending {
+					ready = false
+					break
+				}
+				value, found := lookupField(obj, name)
+				if !found {
+					return fmt.Errorf("derived field %s depends on '%s', which does not exist", objectPath(path, k), name)
+				}
+				values[name] = formatExample(value)
+			}
+			if !ready {
+				continue
+			}
+
+			m["value"] = derivedReference.ReplaceAllStringFunc(expr, func(ref string) string {
+				name := derivedReference.FindStringSubmatch(ref)[1]
+				if value, ok := values[name]; ok {
+					return value
+				}
+				return ref
+			})
+			delete(m, derivedKey)
+			delete(pending, k)
+			progress = true
+		}
+
+		if !progress {
+			return fmt.Errorf("derived fields in %s have circular dependencies", path)
+		}
+	}
+
+	return nil
+}
+
+func lookupField(obj map[string]interface{}, name string) (interface{}, bool) {
+	var current interface{} = obj
+
+	for _, part := range strings.Split(name, ".") {
+		m, ok := exampleOf(current).(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+
+	return exampleOf(current), true
+}
+
+func formatExample(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+
+	return string(b)
+}
+
+type numberTerm struct {
+	Specification models.SpecificationVersion `json:"pact:specification"`
+	Type          string                      `json:"pact:matcher:type"`
+	Value         float64                     `json:"value"`
+	Regex         string                      `json:"regex"`
+}
+
+func (n numberTerm) GetValue() interface{} {
+	return n.Value
+}
+
+func (n numberTerm) isMatcher() {}
+
+const (
+	latitude  = `^-?(90(\.0+)?|[1-8]?\d(\.\d+)?)$`
+	longitude = `^-?(180(\.0+)?|(1[0-7]\d|[1-9]?\d)(\.\d+)?)$`
+)
+
+// GeoPoint matches a geographic coordinate, an object of the form {"lat": -33.8688, "lng": 151.2093}
+// where lat is a number in [-90, 90] and lng is a number in [-180, 180].
+// The ranges are enforced with regular expressions over the number, so values written
+// in exponent notation (e.g. 1e-7) are not accepted.
+func GeoPoint() Matcher {
+	return StructMatcher{
+		"lat": numberTerm{
+			Specification: models.V3,
+			Type:          "regex",
+			Value:         -33.8688,
+			Regex:         latitude,
+		},
+		"lng": numberTerm{
+			Specification: models.V3,
+			Type:          "regex",
+			Value:         151.2093,
+			Regex:         longitude,
+		},
+	}
+}
@@ -0,0 +1,33 @@
+package matchers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMoney(t *testing.T) {
+	m := Money()
+	assert.NoError(t, Validate(m))
+
+	testCases := []struct {
+		name       string
+		value      interface{}
+		mismatches int
+	}{
+		{"valid", map[string]interface{}{"amount": "10.50", "currency": "EUR"}, 0},
+		{"negative whole amount", map[string]interface{}{"amount": "-3", "currency": "JPY"}, 0},
+		{"numeric amount", map[string]interface{}{"amount": "1e3", "currency": "USD"}, 1},
+		{"invalid currency", map[string]interface{}{"amount": "10.50", "currency": "ABC"}, 1},
+		{"lower case currency", map[string]interface{}{"amount": "10.50", "currency": "aud"}, 1},
+		{"missing currency", map[string]interface{}{"amount": "10.50"}, 1},
+	}
+
+	for _, test := range testCases {
+		t.Run(test.name, func(t *testing.T) {
+			mismatches, err := Compare(m, test.value)
+			assert.NoError(t, err)
+			assert.Len(t, mismatches, test.mismatches)
+		})
+	}
+}
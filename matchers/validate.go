@@ -0,0 +1,171 @@
+package matchers
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// generatorTypes lists, for each generator, the kind of value it produces and the
+// matchers the generated value can satisfy. ProviderState values may be used with any matcher.
+var generatorTypes = map[string]struct {
+	kind     string
+	matchers []string
+}{
+	"RandomInt":         {"a number", []string{"type", "integer", "number"}},
+	"RandomDecimal":     {"a number", []string{"type", "decimal", "number"}},
+	"RandomBoolean":     {"a boolean", []string{"type", "boolean"}},
+	"RandomString":      {"a string", []string{"type"}},
+	"RandomHexadecimal": {"a string", []string{"type", "regex"}},
+	"Uuid":              {"a string", []string{"type", "regex"}},
+	"Regex":             {"a string", []string{"type", "regex"}},
+	"Date":              {"a string", []string{"type", "date"}},
+	"Time":              {"a string", []string{"type", "time"}},
+	"DateTime":          {"a string", []string{"type", "timestamp", "datetime"}},
+	"MockServerURL":     {"a string", []string{"type", "regex"}},
+	"ProviderState":     {"", nil},
+}
+
+// ValidationError lists the matchers in some content that would produce an invalid contract
+type ValidationError struct {
+	Problems []Mismatch
+}
+
+func (e *ValidationError) Error() string {
+	problems := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		problems[i] = p.String()
+	}
+
+	return fmt.Sprintf("invalid matchers: %s", strings.Join(problems, "; "))
+}
+
+// Validate checks that each matcher in the content is internally consistent, i.e. its
+// example satisfies the matcher and any generator produces values the matcher accepts.
+// A *ValidationError is returned describing every problem found.
+func Validate(content interface{}) error {
+	c, err := normalise(content)
+	if err != nil {
+		return fmt.Errorf("unable to serialise content: %v", err)
+	}
+
+	if problems := validateValue("$", c); len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+func validateValue(path string, v interface{}) []Mismatch {
+	var res []Mismatch
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if matcherType, ok := t["pact:matcher:type"].(string); ok {
+			res = append(res, validateMatcher(path, matcherType, t)...)
+
+			switch value := t["value"].(type) {
+			case []interface{}:
+				for i, item := range value {
+					res = append(res, validateValue(fmt.Sprintf("%s[%d]", path, i), item)...)
+				}
+			case map[string]interface{}:
+				res = append(res, validateValue(path, value)...)
+			}
+			if variants, ok := t["variants"].([]interface{}); ok {
+				for i, item := range variants {
+					res = append(res, validateValue(fmt.Sprintf("%s[%d]", path, i), item)...)
+				}
+			}
+
+			return res
+		}
+		for _, k := range sortedKeys(t) {
+			res = append(res, validateValue(objectPath(path, k), t[k])...)
+		}
+	case []interface{}:
+		for i, item := range t {
+			res = append(res, validateValue(fmt.Sprintf("%s[%d]", path, i), item)...)
+		}
+	}
+
+	return res
+}
+
+func validateMatcher(path string, matcherType string, m map[string]interface{}) []Mismatch {
+	var res []Mismatch
+	value := m["value"]
+	example := exampleOf(value)
+
+	switch matcherType {
+	case "type":
+		items, isArray := value.([]interface{})
+		min, hasMin := m["min"].(float64)
+		max, hasMax := m["max"].(float64)
+		if isArray && hasMin && len(items) < int(min) {
+			res = append(res, mismatch(path, example, nil, "the example has %d element(s) but the matcher requires at least %d", len(items), int(min)))
+		}
+		if isArray && hasMax && max > 0 && len(items) > int(max) {
+			res = append(res, mismatch(path, example, nil, "the example has %d element(s) but the matcher allows at most %d", len(items), int(max)))
+		}
+		if hasMin && hasMax && max > 0 && min > max {
+			res = append(res, mismatch(path, example, nil, "the minimum (%d) is greater than the maximum (%d)", int(min), int(max)))
+		}
+	case "regex":
+		regex, _ := m["regex"].(string)
+		// Expressions using Java only syntax such as lookaheads are left to the core to validate
+		if r, err := regexp.Compile(regex); err == nil {
+			if s, ok := regexSubject(example); !ok || !r.MatchString(s) {
+				res = append(res, mismatch(path, example, nil, "the example %s does not match the regex '%s'", formatValue(example), regex))
+			}
+		}
+	case "integer", "decimal", "number", "boolean", "null", "eachKey", "eachValue":
+		res = append(res, compareMatcher(path, matcherType, m, example, cascadeEquality)...)
+	case "date", "time", "timestamp", "datetime":
+		format, _ := m["format"].(string)
+		if layout, ok := javaToGoLayout(format); ok {
+			if s, isString := example.(string); !isString {
+				res = append(res, mismatch(path, example, nil, "the example for a %s matcher must be a string", matcherType))
+			} else if _, err := time.Parse(layout, s); err != nil {
+				res = append(res, mismatch(path, example, nil, "the example '%s' does not match the format '%s'", s, format))
+			}
+		}
+	}
+
+	if generator, ok := m["pact:generator:type"].(string); ok {
+		res = append(res, validateGenerator(path, generator, matcherType, m, example)...)
+	}
+
+	return res
+}
+
+func validateGenerator(path string, generator string, matcherType string, m map[string]interface{}, example interface{}) []Mismatch {
+	g, ok := generatorTypes[generator]
+	if !ok {
+		return []Mismatch{mismatch(path, example, nil, "unknown generator '%s'", generator)}
+	}
+
+	if generator == "ProviderState" {
+		if expression, _ := m["expression"].(string); expression == "" {
+			return []Mismatch{mismatch(path, example, nil, "the ProviderState generator requires an expression")}
+		}
+		return nil
+	}
+
+	compatible := false
+	for _, t := range g.matchers {
+		if t == matcherType {
+			compatible = true
+		}
+	}
+	if !compatible {
+		return []Mismatch{mismatch(path, example, nil, "the %s generator produces values that can't satisfy a '%s' matcher", generator, matcherType)}
+	}
+
+	if jsonKind(example) != g.kind {
+		return []Mismatch{mismatch(path, example, nil, "the %s generator produces %s but the example is %s", generator, g.kind, jsonKind(example))}
+	}
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package matchers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	t.Run("valid matchers", func(t *testing.T) {
+		err := Validate(StructMatcher{
+			"id":        Integer(1),
+			"price":     Decimal(1.5),
+			"uuid":      UUID(),
+			"ip":        IPAddress(),
+			"timestamp": Timestamp(),
+			"created":   DateTimeGenerated("2020-01-01T10:00:00", "yyyy-MM-dd'T'HH:mm:ss"),
+			"user":      FromProviderState("${id}", "1"),
+			"tags":      ArrayMinMaxLike("a", 1, 3),
+			"nested":    EachLike(map[string]interface{}{"name": Like("billy")}, 2),
+		})
+		assert.NoError(t, err)
+	})
+
+	t.Run("invalid matchers", func(t *testing.T) {
+		err := Validate(map[string]interface{}{
+			"date":      DateGenerated("01/02/2020", "yyyy-MM-dd"),
+			"sku":       Regex("abc", `^[0-9]+$`),
+			"id":        Integer(1),
+			"generated": map[string]interface{}{"pact:matcher:type": "boolean", "value": true, "pact:generator:type": "RandomInt"},
+			"unknown":   map[string]interface{}{"pact:matcher:type": "type", "value": "x", "pact:generator:type": "Magic"},
+			"kind":      map[string]interface{}{"pact:matcher:type": "type", "value": "x", "pact:generator:type": "RandomInt"},
+			"state":     map[string]interface{}{"pact:matcher:type": "type", "value": "x", "pact:generator:type": "ProviderState"},
+			"items":     map[string]interface{}{"pact:matcher:type": "type", "value": []interface{}{1}, "min": 2},
+		})
+		assert.Error(t, err)
+
+		problems := err.(*ValidationError).Problems
+		paths := make([]string, len(problems))
+		for i, p := range problems {
+			paths[i] = p.Path
+		}
+		assert.ElementsMatch(t, []string{"$.date", "$.generated", "$.items", "$.kind", "$.sku", "$.state", "$.unknown"}, paths)
+	})
+}
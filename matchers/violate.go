@@ -0,0 +1,163 @@
+package matchers
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// Violations produces examples of content that each violate a single matcher of the
+// content, for each matcher that has a value it rejects: the example of the content with
+// the value of the matcher replaced, e.g. {"id": "not a number"} for {"id": Integer(1)}.
+// Every violation is rejected by Compare, and the examples are returned in the order of
+// the JSON paths of their matchers.
+func Violations(content interface{}) ([]interface{}, error) {
+	c, err := normalise(content)
+	if err != nil {
+		return nil, fmt.Errorf("unable to serialise content: %v", err)
+	}
+
+	var res []interface{}
+	for _, v := range violationsOf(c) {
+		if len(compareValue("$", c, v, cascadeEquality)) > 0 {
+			res = append(res, v)
+		}
+	}
+
+	return res, nil
+}
+
+// violationsOf returns the examples of a normalised template violating one of its matchers
+func violationsOf(template interface{}) []interface{} {
+	switch t := template.(type) {
+	case map[string]interface{}:
+		if matcherType, ok := t["pact:matcher:type"].(string); ok {
+			res := violatingValues(matcherType, t)
+			if variants, ok := t["variants"]; ok {
+				return append(res, violationsOf(variants)...)
+			}
+			return append(res, violationsOf(t["value"])...)
+		}
+
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		var res []interface{}
+		for _, k := range keys {
+			for _, v := range violationsOf(t[k]) {
+				example := exampleOf(t).(map[string]interface{})
+				example[k] = v
+				res = append(res, example)
+			}
+		}
+		return res
+	case []interface{}:
+		var res []interface{}
+		for i := range t {
+			for _, v := range violationsOf(t[i]) {
+				example := exampleOf(t).([]interface{})
+				example[i] = v
+				res = append(res, example)
+			}
+		}
+		return res
+	default:
+		return nil
+	}
+}
+
+// violatingValues returns values rejected by a matcher
+func violatingValues(matcherType string, m map[string]interface{}) []interface{} {
+	value := m["value"]
+
+	switch matcherType {
+	case "type":
+		res := []interface{}{otherKind(value)}
+		if elements, ok := value.([]interface{}); ok && len(elements) > 0 {
+			if min, ok := m["min"].(float64); ok && min > 0 {
+				res = append(res, []interface{}{})
+			}
+			if max, ok := m["max"].(float64); ok && max > 0 {
+				tooMany := make([]interface{}, int(max)+1)
+				for i := range tooMany {
+					tooMany[i] = exampleOf(elements[0])
+				}
+				res = append(res, tooMany)
+			}
+		}
+		if _, ok := m[uniqueByKey].(string); ok {
+			if elements, ok := value.([]interface{}); ok && len(elements) > 0 {
+				res = append(res, []interface{}{exampleOf(elements[0]), exampleOf(elements[0])})
+			}
+		}
+		return res
+	case "regex":
+		regex, _ := m["regex"].(string)
+		re, err := regexp.Compile(regex)
+		if err != nil {
+			return nil
+		}
+		for _, candidate := range []string{"", "!", "not matching ~"} {
+			if !re.MatchString(candidate) {
+				return []interface{}{candidate}
+			}
+		}
+		return nil
+	case "integer":
+		return []interface{}{1.5, "not an integer"}
+	case "decimal", "number":
+		return []interface{}{"not a number"}
+	case "boolean":
+		return []interface{}{"not a boolean"}
+	case "include":
+		return []interface{}{""}
+	case "equality":
+		return []interface{}{otherValue(exampleOf(value))}
+	case "null":
+		return []interface{}{"not null"}
+	case "notEmpty":
+		switch value.(type) {
+		case string:
+			return []interface{}{""}
+		case []interface{}:
+			return []interface{}{[]interface{}{}}
+		case map[string]interface{}:
+			return []interface{}{map[string]interface{}{}}
+		}
+		return nil
+	case "values", "arrayContains":
+		return []interface{}{otherKind(value)}
+	case "date", "time", "timestamp", "datetime":
+		return []interface{}{"not a date"}
+	default:
+		return nil
+	}
+}
+
+// otherKind returns a value of a different JSON kind to v
+func otherKind(v interface{}) interface{} {
+	if _, ok := v.(string); ok {
+		return float64(0)
+	}
+
+	return "not " + jsonKind(v)
+}
+
+// otherValue returns a value of the same JSON kind as v that is not equal to it
+func otherValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case string:
+		return t + " (changed)"
+	case float64:
+		return t + 1
+	case bool:
+		return !t
+	case nil:
+		return "not null"
+	default:
+		return otherKind(v)
+	}
+}
@@ -0,0 +1,45 @@
+package matchers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestViolations(t *testing.T) {
+	template := StructMatcher{
+		"id":     Integer(1),
+		"sku":    Regex("ABC-123", `^[A-Z]{3}-\d+$`),
+		"status": "active",
+		"items":  UniqueBy("id", EachLike(StructMatcher{"id": Like("a")}, 1)),
+	}
+
+	violations, err := Violations(template)
+	assert.NoError(t, err)
+
+	var paths []string
+	for _, v := range violations {
+		mismatches, err := Compare(template, v)
+		assert.NoError(t, err)
+		assert.Len(t, mismatches, 1, "%v", v)
+		for _, m := range mismatches {
+			paths = append(paths, m.Path)
+		}
+	}
+	assert.Equal(t, []string{
+		"$.id", "$.id",
+		"$.items", "$.items", "$.items[1].id",
+		"$.items[0].id",
+		"$.sku",
+	}, paths)
+	assert.Equal(t, map[string]interface{}{
+		"id":     1.5,
+		"sku":    "ABC-123",
+		"status": "active",
+		"items":  []interface{}{map[string]interface{}{"id": "a"}},
+	}, violations[0])
+
+	violations, err = Violations(map[string]interface{}{"status": "active"})
+	assert.NoError(t, err)
+	assert.Empty(t, violations)
+}
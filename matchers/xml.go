@@ -0,0 +1,186 @@
+package matchers
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+)
+
+// XMLContentType is the content type of XML bodies and message contents
+const XMLContentType = "application/xml"
+
+// XMLBody is an XML document whose elements, attributes and text may be matched, e.g.
+//
+//	matchers.XMLDocument(
+//		matchers.XMLElement("projects").
+//			Attribute("id", matchers.Integer(1234)).
+//			EachLike(matchers.XMLElement("project").
+//				Attribute("name", matchers.Like("Project 1")).
+//				Text(matchers.Regex("active", "active|inactive")), 1),
+//	)
+//
+// It is serialised to the XML DSL of the core, which generates the example document
+// and the matching rules of the body. XML matching requires the V3 specification or later.
+type XMLBody struct {
+	Version string   `json:"version"`
+	Charset string   `json:"charset"`
+	Root    *XMLNode `json:"root"`
+}
+
+// XMLDocument creates an XML body with the root element
+func XMLDocument(root *XMLNode) XMLBody {
+	return XMLBody{
+		Version: "1.0",
+		Charset: "UTF-8",
+		Root:    root,
+	}
+}
+
+// XMLNode is an element of an XML document
+type XMLNode struct {
+	name       string
+	attributes map[string]interface{}
+	children   []interface{}
+}
+
+// xmlText is the text content of an element
+type xmlText struct {
+	Content string  `json:"content"`
+	Matcher Matcher `json:"matcher,omitempty"`
+}
+
+// xmlEachLike is an element that may be repeated
+type xmlEachLike struct {
+	Type     string   `json:"pact:matcher:type"`
+	Value    *XMLNode `json:"value"`
+	Min      int      `json:"min"`
+	Examples int      `json:"examples"`
+}
+
+// XMLElement creates an element with the (optionally prefixed) name, e.g. "ns1:project"
+func XMLElement(name string) *XMLNode {
+	return &XMLNode{
+		name:       name,
+		attributes: make(map[string]interface{}),
+	}
+}
+
+// Attribute adds an attribute to the element, whose value is a string or a Matcher
+func (e *XMLNode) Attribute(name string, value interface{}) *XMLNode {
+	e.attributes[name] = value
+
+	return e
+}
+
+// Text adds text content to the element, given as a string or a Matcher
+func (e *XMLNode) Text(content interface{}) *XMLNode {
+	switch m := content.(type) {
+	case String, S:
+	case Matcher:
+		e.children = append(e.children, xmlText{Content: fmt.Sprint(m.GetValue()), Matcher: m})
+		return e
+	}
+	e.children = append(e.children, xmlText{Content: fmt.Sprint(content)})
+
+	return e
+}
+
+// Children adds child elements to the element
+func (e *XMLNode) Children(children ...*XMLNode) *XMLNode {
+	for _, c := range children {
+		e.children = append(e.children, c)
+	}
+
+	return e
+}
+
+// EachLike adds a child element that may occur any number of times, but at least min,
+// matched by type against the given element
+func (e *XMLNode) EachLike(child *XMLNode, min int) *XMLNode {
+	examples := min
+	if examples < 1 {
+		examples = 1
+	}
+	e.children = append(e.children, xmlEachLike{Type: "type", Value: child, Min: min, Examples: examples})
+
+	return e
+}
+
+// MarshalJSON serialises the element to the XML DSL of the core
+func (e *XMLNode) MarshalJSON() ([]byte, error) {
+	children := e.children
+	if children == nil {
+		children = []interface{}{}
+	}
+
+	return json.Marshal(struct {
+		Name       string                 `json:"name"`
+		Attributes map[string]interface{} `json:"attributes"`
+		Children   []interface{}          `json:"children"`
+	}{e.name, e.attributes, children})
+}
+
+// Example renders the example document of the body, with the example values of its matchers
+func (b XMLBody) Example() ([]byte, error) {
+	if b.Root == nil {
+		return nil, fmt.Errorf("an XML document requires a root element")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<?xml version="%s" encoding="%s"?>`, b.Version, b.Charset)
+	enc := xml.NewEncoder(&buf)
+	if err := b.Root.encode(enc); err != nil {
+		return nil, err
+	}
+	if err := enc.Flush(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (e *XMLNode) encode(enc *xml.Encoder) error {
+	names := make([]string, 0, len(e.attributes))
+	for name := range e.attributes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	start := xml.StartElement{Name: xml.Name{Local: e.name}}
+	for _, name := range names {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: name}, Value: exampleString(e.attributes[name])})
+	}
+	if err := enc.EncodeToken(start); err != nil {
+		return err
+	}
+
+	for _, child := range e.children {
+		var err error
+		switch c := child.(type) {
+		case *XMLNode:
+			err = c.encode(enc)
+		case xmlText:
+			err = enc.EncodeToken(xml.CharData(c.Content))
+		case xmlEachLike:
+			for i := 0; i < c.Examples && err == nil; i++ {
+				err = c.Value.encode(enc)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return enc.EncodeToken(start.End())
+}
+
+// exampleString returns the example value of an attribute
+func exampleString(v interface{}) string {
+	if m, ok := v.(Matcher); ok {
+		return fmt.Sprint(m.GetValue())
+	}
+
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,54 @@
+package matchers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestXMLBody(t *testing.T) {
+	body := XMLDocument(
+		XMLElement("ns1:projects").
+			Attribute("xmlns:ns1", "http://some.namespace/and/more/stuff").
+			Attribute("id", Integer(1234)).
+			EachLike(XMLElement("ns1:project").
+				Attribute("name", Like("Project & co")).
+				Text(Regex("active", "active|inactive")), 2).
+			Children(XMLElement("owner").Text("billy")),
+	)
+
+	raw, err := json.Marshal(body)
+	assert.NoError(t, err)
+
+	var dsl map[string]interface{}
+	assert.NoError(t, json.Unmarshal(raw, &dsl))
+	assert.Equal(t, "1.0", dsl["version"])
+	root := dsl["root"].(map[string]interface{})
+	assert.Equal(t, "ns1:projects", root["name"])
+	assert.Equal(t, "integer", root["attributes"].(map[string]interface{})["id"].(map[string]interface{})["pact:matcher:type"])
+
+	children := root["children"].([]interface{})
+	assert.Len(t, children, 2)
+	each := children[0].(map[string]interface{})
+	assert.Equal(t, "type", each["pact:matcher:type"])
+	assert.Equal(t, float64(2), each["examples"])
+	project := each["value"].(map[string]interface{})
+	text := project["children"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "active", text["content"])
+	assert.Equal(t, "regex", text["matcher"].(map[string]interface{})["pact:matcher:type"])
+	owner := children[1].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"content": "billy"}, owner["children"].([]interface{})[0])
+
+	example, err := body.Example()
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+
+		`<ns1:projects id="1234" xmlns:ns1="http://some.namespace/and/more/stuff">`+
+		`<ns1:project name="Project &amp; co">active</ns1:project>`+
+		`<ns1:project name="Project &amp; co">active</ns1:project>`+
+		`<owner>billy</owner>`+
+		`</ns1:projects>`, string(example))
+
+	_, err = XMLBody{}.Example()
+	assert.Error(t, err)
+}
@@ -0,0 +1,178 @@
+// Package kafka maps Kafka records to and from the metadata and contents of v4 message pacts,
+// so that consumer tests can describe and consume messages in the shape their Kafka
+// consumer code already uses.
+//
+// Record mirrors the fields common to the message types of the Kafka clients, so a client's
+// messages can be used with a small conversion, without pact-go depending on a particular
+// client, e.g. for sarama:
+//
+//	func fromSarama(m *sarama.ConsumerMessage) kafka.Record {
+//		r := kafka.Record{Topic: m.Topic, Partition: m.Partition, Offset: m.Offset, Key: m.Key, Value: m.Value}
+//		for _, h := range m.Headers {
+//			r.Headers = append(r.Headers, kafka.Header{Key: string(h.Key), Value: h.Value})
+//		}
+//		return r
+//	}
+//
+// and for kafka-go, whose kafka.Header has the same fields:
+//
+//	func fromKafkaGo(m kafkago.Message) kafka.Record {
+//		r := kafka.Record{Topic: m.Topic, Partition: int32(m.Partition), Offset: m.Offset, Key: m.Key, Value: m.Value}
+//		for _, h := range m.Headers {
+//			r.Headers = append(r.Headers, kafka.Header{Key: h.Key, Value: h.Value})
+//		}
+//		return r
+//	}
+package kafka
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+
+	v4 "github.com/pact-foundation/pact-go/v2/message/v4"
+)
+
+// Metadata keys used for the parts of a record that aren't its value
+const (
+	// MetadataTopic is the topic the message is published to
+	MetadataTopic = "topic"
+
+	// MetadataKey is the key of the record, used by the producer to choose its partition
+	MetadataKey = "kafka_key"
+
+	// MetadataContentType is the content type of the message, taken from the
+	// ContentTypeHeader of the record
+	MetadataContentType = "contentType"
+)
+
+// ContentTypeHeader is the record header that gives the content type of its value
+const ContentTypeHeader = "content-type"
+
+// defaultContentType is used for records without a ContentTypeHeader
+const defaultContentType = "application/json"
+
+// Header is a record header
+type Header struct {
+	Key   string
+	Value []byte
+}
+
+// Record is a Kafka record, as consumed by the consumer under test.
+//
+// The partition and offset are assigned by the broker, so they are not part of the pact;
+// they are zero in records given to a Consumer.
+type Record struct {
+	Topic     string
+	Partition int32
+	Offset    int64
+	Key       []byte
+	Value     []byte
+	Headers   []Header
+}
+
+// Metadata returns the message metadata of the record: its topic, key and headers, with
+// the ContentTypeHeader given as MetadataContentType
+func Metadata(r Record) map[string]string {
+	metadata := make(map[string]string, len(r.Headers)+2)
+	for _, h := range r.Headers {
+		if h.Key == ContentTypeHeader {
+			metadata[MetadataContentType] = string(h.Value)
+		} else {
+			metadata[h.Key] = string(h.Value)
+		}
+	}
+	if r.Topic != "" {
+		metadata[MetadataTopic] = r.Topic
+	}
+	if r.Key != nil {
+		metadata[MetadataKey] = string(r.Key)
+	}
+
+	return metadata
+}
+
+// ContentType returns the content type of the record's value, from its ContentTypeHeader.
+// Defaults to application/json
+func ContentType(r Record) string {
+	for _, h := range r.Headers {
+		if h.Key == ContentTypeHeader {
+			return string(h.Value)
+		}
+	}
+
+	return defaultContentType
+}
+
+// Expect describes the message as the record, e.g.
+//
+//	kafka.Expect(p.AddAsynchronousMessage().
+//		ExpectsToReceive("an OrderCreated event"), kafka.Record{
+//		Topic:   "orders",
+//		Key:     []byte("order-1"),
+//		Value:   []byte(`{"id": "order-1"}`),
+//		Headers: []kafka.Header{{Key: "content-type", Value: []byte("application/json")}},
+//	}).
+//		ConsumedBy(kafka.Consumer(handleOrder)).
+//		Verify(t)
+//
+// The metadata and contents may be further refined, e.g. with WithMetadataMatchers or
+// WithContentMatchersAt.
+func Expect(m *v4.UnconfiguredAsynchronousMessageBuilder, r Record) *v4.AsynchronousMessageWithContents {
+	return m.WithMetadata(Metadata(r)).WithContent(ContentType(r), r.Value)
+}
+
+// RecordFrom returns the record for a message: the inverse of Metadata, with the
+// contents of the message as its value. Headers are ordered by key.
+func RecordFrom(m v4.AsynchronousMessage) Record {
+	r := Record{
+		Value: m.Contents,
+	}
+
+	keys := make([]string, 0, len(m.Metadata))
+	for k := range m.Metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := metadataValue(m.Metadata[k])
+		switch k {
+		case MetadataTopic:
+			r.Topic = v
+		case MetadataKey:
+			r.Key = []byte(v)
+		case MetadataContentType:
+			r.Headers = append(r.Headers, Header{Key: ContentTypeHeader, Value: []byte(v)})
+		default:
+			r.Headers = append(r.Headers, Header{Key: k, Value: []byte(v)})
+		}
+	}
+
+	return r
+}
+
+// metadataValue returns the string form of a metadata value, as it would be sent in a header
+func metadataValue(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, _ := json.Marshal(v)
+
+	return string(b)
+}
+
+// Consumer adapts a function consuming records, such as the handler of a Kafka consumer,
+// to consume the messages of a pact
+func Consumer(handler func(Record) error) v4.AsynchronousConsumer {
+	return func(m v4.AsynchronousMessage) error {
+		return handler(RecordFrom(m))
+	}
+}
+
+// ConsumerCtx is Consumer for a handler that is given a context
+func ConsumerCtx(handler func(context.Context, Record) error) v4.AsynchronousConsumerCtx {
+	return func(ctx context.Context, m v4.AsynchronousMessage) error {
+		return handler(ctx, RecordFrom(m))
+	}
+}
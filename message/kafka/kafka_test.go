@@ -0,0 +1,81 @@
+package kafka
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	v4 "github.com/pact-foundation/pact-go/v2/message/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadata(t *testing.T) {
+	metadata := Metadata(Record{
+		Topic:     "orders",
+		Partition: 3,
+		Offset:    42,
+		Key:       []byte("order-1"),
+		Value:     []byte(`{"id": "order-1"}`),
+		Headers: []Header{
+			{Key: ContentTypeHeader, Value: []byte("application/vnd.order+json")},
+			{Key: "traceparent", Value: []byte("00-abc-01")},
+		},
+	})
+
+	assert.Equal(t, map[string]string{
+		"topic":       "orders",
+		"kafka_key":   "order-1",
+		"contentType": "application/vnd.order+json",
+		"traceparent": "00-abc-01",
+	}, metadata)
+	assert.Equal(t, map[string]string{}, Metadata(Record{Value: []byte("{}")}))
+}
+
+func TestContentType(t *testing.T) {
+	assert.Equal(t, "text/plain", ContentType(Record{Headers: []Header{{Key: ContentTypeHeader, Value: []byte("text/plain")}}}))
+	assert.Equal(t, "application/json", ContentType(Record{}))
+}
+
+func TestRecordFrom(t *testing.T) {
+	m := v4.AsynchronousMessage{
+		Contents: []byte(`{"id": "order-1"}`),
+		Metadata: v4.Metadata{
+			"topic":       "orders",
+			"kafka_key":   "order-1",
+			"contentType": "application/json",
+			"version":     2,
+		},
+	}
+
+	assert.Equal(t, Record{
+		Topic: "orders",
+		Key:   []byte("order-1"),
+		Value: []byte(`{"id": "order-1"}`),
+		Headers: []Header{
+			{Key: ContentTypeHeader, Value: []byte("application/json")},
+			{Key: "version", Value: []byte("2")},
+		},
+	}, RecordFrom(m))
+}
+
+func TestConsumer(t *testing.T) {
+	var received Record
+	handler := Consumer(func(r Record) error {
+		received = r
+		return fmt.Errorf("unable to process %s", r.Key)
+	})
+
+	err := handler(v4.AsynchronousMessage{
+		Contents: []byte("{}"),
+		Metadata: v4.Metadata{"topic": "orders", "kafka_key": "order-1"},
+	})
+	assert.EqualError(t, err, "unable to process order-1")
+	assert.Equal(t, "orders", received.Topic)
+
+	ctxHandler := ConsumerCtx(func(ctx context.Context, r Record) error {
+		return ctx.Err()
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, ctxHandler(ctx, v4.AsynchronousMessage{}))
+}
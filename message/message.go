@@ -1,6 +1,10 @@
 package message
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/pact-foundation/pact-go/v2/codecs"
 	"github.com/pact-foundation/pact-go/v2/models"
 )
 
@@ -14,3 +18,45 @@ type Producer Handler
 
 // Handlers is a list of handlers ordered by description
 type Handlers map[string]Handler
+
+// RegisterDecoder sets the decoder used by message pacts to decode content of the
+// content type, e.g. "application/avro", before it is given to the consumer handler.
+// A decoder in the Codecs of a pact's Config takes precedence.
+func RegisterDecoder(contentType string, decoder codecs.Decoder) {
+	codecs.Register(contentType, decoder)
+}
+
+// WithVerifyTimeout bounds the time the handler may take to produce its message during
+// verification. If the timeout elapses an error is returned, failing only this interaction
+// rather than stalling the whole verification run, e.g.
+//
+//	Handlers{"a user event": Handler(userEvent).WithVerifyTimeout(5 * time.Second)}
+//
+// A timeout that isn't positive, e.g. the zero Duration, leaves the handler unbounded.
+// The handler can't be cancelled, so it continues to run in the background after a timeout.
+func (h Handler) WithVerifyTimeout(d time.Duration) Handler {
+	if d <= 0 {
+		return h
+	}
+
+	return func(states []models.ProviderState) (Body, Metadata, error) {
+		type result struct {
+			body     Body
+			metadata Metadata
+			err      error
+		}
+		done := make(chan result, 1)
+
+		go func() {
+			body, metadata, err := h(states)
+			done <- result{body, metadata, err}
+		}()
+
+		select {
+		case r := <-done:
+			return r.body, r.metadata, r.err
+		case <-time.After(d):
+			return nil, nil, fmt.Errorf("message handler timed out after %s", d)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package message
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHandler_WithVerifyTimeout(t *testing.T) {
+	fast := Handler(func([]models.ProviderState) (Body, Metadata, error) {
+		return "done", nil, nil
+	}).WithVerifyTimeout(time.Second)
+
+	body, _, err := fast(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "done", body)
+
+	release := make(chan struct{})
+	defer close(release)
+	hanging := Handler(func([]models.ProviderState) (Body, Metadata, error) {
+		<-release
+		return nil, nil, nil
+	}).WithVerifyTimeout(10 * time.Millisecond)
+
+	_, _, err = hanging(nil)
+	assert.EqualError(t, err, "message handler timed out after 10ms")
+
+	slow := func([]models.ProviderState) (Body, Metadata, error) {
+		time.Sleep(10 * time.Millisecond)
+		return "done", nil, nil
+	}
+	for _, d := range []time.Duration{0, -time.Second} {
+		body, _, err = Handler(slow).WithVerifyTimeout(d)(nil)
+		assert.NoError(t, err, "a timeout of %s must not bound the handler", d)
+		assert.Equal(t, "done", body)
+	}
+}
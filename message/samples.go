@@ -0,0 +1,201 @@
+package message
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/v2/matchers"
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+)
+
+// VerifySamplesRequest configures the verification of provider supplied sample
+// messages against the consumer contracts of a provider
+type VerifySamplesRequest struct {
+	// Provider is the name of the provider. Only pacts for this provider are verified,
+	// all given pacts are verified if empty
+	Provider string
+
+	// PactFiles are the consumer pact files to verify
+	PactFiles []string
+
+	// PactDirs are directories containing consumer pact files (*.json) to verify
+	PactDirs []string
+
+	// ConsumerGroup limits verification to messages expected by this consumer group,
+	// see WithConsumerGroup. Messages that don't record a group are always verified
+	ConsumerGroup string
+
+	// MessageHandlers produce the sample message for each message description
+	MessageHandlers Handlers
+}
+
+// SampleFailure describes why the sample for a message failed to satisfy a contract
+type SampleFailure struct {
+	// Interaction is the description of the message in the contract
+	Interaction string
+
+	// Mismatches are the differences between the sample and the contract
+	Mismatches []matchers.Mismatch
+
+	// Error is set if no sample could be produced or compared
+	Error error
+}
+
+func (f SampleFailure) String() string {
+	if f.Error != nil {
+		return fmt.Sprintf("%s: %v", f.Interaction, f.Error)
+	}
+
+	mismatches := make([]string, len(f.Mismatches))
+	for i, m := range f.Mismatches {
+		mismatches[i] = m.String()
+	}
+
+	return fmt.Sprintf("%s: %s", f.Interaction, strings.Join(mismatches, "; "))
+}
+
+// SampleResult is the outcome of verifying samples against a single consumer contract
+type SampleResult struct {
+	Consumer string
+	PactFile string
+	Failures []SampleFailure
+
+	// Warnings are the failures of messages with a "warning" severity, which
+	// don't affect compatibility
+	Warnings []SampleFailure
+}
+
+// Compatible is true if every sample satisfied the consumer's contract
+func (r SampleResult) Compatible() bool {
+	return len(r.Failures) == 0
+}
+
+// VerifySamples checks the sample messages produced by the provider's handlers against
+// every consumer contract for the provider, without running the native verifier.
+// A result is returned per consumer pact.
+func VerifySamples(request VerifySamplesRequest) ([]SampleResult, error) {
+	files := append([]string(nil), request.PactFiles...)
+	for _, dir := range request.PactDirs {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, matches...)
+	}
+
+	var results []SampleResult
+	for _, file := range files {
+		p, err := pactfile.Read(file)
+		if err != nil {
+			return nil, err
+		}
+		if request.Provider != "" && p.Provider.Name != request.Provider {
+			log.Printf("[DEBUG] skipping pact %s for provider %s", file, p.Provider.Name)
+			continue
+		}
+
+		result := SampleResult{
+			Consumer: p.Consumer.Name,
+			PactFile: file,
+		}
+		for _, i := range p.AllInteractions() {
+			if !isAsynchronousMessage(i) || !inConsumerGroup(i, request.ConsumerGroup) {
+				continue
+			}
+			f := verifySample(i, request.MessageHandlers)
+			switch {
+			case f == nil:
+			case metadataValue(i, models.SeverityMetadataKey) == string(models.SeverityWarning):
+				log.Printf("[WARN] sample for '%s' does not satisfy the contract of %s: %s", i.Description, p.Consumer.Name, f)
+				result.Warnings = append(result.Warnings, *f)
+			default:
+				result.Failures = append(result.Failures, *f)
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func isAsynchronousMessage(i *pactfile.Interaction) bool {
+	_, hasContents := i.Raw["contents"]
+	_, hasRequest := i.Raw["request"]
+
+	return hasContents && !hasRequest
+}
+
+func inConsumerGroup(i *pactfile.Interaction, group string) bool {
+	g := metadataValue(i, models.ConsumerGroupMetadataKey)
+
+	return group == "" || g == "" || g == group
+}
+
+func metadataValue(i *pactfile.Interaction, key string) string {
+	metadata, _ := i.Raw["metadata"].(map[string]interface{})
+	v, _ := metadata[key].(string)
+
+	return v
+}
+
+func verifySample(i *pactfile.Interaction, handlers Handlers) *SampleFailure {
+	handler, ok := handlers[i.Description]
+	if !ok {
+		return &SampleFailure{Interaction: i.Description, Error: fmt.Errorf("no message handler found for '%s'", i.Description)}
+	}
+
+	body, metadata, err := handler(i.ProviderStates)
+	if err != nil {
+		return &SampleFailure{Interaction: i.Description, Error: fmt.Errorf("message handler failed: %v", err)}
+	}
+	if metadata == nil {
+		metadata = Metadata{}
+	}
+
+	var mismatches []matchers.Mismatch
+	for _, part := range i.Parts() {
+		var res []matchers.Mismatch
+		switch part.Name {
+		case "contents":
+			res, err = matchers.Compare(part.Template(), body)
+		case "metadata":
+			res, err = matchers.Compare(withoutContentType(part.Template()), metadata)
+		}
+		if err != nil {
+			return &SampleFailure{Interaction: i.Description, Error: err}
+		}
+		for _, m := range res {
+			m.Path = part.Name + " " + m.Path
+			mismatches = append(mismatches, m)
+		}
+	}
+
+	if len(mismatches) > 0 {
+		return &SampleFailure{Interaction: i.Description, Mismatches: mismatches}
+	}
+
+	return nil
+}
+
+// withoutContentType removes the content type from metadata expectations. It is
+// recorded by the consumer DSL but is not required to be set by message handlers.
+func withoutContentType(template interface{}) interface{} {
+	m, ok := template.(map[string]interface{})
+	if !ok {
+		return template
+	}
+
+	res := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		switch strings.ToLower(k) {
+		case "contenttype", "content-type":
+		default:
+			res[k] = v
+		}
+	}
+
+	return res
+}
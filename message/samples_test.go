@@ -0,0 +1,135 @@
+package message
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/stretchr/testify/assert"
+)
+
+const samplesPact = `{
+  "consumer": {"name": "%s"},
+  "provider": {"name": "%s"},
+  "messages": [
+    {
+      "description": "a user event",
+      "contents": {"id": 1, "name": "billy", "tags": ["a"]},
+      "matchingRules": {
+        "body": {
+          "$.id": {"combine": "AND", "matchers": [{"match": "integer"}]},
+          "$.name": {"combine": "AND", "matchers": [{"match": "type"}]},
+          "$.tags": {"combine": "AND", "matchers": [{"match": "type", "min": 1}]}
+        },
+        "metadata": {
+          "queue": {"combine": "AND", "matchers": [{"match": "regex", "regex": "^users-.*$"}]}
+        }
+      },
+      "metadata": {"contentType": "application/json", "queue": "users-created"}
+    }
+  ],
+  "metadata": {"pactSpecification": {"version": "3.0.0"}}
+}`
+
+func writeSamplesPact(t *testing.T, dir, consumer, provider string) {
+	content := []byte(fmt.Sprintf(samplesPact, consumer, provider))
+	err := ioutil.WriteFile(filepath.Join(dir, consumer+"-"+provider+".json"), content, 0644)
+	assert.NoError(t, err)
+}
+
+func TestVerifySamples(t *testing.T) {
+	dir, err := ioutil.TempDir("", "samples")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	writeSamplesPact(t, dir, "consumer-a", "user-service")
+	writeSamplesPact(t, dir, "consumer-b", "user-service")
+	writeSamplesPact(t, dir, "consumer-c", "another-service")
+
+	verify := func(h Handler) []SampleResult {
+		results, err := VerifySamples(VerifySamplesRequest{
+			Provider:        "user-service",
+			PactDirs:        []string{dir},
+			MessageHandlers: Handlers{"a user event": h},
+		})
+		assert.NoError(t, err)
+		assert.Len(t, results, 2)
+
+		return results
+	}
+
+	t.Run("matching samples are compatible", func(t *testing.T) {
+		results := verify(func([]models.ProviderState) (Body, Metadata, error) {
+			return map[string]interface{}{"id": 27, "name": "sally", "tags": []string{"x", "y"}}, Metadata{"queue": "users-updated"}, nil
+		})
+		for _, r := range results {
+			assert.True(t, r.Compatible(), "%s: %v", r.Consumer, r.Failures)
+		}
+	})
+
+	t.Run("mismatches are reported per consumer", func(t *testing.T) {
+		results := verify(func([]models.ProviderState) (Body, Metadata, error) {
+			return []byte(`{"id": "27", "name": "sally", "tags": []}`), Metadata{"queue": "orders"}, nil
+		})
+		for _, r := range results {
+			assert.False(t, r.Compatible())
+			paths := make([]string, len(r.Failures[0].Mismatches))
+			for i, m := range r.Failures[0].Mismatches {
+				paths[i] = m.Path
+			}
+			assert.ElementsMatch(t, []string{"contents $.id", "contents $.tags", "metadata $.queue"}, paths)
+		}
+	})
+
+	t.Run("handler errors are reported", func(t *testing.T) {
+		results := verify(func([]models.ProviderState) (Body, Metadata, error) {
+			return nil, nil, errors.New("boom")
+		})
+		assert.Error(t, results[0].Failures[0].Error)
+	})
+
+	t.Run("messages for other consumer groups are skipped", func(t *testing.T) {
+		groupDir, err := ioutil.TempDir("", "samples")
+		assert.NoError(t, err)
+		defer os.RemoveAll(groupDir)
+
+		grouped := strings.Replace(fmt.Sprintf(samplesPact, "consumer-d", "user-service"), `"queue": "users-created"`, `"queue": "users-created", "consumerGroup": "billing"`, 1)
+		err = ioutil.WriteFile(filepath.Join(groupDir, "grouped.json"), []byte(grouped), 0644)
+		assert.NoError(t, err)
+
+		results, err := VerifySamples(VerifySamplesRequest{PactDirs: []string{groupDir}, ConsumerGroup: "shipping"})
+		assert.NoError(t, err)
+		assert.True(t, results[0].Compatible())
+
+		results, err = VerifySamples(VerifySamplesRequest{PactDirs: []string{groupDir}, ConsumerGroup: "billing"})
+		assert.NoError(t, err)
+		assert.False(t, results[0].Compatible())
+	})
+
+	t.Run("warning level messages don't affect compatibility", func(t *testing.T) {
+		warningDir, err := ioutil.TempDir("", "samples")
+		assert.NoError(t, err)
+		defer os.RemoveAll(warningDir)
+
+		advisory := strings.Replace(fmt.Sprintf(samplesPact, "consumer-e", "user-service"), `"queue": "users-created"`, `"queue": "users-created", "severity": "warning"`, 1)
+		err = ioutil.WriteFile(filepath.Join(warningDir, "advisory.json"), []byte(advisory), 0644)
+		assert.NoError(t, err)
+
+		results, err := VerifySamples(VerifySamplesRequest{PactDirs: []string{warningDir}})
+		assert.NoError(t, err)
+		assert.True(t, results[0].Compatible())
+		assert.Len(t, results[0].Warnings, 1)
+	})
+
+	t.Run("missing handlers are reported", func(t *testing.T) {
+		results, err := VerifySamples(VerifySamplesRequest{PactDirs: []string{dir}})
+		assert.NoError(t, err)
+		assert.Len(t, results, 3)
+		assert.Error(t, results[0].Failures[0].Error)
+	})
+}
@@ -3,16 +3,18 @@ package v3
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
 	"reflect"
 	"testing"
 
+	"github.com/pact-foundation/pact-go/v2/codecs"
 	"github.com/pact-foundation/pact-go/v2/internal/native"
 	mockserver "github.com/pact-foundation/pact-go/v2/internal/native"
 	logging "github.com/pact-foundation/pact-go/v2/log"
+	"github.com/pact-foundation/pact-go/v2/matchers"
 	"github.com/pact-foundation/pact-go/v2/models"
+	"google.golang.org/protobuf/proto"
 )
 
 // TODO: make a builder?
@@ -30,6 +32,22 @@ type AsynchronousMessageBuilder struct {
 
 	// The handler for this message
 	handler AsynchronousConsumer
+
+	// err is the first error encountered while building the message
+	err error
+
+	// The content type of the message, used to find a decoder in Config.Codecs or
+	// registered with message.RegisterDecoder
+	contentType string
+
+	// The metadata expectations, given to the handler as their example values
+	metadata map[string]interface{}
+
+	// pluginContents is set if the contents are built by a plugin, see WithPluginContents
+	pluginContents bool
+
+	// The description of the message, given to the Config.BeforeEach and AfterEach hooks
+	description string
 }
 
 type UnconfiguredAsynchronousMessageBuilder struct {
@@ -50,11 +68,21 @@ func (m *AsynchronousMessageBuilder) Given(state string) *AsynchronousMessageBui
 	return m
 }
 
+// GivenStates specifies several provider states, added to any already given. Optional.
+func (m *AsynchronousMessageBuilder) GivenStates(states ...models.ProviderState) *AsynchronousMessageBuilder {
+	for _, state := range states {
+		m.GivenWithParameter(state)
+	}
+
+	return m
+}
+
 // ExpectsToReceive specifies the content it is expecting to be
 // given from the Provider. The function must be able to handle this
 // message for the interaction to succeed.
 func (m *AsynchronousMessageBuilder) ExpectsToReceive(description string) *UnconfiguredAsynchronousMessageBuilder {
 	m.messageHandle.ExpectsToReceive(description)
+	m.description = description
 
 	return &UnconfiguredAsynchronousMessageBuilder{
 		rootBuilder: m,
@@ -66,17 +94,52 @@ func (m *AsynchronousMessageBuilder) ExpectsToReceive(description string) *Uncon
 // func (m *Message) WithMetadata(metadata MapMatcher) *Message {
 func (m *UnconfiguredAsynchronousMessageBuilder) WithMetadata(metadata map[string]string) *UnconfiguredAsynchronousMessageBuilder {
 	m.rootBuilder.messageHandle.WithMetadata(metadata)
+	for k, v := range metadata {
+		m.rootBuilder.setMetadata(k, v)
+	}
+
+	return m
+}
+
+// WithMetadataMatchers specifies message metadata that is matched by the given matchers,
+// rather than by equality, e.g.
+//
+//	WithMetadataMatchers(matchers.MapMatcher{
+//		"contentType": matchers.Regex("application/json", "application/json.*"),
+//		"routingKey":  matchers.Like("some-partition-key"),
+//	})
+//
+// The message given to the handler carries the example values of the matchers.
+func (m *UnconfiguredAsynchronousMessageBuilder) WithMetadataMatchers(metadata matchers.MapMatcher) *UnconfiguredAsynchronousMessageBuilder {
+	for k, v := range metadata {
+		m.rootBuilder.messageHandle.WithMetadataMatcher(k, v)
+
+		example, err := matchers.Example(v)
+		if err != nil && m.rootBuilder.err == nil {
+			m.rootBuilder.err = fmt.Errorf("invalid matcher for metadata '%s': %v", k, err)
+		}
+		m.rootBuilder.setMetadata(k, example)
+	}
 
 	return m
 }
 
+func (m *AsynchronousMessageBuilder) setMetadata(key string, value interface{}) {
+	if m.metadata == nil {
+		m.metadata = make(map[string]interface{})
+	}
+	m.metadata[key] = value
+}
+
 type AsynchronousMessageBuilderWithContents struct {
 	rootBuilder *AsynchronousMessageBuilder
 }
 
-// WithBinaryContent accepts a binary payload
+// WithBinaryContent accepts a binary payload. The handler receives the bytes as the
+// message Content, unless a decoder is registered for the content type, and from Binary
 func (m *UnconfiguredAsynchronousMessageBuilder) WithBinaryContent(contentType string, body []byte) *AsynchronousMessageBuilderWithContents {
-	m.rootBuilder.messageHandle.WithContents(mockserver.INTERACTION_PART_REQUEST, contentType, body)
+	m.rootBuilder.messageHandle.WithRequestBinaryContentType(contentType, body)
+	m.rootBuilder.contentType = contentType
 
 	return &AsynchronousMessageBuilderWithContents{
 		rootBuilder: m.rootBuilder,
@@ -86,6 +149,7 @@ func (m *UnconfiguredAsynchronousMessageBuilder) WithBinaryContent(contentType s
 // WithContent specifies the payload in bytes that the consumer expects to receive
 func (m *UnconfiguredAsynchronousMessageBuilder) WithContent(contentType string, body []byte) *AsynchronousMessageBuilderWithContents {
 	m.rootBuilder.messageHandle.WithContents(mockserver.INTERACTION_PART_REQUEST, contentType, body)
+	m.rootBuilder.contentType = contentType
 
 	return &AsynchronousMessageBuilderWithContents{
 		rootBuilder: m.rootBuilder,
@@ -95,17 +159,67 @@ func (m *UnconfiguredAsynchronousMessageBuilder) WithContent(contentType string,
 // WithJSONContent specifies the payload as an object (to be marshalled to WithJSONContent) that
 // is expected to be consumed
 func (m *UnconfiguredAsynchronousMessageBuilder) WithJSONContent(content interface{}) *AsynchronousMessageBuilderWithContents {
+	content, err := prepareJSONContent(content)
+	if err != nil && m.rootBuilder.err == nil {
+		m.rootBuilder.err = fmt.Errorf("invalid message content: %v", err)
+	}
 	m.rootBuilder.messageHandle.WithRequestJSONContents(content)
+	m.rootBuilder.contentType = "application/json"
 
 	return &AsynchronousMessageBuilderWithContents{
 		rootBuilder: m.rootBuilder,
 	}
 }
 
+// WithPluginContents specifies the payload as the configuration of a plugin loaded with
+// UsingPlugin, e.g. for a protobuf message
+//
+//	WithPluginContents("application/protobuf", map[string]interface{}{
+//		"pact:proto":        path,
+//		"pact:message-type": "Feature",
+//		"pact:content-type": "application/protobuf",
+//		"name":              "notEmpty('Big Tree')",
+//	})
+//
+// The contents may also be given as a JSON string. The plugin generates the example
+// message and its matching rules. The handler receives the message decoded by any decoder
+// for the content type in Config.Codecs, into a proto.Message given to AsType, or as raw bytes.
+func (m *UnconfiguredAsynchronousMessageBuilder) WithPluginContents(contentType string, contents interface{}) *AsynchronousMessageBuilderWithContents {
+	config, err := pluginContentsConfig(contents)
+	if err != nil && m.rootBuilder.err == nil {
+		m.rootBuilder.err = fmt.Errorf("invalid plugin contents: %v", err)
+	}
+
+	if len(m.rootBuilder.messagePactV3.plugins) == 0 && m.rootBuilder.err == nil {
+		m.rootBuilder.err = fmt.Errorf("no plugin is loaded to build contents of type '%s', see UsingPlugin", contentType)
+	}
+	if m.rootBuilder.err == nil {
+		if err := m.rootBuilder.messageHandle.WithPluginInteractionContents(mockserver.INTERACTION_PART_REQUEST, contentType, config); err != nil {
+			m.rootBuilder.err = fmt.Errorf("unable to set plugin contents: %v", err)
+		}
+	}
+	m.rootBuilder.contentType = contentType
+	m.rootBuilder.pluginContents = true
+
+	return &AsynchronousMessageBuilderWithContents{
+		rootBuilder: m.rootBuilder,
+	}
+}
+
+// pluginContentsConfig returns the plugin configuration as the JSON given to the plugin
+func pluginContentsConfig(contents interface{}) (string, error) {
+	if config, ok := contents.(string); ok {
+		return config, nil
+	}
+	b, err := json.Marshal(contents)
+
+	return string(b), err
+}
+
 // // AsType specifies that the content sent through to the
 // consumer handler should be sent as the given type
 func (m *AsynchronousMessageBuilderWithContents) AsType(t interface{}) *AsynchronousMessageBuilderWithContents {
-	log.Println("[DEBUG] setting Message decoding to type:", reflect.TypeOf(t))
+	m.rootBuilder.messagePactV3.logf("DEBUG", "setting Message decoding to type: %v", reflect.TypeOf(t))
 	m.rootBuilder.Type = t
 
 	return m
@@ -134,6 +248,12 @@ type AsynchronousPact struct {
 
 	// Reference to the native rust handle
 	messageserver *mockserver.MessageServer
+
+	// The plugins loaded with UsingPlugin
+	plugins []PluginConfig
+
+	// written is set once the pact file has been written, see Config.PactFileWriteMode
+	written bool
 }
 
 // Deprecated: use NewAsynchronousPact
@@ -154,16 +274,67 @@ func NewAsynchronousPact(config Config) (*AsynchronousPact, error) {
 	return provider, err
 }
 
+// Close releases the native resources held by the pact, after running the AfterSuite hook.
+// Other pacts in the same process are unaffected. The pact must not be used once closed
+func (p *AsynchronousPact) Close() error {
+	defer native.Shutdown()
+
+	var hookErr error
+	if p.config.AfterSuite != nil {
+		if hookErr = p.config.AfterSuite(); hookErr != nil {
+			hookErr = fmt.Errorf("the AfterSuite hook failed: %v", hookErr)
+		}
+	}
+
+	if len(p.plugins) > 0 {
+		p.messageserver.CleanupPlugins()
+	}
+
+	if err := p.messageserver.Close(); err != nil {
+		return err
+	}
+
+	return hookErr
+}
+
 // validateConfig validates the configuration for the consumer test
 func (p *AsynchronousPact) validateConfig() error {
-	log.Println("[DEBUG] pact message validate config")
+	if p.config.LogLevel != "" {
+		logger, err := logging.WithLevel(p.config.Logger, p.config.LogLevel)
+		if err != nil {
+			return err
+		}
+		p.config.Logger = logger
+	}
+	p.logf("DEBUG", "pact message validate config")
 	dir, _ := os.Getwd()
 
 	if p.config.PactDir == "" {
 		p.config.PactDir = filepath.Join(dir, "pacts")
 	}
 
+	switch p.config.PactFileWriteMode {
+	case "", PactFileWriteModeMerge, PactFileWriteModeOverwrite:
+	default:
+		return fmt.Errorf("unsupported pact file write mode '%s', must be one of '%s' or '%s'", p.config.PactFileWriteMode, PactFileWriteModeMerge, PactFileWriteModeOverwrite)
+	}
+
 	p.messageserver = mockserver.NewMessageServer(p.config.Consumer, p.config.Provider)
+	if p.config.Environment != "" {
+		p.messageserver.WithMetadata(models.MetadataNamespace, models.EnvironmentMetadataKey, p.config.Environment)
+	}
+
+	return nil
+}
+
+// UsingPlugin loads a plugin, such as "protobuf", for the messages of the pact to use
+// with WithPluginContents. Plugins are shut down when the pact is closed.
+func (p *AsynchronousPact) UsingPlugin(config PluginConfig) error {
+	p.logf("DEBUG", "using plugin %s %s", config.Plugin, config.Version)
+	if err := p.messageserver.UsingPlugin(config.Plugin, config.Version); err != nil {
+		return fmt.Errorf("unable to load plugin %s %s: %v", config.Plugin, config.Version, err)
+	}
+	p.plugins = append(p.plugins, config)
 
 	return nil
 }
@@ -176,7 +347,7 @@ func (p *AsynchronousPact) AddMessage() *AsynchronousMessageBuilder {
 
 // AddMessage creates a new asynchronous consumer expectation
 func (p *AsynchronousPact) AddAsynchronousMessage() *AsynchronousMessageBuilder {
-	log.Println("[DEBUG] add message")
+	p.logf("DEBUG", "add message")
 
 	message := p.messageserver.NewMessage()
 
@@ -196,19 +367,26 @@ func (p *AsynchronousPact) AddAsynchronousMessage() *AsynchronousMessageBuilder
 // It is the receiver of an interaction, and needs to be able to handle whatever
 // request was provided.
 func (p *AsynchronousPact) verifyMessageConsumerRaw(messageToVerify *AsynchronousMessageBuilder, handler AsynchronousConsumer) error {
-	log.Printf("[DEBUG] verify message")
+	p.logf("DEBUG", "verify message")
+
+	if messageToVerify.err != nil {
+		return messageToVerify.err
+	}
+	if err := messageToVerify.messageHandle.Err(); err != nil {
+		return fmt.Errorf("unable to build the message: %v", err)
+	}
 
 	// 1. Strip out the matchers
 	// Reify the message back to its "example/generated" form
 	body, err := messageToVerify.messageHandle.GetMessageRequestContents()
-	log.Println("[DEBUG] reified message raw", body)
+	p.logf("DEBUG", "reified message raw %v", body)
 	if err != nil {
 		return fmt.Errorf("unexpected response from message server, this is a bug in the framework")
 	}
 
-	log.Println("[DEBUG] reified message raw", body)
+	p.logf("DEBUG", "reified message raw %v", body)
 
-	var m MessageContents
+	m := MessageContents{contents: body}
 	// err = json.Unmarshal(body, &m)
 	// if err != nil {
 	// 	return fmt.Errorf("unexpected response from message server, this is a bug in the framework")
@@ -218,32 +396,72 @@ func (p *AsynchronousPact) verifyMessageConsumerRaw(messageToVerify *Asynchronou
 	// 2. Convert to an actual type (to avoid wrapping if needed/requested)
 	// 3. Invoke the message handler
 	// 4. write the pact file
-	t := reflect.TypeOf(messageToVerify.Type)
-	if t != nil && t.Name() != "interface" {
-		// s, err := json.Marshal()
-		// if err != nil {
-		// 	return fmt.Errorf("unable to generate message for type: %+v", messageToVerify.Type)
-		// }
-		err = json.Unmarshal(body, &messageToVerify.Type)
+	if m.Content, err = p.decodeContents(messageToVerify, body); err != nil {
+		return err
+	}
+
+	if len(messageToVerify.metadata) > 0 {
+		m.Metadata = Metadata(messageToVerify.metadata)
+	}
+
+	// Yield message, and send through handler function
+	err = p.config.withHooks(messageToVerify.description, func() error { return handler(m) })
+
+	if err != nil {
+		return err
+	}
 
+	return p.WritePactFile()
+}
+
+// decodeContents converts the reified contents of the message for the handler: decoded by a
+// codec for the content type, unmarshalled into the type given to AsType, or as raw bytes
+// if the contents aren't JSON, e.g. plugin contents
+func (p *AsynchronousPact) decodeContents(messageToVerify *AsynchronousMessageBuilder, body []byte) (Body, error) {
+	t := reflect.TypeOf(messageToVerify.Type)
+	if decode, ok := codecs.Lookup(p.config.Codecs, messageToVerify.contentType); ok {
+		content, err := decode(body)
 		if err != nil {
-			return fmt.Errorf("unable to narrow type to %v: %v", t.Name(), err)
+			return nil, fmt.Errorf("unable to decode message contents: %v", err)
 		}
 
-		m.Content = messageToVerify.Type
-	}
+		return content, nil
+	} else if msg, ok := messageToVerify.Type.(proto.Message); ok {
+		decoded := msg.ProtoReflect().New().Interface()
+		if err := proto.Unmarshal(body, decoded); err != nil {
+			return nil, fmt.Errorf("unable to decode message contents as %v: %v", t, err)
+		}
 
-	// TODO: extract metadata from FFI
-	// m.Metadata =
+		return decoded, nil
+	} else if (messageToVerify.pluginContents || !isJSON(messageToVerify.contentType)) && (t == nil || t.Name() == "interface") {
+		return body, nil
+	} else if t != nil && t.Name() != "interface" {
+		if err := json.Unmarshal(body, &messageToVerify.Type); err != nil {
+			return nil, fmt.Errorf("unable to narrow type to %v: %v", t.Name(), err)
+		}
 
-	// Yield message, and send through handler function
-	err = handler(m)
+		return messageToVerify.Type, nil
+	}
 
-	if err != nil {
+	return nil, nil
+}
+
+// WritePactFile writes the pact file with the messages verified so far to Config.PactDir.
+// Verify writes it after each message, so it is only needed to write a pact whose messages
+// were verified by other means.
+func (p *AsynchronousPact) WritePactFile() error {
+	overwrite := p.config.PactFileWriteMode == PactFileWriteModeOverwrite && !p.written
+	if err := p.messageserver.WritePactFile(p.config.PactDir, overwrite); err != nil {
 		return err
 	}
+	p.written = true
+
+	return nil
+}
 
-	return p.messageserver.WritePactFile(p.config.PactDir, false)
+// logf logs through Config.Logger, see logging.Logf
+func (p *AsynchronousPact) logf(level, format string, args ...interface{}) {
+	logging.Logf(p.config.Logger, level, format, args...)
 }
 
 // VerifyMessageConsumer is a test convience function for VerifyMessageConsumerRaw,
@@ -0,0 +1,99 @@
+package v3
+
+import (
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/codecs"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestPluginContentsConfig(t *testing.T) {
+	config, err := pluginContentsConfig(`{"pact:proto": "plugin.proto", "pact:message-type": "Feature"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"pact:proto": "plugin.proto", "pact:message-type": "Feature"}`, config)
+
+	config, err = pluginContentsConfig(map[string]interface{}{
+		"pact:proto":        "plugin.proto",
+		"pact:message-type": "Feature",
+	})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"pact:proto": "plugin.proto", "pact:message-type": "Feature"}`, config)
+
+	_, err = pluginContentsConfig(map[string]interface{}{"invalid": func() {}})
+	assert.Error(t, err)
+}
+
+func TestAsyncWithPluginContentsWithoutPlugin(t *testing.T) {
+	p, err := NewAsynchronousPact(Config{
+		Consumer: "pluginconsumer",
+		Provider: "pluginprovider",
+		PactDir:  t.TempDir(),
+	})
+	assert.NoError(t, err)
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a protobuf message").
+		WithPluginContents("application/protobuf", map[string]interface{}{"pact:proto": "plugin.proto"})
+
+	assert.EqualError(t, message.rootBuilder.err, "no plugin is loaded to build contents of type 'application/protobuf', see UsingPlugin")
+	assert.EqualError(t, p.verifyMessageConsumerRaw(message.rootBuilder, func(m MessageContents) error { return nil }), "no plugin is loaded to build contents of type 'application/protobuf', see UsingPlugin")
+	assert.NoError(t, p.Close())
+}
+
+func TestAsyncUsingUnknownPlugin(t *testing.T) {
+	p, err := NewAsynchronousPact(Config{
+		Consumer: "pluginconsumer",
+		Provider: "pluginprovider",
+		PactDir:  t.TempDir(),
+	})
+	assert.NoError(t, err)
+
+	err = p.UsingPlugin(PluginConfig{Plugin: "not-a-plugin", Version: "0.0.1"})
+	assert.ErrorContains(t, err, "unable to load plugin not-a-plugin 0.0.1")
+	assert.Empty(t, p.plugins)
+	assert.NoError(t, p.Close())
+}
+
+func TestAsyncDecodePluginContents(t *testing.T) {
+	body, _ := proto.Marshal(wrapperspb.String("Big Tree"))
+
+	t.Run("raw bytes", func(t *testing.T) {
+		p := &AsynchronousPact{}
+		message := &AsynchronousMessageBuilder{contentType: "application/protobuf", pluginContents: true}
+
+		content, err := p.decodeContents(message, body)
+		assert.NoError(t, err)
+		assert.Equal(t, body, content)
+	})
+
+	t.Run("raw bytes of a JSON content type", func(t *testing.T) {
+		p := &AsynchronousPact{}
+		message := &AsynchronousMessageBuilder{contentType: "application/json", pluginContents: true}
+
+		content, err := p.decodeContents(message, []byte(`{"name":"Big Tree"}`))
+		assert.NoError(t, err)
+		assert.Equal(t, []byte(`{"name":"Big Tree"}`), content)
+	})
+
+	t.Run("as a proto message", func(t *testing.T) {
+		p := &AsynchronousPact{}
+		message := &AsynchronousMessageBuilder{contentType: "application/protobuf", pluginContents: true, Type: &wrapperspb.StringValue{}}
+
+		content, err := p.decodeContents(message, body)
+		assert.NoError(t, err)
+		assert.Equal(t, "Big Tree", content.(*wrapperspb.StringValue).GetValue())
+	})
+
+	t.Run("with a codec", func(t *testing.T) {
+		p := &AsynchronousPact{config: Config{Codecs: codecs.NewRegistry().Register("application/protobuf", func(b []byte) (interface{}, error) {
+			return len(b), nil
+		})}}
+		message := &AsynchronousMessageBuilder{contentType: "application/protobuf", pluginContents: true}
+
+		content, err := p.decodeContents(message, body)
+		assert.NoError(t, err)
+		assert.Equal(t, len(body), content)
+	})
+}
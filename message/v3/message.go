@@ -1,5 +1,17 @@
 package v3
 
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"strings"
+
+	"github.com/hashicorp/logutils"
+	"github.com/pact-foundation/pact-go/v2/codecs"
+	logging "github.com/pact-foundation/pact-go/v2/log"
+	"github.com/pact-foundation/pact-go/v2/matchers"
+)
+
 type Body interface{}
 type Metadata map[string]interface{}
 
@@ -14,10 +26,113 @@ type MessageContents struct {
 
 	// Message metadata
 	Metadata Metadata `json:"metadata"`
+
+	// The reified contents, exactly as given to the message builder
+	contents []byte
+}
+
+// Binary returns the reified contents of the message byte for byte, e.g. the bytes given
+// to WithBinaryContent
+func (m MessageContents) Binary() ([]byte, error) {
+	if m.contents == nil {
+		return nil, errors.New("the message has no contents")
+	}
+
+	return m.contents, nil
 }
 
+// isJSON reports whether the content type is JSON, including
+// structured syntax suffixes such as application/vnd.user+json
+func isJSON(contentType string) bool {
+	t, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t = strings.ToLower(strings.TrimSpace(contentType))
+	}
+
+	return t == "application/json" || strings.HasSuffix(t, "+json")
+}
+
+// Pact file write modes, see Config.PactFileWriteMode
+const (
+	// PactFileWriteModeMerge adds the interactions to any existing pact file (the default)
+	PactFileWriteModeMerge = "merge"
+
+	// PactFileWriteModeOverwrite replaces any existing pact file on the first write of
+	// the pact, so that interactions removed from the tests don't linger
+	PactFileWriteModeOverwrite = "overwrite"
+)
+
 type Config struct {
 	Consumer string
 	Provider string
 	PactDir  string
+
+	// Environment the pact is generated in (e.g. "staging"), recorded in the pact file metadata.
+	// Optional
+	Environment string
+
+	// Codecs decode message content by content type before it is given to the consumer
+	// handler, e.g. for protobuf or MessagePack messages. Content types without a
+	// registered decoder, here or with message.RegisterDecoder, are unmarshalled from
+	// JSON. Optional
+	Codecs *codecs.Registry
+
+	// Logger receives the log output of the pact, e.g. to silence DEBUG messages or
+	// redirect them to the test log. Defaults to the standard logger. Optional
+	Logger logging.Logger
+
+	// PactFileWriteMode is either PactFileWriteModeMerge (the default), adding to any existing
+	// pact file, or PactFileWriteModeOverwrite, replacing it on the first write of the pact
+	PactFileWriteMode string
+
+	// LogLevel is the minimum level of the messages the pact logs, e.g. "WARN" to
+	// silence a noisy test. Defaults to the framework log level (see log.SetLogLevel). Optional
+	LogLevel logutils.LogLevel
+
+	// BeforeEach is run before the handler of each message verified, given its description,
+	// e.g. to clear a queue or reset a database. Verification fails if it errors. Optional
+	BeforeEach func(description string) error
+
+	// AfterEach is run after the handler of each message verified, given its description and
+	// the error of the handler, e.g. to record metrics. Verification fails if it errors. Optional
+	AfterEach func(description string, err error) error
+
+	// AfterSuite is run when the pact is closed. Optional
+	AfterSuite func() error
+}
+
+// withHooks runs the handler of a message between the BeforeEach and AfterEach hooks
+func (c Config) withHooks(description string, handler func() error) error {
+	if c.BeforeEach != nil {
+		if err := c.BeforeEach(description); err != nil {
+			return fmt.Errorf("the BeforeEach hook failed: %v", err)
+		}
+	}
+
+	err := handler()
+
+	if c.AfterEach != nil {
+		if hookErr := c.AfterEach(description, err); hookErr != nil && err == nil {
+			err = fmt.Errorf("the AfterEach hook failed: %v", hookErr)
+		}
+	}
+
+	return err
+}
+
+// PluginConfig names a plugin, and the version of it, to load through the native layer
+type PluginConfig struct {
+	Plugin  string
+	Version string
+}
+
+// prepareJSONContent computes any derived examples in the content and checks its
+// matchers are valid, returning the content to send to the native core
+func prepareJSONContent(content interface{}) (interface{}, error) {
+	resolved, err := matchers.ResolveDerived(content)
+	if err != nil {
+		return content, err
+	}
+
+	return resolved, matchers.Validate(resolved)
 }
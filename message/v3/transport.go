@@ -0,0 +1,77 @@
+package v3
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+
+	"github.com/pact-foundation/pact-go/v2/message"
+	"github.com/pact-foundation/pact-go/v2/models"
+)
+
+// Transport receives the messages a provider actually sent from where it sends them, e.g.
+// a Kafka topic, an SQS queue or a file, for MessageVerifier to verify. Adapters decouple
+// verification from the broker, e.g. a MemoryTransport in unit tests and the real broker
+// in integration tests.
+type Transport interface {
+	// Receive returns the messages sent by the provider for the interaction
+	Receive(ctx context.Context, spec TransportSpec) ([]MessageContents, error)
+}
+
+// TransportSpec describes the interaction a Transport receives messages for
+type TransportSpec struct {
+	// Description of the interaction
+	Description string
+
+	// States the provider has been set up in for the interaction
+	States []models.ProviderState
+
+	// Metadata the consumer expects of the message in the pact, e.g. the topic or queue
+	Metadata map[string]interface{}
+}
+
+// MemoryTransport is a Transport for messages published in memory, e.g. by a test double
+// of the provider. The zero value is ready to use.
+type MemoryTransport struct {
+	mu       sync.Mutex
+	messages map[string][]MessageContents
+}
+
+// Publish sends a message for the interaction with the description
+func (t *MemoryTransport) Publish(description string, m MessageContents) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.messages == nil {
+		t.messages = make(map[string][]MessageContents)
+	}
+	t.messages[description] = append(t.messages[description], m)
+}
+
+// Receive returns the messages published for the interaction
+func (t *MemoryTransport) Receive(ctx context.Context, spec TransportSpec) ([]MessageContents, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return append([]MessageContents(nil), t.messages[spec.Description]...), nil
+}
+
+// transportHandler adapts a Transport to a handler of the native verifier for an interaction
+func transportHandler(ctx context.Context, transport Transport, description string, metadata map[string]interface{}) message.Handler {
+	return func(states []models.ProviderState) (message.Body, message.Metadata, error) {
+		log.Printf("[DEBUG] receiving message '%s' for states %v", description, states)
+		received, err := transport.Receive(ctx, TransportSpec{Description: description, States: states, Metadata: metadata})
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to receive message '%s': %v", description, err)
+		}
+		if len(received) == 0 {
+			return nil, nil, fmt.Errorf("no message was received for '%s'", description)
+		}
+		if len(received) > 1 {
+			return nil, nil, fmt.Errorf("%d messages were received for '%s', the provider should send exactly one", len(received), description)
+		}
+
+		return received[0].Content, message.Metadata(received[0].Metadata), nil
+	}
+}
@@ -0,0 +1,68 @@
+package v3
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/message"
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMemoryTransport(t *testing.T) {
+	var transport MemoryTransport
+
+	transport.Publish("a user event", MessageContents{Content: map[string]interface{}{"id": 1}})
+	transport.Publish("an order event", MessageContents{Content: map[string]interface{}{"id": 2}})
+
+	received, err := transport.Receive(context.Background(), TransportSpec{Description: "a user event"})
+	assert.NoError(t, err)
+	assert.Equal(t, []MessageContents{{Content: map[string]interface{}{"id": 1}}}, received)
+
+	received, err = transport.Receive(context.Background(), TransportSpec{Description: "an unpublished event"})
+	assert.NoError(t, err)
+	assert.Empty(t, received)
+}
+
+type failingTransport struct{}
+
+func (failingTransport) Receive(ctx context.Context, spec TransportSpec) ([]MessageContents, error) {
+	return nil, errors.New("connection refused")
+}
+
+func TestTransportHandler(t *testing.T) {
+	states := []models.ProviderState{{Name: "a user exists"}}
+
+	t.Run("one message", func(t *testing.T) {
+		var transport MemoryTransport
+		transport.Publish("a user event", MessageContents{
+			Content:  map[string]interface{}{"id": 1},
+			Metadata: Metadata{"topic": "users"},
+		})
+
+		body, metadata, err := transportHandler(context.Background(), &transport, "a user event", nil)(states)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"id": 1}, body)
+		assert.Equal(t, message.Metadata{"topic": "users"}, metadata)
+	})
+
+	t.Run("no message", func(t *testing.T) {
+		_, _, err := transportHandler(context.Background(), &MemoryTransport{}, "a user event", nil)(states)
+		assert.EqualError(t, err, "no message was received for 'a user event'")
+	})
+
+	t.Run("several messages", func(t *testing.T) {
+		var transport MemoryTransport
+		transport.Publish("a user event", MessageContents{Content: map[string]interface{}{"id": 1}})
+		transport.Publish("a user event", MessageContents{Content: map[string]interface{}{"id": 2}})
+
+		_, _, err := transportHandler(context.Background(), &transport, "a user event", nil)(states)
+		assert.EqualError(t, err, "2 messages were received for 'a user event', the provider should send exactly one")
+	})
+
+	t.Run("receive error", func(t *testing.T) {
+		_, _, err := transportHandler(context.Background(), failingTransport{}, "a user event", nil)(states)
+		assert.EqualError(t, err, "unable to receive message 'a user event': connection refused")
+	})
+}
@@ -0,0 +1,173 @@
+package v3
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/pact-foundation/pact-go/v2/message"
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+	"github.com/pact-foundation/pact-go/v2/provider"
+)
+
+// MessageProducer produces the message a provider sends for an interaction, given its
+// provider states. It returns the message content, or a MessageContents to also
+// give the message metadata.
+type MessageProducer func(states []models.ProviderState) (interface{}, error)
+
+// MessageVerifier verifies that a provider produces the messages expected by the
+// consumer written pacts for it. Each interaction is reified and compared with the
+// message of its producer against the pact's matching rules by the native verifier.
+type MessageVerifier struct {
+	// Provider is the name of the provider
+	Provider string
+
+	// PactFiles are local paths to the pact files to verify
+	PactFiles []string
+
+	// PactURLs are the URLs of pacts to verify, e.g. the URL of a pact in a Pact Broker
+	PactURLs []string
+
+	// BrokerURL of a Pact Broker to fetch the pacts for the provider from
+	BrokerURL string
+
+	// Credentials for the Pact Broker, either a token or a username and password.
+	// They may also be given by the PACT_BROKER_TOKEN, PACT_BROKER_USERNAME and
+	// PACT_BROKER_PASSWORD environment variables
+	BrokerToken    string
+	BrokerUsername string
+	BrokerPassword string
+
+	// ConsumerVersionSelectors select the pacts to fetch from the broker, e.g. those of
+	// the main branch and deployed or released versions. See https://docs.pact.io/selectors
+	ConsumerVersionSelectors []provider.Selector
+
+	// ProviderBranch is the branch of the provider version, used by the matchingBranch
+	// selector and to find pending and WIP pacts
+	ProviderBranch string
+
+	// PublishVerificationResults publishes the results of the verification to the broker,
+	// as those of ProviderVersion, so that can-i-deploy can use them
+	PublishVerificationResults bool
+
+	// ProviderVersion is the version of the provider, e.g. a git sha. Required to
+	// fetch pacts from a broker or publish the verification results
+	ProviderVersion string
+
+	// ProviderTags are applied to the provider version when the results are published
+	ProviderTags []string
+
+	// BuildURL links the published results to the build that verified them
+	BuildURL string
+
+	// EnablePending verifies pending pacts without failing the test (see pact.io/pending)
+	EnablePending bool
+
+	// IncludeWIPPactsSince also verifies work in progress pacts created since the time,
+	// as pending pacts (see pact.io/wip)
+	IncludeWIPPactsSince *time.Time
+
+	// Producers produce the message for each interaction, by description
+	Producers map[string]MessageProducer
+
+	// Transport receives the messages of the interactions in PactFiles without a producer
+	// from where the provider sends them. It can only be used with PactFiles, as the
+	// interactions of PactURLs and BrokerURL aren't known until they are verified. Optional
+	Transport Transport
+
+	// StateHandlers set up the provider states given to interactions, by state name.
+	// Each handler is called with setup true before the message is produced, and with
+	// setup false to tear the state down after the interaction is verified
+	StateHandlers models.StateHandlers
+}
+
+// Verify runs the verification, failing the test if any message doesn't satisfy its pact
+func (v *MessageVerifier) Verify(t *testing.T) error {
+	return v.VerifyContext(context.Background(), t)
+}
+
+// VerifyContext is Verify with the context given to the Transport
+func (v *MessageVerifier) VerifyContext(ctx context.Context, t *testing.T) error {
+	request, err := v.request(ctx)
+	if err != nil {
+		t.Error(err)
+		return err
+	}
+
+	return provider.NewVerifier().VerifyProvider(t, request)
+}
+
+// request converts the verifier to a provider verification of its messages
+func (v *MessageVerifier) request(ctx context.Context) (provider.VerifyRequest, error) {
+	if len(v.PactFiles) == 0 && len(v.PactURLs) == 0 && v.BrokerURL == "" {
+		return provider.VerifyRequest{}, fmt.Errorf("no pacts to verify, one of PactFiles, PactURLs or BrokerURL must be given")
+	}
+
+	handlers := make(message.Handlers, len(v.Producers))
+	for description, producer := range v.Producers {
+		handlers[description] = messageHandler(description, producer)
+	}
+
+	if v.Transport != nil {
+		if len(v.PactURLs) > 0 || v.BrokerURL != "" {
+			return provider.VerifyRequest{}, fmt.Errorf("a Transport can only receive the messages of PactFiles, give a producer for the interactions of PactURLs and BrokerURL")
+		}
+		for _, file := range v.PactFiles {
+			p, err := pactfile.Read(file)
+			if err != nil {
+				return provider.VerifyRequest{}, err
+			}
+			for _, i := range p.AllInteractions() {
+				if _, ok := handlers[i.Description]; ok {
+					continue
+				}
+				if _, ok := i.Raw["contents"]; !ok {
+					continue
+				}
+				metadata, _ := i.Raw["metadata"].(map[string]interface{})
+				handlers[i.Description] = transportHandler(ctx, v.Transport, i.Description, metadata)
+			}
+		}
+	}
+
+	return provider.VerifyRequest{
+		Provider:        v.Provider,
+		PactFiles:       v.PactFiles,
+		PactURLs:        v.PactURLs,
+		BrokerURL:       v.BrokerURL,
+		BrokerToken:     v.BrokerToken,
+		BrokerUsername:  v.BrokerUsername,
+		BrokerPassword:  v.BrokerPassword,
+		MessageHandlers: handlers,
+		StateHandlers:   v.StateHandlers,
+
+		ConsumerVersionSelectors:   v.ConsumerVersionSelectors,
+		ProviderBranch:             v.ProviderBranch,
+		PublishVerificationResults: v.PublishVerificationResults,
+		ProviderVersion:            v.ProviderVersion,
+		ProviderTags:               v.ProviderTags,
+		BuildURL:                   v.BuildURL,
+		EnablePending:              v.EnablePending,
+		IncludeWIPPactsSince:       v.IncludeWIPPactsSince,
+	}, nil
+}
+
+// messageHandler adapts a MessageProducer to a handler of the native verifier
+func messageHandler(description string, producer MessageProducer) message.Handler {
+	return func(states []models.ProviderState) (message.Body, message.Metadata, error) {
+		log.Printf("[DEBUG] producing message '%s' for states %v", description, states)
+		produced, err := producer(states)
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to produce message '%s': %v", description, err)
+		}
+
+		if m, ok := produced.(MessageContents); ok {
+			return m.Content, message.Metadata(m.Metadata), nil
+		}
+
+		return produced, nil, nil
+	}
+}
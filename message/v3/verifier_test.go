@@ -0,0 +1,264 @@
+package v3
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pact-foundation/pact-go/v2/message"
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/pact-foundation/pact-go/v2/provider"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageVerifierRequest(t *testing.T) {
+	states := []models.ProviderState{{Name: "a user exists", Parameters: map[string]interface{}{"id": 1}}}
+
+	tests := []struct {
+		name     string
+		producer MessageProducer
+		body     message.Body
+		metadata message.Metadata
+		err      string
+	}{
+		{
+			name: "content",
+			producer: func(states []models.ProviderState) (interface{}, error) {
+				return map[string]interface{}{"id": 1}, nil
+			},
+			body: map[string]interface{}{"id": 1},
+		},
+		{
+			name: "content and metadata",
+			producer: func(states []models.ProviderState) (interface{}, error) {
+				return MessageContents{
+					Content:  map[string]interface{}{"id": 1},
+					Metadata: Metadata{"topic": "users"},
+				}, nil
+			},
+			body:     map[string]interface{}{"id": 1},
+			metadata: message.Metadata{"topic": "users"},
+		},
+		{
+			name: "provider states",
+			producer: func(s []models.ProviderState) (interface{}, error) {
+				assert.Equal(t, states, s)
+				return "ok", nil
+			},
+			body: "ok",
+		},
+		{
+			name: "producer error",
+			producer: func(states []models.ProviderState) (interface{}, error) {
+				return nil, errors.New("broker unavailable")
+			},
+			err: "unable to produce message 'a user event': broker unavailable",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := &MessageVerifier{
+				Provider:  "userprovider",
+				PactFiles: []string{"user-pact.json"},
+				Producers: map[string]MessageProducer{"a user event": tt.producer},
+			}
+
+			request, err := v.request(context.Background())
+			assert.NoError(t, err)
+			assert.Equal(t, "userprovider", request.Provider)
+			assert.Equal(t, []string{"user-pact.json"}, request.PactFiles)
+			assert.Len(t, request.MessageHandlers, 1)
+
+			body, metadata, err := request.MessageHandlers["a user event"](states)
+			if tt.err != "" {
+				assert.EqualError(t, err, tt.err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.body, body)
+			assert.Equal(t, tt.metadata, metadata)
+		})
+	}
+
+	t.Run("state handlers", func(t *testing.T) {
+		var calls []bool
+		v := &MessageVerifier{
+			PactFiles: []string{"user-pact.json"},
+			StateHandlers: models.StateHandlers{
+				"a user exists": func(setup bool, state models.ProviderState) (models.ProviderStateResponse, error) {
+					calls = append(calls, setup)
+					return models.ProviderStateResponse{"id": 1}, nil
+				},
+			},
+		}
+
+		request, err := v.request(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, request.StateHandlers, 1)
+
+		res, err := request.StateHandlers["a user exists"](true, states[0])
+		assert.NoError(t, err)
+		assert.Equal(t, models.ProviderStateResponse{"id": 1}, res)
+		assert.Equal(t, []bool{true}, calls)
+	})
+
+	t.Run("transport", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "pact.json")
+		pact := `{
+			"consumer": {"name": "userconsumer"},
+			"provider": {"name": "userprovider"},
+			"messages": [
+				{"description": "a user event", "contents": {"id": 1}},
+				{"description": "an order event", "contents": {"id": 2}, "metadata": {"topic": "orders"}}
+			],
+			"metadata": {"pactSpecification": {"version": "3.0.0"}}
+		}`
+		assert.NoError(t, os.WriteFile(file, []byte(pact), 0644))
+
+		var spec TransportSpec
+		transport := &MemoryTransport{}
+		transport.Publish("an order event", MessageContents{Content: map[string]interface{}{"id": 2}})
+		v := &MessageVerifier{
+			PactFiles: []string{file},
+			Producers: map[string]MessageProducer{"a user event": func(states []models.ProviderState) (interface{}, error) {
+				return "produced", nil
+			}},
+			Transport: recordingTransport{transport, &spec},
+		}
+
+		request, err := v.request(context.Background())
+		assert.NoError(t, err)
+		assert.Len(t, request.MessageHandlers, 2)
+
+		body, _, err := request.MessageHandlers["a user event"](nil)
+		assert.NoError(t, err)
+		assert.Equal(t, "produced", body)
+
+		body, _, err = request.MessageHandlers["an order event"](states)
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"id": 2}, body)
+		assert.Equal(t, TransportSpec{
+			Description: "an order event",
+			States:      states,
+			Metadata:    map[string]interface{}{"topic": "orders"},
+		}, spec)
+	})
+
+	t.Run("transport with pacts that aren't files", func(t *testing.T) {
+		for _, v := range []*MessageVerifier{
+			{PactURLs: []string{"http://broker/pacts/provider/userprovider/consumer/userconsumer/latest"}, Transport: &MemoryTransport{}},
+			{BrokerURL: "http://broker", Transport: &MemoryTransport{}},
+		} {
+			_, err := v.request(context.Background())
+			assert.EqualError(t, err, "a Transport can only receive the messages of PactFiles, give a producer for the interactions of PactURLs and BrokerURL")
+		}
+	})
+
+	t.Run("no pacts to verify", func(t *testing.T) {
+		v := &MessageVerifier{
+			Producers: map[string]MessageProducer{"a user event": func(states []models.ProviderState) (interface{}, error) {
+				return nil, nil
+			}},
+		}
+
+		_, err := v.request(context.Background())
+		assert.EqualError(t, err, "no pacts to verify, one of PactFiles, PactURLs or BrokerURL must be given")
+	})
+}
+
+func TestMessageVerifierBrokerOptions(t *testing.T) {
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	selectors := []provider.Selector{
+		&provider.ConsumerVersionSelector{MainBranch: true},
+		&provider.ConsumerVersionSelector{DeployedOrReleased: true},
+	}
+	v := &MessageVerifier{
+		Provider:                   "userprovider",
+		BrokerURL:                  "http://broker",
+		BrokerToken:                "token",
+		ConsumerVersionSelectors:   selectors,
+		ProviderBranch:             "main",
+		ProviderVersion:            "1.0.0",
+		ProviderTags:               []string{"prod"},
+		PublishVerificationResults: true,
+		BuildURL:                   "https://ci.example.com/builds/1",
+		EnablePending:              true,
+		IncludeWIPPactsSince:       &since,
+	}
+
+	request, err := v.request(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "http://broker", request.BrokerURL)
+	assert.Equal(t, "token", request.BrokerToken)
+	assert.Equal(t, selectors, request.ConsumerVersionSelectors)
+	assert.Equal(t, "main", request.ProviderBranch)
+	assert.Equal(t, "1.0.0", request.ProviderVersion)
+	assert.Equal(t, []string{"prod"}, request.ProviderTags)
+	assert.True(t, request.PublishVerificationResults)
+	assert.Equal(t, "https://ci.example.com/builds/1", request.BuildURL)
+	assert.True(t, request.EnablePending)
+	assert.Equal(t, &since, request.IncludeWIPPactsSince)
+}
+
+func TestMessageVerifierStateTeardown(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "pact.json")
+	pact := `{
+		"consumer": {"name": "teardownconsumer"},
+		"provider": {"name": "teardownprovider"},
+		"messages": [
+			{
+				"description": "a user event",
+				"providerStates": [{"name": "a user exists"}],
+				"contents": {"id": 1}
+			}
+		],
+		"metadata": {"pactSpecification": {"version": "3.0.0"}}
+	}`
+	assert.NoError(t, os.WriteFile(file, []byte(pact), 0644))
+
+	var mu sync.Mutex
+	var calls []string
+	record := func(call string) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, call)
+	}
+
+	v := &MessageVerifier{
+		Provider:  "teardownprovider",
+		PactFiles: []string{file},
+		Producers: map[string]MessageProducer{"a user event": func(states []models.ProviderState) (interface{}, error) {
+			record("produce")
+			return map[string]interface{}{"id": 1}, nil
+		}},
+		StateHandlers: models.StateHandlers{
+			"a user exists": func(setup bool, state models.ProviderState) (models.ProviderStateResponse, error) {
+				if setup {
+					record("setup")
+				} else {
+					record("teardown")
+				}
+				return nil, nil
+			},
+		},
+	}
+
+	assert.NoError(t, v.Verify(t))
+	assert.Equal(t, []string{"setup", "produce", "teardown"}, calls)
+}
+
+// recordingTransport records the spec of the last message received
+type recordingTransport struct {
+	Transport
+	spec *TransportSpec
+}
+
+func (r recordingTransport) Receive(ctx context.Context, spec TransportSpec) ([]MessageContents, error) {
+	*r.spec = spec
+	return r.Transport.Receive(ctx, spec)
+}
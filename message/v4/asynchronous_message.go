@@ -1,18 +1,37 @@
 package v4
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"flag"
 	"fmt"
+	"io/fs"
+	"io/ioutil"
 	"log"
+	"math/rand"
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/pact-foundation/pact-go/v2/codecs"
+	"github.com/pact-foundation/pact-go/v2/internal/jsonschema"
 	"github.com/pact-foundation/pact-go/v2/internal/native"
 	mockserver "github.com/pact-foundation/pact-go/v2/internal/native"
+	"github.com/pact-foundation/pact-go/v2/internal/schemaregistry"
 	logging "github.com/pact-foundation/pact-go/v2/log"
+	"github.com/pact-foundation/pact-go/v2/matchers"
 	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/pact-foundation/pact-go/v2/pactfile"
 )
 
 // Builder 1: Async with no plugin/transport
@@ -23,17 +42,64 @@ type AsynchronousMessageBuilder struct {
 	messageHandle *mockserver.Message
 	pact          *AsynchronousPact
 
+	// verifying is set while the message is being verified
+	verifying int32
+
 	// Type to Marshal content into when sending back to the consumer
 	// Defaults to interface{}
 	Type interface{}
 
-	// The handler for this message
-	handler AsynchronousConsumer
+	// The handler for this message, set by ConsumedBy or ConsumedByCtx
+	handler        AsynchronousConsumer
+	contextHandler AsynchronousConsumerCtx
+
+	// The content and metadata expectations, retained so they can be
+	// evaluated without a round trip through the native core
+	content  interface{}
+	metadata map[string]interface{}
+
+	// The interaction description, used to name exported examples
+	description string
+
+	// err is the first error encountered while building the message
+	err error
+
+	// The maximum number of heap allocations the handler may make, 0 for no limit
+	maxAllocs uint64
+
+	// The content type of the message, used to find a decoder in Config.Codecs or
+	// registered with message.RegisterDecoder
+	contentType string
+
+	// The provider states registered on the message, see AssertGiven
+	states []models.ProviderState
+
+	// Malformed payloads the handler must reject, see WithNegativeExample
+	negativeExamples [][]byte
+
+	// The schema registry subject the content must conform to, see WithSchemaSubject
+	schemaSubject string
+
+	// Metadata required only for some content, see RequireMetadataWhen
+	metadataConditions []MetadataCondition
+
+	// The most messages the consumer processes concurrently, see WithMaxInFlight
+	maxInFlight int
+
+	// Metadata that must mirror content fields, see LinkMetadataToContent
+	metadataLinks []MetadataLink
+
+	// The retirement of the message, see Deprecated
+	deprecation *Deprecation
+
+	// The metadata keys whose order is significant, see WithOrderedMetadata
+	metadataOrder []string
 }
 
 // Given specifies a provider state. Optional.
 func (m *AsynchronousMessageBuilder) Given(state string) *AsynchronousMessageBuilder {
 	m.messageHandle.Given(state)
+	m.states = append(m.states, models.ProviderState{Name: state})
 
 	return m
 }
@@ -41,15 +107,57 @@ func (m *AsynchronousMessageBuilder) Given(state string) *AsynchronousMessageBui
 // Given specifies a provider state. Optional.
 func (m *AsynchronousMessageBuilder) GivenWithParameter(state models.ProviderState) *AsynchronousMessageBuilder {
 	m.messageHandle.GivenWithParameter(state.Name, state.Parameters)
+	m.states = append(m.states, state)
+
+	return m
+}
+
+// GivenStates specifies several provider states the message depends on. Like calls to Given
+// and GivenWithParameter, the states are added to any already given, and states with the same
+// name and different parameters are each recorded. Optional.
+func (m *AsynchronousMessageBuilder) GivenStates(states ...models.ProviderState) *AsynchronousMessageBuilder {
+	for _, state := range states {
+		m.GivenWithParameter(state)
+	}
 
 	return m
 }
 
+// GivenWithSchema specifies a provider state, checking its parameters against a JSON Schema,
+// e.g. {"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"]}.
+// Parameters that don't satisfy the schema fail verification of the message, rather than
+// surfacing as a state handler error during provider verification.
+func (m *AsynchronousMessageBuilder) GivenWithSchema(state models.ProviderState, schema []byte) *AsynchronousMessageBuilder {
+	if err := jsonschema.Validate(schema, state.Parameters); err != nil && m.err == nil {
+		m.err = fmt.Errorf("invalid parameters for provider state '%s': %v", state.Name, err)
+	}
+
+	return m.GivenWithParameter(state)
+}
+
+// AssertGiven checks that a provider state with the given name was registered on
+// the message, e.g. by a shared helper that builds the interaction
+func (m *AsynchronousMessageBuilder) AssertGiven(t *testing.T, name string) bool {
+	t.Helper()
+
+	names := make([]string, len(m.states))
+	for i, s := range m.states {
+		if s.Name == name {
+			return true
+		}
+		names[i] = s.Name
+	}
+	t.Errorf("expected provider state '%s' to be registered on the message, found %v", name, names)
+
+	return false
+}
+
 // ExpectsToReceive specifies the content it is expecting to be
 // given from the Provider. The function must be able to handle this
 // message for the interaction to succeed.
 func (m *AsynchronousMessageBuilder) ExpectsToReceive(description string) *UnconfiguredAsynchronousMessageBuilder {
 	m.messageHandle.ExpectsToReceive(description)
+	m.description = description
 
 	return &UnconfiguredAsynchronousMessageBuilder{
 		rootBuilder: m,
@@ -60,33 +168,69 @@ type UnconfiguredAsynchronousMessageBuilder struct {
 	rootBuilder *AsynchronousMessageBuilder
 }
 
-// AddMessage creates a new asynchronous consumer expectation
+// UsingPlugin enables a plugin for use in the current test case
 func (m *UnconfiguredAsynchronousMessageBuilder) UsingPlugin(config PluginConfig) *AsynchronousMessageWithPlugin {
-	m.rootBuilder.pact.messageserver.UsingPlugin(config.Plugin, config.Version)
-
-	return &AsynchronousMessageWithPlugin{
+	s := &AsynchronousMessageWithPlugin{
 		rootBuilder: m.rootBuilder,
 	}
+
+	return s.UsingPlugin(config)
 }
 
 type AsynchronousMessageWithPlugin struct {
 	rootBuilder *AsynchronousMessageBuilder
 }
 
+// UsingPlugin enables a further plugin for use in the current test case
+func (s *AsynchronousMessageWithPlugin) UsingPlugin(config PluginConfig) *AsynchronousMessageWithPlugin {
+	if err := s.rootBuilder.pact.messageserver.UsingPlugin(config.Plugin, config.Version); err != nil && s.rootBuilder.err == nil {
+		s.rootBuilder.err = fmt.Errorf("unable to load plugin %s %s: %v", config.Plugin, config.Version, err)
+	}
+
+	return s
+}
+
+// WithContents specifies the contents of the message, in the format of the plugin for
+// the content type, e.g. the fields of a protobuf message
 func (s *AsynchronousMessageWithPlugin) WithContents(contents string, contentType string) *AsynchronousMessageWithPluginContents {
-	s.rootBuilder.messageHandle.WithPluginInteractionContents(native.INTERACTION_PART_REQUEST, contentType, contents)
+	if err := s.rootBuilder.messageHandle.WithPluginInteractionContents(native.INTERACTION_PART_REQUEST, contentType, contents); err != nil && s.rootBuilder.err == nil {
+		s.rootBuilder.err = fmt.Errorf("invalid plugin contents: %v", err)
+	}
+	s.rootBuilder.contentType = contentType
 
 	return &AsynchronousMessageWithPluginContents{
 		rootBuilder: s.rootBuilder,
 	}
 }
 
+// WithContentsFromPlugin specifies the contents of the message as the configuration of the
+// plugin for the content type, as a JSON string or a value marshalled to JSON, e.g.
+//
+//	WithContentsFromPlugin("application/protobuf", map[string]interface{}{
+//		"pact:proto":        path,
+//		"pact:message-type": "Feature",
+//		"pact:content-type": "application/protobuf",
+//		"name":              "notEmpty('Big Tree')",
+//	})
+func (s *AsynchronousMessageWithPlugin) WithContentsFromPlugin(contentType string, config interface{}) *AsynchronousMessageWithPluginContents {
+	contents, err := pluginContents(config)
+	if err != nil && s.rootBuilder.err == nil {
+		s.rootBuilder.err = fmt.Errorf("invalid plugin contents: %v", err)
+	}
+
+	return s.WithContents(contents, contentType)
+}
+
 type AsynchronousMessageWithPluginContents struct {
 	rootBuilder *AsynchronousMessageBuilder
 }
 
 func (s *AsynchronousMessageWithPluginContents) ExecuteTest(t *testing.T, integrationTest func(m AsynchronousMessage) error) error {
-	message, err := getAsynchronousMessageWithReifiedContents(s.rootBuilder.messageHandle, s.rootBuilder.Type)
+	if err := s.rootBuilder.buildErr(); err != nil {
+		return err
+	}
+
+	message, err := s.rootBuilder.reify()
 	if err != nil {
 		return err
 	}
@@ -100,7 +244,7 @@ func (s *AsynchronousMessageWithPluginContents) ExecuteTest(t *testing.T, integr
 
 	s.rootBuilder.pact.messageserver.CleanupPlugins()
 
-	return s.rootBuilder.pact.messageserver.WritePactFile(s.rootBuilder.pact.config.PactDir, false)
+	return s.rootBuilder.pact.writePact(s.rootBuilder.pact.messageserver.WritePactFile)
 }
 
 func (s *AsynchronousMessageWithPluginContents) StartTransport(transport string, address string, config map[string][]interface{}) *AsynchronousMessageWithTransport {
@@ -125,7 +269,11 @@ type AsynchronousMessageWithTransport struct {
 }
 
 func (s *AsynchronousMessageWithTransport) ExecuteTest(t *testing.T, integrationTest func(tc TransportConfig, m AsynchronousMessage) error) error {
-	message, err := getAsynchronousMessageWithReifiedContents(s.rootBuilder.messageHandle, s.rootBuilder.Type)
+	if err := s.rootBuilder.buildErr(); err != nil {
+		return err
+	}
+
+	message, err := s.rootBuilder.reify()
 	if err != nil {
 		return err
 	}
@@ -144,7 +292,9 @@ func (s *AsynchronousMessageWithTransport) ExecuteTest(t *testing.T, integration
 		return fmt.Errorf("pact validation failed: %+v", mismatches)
 	}
 
-	return s.rootBuilder.pact.messageserver.WritePactFileForServer(s.transport.Port, s.rootBuilder.pact.config.PactDir, false)
+	return s.rootBuilder.pact.writePact(func(dir string, overwrite bool) error {
+		return s.rootBuilder.pact.messageserver.WritePactFileForServer(s.transport.Port, dir, overwrite)
+	})
 }
 
 // WithMetadata specifies message-implementation specific metadata
@@ -153,9 +303,90 @@ func (s *AsynchronousMessageWithTransport) ExecuteTest(t *testing.T, integration
 func (m *UnconfiguredAsynchronousMessageBuilder) WithMetadata(metadata map[string]string) *UnconfiguredAsynchronousMessageBuilder {
 	m.rootBuilder.messageHandle.WithMetadata(metadata)
 
+	if m.rootBuilder.metadata == nil {
+		m.rootBuilder.metadata = make(map[string]interface{}, len(metadata))
+	}
+	for k, v := range metadata {
+		m.rootBuilder.metadata[k] = v
+	}
+
+	return m
+}
+
+// WithMetadataMatchers specifies message metadata that is matched by the given matchers,
+// rather than by equality, e.g.
+//
+//	WithMetadataMatchers(matchers.MapMatcher{
+//		"timestamp": matchers.RFC3339Time(),
+//		"source":    matchers.URI(),
+//		"headers":   matchers.StructMatcher{"partitionKey": matchers.Like("orders-1")},
+//	})
+//
+// The reified message and exported examples carry the example values of the matchers.
+func (m *UnconfiguredAsynchronousMessageBuilder) WithMetadataMatchers(metadata matchers.MapMatcher) *UnconfiguredAsynchronousMessageBuilder {
+	if m.rootBuilder.metadata == nil {
+		m.rootBuilder.metadata = make(map[string]interface{}, len(metadata))
+	}
+	for k, v := range metadata {
+		m.rootBuilder.messageHandle.WithMetadataMatcher(k, v)
+		m.rootBuilder.metadata[k] = v
+	}
+
 	return m
 }
 
+// Metadata keys used to record the consumer's retry policy
+const (
+	RetryPolicyMaxAttemptsKey = "retryPolicy.maxAttempts"
+	RetryPolicyBackoffKey     = "retryPolicy.backoff"
+)
+
+// WithRetryPolicy documents how the consumer retries this message if it fails
+// to be processed, recording the policy in the message metadata
+func (m *UnconfiguredAsynchronousMessageBuilder) WithRetryPolicy(maxAttempts int, backoff time.Duration) *UnconfiguredAsynchronousMessageBuilder {
+	if maxAttempts < 1 {
+		m.rootBuilder.pact.logf("WARN", "retry policy max attempts can't be less than one")
+		maxAttempts = 1
+	}
+
+	return m.WithMetadata(map[string]string{
+		RetryPolicyMaxAttemptsKey: strconv.Itoa(maxAttempts),
+		RetryPolicyBackoffKey:     backoff.String(),
+	})
+}
+
+// WithSeverity sets how provider verification treats failures of this message, either
+// "critical" (the default) or "warning". Warning level messages are marked as pending,
+// so failures are reported but don't fail the build, allowing new expectations to be
+// rolled out as advisory before being made blocking. The severity is recorded in the
+// message metadata.
+func (m *UnconfiguredAsynchronousMessageBuilder) WithSeverity(level string) *UnconfiguredAsynchronousMessageBuilder {
+	switch models.Severity(level) {
+	case models.SeverityCritical:
+		m.rootBuilder.messageHandle.SetPending(false)
+	case models.SeverityWarning:
+		m.rootBuilder.messageHandle.SetPending(true)
+	default:
+		if m.rootBuilder.err == nil {
+			m.rootBuilder.err = fmt.Errorf("invalid severity '%s', must be one of '%s' or '%s'", level, models.SeverityCritical, models.SeverityWarning)
+		}
+		return m
+	}
+
+	return m.WithMetadata(map[string]string{
+		models.SeverityMetadataKey: level,
+	})
+}
+
+// WithConsumerGroup documents the consumer group (e.g. a Kafka consumer group) that
+// expects this message, recording it in the message metadata. Where several groups
+// read the same topic, the provider can use it to scope which contracts apply.
+func (m *UnconfiguredAsynchronousMessageBuilder) WithConsumerGroup(name string) *UnconfiguredAsynchronousMessageBuilder {
+	return m.WithMetadata(map[string]string{
+		models.ConsumerGroupMetadataKey: name,
+	})
+}
+
 type AsynchronousMessageWithContents struct {
 	rootBuilder *AsynchronousMessageBuilder
 }
@@ -163,182 +394,2171 @@ type AsynchronousMessageWithContents struct {
 // WithContent specifies the payload in bytes that the consumer expects to receive
 func (m *UnconfiguredAsynchronousMessageBuilder) WithContent(contentType string, body []byte) *AsynchronousMessageWithContents {
 	m.rootBuilder.messageHandle.WithContents(mockserver.INTERACTION_PART_REQUEST, contentType, body)
+	m.rootBuilder.contentType = contentType
+
+	if decode := m.rootBuilder.decoder(); decode != nil {
+		if _, err := decode(body); err != nil && m.rootBuilder.err == nil {
+			m.rootBuilder.err = err
+		}
+	}
+
+	if json.Valid(body) {
+		m.rootBuilder.content = json.RawMessage(body)
+	} else {
+		m.rootBuilder.content = body
+	}
 
 	return &AsynchronousMessageWithContents{
 		rootBuilder: m.rootBuilder,
 	}
 }
 
+// Extends copies the contents, metadata and provider states of a base message, so that
+// interactions differing in only a few details needn't repeat the rest, e.g.
+//
+//	suspended := p.AddAsynchronousMessage().
+//		Given("the account is suspended").
+//		ExpectsToReceive("a suspended account event").
+//		Extends(active).
+//		WithContentMatchersAt("$.status", "suspended")
+//
+// Metadata keys and provider states given before Extends take precedence: base metadata
+// is only copied for keys that have not been set, and the base provider states are only
+// copied if the message has none of its own. Metadata matchers are copied with their
+// matching rules. The contents may be further refined with WithContentMatchersAt and
+// IgnoreContentFields.
+//
+// The schema subject and maximum in flight of the base message are also copied, but its
+// metadata conditions and links, and negative examples, are not inherited, and must be
+// given again if they apply to the message.
+func (m *UnconfiguredAsynchronousMessageBuilder) Extends(base *AsynchronousMessageWithContents) *AsynchronousMessageWithContents {
+	b := m.rootBuilder
+	parent := base.rootBuilder
+	if parent.err != nil && b.err == nil {
+		b.err = fmt.Errorf("the base message '%s' is invalid: %v", parent.description, parent.err)
+	}
+
+	if len(b.states) == 0 {
+		for _, state := range parent.states {
+			if state.Parameters != nil {
+				b.messageHandle.GivenWithParameter(state.Name, state.Parameters)
+			} else {
+				b.messageHandle.Given(state.Name)
+			}
+			b.states = append(b.states, state)
+		}
+	}
+
+	inherited := make(map[string]interface{})
+	for k, v := range parent.metadata {
+		if _, ok := b.metadata[k]; !ok {
+			inherited[k] = v
+		}
+	}
+	applyMetadata(b.messageHandle, inherited)
+	if b.metadata == nil && len(inherited) > 0 {
+		b.metadata = make(map[string]interface{}, len(inherited))
+	}
+	for k, v := range inherited {
+		b.metadata[k] = v
+	}
+	if inherited[models.SeverityMetadataKey] == string(models.SeverityWarning) {
+		b.messageHandle.SetPending(true)
+	}
+
+	b.content = parent.content
+	b.contentType = parent.contentType
+	b.Type = parent.Type
+	b.maxAllocs = parent.maxAllocs
+	b.schemaSubject = parent.schemaSubject
+	b.maxInFlight = parent.maxInFlight
+	b.applyContent(b.messageHandle)
+
+	return &AsynchronousMessageWithContents{
+		rootBuilder: b,
+	}
+}
+
+// applyMetadata sets metadata on a native message handle, matchers, e.g. given with
+// WithMetadataMatchers, with their matching rules and other values as they are
+func applyMetadata(message *mockserver.Message, metadata map[string]interface{}) {
+	values := make(map[string]string)
+	for k, v := range metadata {
+		if value, ok := v.(string); ok {
+			values[k] = value
+		} else {
+			message.WithMetadataMatcher(k, v)
+		}
+	}
+	if len(values) > 0 {
+		message.WithMetadata(values)
+	}
+}
+
+// applyContent sets the content of the message on a native message handle
+func (m *AsynchronousMessageBuilder) applyContent(message *mockserver.Message) {
+	switch c := m.content.(type) {
+	case nil:
+	case []byte:
+		message.WithContents(mockserver.INTERACTION_PART_REQUEST, m.contentType, c)
+	case json.RawMessage:
+		if m.contentType != "" && m.contentType != "application/json" {
+			message.WithContents(mockserver.INTERACTION_PART_REQUEST, m.contentType, c)
+		} else {
+			message.WithRequestJSONContents(c)
+		}
+	default:
+		message.WithRequestJSONContents(c)
+	}
+}
+
 // WithJSONContent specifies the payload as an object (to be marshalled to WithJSONContent) that
 // is expected to be consumed
 func (m *UnconfiguredAsynchronousMessageBuilder) WithJSONContent(content interface{}) *AsynchronousMessageWithContents {
+	content, err := prepareJSONContent(content)
+	if err != nil && m.rootBuilder.err == nil {
+		m.rootBuilder.err = fmt.Errorf("invalid message content: %v", err)
+	}
 	m.rootBuilder.messageHandle.WithRequestJSONContents(content)
+	m.rootBuilder.content = content
+	m.rootBuilder.contentType = "application/json"
+	m.rootBuilder.recordFieldNotes()
 
 	return &AsynchronousMessageWithContents{
 		rootBuilder: m.rootBuilder,
 	}
 }
 
-// AsType specifies that the content sent through to the
-// consumer handler should be sent as the given type
-func (m *AsynchronousMessageWithContents) AsType(t interface{}) *AsynchronousMessageWithContents {
-	log.Println("[DEBUG] setting Message decoding to type:", reflect.TypeOf(t))
-	m.rootBuilder.Type = t
+// WithXMLContent specifies the payload as an XML document, whose elements, attributes and
+// text may be matched. See matchers.XMLDocument
+func (m *UnconfiguredAsynchronousMessageBuilder) WithXMLContent(body matchers.XMLBody) *AsynchronousMessageWithContents {
+	dsl, example, err := prepareXMLContent(body)
+	if err != nil && m.rootBuilder.err == nil {
+		m.rootBuilder.err = fmt.Errorf("invalid message content: %v", err)
+	}
+	m.rootBuilder.messageHandle.WithContents(mockserver.INTERACTION_PART_REQUEST, matchers.XMLContentType, dsl)
+	// the example document is kept, as the matching rules are applied by the core
+	m.rootBuilder.content = example
+	m.rootBuilder.contentType = matchers.XMLContentType
 
-	return m
+	return &AsynchronousMessageWithContents{
+		rootBuilder: m.rootBuilder,
+	}
 }
 
-// The function that will consume the message
-func (m *AsynchronousMessageWithContents) ConsumedBy(handler AsynchronousConsumer) *AsynchronousMessageWithConsumer {
-	m.rootBuilder.handler = handler
+// recordFieldNotes records the fields of the content documented with matchers.Described,
+// and those marked with matchers.Deprecated, in the pact file metadata
+func (m *AsynchronousMessageBuilder) recordFieldNotes() {
+	descriptions, err := matchers.FieldDescriptions(m.content)
+	if err != nil {
+		return
+	}
+	deprecated, err := matchers.DeprecatedFields(m.content)
+	if err != nil || len(descriptions)+len(deprecated) == 0 {
+		return
+	}
 
-	return &AsynchronousMessageWithConsumer{
-		rootBuilder: m.rootBuilder,
+	pact := m.pact
+	pact.mu.Lock()
+	defer pact.mu.Unlock()
+
+	if len(descriptions) > 0 {
+		if pact.fieldDescriptions == nil {
+			pact.fieldDescriptions = make(map[string]map[string]string)
+		}
+		pact.fieldDescriptions[m.interactionKey()] = descriptions
+		pact.recordMetadata(FieldDescriptionsMetadataKey)
+	}
+	if len(deprecated) > 0 {
+		if pact.deprecatedFields == nil {
+			pact.deprecatedFields = make(map[string]map[string]string)
+		}
+		pact.deprecatedFields[m.interactionKey()] = deprecated
+		pact.recordMetadata(DeprecatedFieldsMetadataKey)
 	}
 }
 
-type AsynchronousMessageWithConsumer struct {
-	rootBuilder *AsynchronousMessageBuilder
-}
+// WithMaxAllocs fails verification if the consumer handler makes more than n heap
+// allocations while processing the message.
+//
+// Allocations are measured with runtime.MemStats across the whole process, so the
+// handler should not be verified concurrently with other allocating work.
+func (m *AsynchronousMessageWithContents) WithMaxAllocs(n uint64) *AsynchronousMessageWithContents {
+	m.rootBuilder.maxAllocs = n
 
-// The function that will consume the message
-func (m *AsynchronousMessageWithConsumer) Verify(t *testing.T) error {
-	return m.rootBuilder.pact.Verify(t, m.rootBuilder, m.rootBuilder.handler)
+	return m
 }
 
-type AsynchronousPact struct {
-	config Config
+// WithMaxInFlight declares that the consumer processes at most n messages concurrently,
+// e.g. with a bounded worker pool, recording the bound in the message metadata. Use
+// VerifyMaxInFlight to check the consumer respects it.
+func (m *AsynchronousMessageWithContents) WithMaxInFlight(n int) *AsynchronousMessageWithContents {
+	if n < 1 {
+		m.setErr(fmt.Errorf("invalid maximum in flight %d, must be at least one", n))
+		return m
+	}
 
-	// Reference to the native rust handle
-	messageserver *mockserver.MessageServer
+	m.rootBuilder.maxInFlight = n
+	m.rootBuilder.messageHandle.WithMetadata(map[string]string{
+		models.MaxInFlightMetadataKey: strconv.Itoa(n),
+	})
+	if m.rootBuilder.metadata == nil {
+		m.rootBuilder.metadata = make(map[string]interface{})
+	}
+	m.rootBuilder.metadata[models.MaxInFlightMetadataKey] = strconv.Itoa(n)
+
+	return m
 }
 
-func NewAsynchronousPact(config Config) (*AsynchronousPact, error) {
-	provider := &AsynchronousPact{
-		config: config,
+// WithSchemaRef specifies the payload as a schema previously registered with
+// DefineSchema, so the same matcher tree can be shared between messages
+func (m *UnconfiguredAsynchronousMessageBuilder) WithSchemaRef(name string) *AsynchronousMessageWithContents {
+	schema, ok := m.rootBuilder.pact.Schema(name)
+	if !ok {
+		if m.rootBuilder.err == nil {
+			m.rootBuilder.err = fmt.Errorf("schema '%s' has not been defined, use DefineSchema to register it", name)
+		}
+
+		return &AsynchronousMessageWithContents{
+			rootBuilder: m.rootBuilder,
+		}
 	}
-	err := provider.validateConfig()
 
+	return m.WithJSONContent(schema)
+}
+
+// IgnoreContentFields removes the given JSON paths (e.g. $.createdAt or $.items[*].id)
+// from the contract, so the provider verifier does not check fields the consumer
+// doesn't control, such as server assigned timestamps. As the fields are no longer
+// part of the contract, they are not present in the message given to the consumer.
+//
+// The ignored paths are recorded in the pact file metadata.
+func (m *AsynchronousMessageWithContents) IgnoreContentFields(paths ...string) *AsynchronousMessageWithContents {
+	content, err := m.jsonContent()
 	if err != nil {
-		return nil, err
+		m.setErr(fmt.Errorf("fields can only be ignored in JSON content: %v", err))
+		return m
 	}
 
-	native.Init(string(logging.LogLevel()))
+	for _, path := range paths {
+		if content, err = removePath(content, path); err != nil {
+			m.setErr(err)
+			return m
+		}
+	}
 
-	return provider, err
+	m.rootBuilder.content = content
+	m.rootBuilder.messageHandle.WithRequestJSONContents(content)
+
+	pact := m.rootBuilder.pact
+	pact.mu.Lock()
+	defer pact.mu.Unlock()
+
+	if pact.ignoredFields == nil {
+		pact.ignoredFields = make(map[string][]string)
+	}
+	key := m.rootBuilder.interactionKey()
+	pact.ignoredFields[key] = append(pact.ignoredFields[key], paths...)
+	pact.recordMetadata(IgnoredFieldsMetadataKey)
+
+	return m
 }
 
-// validateConfig validates the configuration for the consumer test
-func (p *AsynchronousPact) validateConfig() error {
-	log.Println("[DEBUG] pact message validate config")
-	dir, _ := os.Getwd()
+// RequireMetadataWhen requires the metadata key only for messages whose content at the JSON
+// path has the given value, e.g. a "signature" header for high value transactions:
+//
+//	RequireMetadataWhen("signature", "$.tier", "high-value")
+//
+// The condition is recorded in the pact file metadata and checked by VerifySample.
+func (m *AsynchronousMessageWithContents) RequireMetadataWhen(metadataKey string, contentPath string, value interface{}) *AsynchronousMessageWithContents {
+	content, err := m.jsonContent()
+	if err != nil {
+		m.setErr(fmt.Errorf("metadata can only be required for JSON content: %v", err))
+		return m
+	}
+	if _, err = getPath(content, contentPath); err != nil {
+		m.setErr(err)
+		return m
+	}
 
-	if p.config.PactDir == "" {
-		p.config.PactDir = filepath.Join(dir, "pacts")
+	condition := MetadataCondition{MetadataKey: metadataKey, ContentPath: contentPath, Value: value}
+	if _, ok := m.rootBuilder.metadata[metadataKey]; !ok && condition.appliesTo(content) {
+		m.setErr(fmt.Errorf("the example content has %s %v, which requires metadata '%s' but it was not given", contentPath, value, metadataKey))
+		return m
 	}
+	m.rootBuilder.metadataConditions = append(m.rootBuilder.metadataConditions, condition)
 
-	p.messageserver = mockserver.NewMessageServer(p.config.Consumer, p.config.Provider)
-	p.messageserver.WithSpecificationVersion(mockserver.SPECIFICATION_VERSION_V4)
+	pact := m.rootBuilder.pact
+	pact.mu.Lock()
+	defer pact.mu.Unlock()
 
-	return nil
+	if pact.metadataConditions == nil {
+		pact.metadataConditions = make(map[string][]MetadataCondition)
+	}
+	key := m.rootBuilder.interactionKey()
+	pact.metadataConditions[key] = append(pact.metadataConditions[key], condition)
+	pact.recordMetadata(MetadataConditionsMetadataKey)
+
+	return m
 }
 
-// AddMessage creates a new asynchronous consumer expectation
-// Deprecated: use AddAsynchronousMessage() instead
-func (p *AsynchronousPact) AddMessage() *AsynchronousMessageBuilder {
-	return p.AddAsynchronousMessage()
+// LinkMetadataToContent requires the metadata value of the key to equal the content field
+// at the JSON path, e.g. a "messageId" header mirroring the id of the content:
+//
+//	LinkMetadataToContent("messageId", "$.id")
+//
+// The link is recorded in the pact file metadata and checked against the example when it is
+// given, the reified message when it is verified and the payloads given to VerifySample.
+func (m *AsynchronousMessageWithContents) LinkMetadataToContent(metadataKey string, contentPath string) *AsynchronousMessageWithContents {
+	content, err := m.jsonContent()
+	if err != nil {
+		m.setErr(fmt.Errorf("metadata can only be linked to JSON content: %v", err))
+		return m
+	}
+
+	link := MetadataLink{MetadataKey: metadataKey, ContentPath: contentPath}
+	if mismatch := link.mismatch(content, m.rootBuilder.metadata); mismatch != nil {
+		m.setErr(fmt.Errorf("the example does not satisfy the link of metadata '%s' to %s: %s", metadataKey, contentPath, mismatch.Mismatch))
+		return m
+	}
+	m.rootBuilder.metadataLinks = append(m.rootBuilder.metadataLinks, link)
+
+	pact := m.rootBuilder.pact
+	pact.mu.Lock()
+	defer pact.mu.Unlock()
+
+	if pact.metadataLinks == nil {
+		pact.metadataLinks = make(map[string][]MetadataLink)
+	}
+	key := m.rootBuilder.interactionKey()
+	pact.metadataLinks[key] = append(pact.metadataLinks[key], link)
+	pact.recordMetadata(MetadataLinksMetadataKey)
+
+	return m
 }
 
-// AddMessage creates a new asynchronous consumer expectation
-func (p *AsynchronousPact) AddAsynchronousMessage() *AsynchronousMessageBuilder {
-	log.Println("[DEBUG] add message")
+// Deprecated marks the message as deprecated, to be retired on the sunset date in favour of
+// the replacement interaction (by description, optional). The deprecation is recorded in the
+// pact file metadata. Once the sunset date has passed, verifying the message logs a warning,
+// or fails if Config.FailOnSunset is set.
+func (m *AsynchronousMessageWithContents) Deprecated(sunset time.Time, replacement string) *AsynchronousMessageWithContents {
+	deprecation := Deprecation{Sunset: sunset, Replacement: replacement}
+	m.rootBuilder.deprecation = &deprecation
 
-	message := p.messageserver.NewMessage()
+	pact := m.rootBuilder.pact
+	pact.mu.Lock()
+	defer pact.mu.Unlock()
 
-	return &AsynchronousMessageBuilder{
-		messageHandle: message,
-		pact:          p,
+	if pact.deprecations == nil {
+		pact.deprecations = make(map[string]Deprecation)
 	}
+	pact.deprecations[m.rootBuilder.interactionKey()] = deprecation
+	pact.recordMetadata(DeprecationsMetadataKey)
+
+	return m
 }
 
-// VerifyMessageConsumerRaw creates a new Pact _message_ interaction to build a testable
-// interaction.
+// WithSchemaSubject validates the content of the message against the latest schema
+// registered for the subject (e.g. "orders-value") in Config.SchemaRegistryURL when the
+// message is verified. Content in the Confluent wire format must be encoded with a schema
+// registered for the subject, JSON content is validated against JSON and Avro schemas.
+func (m *AsynchronousMessageWithContents) WithSchemaSubject(subject string) *AsynchronousMessageWithContents {
+	if m.rootBuilder.pact.config.SchemaRegistryURL == "" {
+		m.setErr(fmt.Errorf("a schema subject was given but Config.SchemaRegistryURL is not set"))
+		return m
+	}
+	m.rootBuilder.schemaSubject = subject
+
+	return m
+}
+
+// WithNegativeExample adds a malformed payload that the consumer must reject. When the
+// message is verified, each negative example is delivered to the handler after the
+// valid message, and verification fails if the handler doesn't return an error.
+// A payload that can't be decoded into the type given to AsType counts as rejected.
 //
-// A Message Consumer is analagous to a Provider in the HTTP Interaction model.
-// It is the receiver of an interaction, and needs to be able to handle whatever
-// request was provided.
-func (p *AsynchronousPact) verifyMessageConsumerRaw(messageToVerify *AsynchronousMessageBuilder, handler AsynchronousConsumer) error {
-	log.Printf("[DEBUG] verify message")
+// Negative examples are not part of the contract, so provider verification ignores
+// them. They are recorded in the pact file metadata for reference.
+func (m *AsynchronousMessageWithContents) WithNegativeExample(body []byte) *AsynchronousMessageWithContents {
+	m.rootBuilder.negativeExamples = append(m.rootBuilder.negativeExamples, body)
 
-	m, err := getAsynchronousMessageWithReifiedContents(messageToVerify.messageHandle, messageToVerify.Type)
-	if err != nil {
-		return err
+	pact := m.rootBuilder.pact
+	pact.mu.Lock()
+	defer pact.mu.Unlock()
+
+	if pact.negativeExamples == nil {
+		pact.negativeExamples = make(map[string][][]byte)
 	}
+	key := m.rootBuilder.interactionKey()
+	pact.negativeExamples[key] = append(pact.negativeExamples[key], body)
+	pact.recordMetadata(NegativeExamplesMetadataKey)
 
-	// Yield message, and send through handler function
-	err = handler(m)
+	return m
+}
 
+// WithContentMatchersAt applies matchers to a subtree of otherwise fixed content, e.g.
+// for a mostly static message with a single dynamic object:
+//
+//	WithJSONContent(fixture).
+//		WithContentMatchersAt("$.order.customer", matchers.StructMatcher{
+//			"id":   matchers.UUID(),
+//			"name": matchers.Like("billy"),
+//		})
+//
+// The value at the path (which may use the * wildcard for array elements) is
+// replaced by the given content. The rest of the message is matched exactly.
+func (m *AsynchronousMessageWithContents) WithContentMatchersAt(jsonPath string, content interface{}) *AsynchronousMessageWithContents {
+	current, err := m.jsonContent()
 	if err != nil {
-		return err
+		m.setErr(fmt.Errorf("matchers can only be applied to JSON content: %v", err))
+		return m
 	}
 
-	return p.messageserver.WritePactFile(p.config.PactDir, false)
-}
+	subtree, err := json.Marshal(content)
+	if err != nil {
+		m.setErr(fmt.Errorf("unable to serialise the content for %s: %v", jsonPath, err))
+		return m
+	}
 
-// VerifyMessageConsumer is a test convience function for VerifyMessageConsumerRaw,
-// accepting an instance of `*testing.T`
-func (p *AsynchronousPact) Verify(t *testing.T, message *AsynchronousMessageBuilder, handler AsynchronousConsumer) error {
-	err := p.verifyMessageConsumerRaw(message, handler)
+	updated, err := setPath(current, jsonPath, subtree)
+	if err != nil {
+		m.setErr(err)
+		return m
+	}
 
+	updated, err = prepareJSONContent(updated)
 	if err != nil {
-		t.Errorf("VerifyMessageConsumer failed: %v", err)
+		m.setErr(fmt.Errorf("invalid message content: %v", err))
 	}
+	m.rootBuilder.content = updated
+	m.rootBuilder.messageHandle.WithRequestJSONContents(updated)
 
-	return err
+	return m
 }
 
-func getAsynchronousMessageWithContents(message *native.Message) (AsynchronousMessage, error) {
-	var m AsynchronousMessage
+// CorrelatesWith takes the example value of a field from the example content of another
+// message, so that identifiers shared across a multi-message flow agree in the contract, e.g.
+//
+//	shipped := p.AddAsynchronousMessage().
+//		ExpectsToReceive("an order shipped event").
+//		WithJSONContent(map[string]interface{}{"orderId": matchers.UUID()}).
+//		CorrelatesWith(created, "$.orderId", "$.id")
+//
+// sets the example of $.orderId to that of $.id in the created message. Any matcher on the
+// field is kept. Both messages must have JSON content.
+func (m *AsynchronousMessageWithContents) CorrelatesWith(other *AsynchronousMessageWithContents, field, otherField string) *AsynchronousMessageWithContents {
+	example, err := getAsynchronousMessageWithContents(other.rootBuilder.messageHandle)
+	if err != nil {
+		m.setErr(fmt.Errorf("unable to read the content of message '%s': %v", other.rootBuilder.description, err))
+		return m
+	}
 
-	contents, err := message.GetMessageRequestContents()
+	var otherContent interface{}
+	if err = json.Unmarshal(example.Contents, &otherContent); err != nil {
+		m.setErr(fmt.Errorf("messages can only be correlated with JSON content, message '%s': %v", other.rootBuilder.description, err))
+		return m
+	}
+	value, err := getPath(otherContent, otherField)
 	if err != nil {
-		return m, err
+		m.setErr(fmt.Errorf("message '%s': %v", other.rootBuilder.description, err))
+		return m
 	}
 
-	return AsynchronousMessage{
-		Contents: contents,
-	}, nil
-}
+	current, err := m.jsonContent()
+	if err != nil {
+		m.setErr(fmt.Errorf("messages can only be correlated with JSON content: %v", err))
+		return m
+	}
+	existing, err := getPath(current, field)
+	if err != nil {
+		m.setErr(err)
+		return m
+	}
 
-func getAsynchronousMessageWithReifiedContents(message *mockserver.Message, reifiedType interface{}) (AsynchronousMessage, error) {
-	var m AsynchronousMessage
-	var err error
+	if matcher, ok := existing.(map[string]interface{}); ok {
+		if _, ok := matcher["pact:matcher:type"]; ok {
+			matcher["value"] = value
+			value = matcher
+		}
+	}
+	b, err := json.Marshal(value)
+	if err != nil {
+		m.setErr(err)
+		return m
+	}
 
-	m, err = getAsynchronousMessageWithContents(message)
+	updated, err := setPath(current, field, b)
 	if err != nil {
-		return m, fmt.Errorf("unexpected response from message server, this is a bug in the framework: %v", err)
+		m.setErr(err)
+		return m
 	}
-	log.Println("[DEBUG] reified body raw", string(m.Contents))
 
-	// // 1. Strip out the matchers
+	updated, err = prepareJSONContent(updated)
+	if err != nil {
+		m.setErr(fmt.Errorf("invalid message content: %v", err))
+	}
+	m.rootBuilder.content = updated
+	m.rootBuilder.messageHandle.WithRequestJSONContents(updated)
+
+	return m
+}
+
+// WithOrderingKey records the JSON path (e.g. $.accountId) of the content field that acts
+// as the ordering or partition key of the message, in the message metadata. Providers
+// replaying messages from a partitioned topic can use it to check ordering within a key.
+func (m *AsynchronousMessageWithContents) WithOrderingKey(jsonPath string) *AsynchronousMessageWithContents {
+	content, err := m.jsonContent()
+	if err != nil {
+		m.setErr(fmt.Errorf("an ordering key can only be given for JSON content: %v", err))
+		return m
+	}
+	if _, err = getPath(content, jsonPath); err != nil {
+		m.setErr(fmt.Errorf("invalid ordering key: %v", err))
+		return m
+	}
+
+	m.rootBuilder.messageHandle.WithMetadata(map[string]string{
+		models.OrderingKeyMetadataKey: jsonPath,
+	})
+	if m.rootBuilder.metadata == nil {
+		m.rootBuilder.metadata = make(map[string]interface{})
+	}
+	m.rootBuilder.metadata[models.OrderingKeyMetadataKey] = jsonPath
+
+	return m
+}
+
+// WithOrderedMetadata pins the order of the given metadata keys, for transports where the
+// order of headers is significant (e.g. some binary protocols). The keys must have been
+// given with WithMetadata or WithMetadataMatchers, and are recorded in order in the message
+// metadata under models.MetadataOrderMetadataKey. VerifyMetadataOrder checks the order of
+// the headers of a delivered message.
+func (m *AsynchronousMessageWithContents) WithOrderedMetadata(keys ...string) *AsynchronousMessageWithContents {
+	seen := make(map[string]bool, len(keys))
+	for _, k := range keys {
+		if _, ok := m.rootBuilder.metadata[k]; !ok {
+			m.setErr(fmt.Errorf("the ordered metadata key '%s' has not been given with WithMetadata", k))
+			return m
+		}
+		if seen[k] {
+			m.setErr(fmt.Errorf("the ordered metadata key '%s' was given more than once", k))
+			return m
+		}
+		seen[k] = true
+	}
+
+	m.rootBuilder.metadataOrder = keys
+	m.rootBuilder.messageHandle.WithMetadataMatcher(models.MetadataOrderMetadataKey, keys)
+
+	return m
+}
+
+// VerifyMetadataOrder checks the metadata keys of a delivered message, in the order the
+// transport delivered them, preserve the order given to WithOrderedMetadata. Any failures
+// are returned as a *SampleMismatchError
+func (m *AsynchronousMessageWithContents) VerifyMetadataOrder(keys []string) error {
+	if mismatches := metadataOrderMismatches(m.rootBuilder.metadataOrder, keys); len(mismatches) > 0 {
+		return &SampleMismatchError{Mismatches: mismatches}
+	}
+
+	return nil
+}
+
+// WithMatchStrictness sets the default matching of the whole content, one of MatchStrict
+// (exact values, the default), MatchType (values of the same type) or MatchLenient (only
+// the presence of each field, with arrays of any length). Matchers already in the content
+// override the default for the fields they apply to, e.g.
+//
+//	WithJSONContent(map[string]interface{}{
+//		"id":     1,
+//		"status": matchers.Term("active", "^(active|retired)$"),
+//	}).
+//		WithMatchStrictness(MatchType)
+func (m *AsynchronousMessageWithContents) WithMatchStrictness(level string) *AsynchronousMessageWithContents {
+	content, err := m.jsonContent()
+	if err != nil {
+		m.setErr(fmt.Errorf("match strictness can only be applied to JSON content: %v", err))
+		return m
+	}
+
+	updated, err := applyStrictness(content, level)
+	if err != nil {
+		m.setErr(err)
+		return m
+	}
+
+	updated, err = prepareJSONContent(updated)
+	if err != nil {
+		m.setErr(fmt.Errorf("invalid message content: %v", err))
+	}
+	m.rootBuilder.content = updated
+	m.rootBuilder.messageHandle.WithRequestJSONContents(updated)
+
+	return m
+}
+
+// ProducerSnippet returns a shell command that publishes the example contents and metadata
+// of the message, to hand to the team implementing the provider. The transport is one of
+// TransportKafka (a kafka-console-producer command, with the metadata as headers) or
+// TransportAMQP (a rabbitmqadmin publish command). The topic, exchange and routing key are
+// taken from the metadata keys "topic", "exchange" and "routingKey" where present.
+func (m *AsynchronousMessageWithContents) ProducerSnippet(transport string) (string, error) {
+	if m.rootBuilder.err != nil {
+		return "", m.rootBuilder.err
+	}
+
+	example, err := getAsynchronousMessageWithContents(m.rootBuilder.messageHandle)
+	if err != nil {
+		return "", fmt.Errorf("unable to reify the message content: %v", err)
+	}
+
+	return producerSnippet(transport, example.Contents, m.rootBuilder.declaredContentType(), m.rootBuilder.exampleMetadata())
+}
+
+// ValidateMatchers reifies the content of the message and checks that the value produced
+// for each matcher is of the type it declares, e.g. that an Integer matcher produced an
+// integral number. Every violation is returned, along with any error building the message.
+func (m *AsynchronousMessageWithContents) ValidateMatchers() []error {
+	var errs []error
+	if m.rootBuilder.err != nil {
+		errs = append(errs, m.rootBuilder.err)
+	}
+
+	if _, ok := m.rootBuilder.content.([]byte); ok {
+		return errs
+	}
+
+	reified, err := getAsynchronousMessageWithContents(m.rootBuilder.messageHandle)
+	if err != nil {
+		return append(errs, fmt.Errorf("unable to reify the message content: %v", err))
+	}
+
+	mismatches, err := matchers.Compare(m.rootBuilder.content, json.RawMessage(reified.Contents))
+	if err != nil {
+		return append(errs, err)
+	}
+	for _, mismatch := range mismatches {
+		errs = append(errs, fmt.Errorf("the reified content does not satisfy its matcher: %s", mismatch))
+	}
+
+	return errs
+}
+
+// jsonContent returns the content of the message as normalised JSON
+func (m *AsynchronousMessageWithContents) jsonContent() (interface{}, error) {
+	var content interface{}
+
+	switch c := m.rootBuilder.content.(type) {
+	case []byte:
+		return nil, fmt.Errorf("the content is not valid JSON")
+	case json.RawMessage:
+		if err := json.Unmarshal(c, &content); err != nil {
+			return nil, fmt.Errorf("unable to parse message content: %v", err)
+		}
+	default:
+		b, err := json.Marshal(c)
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialise message content: %v", err)
+		}
+		if err = json.Unmarshal(b, &content); err != nil {
+			return nil, fmt.Errorf("unable to parse message content: %v", err)
+		}
+	}
+
+	return content, nil
+}
+
+// DebugDump returns the state of the message as held by the native core (contents,
+// matching rules, metadata and provider states), along with any builder error.
+// It is intended to be attached to bug reports.
+func (m *AsynchronousMessageWithContents) DebugDump() (string, error) {
+	return m.rootBuilder.DebugDump()
+}
+
+// DebugDump returns the state of the message as held by the native core (contents,
+// matching rules, metadata and provider states), along with any builder error.
+// It is intended to be attached to bug reports.
+func (m *AsynchronousMessageBuilder) DebugDump() (string, error) {
+	dir, err := ioutil.TempDir("", "pact-debug")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(dir)
+
+	m.pact.mu.Lock()
+	m.pact.flushMetadata()
+	m.pact.mu.Unlock()
+	if err = m.pact.messageserver.WritePactFile(dir, true); err != nil {
+		return "", fmt.Errorf("unable to read the native state: %v", err)
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		return "", fmt.Errorf("unable to read the native state: expected a single pact file, found %d", len(files))
+	}
+	pact, err := pactfile.Read(files[0])
+	if err != nil {
+		return "", err
+	}
+
+	dump := struct {
+		Description string                 `json:"description"`
+		Interaction map[string]interface{} `json:"interaction"`
+		Type        string                 `json:"type,omitempty"`
+		Error       string                 `json:"error,omitempty"`
+	}{
+		Description: m.description,
+	}
+	for _, i := range pact.AllInteractions() {
+		if i.Description == m.description {
+			dump.Interaction = i.Raw
+		}
+	}
+	if m.Type != nil {
+		dump.Type = reflect.TypeOf(m.Type).String()
+	}
+	if m.err != nil {
+		dump.Error = m.err.Error()
+	}
+
+	res, err := json.MarshalIndent(dump, "", "  ")
+
+	return string(res), err
+}
+
+// AssertGiven checks that a provider state with the given name was registered on the message
+func (m *AsynchronousMessageWithContents) AssertGiven(t *testing.T, name string) bool {
+	t.Helper()
+
+	return m.rootBuilder.AssertGiven(t, name)
+}
+
+// buildErr returns the first error building the message, either here or in the native core
+func (m *AsynchronousMessageBuilder) buildErr() error {
+	if m.err != nil {
+		return m.err
+	}
+	if err := m.messageHandle.Err(); err != nil {
+		return fmt.Errorf("unable to build message '%s': %v", m.description, err)
+	}
+
+	return nil
+}
+
+func (m *AsynchronousMessageWithContents) setErr(err error) {
+	if m.rootBuilder.err == nil {
+		m.rootBuilder.err = err
+	}
+}
+
+// AsType specifies that the content sent through to the
+// consumer handler should be sent as the given type
+func (m *AsynchronousMessageWithContents) AsType(t interface{}) *AsynchronousMessageWithContents {
+	m.rootBuilder.pact.logf("DEBUG", "setting Message decoding to type: %v", reflect.TypeOf(t))
+	m.rootBuilder.Type = t
+
+	return m
+}
+
+// VerifySample checks a concrete payload (e.g. a message captured from production)
+// against the matching rules of this interaction, without invoking a consumer handler.
+// Metadata is optional, but if given, must satisfy the expected metadata.
+// Any failures are returned as a *SampleMismatchError
+func (m *AsynchronousMessageWithContents) VerifySample(payload []byte, metadata map[string]interface{}) error {
+	var mismatches []matchers.Mismatch
+
+	if raw, ok := m.rootBuilder.content.([]byte); ok {
+		if !bytes.Equal(raw, payload) {
+			mismatches = append(mismatches, matchers.Mismatch{
+				Path:     "$",
+				Expected: raw,
+				Actual:   payload,
+				Mismatch: "expected the binary payload to be equal",
+			})
+		}
+	} else {
+		res, err := matchers.Compare(m.rootBuilder.content, payload)
+		if err != nil {
+			return err
+		}
+		mismatches = append(mismatches, res...)
+	}
+
+	if metadata != nil && len(m.rootBuilder.metadata) > 0 {
+		res, err := matchers.Compare(m.rootBuilder.metadata, metadata)
+		if err != nil {
+			return err
+		}
+		for _, r := range res {
+			r.Path = strings.Replace(r.Path, "$", "metadata", 1)
+			mismatches = append(mismatches, r)
+		}
+	}
+
+	if len(m.rootBuilder.metadataConditions) > 0 {
+		var content interface{}
+		if err := json.Unmarshal(payload, &content); err == nil {
+			for _, c := range m.rootBuilder.metadataConditions {
+				if _, ok := metadata[c.MetadataKey]; !ok && c.appliesTo(content) {
+					mismatches = append(mismatches, matchers.Mismatch{
+						Path:     "metadata." + c.MetadataKey,
+						Expected: c.Value,
+						Mismatch: fmt.Sprintf("expected key '%s' as %s is %v", c.MetadataKey, c.ContentPath, c.Value),
+					})
+				}
+			}
+		}
+	}
+
+	mismatches = append(mismatches, m.rootBuilder.linkMismatches(payload, metadata)...)
+
+	if len(mismatches) > 0 {
+		return &SampleMismatchError{Mismatches: mismatches}
+	}
+
+	return nil
+}
+
+// linkMismatches checks the metadata of a message mirrors its JSON content, see LinkMetadataToContent
+func (m *AsynchronousMessageBuilder) linkMismatches(contents []byte, metadata map[string]interface{}) []matchers.Mismatch {
+	if len(m.metadataLinks) == 0 {
+		return nil
+	}
+
+	var content interface{}
+	if err := json.Unmarshal(contents, &content); err != nil {
+		return []matchers.Mismatch{{Path: "$", Mismatch: fmt.Sprintf("linked metadata requires JSON content: %v", err)}}
+	}
+
+	var mismatches []matchers.Mismatch
+	for _, l := range m.metadataLinks {
+		if mismatch := l.mismatch(content, metadata); mismatch != nil {
+			mismatches = append(mismatches, *mismatch)
+		}
+	}
+
+	return mismatches
+}
+
+// VerifyAgainstRecording runs the handler against each message recorded in dir, checking
+// the recording also satisfies the contract. Recordings use the layout of ExportExamples:
+// the contents of each message in a file, with its metadata (if any) in a JSON object in
+// the neighbouring <name>.meta.json file.
+func (m *AsynchronousMessageWithContents) VerifyAgainstRecording(t *testing.T, dir string, handler AsynchronousConsumer) error {
+	err := m.verifyAgainstRecording(dir, handler)
+	if err != nil {
+		t.Errorf("VerifyAgainstRecording failed: %v", err)
+	}
+
+	return err
+}
+
+func (m *AsynchronousMessageWithContents) verifyAgainstRecording(dir string, handler AsynchronousConsumer) error {
+	if err := m.rootBuilder.buildErr(); err != nil {
+		return err
+	}
+	if handler == nil {
+		return fmt.Errorf("no handler given for message '%s'", m.rootBuilder.description)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("unable to read the recordings: %v", err)
+	}
+
+	var failures []string
+	recordings := 0
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasSuffix(name, ".meta.json") {
+			continue
+		}
+		recordings++
+
+		if err = m.verifyRecording(filepath.Join(dir, name), handler); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+
+	if recordings == 0 {
+		return fmt.Errorf("no recorded messages were found in %s", dir)
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d recorded messages failed:\n%s", len(failures), recordings, strings.Join(failures, "\n"))
+	}
+
+	return nil
+}
+
+func (m *AsynchronousMessageWithContents) verifyRecording(file string, handler AsynchronousConsumer) error {
+	body, err := ioutil.ReadFile(file)
+	if err != nil {
+		return err
+	}
+
+	var metadata map[string]interface{}
+	meta, err := ioutil.ReadFile(strings.TrimSuffix(file, filepath.Ext(file)) + ".meta.json")
+	switch {
+	case err == nil:
+		if err = json.Unmarshal(meta, &metadata); err != nil {
+			return fmt.Errorf("invalid metadata: %v", err)
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	if err = m.VerifySample(body, metadata); err != nil {
+		return err
+	}
+
+	if metadata == nil {
+		metadata = Metadata{}
+	}
+
+	message, err := decodeContents(AsynchronousMessage{Contents: body, Metadata: metadata}, m.rootBuilder.Type, m.rootBuilder.decoder())
+	if err != nil {
+		return err
+	}
+	if err = handler(message); err != nil {
+		return fmt.Errorf("the handler failed: %v", err)
+	}
+
+	return nil
+}
+
+// The function that will consume the message
+func (m *AsynchronousMessageWithContents) ConsumedBy(handler AsynchronousConsumer) *AsynchronousMessageWithConsumer {
+	m.rootBuilder.handler = handler
+	m.rootBuilder.contextHandler = nil
+
+	return &AsynchronousMessageWithConsumer{
+		rootBuilder: m.rootBuilder,
+	}
+}
+
+// ConsumedByCtx sets a function that will consume the message, given a context that is
+// cancelled once Config.HandlerTimeout has passed or the context given to VerifyContext is done
+func (m *AsynchronousMessageWithContents) ConsumedByCtx(handler AsynchronousConsumerCtx) *AsynchronousMessageWithConsumer {
+	m.rootBuilder.handler = nil
+	m.rootBuilder.contextHandler = handler
+
+	return &AsynchronousMessageWithConsumer{
+		rootBuilder: m.rootBuilder,
+	}
+}
+
+// consumer returns the handler set by ConsumedBy or ConsumedByCtx, or nil if neither was called
+func (m *AsynchronousMessageBuilder) consumer() AsynchronousConsumerCtx {
+	if m.contextHandler != nil {
+		return m.contextHandler
+	}
+
+	return withContext(m.handler)
+}
+
+type AsynchronousMessageWithConsumer struct {
+	rootBuilder *AsynchronousMessageBuilder
+}
+
+// VerifyIdempotent delivers the same reified message to the handler the given number
+// of times, failing if any delivery returns an error. This validates that consumers
+// of at-least-once delivery systems tolerate duplicate messages.
+func (m *AsynchronousMessageWithContents) VerifyIdempotent(t *testing.T, handler AsynchronousConsumer, times int) error {
+	if times < 2 {
+		m.rootBuilder.pact.logf("WARN", "idempotency verification requires at least two deliveries")
+		times = 2
+	}
+
+	return m.rootBuilder.pact.Verify(t, m.rootBuilder, func(message AsynchronousMessage) error {
+		for i := 1; i <= times; i++ {
+			if err := handler(message); err != nil {
+				return fmt.Errorf("delivery %d of %d failed: %v", i, times, err)
+			}
+		}
+
+		return nil
+	})
+}
+
+// VerifyDLQReplay delivers the message as it is replayed from a dead-letter queue, with
+// an x-death entry (see DeathMetadataKey) in its metadata recording that it was rejected
+// once, failing if the handler returns an error. This validates that consumers which treat
+// redelivered messages differently still process them.
+func (m *AsynchronousMessageWithContents) VerifyDLQReplay(t *testing.T, handler AsynchronousConsumer) error {
+	return m.rootBuilder.pact.Verify(t, m.rootBuilder, func(message AsynchronousMessage) error {
+		if err := handler(deadLettered(message)); err != nil {
+			return fmt.Errorf("the message replayed from a dead-letter queue was not processed: %v", err)
+		}
+
+		return nil
+	})
+}
+
+// VerifyMaxInFlight delivers one more message than the bound declared with WithMaxInFlight
+// to the handler concurrently, failing if any delivery returns an error or if more messages
+// than the bound were in flight at once. The handler must count the processing of each
+// message with the given InFlight, e.g.
+//
+//	VerifyMaxInFlight(t, func(m AsynchronousMessage, inFlight *InFlight) error {
+//		return pool.Submit(func() error {
+//			return inFlight.Track(func() error { return process(m) })
+//		})
+//	})
+func (m *AsynchronousMessageWithContents) VerifyMaxInFlight(t *testing.T, handler func(AsynchronousMessage, *InFlight) error) error {
+	n := m.rootBuilder.maxInFlight
+	if n == 0 && m.rootBuilder.err == nil {
+		m.setErr(fmt.Errorf("no maximum in flight has been declared for message '%s', see WithMaxInFlight", m.rootBuilder.description))
+	}
+
+	return m.rootBuilder.pact.Verify(t, m.rootBuilder, func(message AsynchronousMessage) error {
+		inFlight := &InFlight{}
+		errs := make([]error, n+1)
+
+		var wg sync.WaitGroup
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				errs[i] = handler(message, inFlight)
+			}(i)
+		}
+		wg.Wait()
+
+		for i, err := range errs {
+			if err != nil {
+				return fmt.Errorf("delivery %d of %d failed: %v", i+1, n+1, err)
+			}
+		}
+		if inFlight.Max() == 0 {
+			return fmt.Errorf("the handler did not track the processing of any message")
+		}
+		if inFlight.Max() > n {
+			return fmt.Errorf("the handler processed %d messages concurrently, exceeding the maximum in flight of %d", inFlight.Max(), n)
+		}
+
+		return nil
+	})
+}
+
+// VerifyAcrossVersions reifies the message under each of the given specification versions
+// and delivers it to the handler, failing if the handler returns an error for any of them.
+// This catches serialisation or matching differences when migrating a contract between
+// versions, e.g. from V3 to V4.
+//
+// Each version uses a separate, temporary pact, so no pact files are written; use Verify
+// to record the interaction.
+func (m *AsynchronousMessageWithContents) VerifyAcrossVersions(t *testing.T, versions []models.SpecificationVersion, handler AsynchronousConsumer) error {
+	if m.rootBuilder.err != nil {
+		t.Errorf("VerifyAcrossVersions failed: %v", m.rootBuilder.err)
+		return m.rootBuilder.err
+	}
+
+	var failures []string
+	for _, version := range versions {
+		if err := m.verifyVersion(version, handler); err != nil {
+			t.Errorf("VerifyAcrossVersions failed for specification version %s: %v", version, err)
+			failures = append(failures, fmt.Sprintf("%s: %v", version, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("message '%s' failed verification for %d of %d specification versions: %s", m.rootBuilder.description, len(failures), len(versions), strings.Join(failures, "; "))
+	}
+
+	return nil
+}
+
+// verifyVersion rebuilds the message in a temporary pact of the given specification
+// version, then reifies it and delivers it to the handler
+func (m *AsynchronousMessageWithContents) verifyVersion(version models.SpecificationVersion, handler AsynchronousConsumer) error {
+	b := m.rootBuilder
+	server := mockserver.NewMessageServer(b.pact.config.Consumer, b.pact.config.Provider)
+	defer server.Close()
+
+	switch version {
+	case models.V2:
+		server.WithSpecificationVersion(mockserver.SPECIFICATION_VERSION_V2)
+	case models.V3:
+		server.WithSpecificationVersion(mockserver.SPECIFICATION_VERSION_V3)
+	case models.V4:
+		server.WithSpecificationVersion(mockserver.SPECIFICATION_VERSION_V4)
+	default:
+		return fmt.Errorf("unsupported specification version '%s'", version)
+	}
+
+	message := server.NewMessage()
+	for _, state := range b.states {
+		if state.Parameters != nil {
+			message.GivenWithParameter(state.Name, state.Parameters)
+		} else {
+			message.Given(state.Name)
+		}
+	}
+	message.ExpectsToReceive(b.description)
+
+	applyMetadata(message, b.metadata)
+
+	b.applyContent(message)
+
+	reified, err := getAsynchronousMessageWithReifiedContents(message, b.Type, b.decoder(), b.pact.config.Logger)
+	if err != nil {
+		return err
+	}
+	reified.Metadata = b.handlerMetadata()
+
+	return handler(reified)
+}
+
+// The function that will consume the message
+func (m *AsynchronousMessageWithConsumer) Verify(t *testing.T) error {
+	return m.VerifyContext(context.Background(), t)
+}
+
+// VerifyContext verifies the message like Verify, giving the handler a context derived from ctx
+func (m *AsynchronousMessageWithConsumer) VerifyContext(ctx context.Context, t *testing.T) error {
+	return m.rootBuilder.pact.VerifyContext(ctx, t, m.rootBuilder, m.rootBuilder.consumer())
+}
+
+// AsynchronousPact is a contract for asynchronous messages. Messages may be added to and
+// verified by parallel tests (t.Parallel) sharing the pact, provided each message is
+// verified by a single test. Set Config.DeferPactWrite to write the pact file once, with
+// WritePactFile, rather than after each message.
+//
+// Each pact created with Config.SharedHandle is a handle on the same shared pact.
+type AsynchronousPact struct {
+	*asynchronousPact
+
+	// closed is set once the handle has been closed, guarded by closeMu
+	closed  bool
+	closeMu sync.Mutex
+}
+
+// asynchronousPact is the state of a pact, shared by its handles with Config.SharedHandle
+type asynchronousPact struct {
+	config Config
+
+	// Reference to the native rust handle
+	messageserver *mockserver.MessageServer
+
+	// Messages added to the pact, in order
+	messages []*AsynchronousMessageBuilder
+
+	// Shared content definitions, see DefineSchema
+	schemas map[string]interface{}
+
+	// Content paths excluded from each message, by interaction key
+	ignoredFields map[string][]string
+
+	// Documented content fields of each message, by interaction key, see matchers.Described
+	fieldDescriptions map[string]map[string]string
+
+	// Deprecated content fields of each message, by interaction key, see matchers.Deprecated
+	deprecatedFields map[string]map[string]string
+
+	// Conditionally required metadata of each message, by interaction key
+	metadataConditions map[string][]MetadataCondition
+
+	// Metadata linked to content fields of each message, by interaction key
+	metadataLinks map[string][]MetadataLink
+
+	// Deprecated messages, by interaction key
+	deprecations map[string]Deprecation
+
+	// The messages of each sequence in order, by scenario, see AddMessageSequence
+	sequences map[string][]string
+
+	// Negative examples of each message, by interaction key
+	negativeExamples map[string][][]byte
+
+	// The outcome of each message verification, in order
+	results []VerifyResult
+
+	// The committed contract the pact must conform to, see FreezeFrom
+	frozen     *pactfile.Pact
+	frozenFile string
+
+	// written is set once the pact file has been written, see Config.PactFileWriteMode
+	written bool
+
+	// unwritten is set when a message has been verified since the pact file was written
+	unwritten bool
+
+	// Pact file metadata not yet sent to the native core, see recordMetadata
+	pendingMetadata map[string]bool
+
+	// sharedKey identifies the pact among those shared with Config.SharedHandle
+	sharedKey string
+
+	// mu guards the state of the pact and its native handle, so that messages may be
+	// built and verified by parallel tests
+	mu sync.Mutex
+}
+
+func NewAsynchronousPact(config Config) (*AsynchronousPact, error) {
+	if config.SharedHandle {
+		return newSharedAsynchronousPact(config)
+	}
+
+	return newAsynchronousPact(config)
+}
+
+func newAsynchronousPact(config Config) (*AsynchronousPact, error) {
+	provider := &AsynchronousPact{
+		asynchronousPact: &asynchronousPact{config: config},
+	}
+	err := provider.validateConfig()
+
+	if err != nil {
+		return nil, err
+	}
+
+	native.Init(string(logging.LogLevel()))
+
+	return provider, err
+}
+
+// Close releases the native resources held by the pact, after running the AfterSuite hook.
+// Other pacts in the same process are unaffected. The pact must not be used once closed.
+//
+// A pact shared with Config.SharedHandle is only closed, and its pact file written, once
+// every handle on it has been closed. Closing a pact, or a handle, again has no effect.
+func (p *AsynchronousPact) Close() error {
+	if !p.markClosed() {
+		return nil
+	}
+	if p.sharedKey != "" && !releaseSharedPact(p) {
+		native.Shutdown()
+		return nil
+	}
+	defer native.Shutdown()
+
+	var writeErr error
+	if p.sharedKey != "" {
+		p.mu.Lock()
+		unwritten := p.unwritten
+		p.mu.Unlock()
+		if unwritten {
+			writeErr = p.WritePactFile()
+		}
+	}
+
+	var hookErr error
+	if p.config.AfterSuite != nil {
+		if hookErr = p.config.AfterSuite(); hookErr != nil {
+			hookErr = fmt.Errorf("the AfterSuite hook failed: %v", hookErr)
+		}
+	}
+	if err := p.messageserver.Close(); err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+
+	return hookErr
+}
+
+// markClosed marks the pact closed, returning false if it was already closed
+func (p *AsynchronousPact) markClosed() bool {
+	p.closeMu.Lock()
+	defer p.closeMu.Unlock()
+
+	if p.closed {
+		return false
+	}
+	p.closed = true
+
+	return true
+}
+
+// validateConfig validates the configuration for the consumer test
+func (p *AsynchronousPact) validateConfig() error {
+	if err := p.config.applyLogLevel(); err != nil {
+		return err
+	}
+	p.logf("DEBUG", "pact message validate config")
+	dir, _ := os.Getwd()
+
+	if p.config.PactDir == "" {
+		p.config.PactDir = filepath.Join(dir, "pacts")
+	}
+
+	if err := validateOutputFormat(p.config.OutputFormat); err != nil {
+		return err
+	}
+	if err := validateDedupStrategy(p.config.DedupStrategy); err != nil {
+		return err
+	}
+	if err := validateWriteMode(p.config.PactFileWriteMode); err != nil {
+		return err
+	}
+
+	p.messageserver = mockserver.NewMessageServer(p.config.Consumer, p.config.Provider)
+	switch p.config.PactSpecification {
+	case "", models.V4:
+		p.messageserver.WithSpecificationVersion(mockserver.SPECIFICATION_VERSION_V4)
+	case models.V3:
+		p.messageserver.WithSpecificationVersion(mockserver.SPECIFICATION_VERSION_V3)
+	default:
+		return fmt.Errorf("unsupported pact specification '%s' for asynchronous messages, must be %s or %s", p.config.PactSpecification, models.V3, models.V4)
+	}
+	if p.config.Environment != "" {
+		p.messageserver.WithMetadata(models.MetadataNamespace, models.EnvironmentMetadataKey, p.config.Environment)
+	}
+
+	return nil
+}
+
+// AddMessage creates a new asynchronous consumer expectation
+// Deprecated: use AddAsynchronousMessage() instead
+func (p *AsynchronousPact) AddMessage() *AsynchronousMessageBuilder {
+	return p.AddAsynchronousMessage()
+}
+
+// AddMessage creates a new asynchronous consumer expectation
+func (p *AsynchronousPact) AddAsynchronousMessage() *AsynchronousMessageBuilder {
+	p.logf("DEBUG", "add message")
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	message := p.messageserver.NewMessage()
+
+	builder := &AsynchronousMessageBuilder{
+		messageHandle: message,
+		pact:          p,
+	}
+	p.messages = append(p.messages, builder)
+
+	return builder
+}
+
+// addedMessages returns the messages added to the pact so far, which may be added to
+// concurrently
+func (p *AsynchronousPact) addedMessages() []*AsynchronousMessageBuilder {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]*AsynchronousMessageBuilder(nil), p.messages...)
+}
+
+// MessageDefinition is the file representation of an asynchronous message, as
+// loaded by AddMessageFromFile and AddMessageFromFS. Contents may contain matchers
+// in their JSON form, e.g. {"pact:matcher:type": "type", "value": "billy"}
+type MessageDefinition struct {
+	Description    string                 `json:"description"`
+	ProviderStates []models.ProviderState `json:"providerStates,omitempty"`
+	Metadata       map[string]string      `json:"metadata,omitempty"`
+	Contents       json.RawMessage        `json:"contents"`
+}
+
+// AddMessageFromFile adds a message defined in a JSON file, see MessageDefinition
+func (p *AsynchronousPact) AddMessageFromFile(path string) (*AsynchronousMessageWithContents, error) {
+	return p.AddMessageFromFS(os.DirFS(filepath.Dir(path)), filepath.Base(path))
+}
+
+// AddMessageFromFS adds a message defined in a JSON file within fsys, such as an
+// embed.FS, see MessageDefinition
+func (p *AsynchronousPact) AddMessageFromFS(fsys fs.FS, path string) (*AsynchronousMessageWithContents, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read message definition: %v", err)
+	}
+
+	var definition MessageDefinition
+	if err = json.Unmarshal(data, &definition); err != nil {
+		return nil, fmt.Errorf("unable to parse message definition %s: %v", path, err)
+	}
+
+	return p.addMessageDefinition(definition, path)
+}
+
+// AddMessageTable adds one message per row of a data table, substituting the row values
+// into ${name} placeholders in the description, provider states, metadata and contents
+// of the base definition. A string in the contents consisting of only a placeholder is
+// replaced by the value itself, so numbers and objects keep their type, e.g.
+//
+//	p.AddMessageTable(MessageDefinition{
+//		Description: "a ${currency} payment",
+//		Contents:    json.RawMessage(`{"amount": "${amount}", "currency": "${currency}"}`),
+//	}, []map[string]interface{}{
+//		{"currency": "AUD", "amount": 10},
+//		{"currency": "EUR", "amount": 5.5},
+//	})
+//
+// If the description has no placeholders the row number is appended to it, so that
+// the interactions remain distinct.
+func (p *AsynchronousPact) AddMessageTable(base MessageDefinition, rows []map[string]interface{}) ([]*AsynchronousMessageWithContents, error) {
+	var template interface{}
+	if err := json.Unmarshal(base.Contents, &template); err != nil {
+		return nil, fmt.Errorf("unable to parse the message table contents: %v", err)
+	}
+
+	messages := make([]*AsynchronousMessageWithContents, 0, len(rows))
+	for i, row := range rows {
+		source := fmt.Sprintf("table row %d", i+1)
+		definition := MessageDefinition{}
+
+		var err error
+		if definition.Description, err = substituteString(base.Description, row); err != nil {
+			return messages, fmt.Errorf("%s: %v", source, err)
+		}
+		if definition.Description == base.Description {
+			definition.Description = fmt.Sprintf("%s (%d)", base.Description, i+1)
+		}
+
+		for _, state := range base.ProviderStates {
+			name, err := substituteString(state.Name, row)
+			if err != nil {
+				return messages, fmt.Errorf("%s: %v", source, err)
+			}
+			params, err := substitute(state.Parameters, row)
+			if err != nil {
+				return messages, fmt.Errorf("%s: %v", source, err)
+			}
+			parameters, _ := params.(map[string]interface{})
+			definition.ProviderStates = append(definition.ProviderStates, models.ProviderState{Name: name, Parameters: parameters})
+		}
+
+		if len(base.Metadata) > 0 {
+			definition.Metadata = make(map[string]string, len(base.Metadata))
+			for k, v := range base.Metadata {
+				if definition.Metadata[k], err = substituteString(v, row); err != nil {
+					return messages, fmt.Errorf("%s: %v", source, err)
+				}
+			}
+		}
+
+		contents, err := substitute(template, row)
+		if err != nil {
+			return messages, fmt.Errorf("%s: %v", source, err)
+		}
+		if definition.Contents, err = json.Marshal(contents); err != nil {
+			return messages, fmt.Errorf("%s: %v", source, err)
+		}
+
+		message, err := p.addMessageDefinition(definition, source)
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+func (p *AsynchronousPact) addMessageDefinition(definition MessageDefinition, source string) (*AsynchronousMessageWithContents, error) {
+	if definition.Description == "" {
+		return nil, fmt.Errorf("message definition %s must have a description", source)
+	}
+	if len(definition.Contents) == 0 {
+		return nil, fmt.Errorf("message definition %s must have contents", source)
+	}
+
+	message := p.AddAsynchronousMessage()
+	for _, state := range definition.ProviderStates {
+		message.GivenWithParameter(state)
+	}
+
+	builder := message.ExpectsToReceive(definition.Description)
+	if len(definition.Metadata) > 0 {
+		builder.WithMetadata(definition.Metadata)
+	}
+
+	return builder.WithJSONContent(definition.Contents), message.err
+}
+
+// DefineSchema registers a named content definition (e.g. a StructMatcher for an Address)
+// that may be used as the content of any message in the pact with WithSchemaRef.
+// Schemas are plain values, so they may also be composed into larger content trees.
+func (p *AsynchronousPact) DefineSchema(name string, schema interface{}) *AsynchronousPact {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.schemas == nil {
+		p.schemas = make(map[string]interface{})
+	}
+	p.schemas[name] = schema
+
+	return p
+}
+
+// Schema returns a schema registered with DefineSchema, for use within other content
+func (p *AsynchronousPact) Schema(name string) (interface{}, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	schema, ok := p.schemas[name]
+
+	return schema, ok
+}
+
+var unsafeFilenameChars = regexp.MustCompile(`[/\\:*?"<>|]`)
+
+// updatePactFlag is the test flag that makes VerifySnapshots overwrite the snapshots
+const updatePactFlag = "update-pact"
+
+// The -update-pact flag is only registered if no package initialised earlier has defined
+// it, and is looked up by name when it is read, so that a flag of the same name is shared
+// rather than redefined
+func init() {
+	if flag.Lookup(updatePactFlag) == nil {
+		flag.Bool(updatePactFlag, false, "overwrite the message snapshots checked by VerifySnapshots")
+	}
+}
+
+// updateSnapshots reports whether VerifySnapshots should overwrite the snapshots, when the
+// tests are run with -update-pact, or PACT_UPDATE_SNAPSHOTS is set to true
+func updateSnapshots() bool {
+	if f := flag.Lookup(updatePactFlag); f != nil {
+		if update, _ := strconv.ParseBool(f.Value.String()); update {
+			return true
+		}
+	}
+	update, _ := strconv.ParseBool(os.Getenv("PACT_UPDATE_SNAPSHOTS"))
+
+	return update
+}
+
+// ExportExamples writes the reified contents of each message in the pact to
+// dir/<description>.json, along with its metadata to dir/<description>.meta.json
+func (p *AsynchronousPact) ExportExamples(dir string) error {
+	p.logf("DEBUG", "exporting message examples to %s", dir)
+
+	examples, err := p.examples()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return fmt.Errorf("unable to create the examples directory: %v", err)
+	}
+
+	for _, name := range sortedKeys(examples) {
+		if err = ioutil.WriteFile(filepath.Join(dir, name), examples[name], 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// VerifySnapshots checks the example contents and metadata of each message in the pact
+// (as written by ExportExamples) against the snapshots committed in dir. Running the
+// tests with the -update-pact flag overwrites the snapshots instead, so changes to the
+// generated content can be reviewed and accepted deliberately, e.g.
+//
+//	go test ./consumer -update-pact
+//
+// The flag is only defined in test binaries importing this package, so go test ./... fails
+// with it in packages that don't. Set PACT_UPDATE_SNAPSHOTS=true to update the snapshots
+// of every package instead:
+//
+//	PACT_UPDATE_SNAPSHOTS=true go test ./...
+func (p *AsynchronousPact) VerifySnapshots(t *testing.T, dir string) error {
+	if updateSnapshots() {
+		p.logf("INFO", "updating message snapshots in %s", dir)
+		err := p.ExportExamples(dir)
+		if err != nil {
+			t.Errorf("VerifySnapshots failed: %v", err)
+		}
+		return err
+	}
+
+	examples, err := p.examples()
+	if err != nil {
+		t.Errorf("VerifySnapshots failed: %v", err)
+		return err
+	}
+
+	var failures []string
+	for _, name := range sortedKeys(examples) {
+		snapshot, err := ioutil.ReadFile(filepath.Join(dir, name))
+		switch {
+		case os.IsNotExist(err):
+			failures = append(failures, fmt.Sprintf("%s: no snapshot has been recorded", name))
+		case err != nil:
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+		case !bytes.Equal(bytes.TrimSpace(snapshot), bytes.TrimSpace(examples[name])):
+			failures = append(failures, fmt.Sprintf("%s: expected %s but generated %s", name, bytes.TrimSpace(snapshot), bytes.TrimSpace(examples[name])))
+		}
+	}
+
+	if len(failures) > 0 {
+		err = fmt.Errorf("the messages differ from their snapshots in %s, run the tests with -update-pact to accept the changes:\n%s", dir, strings.Join(failures, "\n"))
+		t.Errorf("VerifySnapshots failed: %v", err)
+		return err
+	}
+
+	return nil
+}
+
+// examples returns the example contents and metadata files of each message, by file name
+func (p *AsynchronousPact) examples() (map[string][]byte, error) {
+	examples := make(map[string][]byte)
+	for _, message := range p.addedMessages() {
+		if message.description == "" {
+			p.logf("WARN", "skipping export of a message without a description")
+			continue
+		}
+
+		name := unsafeFilenameChars.ReplaceAllString(message.description, "_")
+		if _, ok := examples[name+".json"]; ok {
+			return nil, fmt.Errorf("more than one message would be exported to '%s.json', message descriptions must be unique", name)
+		}
+
+		m, err := getAsynchronousMessageWithContents(message.messageHandle)
+		if err != nil {
+			return nil, fmt.Errorf("unable to reify the contents of message '%s': %v", message.description, err)
+		}
+
+		meta, err := json.MarshalIndent(message.exampleMetadata(), "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("unable to serialise the metadata of message '%s': %v", message.description, err)
+		}
+
+		examples[name+".json"] = m.Contents
+		examples[name+".meta.json"] = meta
+	}
+
+	return examples, nil
+}
+
+// interactionKey identifies the message in the pact file metadata recorded for each message,
+// e.g. IgnoredFieldsMetadataKey. Messages with different provider states may share a
+// description, so the names of the states, if any, follow it, like pactfile.Interaction.Key
+func (m *AsynchronousMessageBuilder) interactionKey() string {
+	if len(m.states) == 0 {
+		return m.description
+	}
+	states := make([]string, len(m.states))
+	for i, state := range m.states {
+		states[i] = state.Name
+	}
+
+	return fmt.Sprintf("%s|%s", m.description, strings.Join(states, ","))
+}
+
+// exampleMetadata returns the metadata of the message, with matchers replaced by their examples
+func (m *AsynchronousMessageBuilder) exampleMetadata() map[string]interface{} {
+	metadata := make(map[string]interface{}, len(m.metadata))
+	for k, v := range m.metadata {
+		if matcher, ok := v.(matchers.Matcher); ok {
+			example, err := matchers.Example(matcher)
+			if err != nil {
+				m.pact.logf("WARN", "unable to compute the example of metadata '%s': %v", k, err)
+				example = matcher.GetValue()
+			}
+			v = example
+		}
+		metadata[k] = v
+	}
+
+	return metadata
+}
+
+// handlerMetadata is the metadata given to the handler: the examples of the metadata
+// expectations, along with the content type of the message if not given as metadata
+func (m *AsynchronousMessageBuilder) handlerMetadata() Metadata {
+	metadata := Metadata(m.exampleMetadata())
+	for _, key := range contentTypeKeys {
+		if _, ok := metadata[key]; ok {
+			return metadata
+		}
+	}
+	if m.contentType != "" {
+		metadata["contentType"] = m.contentType
+	}
+
+	return metadata
+}
+
+// reify returns the message to give to the handler, with its contents as reified by the
+// native core and its metadata
+func (m *AsynchronousMessageBuilder) reify() (AsynchronousMessage, error) {
+	message, err := getAsynchronousMessageWithReifiedContents(m.messageHandle, m.Type, m.decoder(), m.pact.config.Logger)
+	message.Metadata = m.handlerMetadata()
+
+	return message, err
+}
+
+func sortedKeys(m map[string][]byte) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+// VerifyMessageConsumerRaw creates a new Pact _message_ interaction to build a testable
+// interaction.
+//
+// A Message Consumer is analagous to a Provider in the HTTP Interaction model.
+// It is the receiver of an interaction, and needs to be able to handle whatever
+// request was provided.
+func (p *AsynchronousPact) verifyMessageConsumerRaw(messageToVerify *AsynchronousMessageBuilder, handler AsynchronousConsumer) error {
+	return p.verifyMessageConsumerContext(context.Background(), messageToVerify, withContext(handler))
+}
+
+// verifyMessageConsumerContext is verifyMessageConsumerRaw for a handler given a context
+// derived from ctx, bounded by Config.HandlerTimeout
+func (p *AsynchronousPact) verifyMessageConsumerContext(ctx context.Context, messageToVerify *AsynchronousMessageBuilder, handler AsynchronousConsumerCtx) error {
+	return p.verifyMessage(ctx, messageToVerify, handler, !p.config.DeferPactWrite)
+}
+
+// verifyMessage verifies the message with the handler, writing the pact file if write is set
+func (p *AsynchronousPact) verifyMessage(ctx context.Context, messageToVerify *AsynchronousMessageBuilder, handler AsynchronousConsumerCtx, write bool) (err error) {
+	p.logf("DEBUG", "verify message")
+
+	start := time.Now()
+	defer func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+
+		p.results = append(p.results, VerifyResult{
+			Description: messageToVerify.description,
+			Duration:    time.Since(start),
+			Error:       err,
+		})
+	}()
+
+	if !atomic.CompareAndSwapInt32(&messageToVerify.verifying, 0, 1) {
+		return fmt.Errorf("message '%s' is already being verified, each message may only be verified by one test at a time", messageToVerify.description)
+	}
+	defer atomic.StoreInt32(&messageToVerify.verifying, 0)
+
+	if err = messageToVerify.buildErr(); err != nil {
+		return err
+	}
+	if handler == nil {
+		return fmt.Errorf("no handler given for message '%s'", messageToVerify.description)
+	}
+	if messageToVerify.deprecation != nil {
+		if sunset := messageToVerify.deprecation.sunsetError(messageToVerify.description, time.Now()); sunset != nil {
+			if p.config.FailOnSunset {
+				return sunset
+			}
+			p.logf("WARN", "%v", sunset)
+		}
+	}
+
+	ctx, span := startSpan(ctx, p.config.TracerProvider, "pact.verify "+messageToVerify.description)
+	defer span.End()
+
+	_, reifySpan := startSpan(ctx, p.config.TracerProvider, "pact.reify")
+	p.mu.Lock()
+	m, err := messageToVerify.reify()
+	p.mu.Unlock()
+	endSpan(reifySpan, err)
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	if mismatches := messageToVerify.linkMismatches(m.Contents, m.Metadata); len(mismatches) > 0 {
+		descriptions := make([]string, len(mismatches))
+		for i, mismatch := range mismatches {
+			descriptions[i] = mismatch.String()
+		}
+		err = fmt.Errorf("the message metadata does not mirror its content: %s", strings.Join(descriptions, "; "))
+		span.RecordError(err)
+		return err
+	}
+
+	if messageToVerify.schemaSubject != "" {
+		registry := schemaregistry.Client{URL: p.config.SchemaRegistryURL}
+		if err = registry.Validate(messageToVerify.schemaSubject, m.Contents); err != nil {
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	// Yield message, and send through handler function
+	handlerCtx, handlerSpan := startSpan(ctx, p.config.TracerProvider, "pact.handler")
+	err = p.config.withHooks(messageToVerify.description, func() error {
+		if messageToVerify.maxAllocs == 0 {
+			return callHandler(handlerCtx, p.config.HandlerTimeout, handler, m)
+		}
+
+		allocs, err := measureAllocs(func() error { return callHandler(handlerCtx, p.config.HandlerTimeout, handler, m) })
+		if err == nil && allocs > messageToVerify.maxAllocs {
+			err = fmt.Errorf("message handler made %d allocations, exceeding the budget of %d", allocs, messageToVerify.maxAllocs)
+		}
+		return err
+	})
+	endSpan(handlerSpan, err)
+
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	for i, body := range messageToVerify.negativeExamples {
+		if err = verifyNegativeExample(ctx, p.config.HandlerTimeout, messageToVerify, handler, body); err != nil {
+			err = fmt.Errorf("negative example %d: %v", i, err)
+			span.RecordError(err)
+			return err
+		}
+	}
+
+	if !write {
+		p.mu.Lock()
+		p.unwritten = true
+		p.mu.Unlock()
+		return nil
+	}
+
+	_, writeSpan := startSpan(ctx, p.config.TracerProvider, "pact.write")
+	err = p.writePact(p.messageserver.WritePactFile)
+	endSpan(writeSpan, err)
+	if err != nil {
+		span.RecordError(err)
+	}
+
+	return err
+}
+
+// verifyNegativeExample checks the handler rejects a malformed payload
+func verifyNegativeExample(ctx context.Context, timeout time.Duration, message *AsynchronousMessageBuilder, handler AsynchronousConsumerCtx, body []byte) error {
+	m, err := decodeContents(AsynchronousMessage{Contents: body, Metadata: message.handlerMetadata()}, message.Type, message.decoder())
+	if err != nil {
+		message.pact.logf("DEBUG", "negative example rejected when decoding: %v", err)
+		return nil
+	}
+
+	if err = callHandler(ctx, timeout, handler, m); err == nil {
+		return fmt.Errorf("the handler accepted a payload it should have rejected: %s", body)
+	}
+	message.pact.logf("DEBUG", "negative example rejected by the handler: %v", err)
+
+	return nil
+}
+
+// GenerateMessages produces count examples of the content of a message, applying the
+// generators of its matchers (e.g. RandomInt or Uuid) to vary each example, for use as
+// synthetic traffic in a load test. The message must have JSON content.
+func (p *AsynchronousPact) GenerateMessages(message *AsynchronousMessageWithContents, count int) ([][]byte, error) {
+	if message.rootBuilder.err != nil {
+		return nil, message.rootBuilder.err
+	}
+	if _, ok := message.rootBuilder.content.([]byte); ok {
+		return nil, fmt.Errorf("messages can only be generated for JSON content")
+	}
+
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	messages := make([][]byte, 0, count)
+	for i := 0; i < count; i++ {
+		v, err := matchers.Generate(message.rootBuilder.content, r)
+		if err != nil {
+			return messages, err
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return messages, err
+		}
+		messages = append(messages, b)
+	}
+
+	return messages, nil
+}
+
+// GenerateViolations produces, for each matcher in the content of the message, a payload
+// violating just that matcher, see matchers.Violations. Delivering them to the consumer
+// handler, e.g. with WithNegativeExample, checks it rejects each category of bad input.
+// The message must have JSON content.
+func (m *AsynchronousMessageWithContents) GenerateViolations() ([][]byte, error) {
+	if m.rootBuilder.err != nil {
+		return nil, m.rootBuilder.err
+	}
+	if _, ok := m.rootBuilder.content.([]byte); ok {
+		return nil, fmt.Errorf("violations can only be generated for JSON content")
+	}
+
+	violations, err := matchers.Violations(m.rootBuilder.content)
+	if err != nil {
+		return nil, err
+	}
+
+	payloads := make([][]byte, len(violations))
+	for i, v := range violations {
+		if payloads[i], err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return payloads, nil
+}
+
+// FreezeFrom makes a committed contract the source of truth for the pact. Messages are
+// verified as usual, but the pact file is never written; instead verification fails with
+// a *FrozenContractError describing the differences if the generated interactions don't
+// match those in the frozen contract.
+func (p *AsynchronousPact) FreezeFrom(pactFile string) error {
+	frozen, err := pactfile.Read(pactFile)
+	if err != nil {
+		return err
+	}
+
+	p.frozen = frozen
+	p.frozenFile = pactFile
+
+	return nil
+}
+
+// WritePactFile writes the pact file with the messages verified so far, or checks it against
+// the frozen contract. It is needed only with Config.DeferPactWrite, e.g. from TestMain or the
+// t.Cleanup of a test whose parallel subtests verify the messages.
+func (p *AsynchronousPact) WritePactFile() error {
+	return p.writePact(p.messageserver.WritePactFile)
+}
+
+// writePact writes the pact file, or checks it against the frozen contract
+func (p *AsynchronousPact) writePact(write pactFileWriter) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.flushMetadata()
+	if p.frozen != nil {
+		return checkFrozen(write, p.frozenFile, p.frozen, p.config.Logger)
+	}
+
+	err := writePact(write, p.config, !p.written)
+	if err == nil {
+		p.written = true
+		p.unwritten = false
+	}
+
+	return err
+}
+
+// WriteDelta writes a pact file holding only the interactions that were added or changed
+// since previousPactFile to outputFile, listing the interactions removed since in its
+// metadata (see pactfile.Delta), so that large pacts can be published incrementally.
+// The pact directory is not written.
+func (p *AsynchronousPact) WriteDelta(previousPactFile, outputFile string) error {
+	p.mu.Lock()
+	p.flushMetadata()
+	p.mu.Unlock()
+
+	return writeDelta(p.messageserver.WritePactFile, previousPactFile, outputFile, p.config.Logger)
+}
+
+// Results returns the outcome of each message verified so far, in order
+func (p *AsynchronousPact) Results() []VerifyResult {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return append([]VerifyResult(nil), p.results...)
+}
+
+// WriteJUnitReport writes the results of the messages verified so far as a JUnit XML
+// report, with one test case per message, for CI dashboards
+func (p *AsynchronousPact) WriteJUnitReport(path string) error {
+	return writeJUnitReport(path, fmt.Sprintf("%s-%s", p.config.Consumer, p.config.Provider), p.Results())
+}
+
+// measureAllocs returns the number of heap allocations made while running f
+func measureAllocs(f func() error) (uint64, error) {
+	var before, after runtime.MemStats
+
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	err := f()
+	runtime.ReadMemStats(&after)
+
+	return after.Mallocs - before.Mallocs, err
+}
+
+// logf logs through Config.Logger, see logging.Logf
+func (p *AsynchronousPact) logf(level, format string, args ...interface{}) {
+	logging.Logf(p.config.Logger, level, format, args...)
+}
+
+// VerifyMessageConsumer is a test convience function for VerifyMessageConsumerRaw,
+// accepting an instance of `*testing.T`
+func (p *AsynchronousPact) Verify(t *testing.T, message *AsynchronousMessageBuilder, handler AsynchronousConsumer) error {
+	return p.VerifyContext(context.Background(), t, message, withContext(handler))
+}
+
+// VerifyContext is Verify for a handler given a context derived from ctx, bounded by
+// Config.HandlerTimeout
+func (p *AsynchronousPact) VerifyContext(ctx context.Context, t *testing.T, message *AsynchronousMessageBuilder, handler AsynchronousConsumerCtx) error {
+	err := p.verifyMessageConsumerContext(ctx, message, handler)
+
+	if err != nil {
+		t.Errorf("VerifyMessageConsumer failed: %v", err)
+	}
+
+	return err
+}
+
+// BatchVerification is a message and the handler to verify it with, see VerifyBatch
+type BatchVerification struct {
+	Msg     *AsynchronousMessageBuilder
+	Handler AsynchronousConsumer
+}
+
+// VerifyBatch verifies each message with its handler, continuing past failed verifications,
+// and returns the result of every verification in order, e.g. for a report of which messages
+// passed. As with Verify, each failure also fails the test.
+func (p *AsynchronousPact) VerifyBatch(t *testing.T, pairs []BatchVerification) []VerifyResult {
+	results := make([]VerifyResult, 0, len(pairs))
+	for i, pair := range pairs {
+		if pair.Msg == nil {
+			err := fmt.Errorf("no message given for verification %d of the batch", i)
+			t.Errorf("VerifyBatch failed: %v", err)
+			results = append(results, VerifyResult{Error: err})
+			continue
+		}
+
+		recorded := len(p.Results())
+		err := p.Verify(t, pair.Msg, pair.Handler)
+		result := VerifyResult{Description: pair.Msg.description, Error: err}
+
+		// Messages verified by parallel tests may be recorded in between
+		all := p.Results()
+		for j := len(all) - 1; j >= recorded; j-- {
+			if all[j].Description == pair.Msg.description {
+				result = all[j]
+				break
+			}
+		}
+		results = append(results, result)
+	}
+
+	return results
+}
+
+// VerifyAll verifies every message added to the pact with the handler given to its
+// ConsumedBy. Before any message is verified, it fails if a message has no handler,
+// listing the descriptions of the unhandled messages.
+func (p *AsynchronousPact) VerifyAll(t *testing.T) error {
+	messages := p.addedMessages()
+
+	var unhandled []string
+	for _, message := range messages {
+		if message.consumer() == nil {
+			unhandled = append(unhandled, fmt.Sprintf("'%s'", message.description))
+		}
+	}
+	if len(unhandled) > 0 {
+		err := fmt.Errorf("no handler has been registered with ConsumedBy or ConsumedByCtx for %d message(s): %s", len(unhandled), strings.Join(unhandled, ", "))
+		t.Errorf("VerifyAll failed: %v", err)
+		return err
+	}
+
+	var failed int
+	for _, message := range messages {
+		if err := p.VerifyContext(context.Background(), t, message, message.consumer()); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d messages failed verification", failed, len(messages))
+	}
+
+	return nil
+}
+
+func getAsynchronousMessageWithContents(message *native.Message) (AsynchronousMessage, error) {
+	var m AsynchronousMessage
+
+	contents, err := message.GetMessageRequestContents()
+	if err != nil {
+		return m, err
+	}
+
+	return AsynchronousMessage{
+		Contents: contents,
+	}, nil
+}
+
+// contentTypeKeys are the metadata keys that may declare the content type of a message
+var contentTypeKeys = []string{"contentType", "content-type", "Content-Type"}
+
+// declaredContentType is the content type given in the message metadata, or
+// otherwise the type of its content
+func (m *AsynchronousMessageBuilder) declaredContentType() string {
+	for _, key := range contentTypeKeys {
+		if t, ok := m.metadata[key].(string); ok && t != "" {
+			return t
+		}
+	}
+
+	return m.contentType
+}
+
+// decoder returns the codec registered for the message's declared content type, if any.
+// The decoder fails if the content doesn't decode, so a message declared as e.g.
+// application/x-protobuf but containing JSON is rejected.
+func (m *AsynchronousMessageBuilder) decoder() codecs.Decoder {
+	contentType := m.declaredContentType()
+	d, ok := codecs.Lookup(m.pact.config.Codecs, contentType)
+	if !ok {
+		return nil
+	}
+
+	return func(body []byte) (interface{}, error) {
+		v, err := d(body)
+		if err != nil {
+			return nil, fmt.Errorf("the content does not decode as %s, the content type declared for the message: %v", contentType, err)
+		}
+
+		return v, nil
+	}
+}
+
+// getAsynchronousMessageWithReifiedContents sets the Body of the message by decoding its
+// contents with the given decoder if not nil, otherwise by unmarshalling the JSON contents
+// into the reified type
+func getAsynchronousMessageWithReifiedContents(message *mockserver.Message, reifiedType interface{}, decode codecs.Decoder, logger logging.Logger) (AsynchronousMessage, error) {
+	var m AsynchronousMessage
+	var err error
+
+	m, err = getAsynchronousMessageWithContents(message)
+	if err != nil {
+		return m, fmt.Errorf("unexpected response from message server, this is a bug in the framework: %v", err)
+	}
+	logging.Logf(logger, "DEBUG", "reified body raw %s", m.Contents)
+
+	// // 1. Strip out the matchers
 	// // Reify the message back to its "example/generated" form
 	// body, err := message.GetMessageRequestContents()
 	// if err != nil {
 	// 	return m, fmt.Errorf("unexpected response from message server, this is a bug in the framework: %v", err)
 	// }
 
-	log.Println("[DEBUG] unmarshalled into an AsynchronousMessage", m)
+	logging.Logf(logger, "DEBUG", "unmarshalled into an AsynchronousMessage %v", m)
+
+	return decodeContents(m, reifiedType, decode)
+}
+
+// decodeContents sets the Body of the message from its Contents, see getAsynchronousMessageWithReifiedContents
+func decodeContents(m AsynchronousMessage, reifiedType interface{}, decode codecs.Decoder) (AsynchronousMessage, error) {
+	var err error
+
+	if decode != nil {
+		m.Body, err = decode(m.Contents)
+		if err != nil {
+			return m, fmt.Errorf("unable to decode message contents: %v", err)
+		}
+
+		return m, nil
+	}
 
 	// 2. Convert to an actual type (to avoid wrapping if needed/requested)
 	t := reflect.TypeOf(reifiedType)
-	if t != nil && t.Name() != "interface" {
-		err = json.Unmarshal(m.Contents, &reifiedType)
+	if t != nil {
+		value := reflect.New(t)
+		err = json.Unmarshal(m.Contents, value.Interface())
 
 		if err != nil {
-			return m, fmt.Errorf("unable to narrow type to %v: %v", t.Name(), err)
+			return m, fmt.Errorf("unable to narrow type to %v: %v", t, err)
 		}
 
-		m.Body = reifiedType
+		m.Body = value.Elem().Interface()
 	}
 
 	return m, err
@@ -1,10 +1,26 @@
 package v4
 
 import (
+	"context"
+	"encoding/json"
+	"flag"
 	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
+	"testing/fstest"
+	"time"
 
+	"github.com/pact-foundation/pact-go/v2/codecs"
 	"github.com/pact-foundation/pact-go/v2/log"
+	"github.com/pact-foundation/pact-go/v2/matchers"
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/pact-foundation/pact-go/v2/pactfile"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -75,3 +91,1596 @@ func TestAsyncTypeSystem(t *testing.T) {
 		})
 	assert.NoError(t, err)
 }
+
+func TestAsyncUsingPlugins(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  t.TempDir(),
+	})
+
+	called := false
+	err := p.AddAsynchronousMessage().
+		ExpectsToReceive("a message of plugins that aren't installed").
+		UsingPlugin(PluginConfig{Plugin: "not-a-plugin", Version: "0.0.1"}).
+		UsingPlugin(PluginConfig{Plugin: "nor-a-plugin", Version: "0.0.2"}).
+		WithContentsFromPlugin("application/protobuf", map[string]interface{}{"pact:proto": "plugin.proto"}).
+		ExecuteTest(t, func(m AsynchronousMessage) error {
+			called = true
+			return nil
+		})
+
+	assert.ErrorContains(t, err, "unable to load plugin not-a-plugin 0.0.1")
+	assert.False(t, called)
+}
+
+func TestAsyncVerifySample(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a sampled json message").
+		WithMetadata(map[string]string{
+			"contentType": "application/json",
+		}).
+		WithJSONContent(map[string]interface{}{
+			"id":   matchers.Integer(1),
+			"name": matchers.Like("billy"),
+		})
+
+	err := message.VerifySample([]byte(`{"id": 27, "name": "sally"}`), map[string]interface{}{"contentType": "application/json"})
+	assert.NoError(t, err)
+
+	err = message.VerifySample([]byte(`{"id": "27"}`), nil)
+	assert.Error(t, err)
+	assert.Len(t, err.(*SampleMismatchError).Mismatches, 2)
+}
+
+func TestAsyncExportExamples(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	p.AddAsynchronousMessage().
+		ExpectsToReceive("a user/created event").
+		WithMetadata(map[string]string{
+			"contentType": "application/json",
+		}).
+		WithJSONContent(map[string]interface{}{
+			"id": matchers.Integer(1),
+		})
+
+	dir, err := ioutil.TempDir("", "examples")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = p.ExportExamples(dir)
+	assert.NoError(t, err)
+
+	body, err := ioutil.ReadFile(filepath.Join(dir, "a user_created event.json"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"id": 1}`, string(body))
+
+	meta, err := ioutil.ReadFile(filepath.Join(dir, "a user_created event.meta.json"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"contentType": "application/json"}`, string(meta))
+}
+
+func TestAsyncVerifySnapshots(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	p.AddAsynchronousMessage().
+		ExpectsToReceive("a snapshotted event").
+		WithJSONContent(map[string]interface{}{
+			"id": matchers.Integer(1),
+		})
+
+	dir, err := ioutil.TempDir("", "snapshots")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	err = p.VerifySnapshots(new(testing.T), dir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "no snapshot has been recorded")
+
+	t.Run("updating the snapshots with -update-pact", func(t *testing.T) {
+		assert.NoError(t, flag.Set("update-pact", "true"))
+		defer flag.Set("update-pact", "false")
+		assert.NoError(t, p.VerifySnapshots(t, dir))
+	})
+	assert.NoError(t, p.VerifySnapshots(t, dir))
+
+	err = ioutil.WriteFile(filepath.Join(dir, "a snapshotted event.json"), []byte(`{"id":2}`), 0644)
+	assert.NoError(t, err)
+	t.Run("updating the snapshots with PACT_UPDATE_SNAPSHOTS", func(t *testing.T) {
+		t.Setenv("PACT_UPDATE_SNAPSHOTS", "true")
+		assert.NoError(t, p.VerifySnapshots(t, dir))
+	})
+	assert.NoError(t, p.VerifySnapshots(t, dir))
+
+	err = ioutil.WriteFile(filepath.Join(dir, "a snapshotted event.json"), []byte(`{"id":2}`), 0644)
+	assert.NoError(t, err)
+	err = p.VerifySnapshots(new(testing.T), dir)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "a snapshotted event.json")
+}
+
+func TestAsyncWithRetryPolicy(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage()
+	message.ExpectsToReceive("a retried message").
+		WithRetryPolicy(3, 500*time.Millisecond)
+
+	assert.Equal(t, map[string]interface{}{
+		RetryPolicyMaxAttemptsKey: "3",
+		RetryPolicyBackoffKey:     "500ms",
+	}, message.metadata)
+}
+
+func TestAsyncWithConsumerGroup(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage()
+	message.ExpectsToReceive("a grouped message").
+		WithConsumerGroup("billing")
+
+	assert.Equal(t, "billing", message.metadata[models.ConsumerGroupMetadataKey])
+}
+
+func TestAsyncWithSeverity(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage()
+	message.ExpectsToReceive("an advisory message").
+		WithSeverity("warning")
+	assert.Equal(t, "warning", message.metadata[models.SeverityMetadataKey])
+	assert.NoError(t, message.err)
+
+	invalid := p.AddAsynchronousMessage()
+	invalid.ExpectsToReceive("an invalid severity message").
+		WithSeverity("blocker")
+	assert.Error(t, invalid.err)
+}
+
+func TestAsyncAssertGiven(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		Given("a user exists").
+		GivenWithParameter(models.ProviderState{Name: "an order exists", Parameters: map[string]interface{}{"id": 1}}).
+		ExpectsToReceive("a stateful message").
+		WithJSONContent(map[string]interface{}{"id": 1})
+
+	assert.True(t, message.AssertGiven(t, "a user exists"))
+	assert.True(t, message.AssertGiven(t, "an order exists"))
+
+	mockT := new(testing.T)
+	assert.False(t, message.AssertGiven(mockT, "no state"))
+	assert.True(t, mockT.Failed())
+}
+
+func TestAsyncGivenStates(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		Given("a user exists").
+		GivenStates(
+			models.ProviderState{Name: "an account exists", Parameters: map[string]interface{}{"id": 1}},
+			models.ProviderState{Name: "an account exists", Parameters: map[string]interface{}{"id": 2}},
+		).
+		Given("the account is active")
+
+	assert.Equal(t, []models.ProviderState{
+		{Name: "a user exists"},
+		{Name: "an account exists", Parameters: map[string]interface{}{"id": 1}},
+		{Name: "an account exists", Parameters: map[string]interface{}{"id": 2}},
+		{Name: "the account is active"},
+	}, message.states)
+}
+
+func TestAsyncInvalidMatchers(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage()
+	message.ExpectsToReceive("an invalid message").
+		WithJSONContent(map[string]interface{}{
+			"date": matchers.DateGenerated("01/02/2020", "yyyy-MM-dd"),
+		})
+
+	err := p.verifyMessageConsumerRaw(message, func(AsynchronousMessage) error {
+		t.Fatal("the consumer should not be invoked")
+		return nil
+	})
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "$.date")
+}
+
+func TestMeasureAllocs(t *testing.T) {
+	var sink [][]byte
+
+	allocs, err := measureAllocs(func() error {
+		for i := 0; i < 100; i++ {
+			sink = append(sink, make([]byte, 1024))
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, allocs, uint64(100))
+	assert.Len(t, sink, 100)
+
+	_, err = measureAllocs(func() error {
+		return fmt.Errorf("handler failed")
+	})
+	assert.Error(t, err)
+}
+
+func TestAsyncSchemaRef(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	address := matchers.StructMatcher{
+		"street": matchers.Like("1 Main St"),
+		"city":   matchers.Like("Melbourne"),
+	}
+	p.DefineSchema("Address", address)
+
+	schema, ok := p.Schema("Address")
+	assert.True(t, ok)
+	assert.Equal(t, address, schema)
+
+	message := p.AddAsynchronousMessage()
+	message.ExpectsToReceive("an address changed event").
+		WithSchemaRef("Address")
+	assert.NoError(t, message.err)
+
+	missing := p.AddAsynchronousMessage()
+	missing.ExpectsToReceive("a money event").
+		WithSchemaRef("Money")
+	assert.Error(t, missing.err)
+}
+
+func TestAsyncSchemaRefParallel(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+	p.DefineSchema("Address", matchers.StructMatcher{"city": matchers.Like("Melbourne")})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			p.DefineSchema(fmt.Sprintf("Schema%d", i), matchers.StructMatcher{"id": matchers.Like(i)})
+		}(i)
+		go func(i int) {
+			defer wg.Done()
+			p.AddAsynchronousMessage().
+				ExpectsToReceive(fmt.Sprintf("an address event %d", i)).
+				WithSchemaRef("Address")
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestAsyncAddMessageFromFS(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message, err := p.AddMessageFromFile("testdata/user_created.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "a user created event", message.rootBuilder.description)
+	assert.NoError(t, message.VerifySample([]byte(`{"id": 2, "name": "sally"}`), map[string]interface{}{"contentType": "application/json"}))
+	assert.Error(t, message.VerifySample([]byte(`{"id": "2", "name": "sally"}`), nil))
+
+	fsys := fstest.MapFS{
+		"missing-description.json": &fstest.MapFile{Data: []byte(`{"contents": {}}`)},
+		"invalid.json":             &fstest.MapFile{Data: []byte(`{`)},
+	}
+	_, err = p.AddMessageFromFS(fsys, "missing-description.json")
+	assert.Error(t, err)
+	_, err = p.AddMessageFromFS(fsys, "invalid.json")
+	assert.Error(t, err)
+	_, err = p.AddMessageFromFS(fsys, "not-found.json")
+	assert.Error(t, err)
+}
+
+func TestAsyncAddMessageTable(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	messages, err := p.AddMessageTable(MessageDefinition{
+		Description: "a ${currency} payment",
+		Metadata:    map[string]string{"currency": "${currency}"},
+		Contents:    []byte(`{"amount": "${amount}", "currency": "${currency}"}`),
+	}, []map[string]interface{}{
+		{"currency": "AUD", "amount": 10},
+		{"currency": "EUR", "amount": 5.5},
+	})
+	assert.NoError(t, err)
+	assert.Len(t, messages, 2)
+	assert.Equal(t, "a EUR payment", messages[1].rootBuilder.description)
+	assert.NoError(t, messages[1].VerifySample([]byte(`{"amount": 5.5, "currency": "EUR"}`), map[string]interface{}{"currency": "EUR"}))
+
+	_, err = p.AddMessageTable(MessageDefinition{
+		Description: "a payment",
+		Contents:    []byte(`{"amount": "${amount}"}`),
+	}, []map[string]interface{}{{"currency": "AUD"}})
+	assert.Error(t, err)
+}
+
+func TestAsyncWithContentMatchersAt(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a mostly static message").
+		WithJSONContent(map[string]interface{}{
+			"kind":     "order",
+			"customer": map[string]interface{}{"id": 1},
+		}).
+		WithContentMatchersAt("$.customer", matchers.StructMatcher{"id": matchers.Integer(1)})
+
+	assert.NoError(t, message.rootBuilder.err)
+	assert.NoError(t, message.VerifySample([]byte(`{"kind": "order", "customer": {"id": 27}}`), nil))
+	assert.Error(t, message.VerifySample([]byte(`{"kind": "refund", "customer": {"id": 27}}`), nil))
+}
+
+func TestAsyncVerifyIdempotent(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	deliveries := 0
+	err := p.AddAsynchronousMessage().
+		ExpectsToReceive("a duplicated message").
+		WithJSONContent(map[string]interface{}{
+			"id": matchers.Like("abc"),
+		}).
+		VerifyIdempotent(t, func(m AsynchronousMessage) error {
+			deliveries++
+			return nil
+		}, 3)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, deliveries)
+}
+
+func TestAsyncIgnoreContentFields(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a timestamped message").
+		WithJSONContent(map[string]interface{}{
+			"id":        matchers.Like(1),
+			"createdAt": "2020-01-01T00:00:00Z",
+		}).
+		IgnoreContentFields("$.createdAt")
+
+	assert.NoError(t, message.rootBuilder.err)
+	assert.NoError(t, message.VerifySample([]byte(`{"id": 2, "createdAt": "2023-06-30T10:00:00Z"}`), nil))
+	assert.Equal(t, map[string][]string{"a timestamped message": {"$.createdAt"}}, p.ignoredFields)
+
+	message.IgnoreContentFields("$.missing")
+	assert.Error(t, message.rootBuilder.err)
+
+	p.AddAsynchronousMessage().
+		Given("an audited account").
+		ExpectsToReceive("a timestamped message").
+		WithJSONContent(map[string]interface{}{"id": 1, "auditedAt": "2020-01-01T00:00:00Z"}).
+		IgnoreContentFields("$.auditedAt")
+	assert.Equal(t, map[string][]string{
+		"a timestamped message":                    {"$.createdAt"},
+		"a timestamped message|an audited account": {"$.auditedAt"},
+	}, p.ignoredFields)
+}
+
+func TestAsyncDebugDump(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		Given("a user exists").
+		ExpectsToReceive("a dumped message").
+		WithJSONContent(map[string]interface{}{
+			"id": matchers.Like(1),
+		})
+
+	dump, err := message.DebugDump()
+	assert.NoError(t, err)
+	assert.Contains(t, dump, `"description": "a dumped message"`)
+	assert.Contains(t, dump, "a user exists")
+	assert.Contains(t, dump, "matchingRules")
+}
+
+func TestAsyncCodecs(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+		Codecs: codecs.NewRegistry().Register("application/x-lines", func(body []byte) (interface{}, error) {
+			return strings.Split(string(body), "\n"), nil
+		}),
+	})
+
+	message := p.AddAsynchronousMessage()
+	message.ExpectsToReceive("a lines message").
+		WithContent("application/x-lines", []byte("a\nb"))
+
+	err := p.verifyMessageConsumerRaw(message, func(m AsynchronousMessage) error {
+		assert.Equal(t, []string{"a", "b"}, m.Body)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestAsyncWithNegativeExample(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	type user struct {
+		ID int `json:"id"`
+	}
+
+	message := p.AddAsynchronousMessage()
+	message.ExpectsToReceive("a user with negative examples").
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(1)}).
+		AsType(&user{}).
+		WithNegativeExample([]byte(`{"id": "not a number"}`)).
+		WithNegativeExample([]byte(`{"id": 0}`))
+
+	strict := func(m AsynchronousMessage) error {
+		if m.Body.(*user).ID < 1 {
+			return fmt.Errorf("invalid id")
+		}
+		return nil
+	}
+	assert.NoError(t, p.verifyMessageConsumerRaw(message, strict))
+
+	lenient := func(m AsynchronousMessage) error {
+		return nil
+	}
+	err := p.verifyMessageConsumerRaw(message, lenient)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "negative example 1")
+
+	results := p.Results()
+	assert.Len(t, results, 2)
+	assert.NoError(t, results[0].Error)
+	assert.Error(t, results[1].Error)
+}
+
+func TestAsyncNegativeExamplesSharedDescription(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	active := p.AddAsynchronousMessage().
+		Given("the user is active").
+		ExpectsToReceive("a user event").
+		WithJSONContent(map[string]interface{}{"id": 1}).
+		WithNegativeExample([]byte(`{"id": "not a number"}`))
+	suspended := p.AddAsynchronousMessage().
+		Given("the user is suspended").
+		ExpectsToReceive("a user event").
+		WithJSONContent(map[string]interface{}{"id": 1, "suspended": true}).
+		WithNegativeExample([]byte(`{"id": 1}`))
+
+	assert.Equal(t, map[string][][]byte{
+		"a user event|the user is active":    {[]byte(`{"id": "not a number"}`)},
+		"a user event|the user is suspended": {[]byte(`{"id": 1}`)},
+	}, p.negativeExamples)
+	assert.Equal(t, [][]byte{[]byte(`{"id": "not a number"}`)}, active.rootBuilder.negativeExamples)
+	assert.Equal(t, [][]byte{[]byte(`{"id": 1}`)}, suspended.rootBuilder.negativeExamples)
+}
+
+func TestAsyncParallelPactsShareDir(t *testing.T) {
+	dir := t.TempDir()
+	const n = 8
+
+	var wg sync.WaitGroup
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			p, err := NewAsynchronousPact(Config{
+				Consumer: "parallelconsumer",
+				Provider: "parallelprovider",
+				PactDir:  dir,
+			})
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			defer p.Close()
+
+			message := p.AddAsynchronousMessage().
+				ExpectsToReceive(fmt.Sprintf("parallel message %d", i)).
+				WithJSONContent(map[string]interface{}{"id": i})
+			errs[i] = p.verifyMessageConsumerRaw(message.rootBuilder, func(m AsynchronousMessage) error { return nil })
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		assert.NoError(t, err)
+	}
+
+	pact, err := pactfile.Read(filepath.Join(dir, "parallelconsumer-parallelprovider.json"))
+	assert.NoError(t, err)
+
+	descriptions := make(map[string]bool)
+	for _, i := range pact.AllInteractions() {
+		descriptions[i.Description] = true
+	}
+	assert.Len(t, descriptions, n)
+}
+
+func TestAsyncParallelMessagesSharePact(t *testing.T) {
+	dir := t.TempDir()
+	p, _ := NewAsynchronousPact(Config{
+		Consumer:       "sharedconsumer",
+		Provider:       "sharedprovider",
+		PactDir:        dir,
+		DeferPactWrite: true,
+	})
+
+	t.Run("messages", func(t *testing.T) {
+		for i := 0; i < 8; i++ {
+			i := i
+			t.Run(fmt.Sprintf("message %d", i), func(t *testing.T) {
+				t.Parallel()
+
+				err := p.AddAsynchronousMessage().
+					ExpectsToReceive(fmt.Sprintf("shared message %d", i)).
+					WithJSONContent(map[string]interface{}{"id": matchers.Integer(i)}).
+					ConsumedBy(func(m AsynchronousMessage) error { return nil }).
+					Verify(t)
+				assert.NoError(t, err)
+			})
+		}
+	})
+
+	_, err := os.Stat(filepath.Join(dir, "sharedconsumer-sharedprovider.json"))
+	assert.True(t, os.IsNotExist(err))
+	assert.Len(t, p.Results(), 8)
+
+	assert.NoError(t, p.WritePactFile())
+	pact, err := pactfile.Read(filepath.Join(dir, "sharedconsumer-sharedprovider.json"))
+	assert.NoError(t, err)
+	assert.Len(t, pact.AllInteractions(), 8)
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a message verified twice at once").
+		WithJSONContent(map[string]interface{}{"id": 1})
+	message.rootBuilder.verifying = 1
+	err = p.verifyMessageConsumerRaw(message.rootBuilder, func(m AsynchronousMessage) error { return nil })
+	assert.EqualError(t, err, "message 'a message verified twice at once' is already being verified, each message may only be verified by one test at a time")
+}
+
+func TestAsyncDeprecated(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+	handler := func(m AsynchronousMessage) error { return nil }
+
+	sunset := p.AddAsynchronousMessage().
+		ExpectsToReceive("a retired order event").
+		WithJSONContent(map[string]interface{}{"id": 1}).
+		Deprecated(time.Now().Add(-24*time.Hour), "an order event v2")
+	assert.Contains(t, p.deprecations, "a retired order event")
+
+	p.AddAsynchronousMessage().
+		Given("the order is archived").
+		ExpectsToReceive("a retired order event").
+		WithJSONContent(map[string]interface{}{"id": 2}).
+		Deprecated(time.Now().Add(24*time.Hour), "an archived order event v2")
+	assert.Equal(t, "an order event v2", p.deprecations["a retired order event"].Replacement)
+	assert.Equal(t, "an archived order event v2", p.deprecations["a retired order event|the order is archived"].Replacement)
+	assert.NoError(t, p.verifyMessageConsumerRaw(sunset.rootBuilder, handler))
+
+	p.config.FailOnSunset = true
+	err := p.verifyMessageConsumerRaw(sunset.rootBuilder, handler)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "an order event v2")
+
+	current := p.AddAsynchronousMessage().
+		ExpectsToReceive("a deprecated order event").
+		WithJSONContent(map[string]interface{}{"id": 1}).
+		Deprecated(time.Now().Add(24*time.Hour), "")
+	assert.NoError(t, p.verifyMessageConsumerRaw(current.rootBuilder, handler))
+}
+
+func TestAsyncGenerateViolations(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("an order to violate").
+		WithJSONContent(map[string]interface{}{
+			"id":     matchers.Integer(1),
+			"status": "placed",
+		})
+
+	violations, err := message.GenerateViolations()
+	assert.NoError(t, err)
+	assert.NotEmpty(t, violations)
+	for _, v := range violations {
+		assert.Error(t, message.VerifySample(v, nil))
+	}
+
+	binary := p.AddAsynchronousMessage().
+		ExpectsToReceive("a binary message to violate").
+		WithContent("application/octet-stream", []byte{1, 2, 3})
+	_, err = binary.GenerateViolations()
+	assert.Error(t, err)
+}
+
+func TestAsyncWithOrderedMetadata(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a frame with ordered headers").
+		WithMetadata(map[string]string{"version": "1", "type": "frame", "length": "3"}).
+		WithJSONContent(map[string]interface{}{"id": 1}).
+		WithOrderedMetadata("version", "type", "length")
+	assert.NoError(t, message.rootBuilder.err)
+
+	m, err := message.rootBuilder.reify()
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"version", "type", "length"}, m.Metadata[models.MetadataOrderMetadataKey])
+
+	assert.NoError(t, message.VerifyMetadataOrder([]string{"version", "type", "length"}))
+	err = message.VerifyMetadataOrder([]string{"type", "version", "length"})
+	assert.Error(t, err)
+	assert.Equal(t, "metadata.type", err.(*SampleMismatchError).Mismatches[0].Path)
+
+	missing := p.AddAsynchronousMessage().
+		ExpectsToReceive("a frame with an unknown ordered header").
+		WithMetadata(map[string]string{"version": "1"}).
+		WithJSONContent(map[string]interface{}{"id": 1}).
+		WithOrderedMetadata("version", "type")
+	assert.Error(t, missing.rootBuilder.err)
+}
+
+func TestAsyncVerifyBatch(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	created := p.AddAsynchronousMessage().
+		ExpectsToReceive("a batched user created event").
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(1)})
+	deleted := p.AddAsynchronousMessage().
+		ExpectsToReceive("a batched user deleted event").
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(1)})
+
+	mockT := new(testing.T)
+	results := p.VerifyBatch(mockT, []BatchVerification{
+		{Msg: created.rootBuilder, Handler: func(m AsynchronousMessage) error { return fmt.Errorf("unable to process") }},
+		{Msg: deleted.rootBuilder, Handler: func(m AsynchronousMessage) error { return nil }},
+		{Handler: func(m AsynchronousMessage) error { return nil }},
+	})
+	assert.True(t, mockT.Failed())
+
+	assert.Len(t, results, 3)
+	assert.Equal(t, "a batched user created event", results[0].Description)
+	assert.Error(t, results[0].Error)
+	assert.Equal(t, "a batched user deleted event", results[1].Description)
+	assert.NoError(t, results[1].Error)
+	assert.Error(t, results[2].Error)
+}
+
+func TestAsyncCodecContentTypeMismatch(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+		Codecs: codecs.NewRegistry().Register("application/x-protobuf", func(body []byte) (interface{}, error) {
+			if json.Valid(body) {
+				return nil, fmt.Errorf("not a protobuf message")
+			}
+			return body, nil
+		}),
+	})
+
+	raw := p.AddAsynchronousMessage()
+	raw.ExpectsToReceive("a protobuf message containing JSON").
+		WithContent("application/x-protobuf", []byte(`{"id": 1}`))
+	assert.Error(t, raw.err)
+
+	declared := p.AddAsynchronousMessage()
+	declared.ExpectsToReceive("a JSON message declared as protobuf").
+		WithMetadata(map[string]string{"contentType": "application/x-protobuf"}).
+		WithJSONContent(map[string]interface{}{"id": 1})
+
+	err := p.verifyMessageConsumerRaw(declared, func(AsynchronousMessage) error {
+		t.Fatal("the consumer should not be invoked")
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "does not decode as application/x-protobuf")
+}
+
+func TestAsyncVerifyAcrossVersions(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	message := p.AddAsynchronousMessage().
+		Given("a user exists").
+		ExpectsToReceive("a user across versions").
+		WithMetadata(map[string]string{"topic": "users"}).
+		WithMetadataMatchers(matchers.MapMatcher{"timestamp": matchers.RFC3339Time()}).
+		WithJSONContent(map[string]interface{}{
+			"id":   matchers.Integer(1),
+			"name": matchers.Like("billy"),
+		}).
+		AsType(&user{})
+
+	var delivered int
+	err := message.VerifyAcrossVersions(t, []models.SpecificationVersion{models.V3, models.V4}, func(m AsynchronousMessage) error {
+		delivered++
+		assert.Equal(t, "billy", m.Body.(*user).Name)
+		assert.IsType(t, "", m.Metadata["timestamp"])
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, delivered)
+
+	err = message.VerifyAcrossVersions(new(testing.T), []models.SpecificationVersion{"5.0.0"}, func(m AsynchronousMessage) error {
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "unsupported specification version")
+}
+
+func TestAsyncExtends(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	type account struct {
+		ID     int    `json:"id"`
+		Status string `json:"status"`
+	}
+
+	active := p.AddAsynchronousMessage().
+		Given("an account exists").
+		ExpectsToReceive("an active account event").
+		WithMetadata(map[string]string{"topic": "accounts", "version": "1"}).
+		WithJSONContent(map[string]interface{}{
+			"id":     matchers.Integer(1),
+			"status": "active",
+		}).
+		AsType(&account{})
+
+	suspended := p.AddAsynchronousMessage().
+		ExpectsToReceive("a suspended account event").
+		WithMetadata(map[string]string{"version": "2"}).
+		Extends(active).
+		WithContentMatchersAt("$.status", "suspended")
+
+	assert.True(t, suspended.AssertGiven(t, "an account exists"))
+	assert.Equal(t, map[string]interface{}{"topic": "accounts", "version": "2"}, suspended.rootBuilder.metadata)
+
+	err := p.verifyMessageConsumerRaw(suspended.rootBuilder, func(m AsynchronousMessage) error {
+		a := m.Body.(*account)
+		assert.Equal(t, 1, a.ID)
+		assert.Equal(t, "suspended", a.Status)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestAsyncExtendsMetadataMatchers(t *testing.T) {
+	dir := t.TempDir()
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "extendsconsumer",
+		Provider: "extendsprovider",
+		PactDir:  dir,
+	})
+
+	created := p.AddAsynchronousMessage().
+		ExpectsToReceive("an order created event").
+		WithMetadata(map[string]string{"topic": "orders"}).
+		WithMetadataMatchers(matchers.MapMatcher{"timestamp": matchers.RFC3339Time()}).
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(1)})
+
+	updated := p.AddAsynchronousMessage().
+		ExpectsToReceive("an order updated event").
+		Extends(created).
+		ConsumedBy(func(m AsynchronousMessage) error { return nil })
+
+	assert.Equal(t, "orders", updated.rootBuilder.metadata["topic"])
+	assert.Equal(t, created.rootBuilder.metadata["timestamp"], updated.rootBuilder.metadata["timestamp"])
+
+	sample := AsynchronousMessageWithContents{rootBuilder: updated.rootBuilder}
+	assert.NoError(t, sample.VerifySample([]byte(`{"id": 2}`), map[string]interface{}{
+		"topic":     "orders",
+		"timestamp": "2021-06-01T09:00:00+10:00",
+	}))
+	assert.Error(t, sample.VerifySample([]byte(`{"id": 2}`), map[string]interface{}{
+		"topic":     "orders",
+		"timestamp": "yesterday",
+	}))
+
+	assert.NoError(t, updated.Verify(t))
+	pact, err := pactfile.Read(filepath.Join(dir, "extendsconsumer-extendsprovider.json"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, i := range pact.AllInteractions() {
+		if i.Description != "an order updated event" {
+			continue
+		}
+		for _, part := range i.Parts() {
+			if part.Name == "metadata" {
+				assert.NotEmpty(t, part.RulesFor("$.timestamp"), "the inherited metadata matcher has no matching rules")
+			}
+		}
+	}
+}
+
+func TestAsyncExtendsNotInherited(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer:          "extendsconsumer",
+		Provider:          "extendsprovider",
+		PactDir:           t.TempDir(),
+		SchemaRegistryURL: "http://localhost:8081",
+	})
+
+	base := p.AddAsynchronousMessage().
+		ExpectsToReceive("a payment event").
+		WithMetadata(map[string]string{"messageId": "1"}).
+		WithJSONContent(map[string]interface{}{"id": "1", "tier": "standard"}).
+		RequireMetadataWhen("signature", "$.tier", "high-value").
+		LinkMetadataToContent("messageId", "$.id").
+		WithNegativeExample([]byte(`{"id": 1}`)).
+		WithSchemaSubject("payments-value").
+		WithMaxInFlight(4)
+	assert.NoError(t, base.rootBuilder.err)
+
+	refund := p.AddAsynchronousMessage().
+		ExpectsToReceive("a refund event").
+		Extends(base).
+		rootBuilder
+
+	assert.Equal(t, "payments-value", refund.schemaSubject)
+	assert.Equal(t, 4, refund.maxInFlight)
+	assert.Empty(t, refund.metadataConditions)
+	assert.Empty(t, refund.metadataLinks)
+	assert.Empty(t, refund.negativeExamples)
+}
+
+func TestAsyncVerifyAll(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	var consumed []string
+	handler := func(description string) AsynchronousConsumer {
+		return func(m AsynchronousMessage) error {
+			consumed = append(consumed, description)
+			return nil
+		}
+	}
+
+	p.AddAsynchronousMessage().
+		ExpectsToReceive("a handled message").
+		WithJSONContent(map[string]interface{}{"id": 1}).
+		ConsumedBy(handler("a handled message"))
+	p.AddAsynchronousMessage().
+		ExpectsToReceive("an unhandled message").
+		WithJSONContent(map[string]interface{}{"id": 2})
+
+	err := p.VerifyAll(new(testing.T))
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "'an unhandled message'")
+	assert.Empty(t, consumed)
+
+	p.messages[1].handler = handler("an unhandled message")
+	assert.NoError(t, p.VerifyAll(t))
+	assert.Equal(t, []string{"a handled message", "an unhandled message"}, consumed)
+}
+
+func TestAsyncGivenWithSchema(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	schema := []byte(`{"type": "object", "properties": {"id": {"type": "integer"}}, "required": ["id"]}`)
+
+	valid := p.AddAsynchronousMessage().
+		GivenWithSchema(models.ProviderState{Name: "a user exists", Parameters: map[string]interface{}{"id": 1}}, schema)
+	assert.NoError(t, valid.err)
+	assert.True(t, valid.AssertGiven(t, "a user exists"))
+
+	invalid := p.AddAsynchronousMessage().
+		GivenWithSchema(models.ProviderState{Name: "a user exists", Parameters: map[string]interface{}{"id": "1"}}, schema)
+	assert.Error(t, invalid.err)
+	assert.Contains(t, invalid.err.Error(), "$.id: expected integer but got string")
+}
+
+func TestAsyncWithSchemaSubject(t *testing.T) {
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"subject": "users-value", "id": 1, "version": 1, "schema": "{\"type\": \"record\", \"name\": \"User\", \"fields\": [{\"name\": \"id\", \"type\": \"long\"}]}"}`))
+	}))
+	defer registry.Close()
+
+	p, _ := NewAsynchronousPact(Config{
+		Consumer:          "asyncconsumer",
+		Provider:          "asyncprovider",
+		PactDir:           "/tmp/",
+		SchemaRegistryURL: registry.URL,
+	})
+
+	valid := p.AddAsynchronousMessage().
+		ExpectsToReceive("a valid user").
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(1)}).
+		WithSchemaSubject("users-value")
+	assert.NoError(t, p.verifyMessageConsumerRaw(valid.rootBuilder, func(AsynchronousMessage) error { return nil }))
+
+	invalid := p.AddAsynchronousMessage().
+		ExpectsToReceive("an invalid user").
+		WithJSONContent(map[string]interface{}{"id": matchers.Like("1")}).
+		WithSchemaSubject("users-value")
+	err := p.verifyMessageConsumerRaw(invalid.rootBuilder, func(AsynchronousMessage) error { return nil })
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "$.id: expected long")
+
+	unconfigured, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+	})
+	message := unconfigured.AddAsynchronousMessage().
+		ExpectsToReceive("a user").
+		WithJSONContent(map[string]interface{}{"id": 1}).
+		WithSchemaSubject("users-value")
+	assert.Error(t, message.rootBuilder.err)
+}
+
+func TestAsyncCorrelatesWith(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	created := p.AddAsynchronousMessage().
+		ExpectsToReceive("an order created event").
+		WithJSONContent(map[string]interface{}{
+			"id": matchers.Like("b3c8a1f0"),
+		})
+
+	shipped := p.AddAsynchronousMessage().
+		ExpectsToReceive("an order shipped event").
+		WithJSONContent(map[string]interface{}{
+			"orderId": matchers.Like("unrelated"),
+		}).
+		CorrelatesWith(created, "$.orderId", "$.id")
+	assert.NoError(t, shipped.rootBuilder.err)
+
+	example, err := getAsynchronousMessageWithContents(shipped.rootBuilder.messageHandle)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"orderId": "b3c8a1f0"}`, string(example.Contents))
+
+	missing := p.AddAsynchronousMessage().
+		ExpectsToReceive("an order cancelled event").
+		WithJSONContent(map[string]interface{}{"orderId": "1"}).
+		CorrelatesWith(created, "$.orderId", "$.missing")
+	assert.Error(t, missing.rootBuilder.err)
+}
+
+func TestAsyncValidateMatchers(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	valid := p.AddAsynchronousMessage().
+		ExpectsToReceive("a message with valid matchers").
+		WithJSONContent(map[string]interface{}{
+			"id":    matchers.Integer(1),
+			"name":  matchers.Like("billy"),
+			"items": matchers.EachLike(map[string]interface{}{"price": matchers.Decimal(1.5)}, 2),
+		})
+	assert.Empty(t, valid.ValidateMatchers())
+
+	invalid := p.AddAsynchronousMessage().
+		ExpectsToReceive("a message with invalid matchers").
+		WithJSONContent(map[string]interface{}{
+			"id":   map[string]interface{}{"pact:matcher:type": "integer", "value": "abc"},
+			"kind": map[string]interface{}{"pact:matcher:type": "boolean", "value": 1},
+		})
+	assert.NotEmpty(t, invalid.ValidateMatchers())
+}
+
+func TestAsyncWithOrderingKey(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("an account event").
+		WithJSONContent(map[string]interface{}{
+			"account": map[string]interface{}{"id": matchers.Like("abc")},
+		}).
+		WithOrderingKey("$.account.id")
+	assert.NoError(t, message.rootBuilder.err)
+	assert.Equal(t, "$.account.id", message.rootBuilder.metadata[models.OrderingKeyMetadataKey])
+
+	missing := p.AddAsynchronousMessage().
+		ExpectsToReceive("an event without the key").
+		WithJSONContent(map[string]interface{}{"id": 1}).
+		WithOrderingKey("$.account.id")
+	assert.Error(t, missing.rootBuilder.err)
+}
+
+func TestAsyncGenerateMessages(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a generated message").
+		WithJSONContent(map[string]interface{}{
+			"id":   map[string]interface{}{"pact:matcher:type": "type", "value": "abc", "pact:generator:type": "RandomString", "size": 12},
+			"name": matchers.Like("billy"),
+		})
+
+	messages, err := p.GenerateMessages(message, 3)
+	assert.NoError(t, err)
+	assert.Len(t, messages, 3)
+	assert.NotEqual(t, messages[0], messages[1])
+	for _, m := range messages {
+		assert.NoError(t, message.VerifySample(m, nil))
+	}
+
+	binary := p.AddAsynchronousMessage().
+		ExpectsToReceive("a binary message").
+		WithContent("application/octet-stream", []byte{0x01})
+	_, err = p.GenerateMessages(binary, 1)
+	assert.Error(t, err)
+}
+
+func TestAsyncWithMetadataMatchers(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a message with formatted metadata").
+		WithMetadataMatchers(matchers.MapMatcher{
+			"timestamp": matchers.RFC3339Time(),
+			"replyTo":   matchers.Email(),
+			"source":    matchers.URI(),
+			"headers":   matchers.StructMatcher{"partitionKey": matchers.Like("orders-1")},
+		}).
+		WithJSONContent(map[string]interface{}{"id": 1})
+
+	assert.Equal(t, map[string]interface{}{"partitionKey": "orders-1"}, message.rootBuilder.exampleMetadata()["headers"])
+
+	err := message.VerifySample([]byte(`{"id": 1}`), map[string]interface{}{
+		"timestamp": "2021-06-01T09:00:00+10:00",
+		"replyTo":   "orders@example.com",
+		"source":    "https://example.com/orders",
+		"headers":   map[string]interface{}{"partitionKey": "orders-2"},
+	})
+	assert.NoError(t, err)
+
+	err = message.VerifySample([]byte(`{"id": 1}`), map[string]interface{}{
+		"timestamp": "yesterday",
+		"replyTo":   "orders",
+		"source":    "orders",
+		"headers":   map[string]interface{}{"partitionKey": "orders-2"},
+	})
+	assert.Error(t, err)
+	assert.Len(t, err.(*SampleMismatchError).Mismatches, 3)
+}
+
+func TestAsyncRequireMetadataWhen(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a transaction").
+		WithJSONContent(map[string]interface{}{
+			"amount": matchers.Integer(10),
+			"tier":   matchers.Like("standard"),
+		}).
+		RequireMetadataWhen("signature", "$.tier", "high-value")
+	assert.NoError(t, message.rootBuilder.err)
+	assert.Len(t, p.metadataConditions["a transaction"], 1)
+
+	assert.NoError(t, message.VerifySample([]byte(`{"amount": 10, "tier": "standard"}`), nil))
+	assert.NoError(t, message.VerifySample([]byte(`{"amount": 5000, "tier": "high-value"}`), map[string]interface{}{"signature": "abc"}))
+
+	err := message.VerifySample([]byte(`{"amount": 5000, "tier": "high-value"}`), map[string]interface{}{})
+	assert.Error(t, err)
+	assert.Equal(t, "metadata.signature", err.(*SampleMismatchError).Mismatches[0].Path)
+
+	inconsistent := p.AddAsynchronousMessage().
+		ExpectsToReceive("a high value transaction").
+		WithJSONContent(map[string]interface{}{"tier": "high-value"}).
+		RequireMetadataWhen("signature", "$.tier", "high-value")
+	assert.Error(t, inconsistent.rootBuilder.err)
+
+	missing := p.AddAsynchronousMessage().
+		ExpectsToReceive("a transaction without a tier").
+		WithJSONContent(map[string]interface{}{"amount": 1}).
+		RequireMetadataWhen("signature", "$.tier", "high-value")
+	assert.Error(t, missing.rootBuilder.err)
+
+	p.AddAsynchronousMessage().
+		Given("the account is audited").
+		ExpectsToReceive("a transaction").
+		WithMetadata(map[string]string{"auditId": "1"}).
+		WithJSONContent(map[string]interface{}{"amount": 10, "tier": "audited"}).
+		RequireMetadataWhen("auditId", "$.tier", "audited")
+	assert.Len(t, p.metadataConditions["a transaction"], 1)
+	assert.Len(t, p.metadataConditions["a transaction|the account is audited"], 1)
+}
+
+func TestAsyncLinkMetadataToContent(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a linked order").
+		WithMetadata(map[string]string{"messageId": "42"}).
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(42)}).
+		LinkMetadataToContent("messageId", "$.id")
+	assert.NoError(t, message.rootBuilder.err)
+	assert.Len(t, p.metadataLinks["a linked order"], 1)
+
+	assert.NoError(t, message.VerifySample([]byte(`{"id": 42}`), map[string]interface{}{"messageId": "42"}))
+
+	err := message.VerifySample([]byte(`{"id": 42}`), map[string]interface{}{"messageId": "7"})
+	assert.Error(t, err)
+	assert.Equal(t, "metadata.messageId", err.(*SampleMismatchError).Mismatches[0].Path)
+
+	assert.NoError(t, message.ConsumedBy(func(m AsynchronousMessage) error { return nil }).Verify(t))
+
+	inconsistent := p.AddAsynchronousMessage().
+		ExpectsToReceive("an unlinked order").
+		WithMetadata(map[string]string{"messageId": "7"}).
+		WithJSONContent(map[string]interface{}{"id": 42}).
+		LinkMetadataToContent("messageId", "$.id")
+	assert.Error(t, inconsistent.rootBuilder.err)
+
+	p.AddAsynchronousMessage().
+		Given("the order is a replay").
+		ExpectsToReceive("a linked order").
+		WithMetadata(map[string]string{"replayOf": "42"}).
+		WithJSONContent(map[string]interface{}{"originalId": 42}).
+		LinkMetadataToContent("replayOf", "$.originalId")
+	assert.Equal(t, []MetadataLink{{MetadataKey: "messageId", ContentPath: "$.id"}}, p.metadataLinks["a linked order"])
+	assert.Equal(t, []MetadataLink{{MetadataKey: "replayOf", ContentPath: "$.originalId"}}, p.metadataLinks["a linked order|the order is a replay"])
+}
+
+func TestAsyncVerifyAgainstRecording(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	type user struct {
+		ID int `json:"id"`
+	}
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a recorded user").
+		WithMetadata(map[string]string{"topic": "users"}).
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(1)}).
+		AsType(&user{})
+
+	dir, err := ioutil.TempDir("", "pact-go-recordings")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "user-1.json"), []byte(`{"id": 27}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "user-1.meta.json"), []byte(`{"topic": "users"}`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "user-2.json"), []byte(`{"id": 42}`), 0644))
+
+	var ids []int
+	err = message.verifyAgainstRecording(dir, func(m AsynchronousMessage) error {
+		ids = append(ids, m.Body.(*user).ID)
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{27, 42}, ids)
+
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "user-3.json"), []byte(`{"id": "43"}`), 0644))
+	err = message.verifyAgainstRecording(dir, func(m AsynchronousMessage) error { return nil })
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "user-3.json")
+
+	err = message.verifyAgainstRecording(t.TempDir(), func(m AsynchronousMessage) error { return nil })
+	assert.Error(t, err)
+}
+
+func TestAsyncHandlerMetadata(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a message with metadata").
+		WithMetadata(map[string]string{"type": "UserCreated"}).
+		WithMetadataMatchers(matchers.MapMatcher{"correlationId": matchers.Like("abc-123")}).
+		WithJSONContent(map[string]interface{}{"id": 1})
+
+	err := p.verifyMessageConsumerRaw(message.rootBuilder, func(m AsynchronousMessage) error {
+		assert.Equal(t, Metadata{"type": "UserCreated", "correlationId": "abc-123", "contentType": "application/json"}, m.Metadata)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	binary := p.AddAsynchronousMessage().
+		ExpectsToReceive("a binary message").
+		WithContent("application/octet-stream", []byte{0x01, 0x02})
+
+	err = p.verifyMessageConsumerRaw(binary.rootBuilder, func(m AsynchronousMessage) error {
+		assert.Equal(t, Metadata{"contentType": "application/octet-stream"}, m.Metadata)
+		return nil
+	})
+	assert.NoError(t, err)
+
+	plain := p.AddAsynchronousMessage().
+		ExpectsToReceive("a message without metadata").
+		WithJSONContent(map[string]interface{}{"id": 1})
+
+	err = p.verifyMessageConsumerRaw(plain.rootBuilder, func(m AsynchronousMessage) error {
+		assert.NotNil(t, m.Metadata)
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestAsyncWithMatchStrictness(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a loosely matched message").
+		WithJSONContent(map[string]interface{}{
+			"id":     1,
+			"status": matchers.Term("active", "^(active|retired)$"),
+		}).
+		WithMatchStrictness(MatchType)
+	assert.NoError(t, message.rootBuilder.err)
+	assert.NoError(t, message.VerifySample([]byte(`{"id": 2, "status": "retired"}`), nil))
+	assert.Error(t, message.VerifySample([]byte(`{"id": 2, "status": "deleted"}`), nil))
+
+	invalid := p.AddAsynchronousMessage().
+		ExpectsToReceive("a message with an invalid strictness").
+		WithJSONContent(map[string]interface{}{"id": 1}).
+		WithMatchStrictness("loose")
+	assert.Error(t, invalid.rootBuilder.err)
+}
+
+func TestAsyncProducerSnippet(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a user created event").
+		WithMetadata(map[string]string{"topic": "users"}).
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(1)})
+
+	snippet, err := message.ProducerSnippet(TransportKafka)
+	assert.NoError(t, err)
+	assert.Equal(t, `echo '{"id":1}' | kafka-console-producer --bootstrap-server localhost:9092 --topic 'users'`, snippet)
+
+	_, err = message.ProducerSnippet("carrier-pigeon")
+	assert.Error(t, err)
+}
+
+func TestAsyncVerifyMaxInFlight(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a message for a bounded pool").
+		WithJSONContent(map[string]interface{}{
+			"id": matchers.Like("abc"),
+		}).
+		WithMaxInFlight(2)
+	assert.Equal(t, "2", message.rootBuilder.metadata[models.MaxInFlightMetadataKey])
+
+	workers := make(chan struct{}, 2)
+	err := message.VerifyMaxInFlight(t, func(m AsynchronousMessage, inFlight *InFlight) error {
+		workers <- struct{}{}
+		defer func() { <-workers }()
+
+		return inFlight.Track(func() error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+	})
+	assert.NoError(t, err)
+
+	mockT := new(testing.T)
+	err = message.VerifyMaxInFlight(mockT, func(m AsynchronousMessage, inFlight *InFlight) error {
+		return inFlight.Track(func() error {
+			time.Sleep(10 * time.Millisecond)
+			return nil
+		})
+	})
+	assert.ErrorContains(t, err, "exceeding the maximum in flight of 2")
+}
+
+func TestAsyncConsumedByCtx(t *testing.T) {
+	dir := t.TempDir()
+	p, _ := NewAsynchronousPact(Config{
+		Consumer:       "asyncconsumer",
+		Provider:       "asyncprovider",
+		PactDir:        dir,
+		HandlerTimeout: 50 * time.Millisecond,
+	})
+
+	mockT := new(testing.T)
+	err := p.AddAsynchronousMessage().
+		ExpectsToReceive("a message that hangs").
+		WithJSONContent(map[string]interface{}{
+			"id": matchers.Like("abc"),
+		}).
+		ConsumedByCtx(func(ctx context.Context, m AsynchronousMessage) error {
+			<-ctx.Done()
+			return ctx.Err()
+		}).
+		Verify(mockT)
+
+	assert.ErrorContains(t, err, "did not complete within the timeout of 50ms")
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	assert.Empty(t, files)
+
+	err = p.AddAsynchronousMessage().
+		ExpectsToReceive("a quick message").
+		WithJSONContent(map[string]interface{}{
+			"id": matchers.Like("abc"),
+		}).
+		ConsumedByCtx(func(ctx context.Context, m AsynchronousMessage) error {
+			_, ok := ctx.Deadline()
+			assert.True(t, ok)
+			return nil
+		}).
+		VerifyContext(context.Background(), t)
+
+	assert.NoError(t, err)
+}
+
+func TestAsyncFieldDescriptions(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	message := p.AddAsynchronousMessage().
+		ExpectsToReceive("a documented message").
+		WithJSONContent(map[string]interface{}{
+			"id":     matchers.Described(matchers.Like(1), "the order number"),
+			"status": matchers.Like("placed"),
+		})
+	p.AddAsynchronousMessage().
+		Given("the order is a gift").
+		ExpectsToReceive("a documented message").
+		WithJSONContent(map[string]interface{}{
+			"id":      matchers.Described(matchers.Like(1), "the gift order number"),
+			"giftFor": matchers.Deprecated(matchers.Like("sally"), "use recipient instead"),
+		})
+
+	assert.NoError(t, message.rootBuilder.err)
+	assert.Equal(t, map[string]map[string]string{
+		"a documented message":                     {"$.id": "the order number"},
+		"a documented message|the order is a gift": {"$.id": "the gift order number"},
+	}, p.fieldDescriptions)
+	assert.Equal(t, map[string]map[string]string{
+		"a documented message|the order is a gift": {"$.giftFor": "use recipient instead"},
+	}, p.deprecatedFields)
+}
+
+func TestAsyncDeprecatedFields(t *testing.T) {
+	dir := t.TempDir()
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "deprecatedconsumer",
+		Provider: "deprecatedprovider",
+		PactDir:  dir,
+	})
+
+	err := p.AddAsynchronousMessage().
+		ExpectsToReceive("a message with a deprecated field").
+		WithJSONContent(map[string]interface{}{
+			"id":  matchers.Described(matchers.Like(1), "the order number"),
+			"fax": matchers.Deprecated(matchers.Like("555-1234"), "use email instead"),
+		}).
+		ConsumedBy(func(m AsynchronousMessage) error { return nil }).
+		Verify(t)
+	assert.NoError(t, err)
+
+	pact, err := pactfile.Read(filepath.Join(dir, "deprecatedconsumer-deprecatedprovider.json"))
+	assert.NoError(t, err)
+	metadata, _ := pact.Metadata[models.MetadataNamespace].(map[string]interface{})
+	assert.Equal(t, `{"a message with a deprecated field":{"$.fax":"use email instead"}}`, metadata[DeprecatedFieldsMetadataKey])
+	assert.Equal(t, `{"a message with a deprecated field":{"$.id":"the order number"}}`, metadata[FieldDescriptionsMetadataKey])
+}
+
+func TestAsyncVerifyDLQReplay(t *testing.T) {
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	var deaths interface{}
+	err := p.AddAsynchronousMessage().
+		ExpectsToReceive("a dead-lettered message").
+		WithJSONContent(map[string]interface{}{
+			"id": matchers.Like("abc"),
+		}).
+		VerifyDLQReplay(t, func(m AsynchronousMessage) error {
+			deaths = m.Metadata[DeathMetadataKey]
+			return nil
+		})
+
+	assert.NoError(t, err)
+	assert.Len(t, deaths, 1)
+}
+
+func TestAsyncPactSpecification(t *testing.T) {
+	dir := t.TempDir()
+	p, err := NewAsynchronousPact(Config{
+		Consumer:          "v3consumer",
+		Provider:          "v3provider",
+		PactDir:           dir,
+		PactSpecification: models.V3,
+	})
+	assert.NoError(t, err)
+
+	err = p.AddAsynchronousMessage().
+		ExpectsToReceive("a V3 message").
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(1)}).
+		ConsumedBy(func(m AsynchronousMessage) error { return nil }).
+		Verify(t)
+	assert.NoError(t, err)
+
+	pact, err := pactfile.Read(filepath.Join(dir, "v3consumer-v3provider.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, "3.0.0", pact.SpecificationVersion())
+
+	_, err = NewAsynchronousPact(Config{Consumer: "c", Provider: "p", PactSpecification: models.V2})
+	assert.Error(t, err)
+	_, err = NewSynchronousPact(Config{Consumer: "c", Provider: "p", PactSpecification: models.V3})
+	assert.Error(t, err)
+}
+
+func TestAsyncCloseTwice(t *testing.T) {
+	afterSuite := 0
+	p, err := NewAsynchronousPact(Config{
+		Consumer: "closeconsumer",
+		Provider: "closeprovider",
+		PactDir:  t.TempDir(),
+		AfterSuite: func() error {
+			afterSuite++
+			return nil
+		},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.Close())
+	assert.NoError(t, p.Close())
+	assert.Equal(t, 1, afterSuite, "closing a pact twice must release it once")
+}
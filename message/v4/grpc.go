@@ -0,0 +1,110 @@
+package v4
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pact-foundation/pact-go/v2/internal/native"
+)
+
+// DefaultGrpcPluginVersion is the version of the protobuf plugin used for gRPC
+// interactions that don't specify one
+const DefaultGrpcPluginVersion = "0.3.4"
+
+// GrpcInteraction describes a call to an RPC of a gRPC service, matched by the protobuf
+// plugin. Request and Response give the fields of the messages as plugin matching
+// expressions, e.g. {"latitude": "matching(number, 180)"}.
+//
+// The consumer's gRPC client is pointed at the port of the TransportConfig given to
+// ExecuteTest. The provider is verified against the resulting pact with a "grpc"
+// provider.Transport.
+type GrpcInteraction struct {
+	// Proto is the path of the .proto file that defines the service
+	Proto string
+
+	// Service is the RPC, as <service>/<method>, e.g. "RouteGuide/GetFeature"
+	Service string
+
+	// Request and Response are the expected request and response messages
+	Request  map[string]interface{}
+	Response map[string]interface{}
+
+	// PluginVersion of the protobuf plugin. Defaults to DefaultGrpcPluginVersion
+	PluginVersion string
+
+	// Address the mock gRPC server listens on. Defaults to 127.0.0.1
+	Address string
+}
+
+// contents returns the plugin configuration of the interaction
+func (g GrpcInteraction) contents() (string, error) {
+	if g.Proto == "" || g.Service == "" {
+		return "", fmt.Errorf("a gRPC interaction requires a proto file and a service")
+	}
+
+	contents, err := json.Marshal(map[string]interface{}{
+		"pact:proto":         g.Proto,
+		"pact:proto-service": g.Service,
+		"pact:content-type":  "application/protobuf",
+		"request":            g.Request,
+		"response":           g.Response,
+	})
+	if err != nil {
+		return "", fmt.Errorf("invalid gRPC interaction: %v", err)
+	}
+
+	return string(contents), nil
+}
+
+// WithGrpcInteraction expects a call to an RPC of a gRPC service, and starts a mock gRPC
+// server for it using the protobuf plugin, e.g.
+//
+//	p.AddSynchronousMessage("Route guide - GetFeature").
+//		WithGrpcInteraction(GrpcInteraction{
+//			Proto:    "routeguide/route_guide.proto",
+//			Service:  "RouteGuide/GetFeature",
+//			Request:  map[string]interface{}{"latitude": "matching(number, 180)"},
+//			Response: map[string]interface{}{"name": "notEmpty('Big Tree')"},
+//		}).
+//		ExecuteTest(t, func(transport TransportConfig, m SynchronousMessage) error {
+//			conn, err := grpc.Dial(fmt.Sprintf("127.0.0.1:%d", transport.Port), ...)
+//			...
+//		})
+func (m *UnconfiguredSynchronousMessageBuilder) WithGrpcInteraction(interaction GrpcInteraction) *SynchronousMessageWithTransport {
+	if interaction.PluginVersion == "" {
+		interaction.PluginVersion = DefaultGrpcPluginVersion
+	}
+	if interaction.Address == "" {
+		interaction.Address = "127.0.0.1"
+	}
+
+	s := &SynchronousMessageWithTransport{
+		pact:          m.pact,
+		messageHandle: m.messageHandle,
+		transport: TransportConfig{
+			Address: interaction.Address,
+		},
+	}
+
+	contents, err := interaction.contents()
+	if err != nil {
+		s.err = err
+		return s
+	}
+
+	if err = m.pact.mockserver.UsingPlugin("protobuf", interaction.PluginVersion); err != nil {
+		s.err = fmt.Errorf("unable to load the protobuf plugin %s: %v", interaction.PluginVersion, err)
+		return s
+	}
+	if err = m.messageHandle.WithPluginInteractionContents(native.INTERACTION_PART_REQUEST, "application/protobuf", contents); err != nil {
+		s.err = fmt.Errorf("invalid gRPC interaction %s: %v", interaction.Service, err)
+		return s
+	}
+
+	s.transport.Port, err = m.pact.mockserver.StartTransport("grpc", interaction.Address, 0, make(map[string][]interface{}))
+	if err != nil {
+		s.err = fmt.Errorf("unable to start the gRPC mock server: %v", err)
+	}
+
+	return s
+}
@@ -0,0 +1,31 @@
+package v4
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGrpcInteractionContents(t *testing.T) {
+	contents, err := GrpcInteraction{
+		Proto:    "routeguide/route_guide.proto",
+		Service:  "RouteGuide/GetFeature",
+		Request:  map[string]interface{}{"latitude": "matching(number, 180)"},
+		Response: map[string]interface{}{"name": "notEmpty('Big Tree')"},
+	}.contents()
+	assert.NoError(t, err)
+
+	var parsed map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(contents), &parsed))
+	assert.Equal(t, map[string]interface{}{
+		"pact:proto":         "routeguide/route_guide.proto",
+		"pact:proto-service": "RouteGuide/GetFeature",
+		"pact:content-type":  "application/protobuf",
+		"request":            map[string]interface{}{"latitude": "matching(number, 180)"},
+		"response":           map[string]interface{}{"name": "notEmpty('Big Tree')"},
+	}, parsed)
+
+	_, err = GrpcInteraction{Service: "RouteGuide/GetFeature"}.contents()
+	assert.Error(t, err)
+}
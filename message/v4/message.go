@@ -1,5 +1,26 @@
 package v4
 
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/hashicorp/logutils"
+	"github.com/pact-foundation/pact-go/v2/codecs"
+	logging "github.com/pact-foundation/pact-go/v2/log"
+	"github.com/pact-foundation/pact-go/v2/matchers"
+	"github.com/pact-foundation/pact-go/v2/models"
+)
+
 type Metadata map[string]interface{}
 
 // AsynchronousMessage is a representation of a single, unidirectional message
@@ -11,6 +32,21 @@ type AsynchronousMessage MessageContents
 // the content
 type AsynchronousConsumer func(AsynchronousMessage) error
 
+// AsynchronousConsumerCtx is an AsynchronousConsumer that is given a context, which is
+// cancelled once Config.HandlerTimeout has passed
+type AsynchronousConsumerCtx func(context.Context, AsynchronousMessage) error
+
+// withContext adapts an AsynchronousConsumer to an AsynchronousConsumerCtx
+func withContext(handler AsynchronousConsumer) AsynchronousConsumerCtx {
+	if handler == nil {
+		return nil
+	}
+
+	return func(_ context.Context, m AsynchronousMessage) error {
+		return handler(m)
+	}
+}
+
 // V3 Message (Asynchronous only)
 type MessageContents struct {
 	// Message Body
@@ -20,12 +56,817 @@ type MessageContents struct {
 	// Not populated for synchronous  messages
 	Body interface{} `json:"contents"`
 
-	// Message metadata. Currently not populated for synchronous messages
-	// Metadata Metadata `json:"metadata"`
+	// Message metadata, with the example values of any matchers. Always set for
+	// asynchronous messages, including the content type of binary messages.
+	// Currently not populated for synchronous messages
+	Metadata Metadata `json:"metadata"`
 }
 
 type Config struct {
 	Consumer string
 	Provider string
 	PactDir  string
+
+	// Environment the pact is generated in (e.g. "staging"), recorded in the pact file metadata.
+	// Optional
+	Environment string
+
+	// TracerProvider records spans for each phase of message verification
+	// (reification, the consumer handler and writing the pact). Optional
+	TracerProvider TracerProvider
+
+	// Codecs decode message content by content type before it is given to the consumer
+	// handler, e.g. for protobuf or MessagePack messages. Content types without a
+	// registered decoder, here or with message.RegisterDecoder, are unmarshalled from
+	// JSON. Optional
+	Codecs *codecs.Registry
+
+	// OutputFormat of the pact file, either OutputFormatJSON (the default) or OutputFormatNDJSON
+	OutputFormat string
+
+	// PactFileWriteMode is either PactFileWriteModeMerge (the default), adding to any existing
+	// pact file, or PactFileWriteModeOverwrite, replacing it on the first write of the pact
+	PactFileWriteMode string
+
+	// PactSpecification is the version of the pact specification the pact file is written
+	// in: models.V4 (the default), or models.V3 for asynchronous messages consumed by tools
+	// that don't support V4. Synchronous messages and plugin content require V4. Optional
+	PactSpecification models.SpecificationVersion
+
+	// PactFileName overrides the file the pact is written to, by default <consumer>-<provider>.json
+	// (or .ndjson) in PactDir, e.g. to keep a file per topic. It is either an absolute path or
+	// relative to PactDir, and its directory is created if it doesn't exist. Optional
+	PactFileName string
+
+	// DeferPactWrite stops Verify from writing the pact file after each message, so that
+	// messages verified by parallel tests (t.Parallel) are written once, with
+	// AsynchronousPact.WritePactFile. Optional
+	DeferPactWrite bool
+
+	// SharedHandle shares a single native message pact between the pacts created with the
+	// same consumer, provider and pact file, e.g. by each test of a large suite, rather than
+	// creating one for each. Each pact is a handle on the shared pact, created with the same
+	// configuration. It implies DeferPactWrite: the pact file is written once, when the last
+	// handle is closed. Optional
+	SharedHandle bool
+
+	// DedupStrategy resolves interactions with the same description and provider states
+	// when the pact file is written: DedupKeepFirst, DedupKeepLast or DedupErrorOnConflict.
+	// By default they are merged by the native core. Optional
+	DedupStrategy string
+
+	// SchemaRegistryURL is the base URL of a Confluent compatible schema registry. Messages
+	// given a subject with WithSchemaSubject are validated against its latest schema. Optional
+	SchemaRegistryURL string
+
+	// HandlerTimeout bounds how long an asynchronous message handler may run. Verification
+	// fails, without writing the pact file, if a handler runs for longer. The context given
+	// to handlers set with ConsumedByCtx is cancelled when it expires. Optional
+	HandlerTimeout time.Duration
+
+	// FailOnSunset fails verification of messages deprecated with a sunset date that has
+	// passed, rather than logging a warning. Optional
+	FailOnSunset bool
+
+	// Logger receives the log output of the pact, e.g. to silence DEBUG messages or
+	// redirect them to the test log. Defaults to the standard logger. Optional
+	Logger logging.Logger
+
+	// LogLevel is the minimum level of the messages the pact logs, e.g. "WARN" to
+	// silence a noisy test. Defaults to the framework log level (see log.SetLogLevel). Optional
+	LogLevel logutils.LogLevel
+
+	// BeforeEach is run before the handler of each message verified, given its description,
+	// e.g. to clear a queue or reset a database. Verification fails if it errors. Optional
+	BeforeEach func(description string) error
+
+	// AfterEach is run after the handler of each message verified, given its description and
+	// the error of the handler, e.g. to record metrics. Verification fails if it errors. Optional
+	AfterEach func(description string, err error) error
+
+	// AfterSuite is run when the pact is closed. Optional
+	AfterSuite func() error
+}
+
+// SampleMismatchError is returned when a sample payload does not satisfy
+// the matching rules of an interaction
+type SampleMismatchError struct {
+	Mismatches []matchers.Mismatch
+}
+
+func (e *SampleMismatchError) Error() string {
+	descriptions := make([]string, len(e.Mismatches))
+	for i, m := range e.Mismatches {
+		descriptions[i] = m.String()
+	}
+
+	return fmt.Sprintf("sample does not satisfy the contract: %s", strings.Join(descriptions, "; "))
+}
+
+// withHooks runs the handler of a message between the BeforeEach and AfterEach hooks
+func (c Config) withHooks(description string, handler func() error) error {
+	if c.BeforeEach != nil {
+		if err := c.BeforeEach(description); err != nil {
+			return fmt.Errorf("the BeforeEach hook failed: %v", err)
+		}
+	}
+
+	err := handler()
+
+	if c.AfterEach != nil {
+		if hookErr := c.AfterEach(description, err); hookErr != nil && err == nil {
+			err = fmt.Errorf("the AfterEach hook failed: %v", hookErr)
+		}
+	}
+
+	return err
+}
+
+// callHandler delivers a message to the handler, failing if it runs for longer than timeout.
+// A handler that times out is left to finish in the background.
+func callHandler(ctx context.Context, timeout time.Duration, handler AsynchronousConsumerCtx, m AsynchronousMessage) error {
+	if timeout <= 0 {
+		return handler(ctx, m)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- handler(ctx, m)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("the message handler did not complete within the timeout of %v", timeout)
+		}
+		return ctx.Err()
+	}
+}
+
+// DeathMetadataKey is the metadata key of the dead-lettering history of a message
+// replayed from a RabbitMQ style dead-letter queue, see VerifyDLQReplay
+const DeathMetadataKey = "x-death"
+
+// deadLettered returns the message as redelivered from a dead-letter queue, after being
+// rejected once from the queue named by its "queue" or "routingKey" metadata
+func deadLettered(m AsynchronousMessage) AsynchronousMessage {
+	metadata := make(Metadata, len(m.Metadata)+2)
+	for k, v := range m.Metadata {
+		metadata[k] = v
+	}
+
+	death := map[string]interface{}{
+		"count":  1,
+		"reason": "rejected",
+	}
+	for _, key := range []string{"queue", "routingKey"} {
+		if queue, ok := metadata[key]; ok {
+			death["queue"] = queue
+			break
+		}
+	}
+	if exchange, ok := metadata["exchange"]; ok {
+		death["exchange"] = exchange
+	}
+
+	metadata[DeathMetadataKey] = []interface{}{death}
+	metadata["x-first-death-reason"] = "rejected"
+	m.Metadata = metadata
+
+	return m
+}
+
+// IgnoredFieldsMetadataKey records the content paths excluded from each message in
+// the pact file metadata, as a JSON object of message to paths. Messages are keyed by
+// description, followed by the names of their provider states if they have any, e.g.
+// "an order event|an order exists,a customer exists"
+const IgnoredFieldsMetadataKey = "ignoredFields"
+
+// FieldDescriptionsMetadataKey records the content fields documented with matchers.Described
+// in the pact file metadata, as a JSON object of message (keyed like IgnoredFieldsMetadataKey) to
+// field paths and descriptions
+const FieldDescriptionsMetadataKey = "fieldDescriptions"
+
+// DeprecatedFieldsMetadataKey records the content fields marked with matchers.Deprecated in the
+// pact file metadata, as a JSON object of message (keyed like IgnoredFieldsMetadataKey) to field
+// paths and notes
+const DeprecatedFieldsMetadataKey = "deprecatedFields"
+
+// NegativeExamplesMetadataKey records the negative examples of each message in the pact
+// file metadata, as a JSON object of message (keyed like IgnoredFieldsMetadataKey) to base64
+// encoded payloads
+const NegativeExamplesMetadataKey = "negativeExamples"
+
+// MetadataConditionsMetadataKey records the conditionally required metadata of each message
+// in the pact file metadata, as a JSON object of message (keyed like IgnoredFieldsMetadataKey)
+// to MetadataConditions
+const MetadataConditionsMetadataKey = "metadataConditions"
+
+// MetadataCondition requires a metadata key for messages whose content at a path has a given value
+type MetadataCondition struct {
+	MetadataKey string      `json:"metadataKey"`
+	ContentPath string      `json:"contentPath"`
+	Value       interface{} `json:"value"`
+}
+
+// appliesTo reports whether the normalised content has the value of the condition at its path
+func (c MetadataCondition) appliesTo(content interface{}) bool {
+	v, err := getPath(content, c.ContentPath)
+	if err != nil {
+		return false
+	}
+	actual, err := matchers.Example(v)
+	if err != nil {
+		return false
+	}
+	expected, err := matchers.Example(c.Value)
+
+	return err == nil && reflect.DeepEqual(actual, expected)
+}
+
+// MetadataLinksMetadataKey records the metadata of each message that must mirror a field of its
+// content in the pact file metadata, as a JSON object of message (keyed like
+// IgnoredFieldsMetadataKey) to MetadataLinks
+const MetadataLinksMetadataKey = "metadataLinks"
+
+// MetadataLink requires the value of a metadata key to equal the content field at a path
+type MetadataLink struct {
+	MetadataKey string `json:"metadataKey"`
+	ContentPath string `json:"contentPath"`
+}
+
+// mismatch compares the metadata value of the link with the field of the normalised content,
+// returning nil if they are equal. A string metadata value, such as a broker header, is equal
+// to a content field of another type with the same string form, e.g. "42" and 42.
+func (l MetadataLink) mismatch(content interface{}, metadata map[string]interface{}) *matchers.Mismatch {
+	path := "metadata." + l.MetadataKey
+
+	field, err := getPath(content, l.ContentPath)
+	if err != nil {
+		return &matchers.Mismatch{Path: path, Mismatch: err.Error()}
+	}
+	expected, err := matchers.Example(field)
+	if err != nil {
+		return &matchers.Mismatch{Path: path, Mismatch: err.Error()}
+	}
+
+	value, ok := metadata[l.MetadataKey]
+	if !ok {
+		return &matchers.Mismatch{
+			Path:     path,
+			Expected: expected,
+			Mismatch: fmt.Sprintf("expected key '%s' equal to %s", l.MetadataKey, l.ContentPath),
+		}
+	}
+	actual, err := matchers.Example(value)
+	if err != nil {
+		return &matchers.Mismatch{Path: path, Mismatch: err.Error()}
+	}
+
+	if reflect.DeepEqual(actual, expected) {
+		return nil
+	}
+	if s, ok := actual.(string); ok && s == fmt.Sprint(expected) {
+		return nil
+	}
+
+	return &matchers.Mismatch{
+		Path:     path,
+		Expected: expected,
+		Actual:   actual,
+		Mismatch: fmt.Sprintf("expected '%s' to equal %s %v but was %v", l.MetadataKey, l.ContentPath, expected, actual),
+	}
+}
+
+// DeprecationsMetadataKey records the deprecated messages in the pact file metadata, as a
+// JSON object of message (keyed like IgnoredFieldsMetadataKey) to Deprecation
+const DeprecationsMetadataKey = "deprecations"
+
+// SequencesMetadataKey records the messages of each sequence, in the order they are
+// consumed, in the pact-go metadata of the pact file, by scenario
+const SequencesMetadataKey = "sequences"
+
+// Deprecation retires a message on its sunset date in favour of a replacement interaction
+type Deprecation struct {
+	Sunset      time.Time `json:"sunset"`
+	Replacement string    `json:"replacement,omitempty"`
+}
+
+// sunsetError describes the deprecation of the message if its sunset date has passed by now
+func (d Deprecation) sunsetError(description string, now time.Time) error {
+	if !now.After(d.Sunset) {
+		return nil
+	}
+	if d.Replacement == "" {
+		return fmt.Errorf("message '%s' was deprecated and its sunset date of %s has passed", description, d.Sunset.Format("2006-01-02"))
+	}
+
+	return fmt.Errorf("message '%s' was deprecated and its sunset date of %s has passed, use '%s' instead", description, d.Sunset.Format("2006-01-02"), d.Replacement)
+}
+
+// metadataOrderMismatches checks the declared metadata keys appear in keys, the metadata
+// keys of a message in the order the transport delivered them, in the declared order
+func metadataOrderMismatches(declared []string, keys []string) []matchers.Mismatch {
+	positions := make(map[string]int, len(keys))
+	for i, k := range keys {
+		if _, ok := positions[k]; !ok {
+			positions[k] = i
+		}
+	}
+
+	var mismatches []matchers.Mismatch
+	previous := ""
+	for _, k := range declared {
+		position, ok := positions[k]
+		if !ok {
+			mismatches = append(mismatches, matchers.Mismatch{
+				Path:     "metadata." + k,
+				Expected: declared,
+				Actual:   keys,
+				Mismatch: fmt.Sprintf("expected key '%s' in the ordered metadata", k),
+			})
+			continue
+		}
+		if previous != "" && position < positions[previous] {
+			mismatches = append(mismatches, matchers.Mismatch{
+				Path:     "metadata." + k,
+				Expected: declared,
+				Actual:   keys,
+				Mismatch: fmt.Sprintf("expected key '%s' after '%s'", k, previous),
+			})
+		}
+		previous = k
+	}
+
+	return mismatches
+}
+
+// InFlight counts the messages a consumer is processing concurrently, see VerifyMaxInFlight
+type InFlight struct {
+	mu      sync.Mutex
+	current int
+	max     int
+}
+
+// Track counts a message as in flight while process runs. Consumers call it from the
+// worker that processes the message, inside any bound on concurrency.
+func (f *InFlight) Track(process func() error) error {
+	f.mu.Lock()
+	f.current++
+	if f.current > f.max {
+		f.max = f.current
+	}
+	f.mu.Unlock()
+
+	defer func() {
+		f.mu.Lock()
+		f.current--
+		f.mu.Unlock()
+	}()
+
+	return process()
+}
+
+// Max is the most messages that were in flight at once
+func (f *InFlight) Max() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.max
+}
+
+// removePath removes the value at a JSON path from normalised content. Array
+// elements may be addressed by index or with the * wildcard, and paths may pass
+// through matchers such as EachLike.
+func removePath(content interface{}, path string) (interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid path '%s', paths must start with $", path)
+	}
+
+	segments, err := splitPath(path[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid path '%s': %v", path, err)
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("invalid path '%s', the root can't be ignored", path)
+	}
+
+	if !removeSegments(content, segments) {
+		return nil, fmt.Errorf("path '%s' was not found in the message content", path)
+	}
+
+	return content, nil
+}
+
+func removeSegments(v interface{}, segments []string) bool {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		// step through matchers to the value they apply to
+		if _, ok := t["pact:matcher:type"]; ok {
+			return removeSegments(t["value"], segments)
+		}
+		if len(segments) == 1 {
+			_, ok := t[segments[0]]
+			delete(t, segments[0])
+			return ok
+		}
+		child, ok := t[segments[0]]
+		return ok && removeSegments(child, segments[1:])
+	case []interface{}:
+		found := false
+		for i, item := range t {
+			if segments[0] != "*" && segments[0] != strconv.Itoa(i) {
+				continue
+			}
+			if len(segments) == 1 {
+				// array elements can't be removed without changing the shape of the array
+				return false
+			}
+			found = removeSegments(item, segments[1:]) || found
+		}
+		return found
+	}
+
+	return false
+}
+
+// setPath replaces the value at a JSON path in normalised content, or adds it if the
+// final segment is a missing object key. Array elements may be addressed by index or
+// with the * wildcard, in which case each element receives its own copy of the value.
+func setPath(content interface{}, path string, value []byte) (interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid path '%s', paths must start with $", path)
+	}
+
+	segments, err := splitPath(path[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid path '%s': %v", path, err)
+	}
+
+	newValue := func() interface{} {
+		var v interface{}
+		_ = json.Unmarshal(value, &v)
+		return v
+	}
+	if len(segments) == 0 {
+		return newValue(), nil
+	}
+
+	if !setSegments(content, segments, newValue) {
+		return nil, fmt.Errorf("path '%s' was not found in the message content", path)
+	}
+
+	return content, nil
+}
+
+func setSegments(v interface{}, segments []string, value func() interface{}) bool {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		// step through matchers to the value they apply to
+		if _, ok := t["pact:matcher:type"]; ok {
+			return setSegments(t["value"], segments, value)
+		}
+		if len(segments) == 1 {
+			t[segments[0]] = value()
+			return true
+		}
+		child, ok := t[segments[0]]
+		return ok && setSegments(child, segments[1:], value)
+	case []interface{}:
+		found := false
+		for i, item := range t {
+			if segments[0] != "*" && segments[0] != strconv.Itoa(i) {
+				continue
+			}
+			if len(segments) == 1 {
+				t[i] = value()
+				found = true
+				continue
+			}
+			found = setSegments(item, segments[1:], value) || found
+		}
+		return found
+	}
+
+	return false
+}
+
+// getPath returns the value at a JSON path (e.g. $.order.id) in normalised content,
+// stepping through matchers to the value they apply to. The value at the path itself
+// is returned as is, which may be a matcher.
+func getPath(content interface{}, path string) (interface{}, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid path '%s', paths must start with $", path)
+	}
+
+	segments, err := splitPath(path[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid path '%s': %v", path, err)
+	}
+
+	v := content
+	for _, segment := range segments {
+		if m, ok := v.(map[string]interface{}); ok {
+			if _, ok := m["pact:matcher:type"]; ok {
+				v = m["value"]
+			}
+		}
+
+		found := false
+		switch t := v.(type) {
+		case map[string]interface{}:
+			v, found = t[segment]
+		case []interface{}:
+			i, err := strconv.Atoi(segment)
+			if found = err == nil && i >= 0 && i < len(t); found {
+				v = t[i]
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("path '%s' was not found in the message content", path)
+		}
+	}
+
+	return v, nil
+}
+
+// splitPath splits the remainder of a JSON path after the $, e.g. .a['b-c'][0] into a, b-c, 0
+func splitPath(path string) ([]string, error) {
+	var segments []string
+
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			end := strings.IndexAny(path[1:], ".[")
+			if end < 0 {
+				end = len(path) - 1
+			}
+			segments = append(segments, path[1:end+1])
+			path = path[end+1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [")
+			}
+			segments = append(segments, strings.Trim(path[1:end], `'"`))
+			path = path[end+1:]
+		default:
+			return nil, fmt.Errorf("unexpected character '%c'", path[0])
+		}
+	}
+
+	return segments, nil
+}
+
+// pluginContents returns the configuration of a plugin as the JSON string given to the plugin
+func pluginContents(config interface{}) (string, error) {
+	if contents, ok := config.(string); ok {
+		return contents, nil
+	}
+	b, err := json.Marshal(config)
+
+	return string(b), err
+}
+
+// prepareJSONContent computes any derived examples in the content and checks its
+// matchers are valid, returning the content to send to the native core
+func prepareJSONContent(content interface{}) (interface{}, error) {
+	resolved, err := matchers.ResolveDerived(content)
+	if err != nil {
+		return content, err
+	}
+
+	return resolved, matchers.Validate(resolved)
+}
+
+// prepareXMLContent returns the XML DSL of the body, to send to the native core, and its
+// example document
+func prepareXMLContent(body matchers.XMLBody) ([]byte, []byte, error) {
+	example, err := body.Example()
+	if err != nil {
+		return nil, nil, err
+	}
+	dsl, err := json.Marshal(body)
+
+	return dsl, example, err
+}
+
+// Match strictness levels, see AsynchronousMessageWithContents.WithMatchStrictness
+const (
+	// MatchStrict matches values exactly, the default
+	MatchStrict = "strict"
+
+	// MatchType matches values by type
+	MatchType = "type"
+
+	// MatchLenient only checks the presence of fields
+	MatchLenient = "lenient"
+)
+
+// applyStrictness sets the default matching of normalised content. Existing matchers
+// are left as they are, so they override the default for their part of the content.
+func applyStrictness(content interface{}, level string) (interface{}, error) {
+	switch level {
+	case MatchStrict:
+		return content, nil
+	case MatchType:
+		return map[string]interface{}{"pact:matcher:type": "type", "value": content}, nil
+	case MatchLenient:
+		return lenient(content), nil
+	default:
+		return nil, fmt.Errorf("invalid match strictness '%s', must be one of '%s', '%s' or '%s'", level, MatchStrict, MatchType, MatchLenient)
+	}
+}
+
+// lenient matches each value by presence: scalars with a regex accepting any value,
+// and arrays by type, with any number of items
+func lenient(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		if _, ok := t["pact:matcher:type"]; ok {
+			return t
+		}
+		res := make(map[string]interface{}, len(t))
+		for k, child := range t {
+			res[k] = lenient(child)
+		}
+		return res
+	case []interface{}:
+		items := make([]interface{}, len(t))
+		for i, item := range t {
+			items[i] = lenient(item)
+		}
+		return map[string]interface{}{"pact:matcher:type": "type", "min": 0, "value": items}
+	case nil:
+		return nil
+	default:
+		return map[string]interface{}{"pact:matcher:type": "regex", "regex": `^[\s\S]*$`, "value": t}
+	}
+}
+
+// Transports supported by ProducerSnippet
+const (
+	TransportKafka = "kafka"
+	TransportAMQP  = "amqp"
+)
+
+// producerSnippet renders a shell command publishing the contents and metadata of a message
+func producerSnippet(transport string, contents []byte, contentType string, metadata map[string]interface{}) (string, error) {
+	if !utf8.Valid(contents) {
+		return "", fmt.Errorf("a producer snippet can only be generated for text content")
+	}
+
+	payload := contents
+	if json.Valid(contents) {
+		var b bytes.Buffer
+		if err := json.Compact(&b, contents); err == nil {
+			payload = b.Bytes()
+		}
+	}
+
+	headers := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		if s, ok := v.(string); ok {
+			headers[k] = s
+		} else {
+			b, _ := json.Marshal(v)
+			headers[k] = string(b)
+		}
+	}
+	lookup := func(def string, keys ...string) string {
+		for _, k := range keys {
+			if v, ok := headers[k]; ok {
+				delete(headers, k)
+				return v
+			}
+		}
+		return def
+	}
+
+	keys := func() []string {
+		names := make([]string, 0, len(headers))
+		for k := range headers {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	switch transport {
+	case TransportKafka:
+		topic := lookup("<topic>", "topic", "kafka_topic")
+		if strings.ContainsAny(string(payload), "\n") {
+			return "", fmt.Errorf("kafka-console-producer can only send single line content")
+		}
+		if len(headers) == 0 {
+			return fmt.Sprintf("echo %s | kafka-console-producer --bootstrap-server localhost:9092 --topic %s", shellQuote(string(payload)), shellQuote(topic)), nil
+		}
+
+		pairs := make([]string, 0, len(headers))
+		for _, k := range keys() {
+			pairs = append(pairs, k+":"+headers[k])
+		}
+		line := strings.Join(pairs, ",") + "\t" + string(payload)
+
+		return fmt.Sprintf("printf '%%s\\n' %s | kafka-console-producer --bootstrap-server localhost:9092 --topic %s --property parse.headers=true --property headers.delimiter=$'\\t'", shellQuote(line), shellQuote(topic)), nil
+	case TransportAMQP:
+		exchange := lookup("amq.default", "exchange")
+		routingKey := lookup("<routing key>", "routingKey", "routing_key", "queue")
+		lookup("", "contentType", "content-type", "Content-Type")
+
+		properties := map[string]interface{}{}
+		if contentType != "" {
+			properties["content_type"] = contentType
+		}
+		if len(headers) > 0 {
+			properties["headers"] = headers
+		}
+		props, _ := json.Marshal(properties)
+
+		return fmt.Sprintf("rabbitmqadmin publish exchange=%s routing_key=%s payload=%s properties=%s",
+			shellQuote(exchange), shellQuote(routingKey), shellQuote(string(payload)), shellQuote(string(props))), nil
+	default:
+		return "", fmt.Errorf("unsupported transport '%s', must be one of '%s' or '%s'", transport, TransportKafka, TransportAMQP)
+	}
+}
+
+// shellQuote quotes a value for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+var placeholder = regexp.MustCompile(`\$\{([^}]+)\}`)
+
+// substitute replaces ${name} placeholders in the strings of normalised content with
+// values from the row. Strings consisting of a single placeholder are replaced by the value.
+func substitute(content interface{}, row map[string]interface{}) (interface{}, error) {
+	switch t := content.(type) {
+	case string:
+		if m := placeholder.FindStringSubmatch(t); m != nil && m[0] == t {
+			v, ok := row[m[1]]
+			if !ok {
+				return nil, fmt.Errorf("no value for placeholder '%s'", m[0])
+			}
+			return v, nil
+		}
+		return substituteString(t, row)
+	case map[string]interface{}:
+		res := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			s, err := substitute(v, row)
+			if err != nil {
+				return nil, err
+			}
+			res[k] = s
+		}
+		return res, nil
+	case []interface{}:
+		res := make([]interface{}, len(t))
+		for i, v := range t {
+			s, err := substitute(v, row)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = s
+		}
+		return res, nil
+	}
+
+	return content, nil
+}
+
+// substituteString interpolates row values into ${name} placeholders in s
+func substituteString(s string, row map[string]interface{}) (string, error) {
+	var err error
+
+	res := placeholder.ReplaceAllStringFunc(s, func(p string) string {
+		v, ok := row[p[2:len(p)-1]]
+		if !ok {
+			err = fmt.Errorf("no value for placeholder '%s'", p)
+			return p
+		}
+		return fmt.Sprint(v)
+	})
+
+	return res, err
+}
+
+// applyLogLevel filters the log output of the pact by Config.LogLevel
+func (c *Config) applyLogLevel() error {
+	if c.LogLevel == "" {
+		return nil
+	}
+
+	logger, err := logging.WithLevel(c.Logger, c.LogLevel)
+	if err != nil {
+		return err
+	}
+	c.Logger = logger
+
+	return nil
 }
@@ -0,0 +1,371 @@
+package v4
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pact-foundation/pact-go/v2/matchers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRemovePath(t *testing.T) {
+	content, err := prepareJSONContent(matchers.StructMatcher{
+		"id":        matchers.Like(1),
+		"createdAt": matchers.Like("2020-01-01T00:00:00Z"),
+		"audit": map[string]interface{}{
+			"by-user": "billy",
+			"at":      "now",
+		},
+		"items": matchers.EachLike(map[string]interface{}{
+			"sku":       matchers.Like("abc"),
+			"updatedAt": "now",
+		}, 2),
+	})
+	assert.NoError(t, err)
+
+	for _, path := range []string{"$.createdAt", "$.audit['by-user']", "$.items[*].updatedAt"} {
+		content, err = removePath(content, path)
+		assert.NoError(t, err, path)
+	}
+
+	body, _ := json.Marshal(content)
+	assert.JSONEq(t, `{
+		"id": {"pact:matcher:type": "type", "specification": "2.0.0", "value": 1},
+		"audit": {"at": "now"},
+		"items": {"pact:matcher:type": "type", "min": 2, "value": [
+			{"sku": {"pact:matcher:type": "type", "specification": "2.0.0", "value": "abc"}},
+			{"sku": {"pact:matcher:type": "type", "specification": "2.0.0", "value": "abc"}}
+		]}
+	}`, string(body))
+
+	for _, path := range []string{"createdAt", "$", "$.missing", "$.items[0]", "$.items[0"} {
+		_, err = removePath(content, path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestSubstitute(t *testing.T) {
+	row := map[string]interface{}{"amount": 10.5, "currency": "AUD", "tags": []string{"a"}}
+
+	var template interface{}
+	_ = json.Unmarshal([]byte(`{
+		"amount": "${amount}",
+		"label": "${amount} ${currency}",
+		"nested": [{"currency": "${currency}", "tags": "${tags}"}],
+		"fixed": 1
+	}`), &template)
+
+	res, err := substitute(template, row)
+	assert.NoError(t, err)
+
+	body, _ := json.Marshal(res)
+	assert.JSONEq(t, `{
+		"amount": 10.5,
+		"label": "10.5 AUD",
+		"nested": [{"currency": "AUD", "tags": ["a"]}],
+		"fixed": 1
+	}`, string(body))
+
+	_, err = substitute("${missing}", row)
+	assert.Error(t, err)
+	_, err = substituteString("a ${missing} value", row)
+	assert.Error(t, err)
+}
+
+func TestSetPath(t *testing.T) {
+	var content interface{}
+	_ = json.Unmarshal([]byte(`{
+		"id": 1,
+		"order": {"customer": {"id": "abc"}, "lines": [{"sku": "a"}, {"sku": "b"}]}
+	}`), &content)
+
+	customer, _ := json.Marshal(matchers.StructMatcher{"id": matchers.Like("abc")})
+	content, err := setPath(content, "$.order.customer", customer)
+	assert.NoError(t, err)
+
+	content, err = setPath(content, "$.order.lines[*].sku", []byte(`{"pact:matcher:type": "type", "value": "a"}`))
+	assert.NoError(t, err)
+
+	body, _ := json.Marshal(content)
+	assert.JSONEq(t, `{
+		"id": 1,
+		"order": {
+			"customer": {"id": {"pact:matcher:type": "type", "specification": "2.0.0", "value": "abc"}},
+			"lines": [
+				{"sku": {"pact:matcher:type": "type", "value": "a"}},
+				{"sku": {"pact:matcher:type": "type", "value": "a"}}
+			]
+		}
+	}`, string(body))
+
+	for _, path := range []string{"order", "$.missing.id", "$.order.lines[5].sku"} {
+		_, err = setPath(content, path, []byte(`1`))
+		assert.Error(t, err, path)
+	}
+}
+
+func TestGetPath(t *testing.T) {
+	var content interface{}
+	_ = json.Unmarshal([]byte(`{
+		"order": {"pact:matcher:type": "type", "value": {"id": {"pact:matcher:type": "type", "value": "abc"}}},
+		"lines": [{"sku": "a"}, {"sku": "b"}]
+	}`), &content)
+
+	id, err := getPath(content, "$.order.id")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"pact:matcher:type": "type", "value": "abc"}, id)
+
+	sku, err := getPath(content, "$.lines[1].sku")
+	assert.NoError(t, err)
+	assert.Equal(t, "b", sku)
+
+	for _, path := range []string{"order", "$.missing", "$.lines[2].sku", "$.lines[*].sku"} {
+		_, err = getPath(content, path)
+		assert.Error(t, err, path)
+	}
+}
+
+func TestApplyStrictness(t *testing.T) {
+	var content interface{}
+	_ = json.Unmarshal([]byte(`{
+		"id": 1,
+		"name": "billy",
+		"tags": ["a"],
+		"email": {"pact:matcher:type": "regex", "value": "billy@example.com", "regex": "^[^@]+@[^@]+$"}
+	}`), &content)
+
+	strict, err := applyStrictness(content, MatchStrict)
+	assert.NoError(t, err)
+	assert.Equal(t, content, strict)
+
+	typed, err := applyStrictness(content, MatchType)
+	assert.NoError(t, err)
+	mismatches, err := matchers.Compare(typed, []byte(`{"id": 2, "name": "bob", "tags": ["b", "c"], "email": "bob@example.com"}`))
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches)
+	mismatches, err = matchers.Compare(typed, []byte(`{"id": "2", "name": "bob", "tags": [], "email": "bob"}`))
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 2)
+
+	lenient, err := applyStrictness(content, MatchLenient)
+	assert.NoError(t, err)
+	mismatches, err = matchers.Compare(lenient, []byte(`{"id": "2", "name": true, "tags": [], "email": "bob@example.com"}`))
+	assert.NoError(t, err)
+	assert.Empty(t, mismatches)
+	mismatches, err = matchers.Compare(lenient, []byte(`{"id": 2, "tags": [], "email": "bob"}`))
+	assert.NoError(t, err)
+	assert.Len(t, mismatches, 2)
+
+	_, err = applyStrictness(content, "loose")
+	assert.Error(t, err)
+}
+
+func TestProducerSnippet(t *testing.T) {
+	contents := []byte(`{
+  "id": 1,
+  "name": "it's billy"
+}`)
+	metadata := map[string]interface{}{"topic": "users", "contentType": "application/json", "version": "1"}
+
+	kafka, err := producerSnippet(TransportKafka, contents, "application/json", metadata)
+	assert.NoError(t, err)
+	assert.Equal(t, "printf '%s\\n' 'contentType:application/json,version:1\t{\"id\":1,\"name\":\"it'\\''s billy\"}' | kafka-console-producer --bootstrap-server localhost:9092 --topic 'users' --property parse.headers=true --property headers.delimiter=$'\\t'", kafka)
+
+	kafka, err = producerSnippet(TransportKafka, []byte("hello"), "text/plain", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "echo 'hello' | kafka-console-producer --bootstrap-server localhost:9092 --topic '<topic>'", kafka)
+
+	amqp, err := producerSnippet(TransportAMQP, contents, "application/json", map[string]interface{}{"routingKey": "users.created", "contentType": "application/json", "version": "1"})
+	assert.NoError(t, err)
+	assert.Equal(t, `rabbitmqadmin publish exchange='amq.default' routing_key='users.created' payload='{"id":1,"name":"it'\''s billy"}' properties='{"content_type":"application/json","headers":{"version":"1"}}'`, amqp)
+
+	_, err = producerSnippet("sqs", contents, "application/json", nil)
+	assert.Error(t, err)
+
+	_, err = producerSnippet(TransportKafka, []byte{0xff, 0xfe}, "application/octet-stream", nil)
+	assert.Error(t, err)
+}
+
+func TestMetadataConditionAppliesTo(t *testing.T) {
+	content := map[string]interface{}{
+		"amount": map[string]interface{}{"pact:matcher:type": "integer", "value": float64(5000)},
+		"tier":   "high-value",
+	}
+
+	assert.True(t, MetadataCondition{MetadataKey: "signature", ContentPath: "$.tier", Value: "high-value"}.appliesTo(content))
+	assert.True(t, MetadataCondition{MetadataKey: "signature", ContentPath: "$.amount", Value: 5000}.appliesTo(content))
+	assert.False(t, MetadataCondition{MetadataKey: "signature", ContentPath: "$.tier", Value: "standard"}.appliesTo(content))
+	assert.False(t, MetadataCondition{MetadataKey: "signature", ContentPath: "$.missing", Value: "x"}.appliesTo(content))
+}
+
+func TestMetadataLinkMismatch(t *testing.T) {
+	link := MetadataLink{MetadataKey: "messageId", ContentPath: "$.id"}
+	content := map[string]interface{}{"id": matchers.Like(42), "name": "order"}
+
+	assert.Nil(t, link.mismatch(content, map[string]interface{}{"messageId": 42}))
+	assert.Nil(t, link.mismatch(content, map[string]interface{}{"messageId": "42"}))
+	assert.Nil(t, link.mismatch(content, map[string]interface{}{"messageId": matchers.Like(42)}))
+
+	mismatch := link.mismatch(content, map[string]interface{}{"messageId": "43"})
+	assert.NotNil(t, mismatch)
+	assert.Equal(t, "metadata.messageId", mismatch.Path)
+	assert.NotNil(t, link.mismatch(content, map[string]interface{}{}))
+	assert.NotNil(t, MetadataLink{MetadataKey: "messageId", ContentPath: "$.missing"}.mismatch(content, map[string]interface{}{"messageId": 42}))
+}
+
+func TestDeprecationSunsetError(t *testing.T) {
+	sunset := time.Date(2026, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	assert.NoError(t, Deprecation{Sunset: sunset}.sunsetError("an order", sunset.Add(-time.Hour)))
+
+	err := Deprecation{Sunset: sunset, Replacement: "an order v2"}.sunsetError("an order", sunset.Add(time.Hour))
+	assert.EqualError(t, err, "message 'an order' was deprecated and its sunset date of 2026-01-31 has passed, use 'an order v2' instead")
+
+	err = Deprecation{Sunset: sunset}.sunsetError("an order", sunset.Add(time.Hour))
+	assert.EqualError(t, err, "message 'an order' was deprecated and its sunset date of 2026-01-31 has passed")
+}
+
+func TestMetadataOrderMismatches(t *testing.T) {
+	declared := []string{"version", "type", "length"}
+
+	assert.Empty(t, metadataOrderMismatches(declared, []string{"version", "type", "length"}))
+	assert.Empty(t, metadataOrderMismatches(declared, []string{"version", "id", "type", "length"}))
+	assert.Empty(t, metadataOrderMismatches(nil, []string{"type", "version"}))
+
+	mismatches := metadataOrderMismatches(declared, []string{"type", "version", "length"})
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, "metadata.type", mismatches[0].Path)
+	assert.Equal(t, "expected key 'type' after 'version'", mismatches[0].Mismatch)
+
+	mismatches = metadataOrderMismatches(declared, []string{"version", "length"})
+	assert.Len(t, mismatches, 1)
+	assert.Equal(t, "metadata.type", mismatches[0].Path)
+}
+
+func TestInFlight(t *testing.T) {
+	var inFlight InFlight
+
+	err := inFlight.Track(func() error {
+		return inFlight.Track(func() error { return nil })
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, inFlight.Track(func() error { return nil }))
+	assert.Equal(t, 2, inFlight.Max())
+}
+
+func TestCallHandler(t *testing.T) {
+	quick := func(ctx context.Context, m AsynchronousMessage) error {
+		return nil
+	}
+	failing := func(ctx context.Context, m AsynchronousMessage) error {
+		return errors.New("failed")
+	}
+	blocking := func(ctx context.Context, m AsynchronousMessage) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+
+	assert.NoError(t, callHandler(context.Background(), 0, quick, AsynchronousMessage{}))
+	assert.NoError(t, callHandler(context.Background(), time.Second, quick, AsynchronousMessage{}))
+	assert.EqualError(t, callHandler(context.Background(), time.Second, failing, AsynchronousMessage{}), "failed")
+	assert.EqualError(t, callHandler(context.Background(), 10*time.Millisecond, blocking, AsynchronousMessage{}), "the message handler did not complete within the timeout of 10ms")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.Equal(t, context.Canceled, callHandler(ctx, time.Second, blocking, AsynchronousMessage{}))
+}
+
+func TestDeadLettered(t *testing.T) {
+	m := AsynchronousMessage{
+		Contents: []byte(`{"id": 1}`),
+		Metadata: Metadata{"contentType": "application/json", "routingKey": "orders"},
+	}
+
+	replayed := deadLettered(m)
+	assert.Equal(t, m.Contents, replayed.Contents)
+	assert.Equal(t, Metadata{
+		"contentType":          "application/json",
+		"routingKey":           "orders",
+		"x-first-death-reason": "rejected",
+		"x-death": []interface{}{map[string]interface{}{
+			"count":  1,
+			"reason": "rejected",
+			"queue":  "orders",
+		}},
+	}, replayed.Metadata)
+	assert.NotContains(t, m.Metadata, DeathMetadataKey)
+}
+
+func TestConfigWithHooks(t *testing.T) {
+	var calls []string
+	config := Config{
+		BeforeEach: func(description string) error {
+			calls = append(calls, "before "+description)
+			return nil
+		},
+		AfterEach: func(description string, err error) error {
+			calls = append(calls, "after "+description)
+			return err
+		},
+	}
+
+	err := config.withHooks("an event", func() error {
+		calls = append(calls, "handler")
+		return nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"before an event", "handler", "after an event"}, calls)
+
+	t.Run("the AfterEach hook is given the handler error", func(t *testing.T) {
+		var handlerErr error
+		config := Config{
+			AfterEach: func(description string, err error) error {
+				handlerErr = err
+				return nil
+			},
+		}
+
+		err := config.withHooks("an event", func() error { return errors.New("boom") })
+		assert.EqualError(t, err, "boom")
+		assert.EqualError(t, handlerErr, "boom")
+	})
+
+	t.Run("a failing BeforeEach hook skips the handler", func(t *testing.T) {
+		called := false
+		config := Config{
+			BeforeEach: func(description string) error { return errors.New("no database") },
+		}
+
+		err := config.withHooks("an event", func() error {
+			called = true
+			return nil
+		})
+		assert.EqualError(t, err, "the BeforeEach hook failed: no database")
+		assert.False(t, called)
+	})
+
+	t.Run("a failing AfterEach hook fails verification", func(t *testing.T) {
+		config := Config{
+			AfterEach: func(description string, err error) error { return errors.New("queue not empty") },
+		}
+
+		err := config.withHooks("an event", func() error { return nil })
+		assert.EqualError(t, err, "the AfterEach hook failed: queue not empty")
+	})
+}
+
+func TestPluginContents(t *testing.T) {
+	contents, err := pluginContents(`{"pact:proto": "plugin.proto"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"pact:proto": "plugin.proto"}`, contents)
+
+	contents, err = pluginContents(map[string]interface{}{"pact:proto": "plugin.proto", "pact:message-type": "Feature"})
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"pact:proto": "plugin.proto", "pact:message-type": "Feature"}`, contents)
+
+	_, err = pluginContents(map[string]interface{}{"invalid": func() {}})
+	assert.Error(t, err)
+}
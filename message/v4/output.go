@@ -0,0 +1,339 @@
+package v4
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/v2/internal/native"
+	logging "github.com/pact-foundation/pact-go/v2/log"
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+)
+
+// Pact file formats, see Config.OutputFormat
+const (
+	// OutputFormatJSON is the standard pact file format
+	OutputFormatJSON = "json"
+
+	// OutputFormatNDJSON writes each interaction as a separate line of JSON to
+	// <consumer>-<provider>.ndjson, for streaming tools
+	OutputFormatNDJSON = "ndjson"
+)
+
+// Pact file write modes, see Config.PactFileWriteMode
+const (
+	// PactFileWriteModeMerge adds the interactions to any existing pact file (the default)
+	PactFileWriteModeMerge = "merge"
+
+	// PactFileWriteModeOverwrite replaces any existing pact file on the first write of
+	// the pact, so that interactions removed from the tests don't linger
+	PactFileWriteModeOverwrite = "overwrite"
+)
+
+// Strategies for interactions with the same description and provider states, see Config.DedupStrategy
+const (
+	// DedupKeepFirst keeps the first of the duplicate interactions
+	DedupKeepFirst = "keep-first"
+
+	// DedupKeepLast keeps the last of the duplicate interactions
+	DedupKeepLast = "keep-last"
+
+	// DedupErrorOnConflict fails to write the pact if duplicate interactions differ
+	DedupErrorOnConflict = "error-on-conflict"
+)
+
+// pactFileWriter writes the pact held by the native core to a directory
+type pactFileWriter func(dir string, overwrite bool) error
+
+func validateOutputFormat(format string) error {
+	switch format {
+	case "", OutputFormatJSON, OutputFormatNDJSON:
+		return nil
+	}
+
+	return fmt.Errorf("unsupported output format '%s', must be one of '%s' or '%s'", format, OutputFormatJSON, OutputFormatNDJSON)
+}
+
+func validateWriteMode(mode string) error {
+	switch mode {
+	case "", PactFileWriteModeMerge, PactFileWriteModeOverwrite:
+		return nil
+	}
+
+	return fmt.Errorf("unsupported pact file write mode '%s', must be one of '%s' or '%s'", mode, PactFileWriteModeMerge, PactFileWriteModeOverwrite)
+}
+
+func validateDedupStrategy(strategy string) error {
+	switch strategy {
+	case "", DedupKeepFirst, DedupKeepLast, DedupErrorOnConflict:
+		return nil
+	}
+
+	return fmt.Errorf("unsupported de-duplication strategy '%s', must be one of '%s', '%s' or '%s'", strategy, DedupKeepFirst, DedupKeepLast, DedupErrorOnConflict)
+}
+
+// pactFilePath is the path to write the pact generated by the native core as file to,
+// see Config.PactFileName
+func pactFilePath(config Config, file string) string {
+	if config.PactFileName != "" {
+		if filepath.IsAbs(config.PactFileName) {
+			return config.PactFileName
+		}
+		return filepath.Join(config.PactDir, config.PactFileName)
+	}
+
+	name := filepath.Base(file)
+	if config.OutputFormat == OutputFormatNDJSON {
+		name = strings.TrimSuffix(name, ".json") + ".ndjson"
+	}
+
+	return filepath.Join(config.PactDir, name)
+}
+
+// writePact writes the pact to the configured file in the configured format. The
+// first write of a pact replaces any existing file in PactFileWriteModeOverwrite.
+func writePact(write pactFileWriter, config Config, first bool) error {
+	overwrite := first && config.PactFileWriteMode == PactFileWriteModeOverwrite
+	if config.OutputFormat != OutputFormatNDJSON && config.DedupStrategy == "" && config.PactFileName == "" {
+		return write(config.PactDir, overwrite)
+	}
+
+	dir, err := ioutil.TempDir("", "pact-ndjson")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	if err = write(dir, true); err != nil {
+		return err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return err
+	}
+
+	defer native.LockPactDir(filepath.Dir(pactFilePath(config, "")))()
+	for _, f := range files {
+		if config.DedupStrategy != "" {
+			if err = dedupe(f, config.DedupStrategy, config.Logger); err != nil {
+				return err
+			}
+		}
+
+		if config.OutputFormat == OutputFormatNDJSON {
+			err = writeNDJSON(f, pactFilePath(config, f), config.Logger)
+		} else {
+			err = mergePactFile(f, pactFilePath(config, f), overwrite, config.Logger)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// dedupe resolves interactions of a pact file with the same type, description and
+// provider states using the strategy, rewriting the file
+func dedupe(file string, strategy string, logger logging.Logger) error {
+	p, err := pactfile.Read(file)
+	if err != nil {
+		return err
+	}
+
+	resolve := func(interactions []*pactfile.Interaction) ([]*pactfile.Interaction, error) {
+		var res []*pactfile.Interaction
+		index := make(map[string]int)
+		for _, i := range interactions {
+			n, seen := index[i.Key()]
+			if !seen {
+				index[i.Key()] = len(res)
+				res = append(res, i)
+				continue
+			}
+			if reflect.DeepEqual(res[n].Raw, i.Raw) {
+				continue
+			}
+
+			switch strategy {
+			case DedupErrorOnConflict:
+				return nil, fmt.Errorf("conflicting interactions named '%s' were defined, descriptions must be unique for the same provider states", i.Description)
+			case DedupKeepLast:
+				logging.Logf(logger, "WARN", "replacing an earlier interaction named '%s' with a later conflicting one", i.Description)
+				res[n] = i
+			default:
+				logging.Logf(logger, "WARN", "ignoring a later conflicting interaction named '%s'", i.Description)
+			}
+		}
+
+		return res, nil
+	}
+
+	if p.Interactions, err = resolve(p.Interactions); err != nil {
+		return err
+	}
+	if p.Messages, err = resolve(p.Messages); err != nil {
+		return err
+	}
+
+	return writePactJSON(file, p)
+}
+
+// mergePactFile writes a generated pact file to path, keeping the interactions of any
+// existing file that were not generated (as the native core does) unless overwriting
+func mergePactFile(file string, path string, overwrite bool, logger logging.Logger) error {
+	generated, err := pactfile.Read(file)
+	if err != nil {
+		return err
+	}
+
+	if overwrite {
+		logging.Logf(logger, "DEBUG", "replacing pact file %s", path)
+	} else if existing, err := pactfile.Read(path); err == nil {
+		keys := make(map[string]bool)
+		for _, i := range generated.AllInteractions() {
+			keys[i.Key()] = true
+		}
+		for _, i := range existing.Interactions {
+			if !keys[i.Key()] {
+				generated.Interactions = append(generated.Interactions, i)
+			}
+		}
+		for _, i := range existing.Messages {
+			if !keys[i.Key()] {
+				generated.Messages = append(generated.Messages, i)
+			}
+		}
+	} else if _, statErr := os.Stat(path); !os.IsNotExist(statErr) {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	logging.Logf(logger, "DEBUG", "writing pact file %s", path)
+
+	return writePactJSON(path, generated)
+}
+
+func writePactJSON(path string, p *pactfile.Pact) error {
+	data, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return fmt.Errorf("unable to serialise the pact: %v", err)
+	}
+
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// writeNDJSON converts a pact file to NDJSON at path. The native core accumulates
+// every interaction of the pact, so any existing file is replaced.
+func writeNDJSON(file string, path string, logger logging.Logger) error {
+	p, err := pactfile.Read(file)
+	if err != nil {
+		return err
+	}
+
+	var out bytes.Buffer
+	for _, i := range p.AllInteractions() {
+		line, err := json.Marshal(i)
+		if err != nil {
+			return fmt.Errorf("unable to serialise interaction '%s': %v", i.Description, err)
+		}
+		out.Write(line)
+		out.WriteByte('\n')
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	logging.Logf(logger, "DEBUG", "writing NDJSON pact file %s", path)
+
+	return ioutil.WriteFile(path, out.Bytes(), 0644)
+}
+
+// FrozenContractError is returned when the generated interactions differ from a
+// frozen contract, see AsynchronousPact.FreezeFrom
+type FrozenContractError struct {
+	PactFile string
+	Changes  []pactfile.Change
+}
+
+func (e *FrozenContractError) Error() string {
+	changes := make([]string, len(e.Changes))
+	for i, c := range e.Changes {
+		changes[i] = c.String()
+	}
+
+	return fmt.Sprintf("the generated interactions differ from the frozen contract %s: %s", e.PactFile, strings.Join(changes, "; "))
+}
+
+// checkFrozen compares the pact held by the native core with a frozen contract.
+// Interactions of the frozen contract that have not (yet) been generated are ignored.
+func checkFrozen(write pactFileWriter, file string, frozen *pactfile.Pact, logger logging.Logger) error {
+	generated, err := generatedPact(write)
+	if err != nil {
+		return err
+	}
+
+	var changes []pactfile.Change
+	for _, c := range pactfile.Diff(frozen, generated) {
+		if c.Kind == pactfile.Removed && c.Path == "" {
+			continue
+		}
+		changes = append(changes, c)
+	}
+	if len(changes) > 0 {
+		return &FrozenContractError{PactFile: file, Changes: changes}
+	}
+
+	logging.Logf(logger, "DEBUG", "the generated interactions match the frozen contract %s", file)
+
+	return nil
+}
+
+// generatedPact reads the pact held by the native core, without writing to the pact directory
+func generatedPact(write pactFileWriter) (*pactfile.Pact, error) {
+	dir, err := ioutil.TempDir("", "pact-generated")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	if err = write(dir, true); err != nil {
+		return nil, err
+	}
+
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil || len(files) != 1 {
+		return nil, fmt.Errorf("unable to read the generated pact: expected a single pact file, found %d", len(files))
+	}
+
+	return pactfile.Read(files[0])
+}
+
+// writeDelta writes the interactions of the pact held by the native core that changed
+// since a previous pact file to outputFile, see pactfile.Delta
+func writeDelta(write pactFileWriter, previousPactFile string, outputFile string, logger logging.Logger) error {
+	previous, err := pactfile.Read(previousPactFile)
+	if err != nil {
+		return err
+	}
+	generated, err := generatedPact(write)
+	if err != nil {
+		return err
+	}
+
+	delta := pactfile.Delta(previous, generated)
+	logging.Logf(logger, "DEBUG", "writing delta of %d interaction(s) since %s to %s", len(delta.AllInteractions()), previousPactFile, outputFile)
+	if err = os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		return err
+	}
+
+	return writePactJSON(outputFile, delta)
+}
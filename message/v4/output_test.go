@@ -0,0 +1,241 @@
+package v4
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWritePact_NDJSON(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ndjson")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(d string, overwrite bool) error {
+		return ioutil.WriteFile(filepath.Join(d, "consumer-provider.json"), []byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "interactions": [
+    {"type": "Asynchronous/Messages", "description": "a", "contents": {"content": {"id": 1}}},
+    {"type": "Asynchronous/Messages", "description": "b", "contents": {"content": {"id": 2}}}
+  ]
+}`), 0644)
+	}
+
+	err = writePact(write, Config{PactDir: dir, OutputFormat: OutputFormatNDJSON}, true)
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "consumer-provider.ndjson"))
+	assert.NoError(t, err)
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	assert.JSONEq(t, `{"type": "Asynchronous/Messages", "description": "a", "contents": {"content": {"id": 1}}}`, lines[0])
+
+	_, err = os.Stat(filepath.Join(dir, "consumer-provider.json"))
+	assert.True(t, os.IsNotExist(err))
+
+	assert.Error(t, validateOutputFormat("xml"))
+}
+
+func TestWritePact_DedupStrategy(t *testing.T) {
+	write := func(d string, overwrite bool) error {
+		return ioutil.WriteFile(filepath.Join(d, "consumer-provider.json"), []byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "interactions": [
+    {"type": "Asynchronous/Messages", "description": "a", "contents": {"content": {"id": 1}}},
+    {"type": "Asynchronous/Messages", "description": "b", "contents": {"content": {"id": 2}}},
+    {"type": "Asynchronous/Messages", "description": "a", "contents": {"content": {"id": 3}}},
+    {"type": "Asynchronous/Messages", "description": "b", "contents": {"content": {"id": 2}}}
+  ]
+}`), 0644)
+	}
+
+	contentOf := func(t *testing.T, dir string) map[string]interface{} {
+		p, err := pactfile.Read(filepath.Join(dir, "consumer-provider.json"))
+		assert.NoError(t, err)
+
+		res := make(map[string]interface{})
+		for _, i := range p.AllInteractions() {
+			res[i.Description] = i.Raw["contents"].(map[string]interface{})["content"]
+		}
+		return res
+	}
+
+	t.Run("keep-first", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, writePact(write, Config{PactDir: dir, DedupStrategy: DedupKeepFirst}, true))
+		assert.Equal(t, map[string]interface{}{
+			"a": map[string]interface{}{"id": float64(1)},
+			"b": map[string]interface{}{"id": float64(2)},
+		}, contentOf(t, dir))
+	})
+
+	t.Run("logs to the configured logger", func(t *testing.T) {
+		logger := &recordingLogger{}
+		assert.NoError(t, writePact(write, Config{PactDir: t.TempDir(), DedupStrategy: DedupKeepFirst, Logger: logger}, true))
+		assert.Contains(t, logger.lines, "WARN ignoring a later conflicting interaction named 'a'")
+	})
+
+	t.Run("keep-last merges with the existing file", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "consumer-provider.json"), []byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "interactions": [
+    {"type": "Asynchronous/Messages", "description": "a", "contents": {"content": {"id": 0}}},
+    {"type": "Asynchronous/Messages", "description": "c", "contents": {"content": {"id": 4}}}
+  ]
+}`), 0644))
+
+		assert.NoError(t, writePact(write, Config{PactDir: dir, DedupStrategy: DedupKeepLast}, true))
+		assert.Equal(t, map[string]interface{}{
+			"a": map[string]interface{}{"id": float64(3)},
+			"b": map[string]interface{}{"id": float64(2)},
+			"c": map[string]interface{}{"id": float64(4)},
+		}, contentOf(t, dir))
+	})
+
+	t.Run("error-on-conflict", func(t *testing.T) {
+		dir := t.TempDir()
+		err := writePact(write, Config{PactDir: dir, DedupStrategy: DedupErrorOnConflict}, true)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "'a'")
+
+		_, err = os.Stat(filepath.Join(dir, "consumer-provider.json"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	assert.Error(t, validateDedupStrategy("keep-some"))
+}
+
+func TestWritePact_WriteMode(t *testing.T) {
+	var modes []bool
+	write := func(d string, overwrite bool) error {
+		modes = append(modes, overwrite)
+		return nil
+	}
+
+	for _, first := range []bool{true, false} {
+		assert.NoError(t, writePact(write, Config{PactDir: t.TempDir()}, first))
+		assert.NoError(t, writePact(write, Config{PactDir: t.TempDir(), PactFileWriteMode: PactFileWriteModeMerge}, first))
+		assert.NoError(t, writePact(write, Config{PactDir: t.TempDir(), PactFileWriteMode: PactFileWriteModeOverwrite}, first))
+	}
+	assert.Equal(t, []bool{false, false, true, false, false, false}, modes)
+
+	assert.Error(t, validateWriteMode("append"))
+}
+
+func TestWritePact_PactFileName(t *testing.T) {
+	write := func(d string, overwrite bool) error {
+		return ioutil.WriteFile(filepath.Join(d, "consumer-provider.json"), []byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "interactions": [{"type": "Asynchronous/Messages", "description": "an order", "contents": {"content": {"id": 1}}}]
+}`), 0644)
+	}
+
+	dir := t.TempDir()
+	assert.NoError(t, writePact(write, Config{PactDir: dir, PactFileName: "orders/consumer-orders.json"}, true))
+	p, err := pactfile.Read(filepath.Join(dir, "orders", "consumer-orders.json"))
+	assert.NoError(t, err)
+	assert.Len(t, p.AllInteractions(), 1)
+	_, err = os.Stat(filepath.Join(dir, "consumer-provider.json"))
+	assert.True(t, os.IsNotExist(err))
+
+	path := filepath.Join(t.TempDir(), "contracts", "orders.ndjson")
+	assert.NoError(t, writePact(write, Config{PactDir: dir, PactFileName: path, OutputFormat: OutputFormatNDJSON}, true))
+	data, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(data), "\n"))
+}
+
+func TestCheckFrozen(t *testing.T) {
+	frozen, err := pactfile.Parse([]byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "interactions": [
+    {"type": "Asynchronous/Messages", "description": "a", "contents": {"content": {"id": 1}}},
+    {"type": "Asynchronous/Messages", "description": "b", "contents": {"content": {"id": 2}}}
+  ]
+}`))
+	assert.NoError(t, err)
+
+	writer := func(interactions string) pactFileWriter {
+		return func(d string, overwrite bool) error {
+			return ioutil.WriteFile(filepath.Join(d, "consumer-provider.json"), []byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "interactions": [`+interactions+`]
+}`), 0644)
+		}
+	}
+
+	t.Run("matching interactions", func(t *testing.T) {
+		err := checkFrozen(writer(`{"type": "Asynchronous/Messages", "description": "a", "contents": {"content": {"id": 1}}}`), "frozen.json", frozen, nil)
+		assert.NoError(t, err)
+	})
+
+	t.Run("differing interactions", func(t *testing.T) {
+		err := checkFrozen(writer(`{"type": "Asynchronous/Messages", "description": "a", "contents": {"content": {"id": "1"}}},
+    {"type": "Asynchronous/Messages", "description": "c", "contents": {"content": {}}}`), "frozen.json", frozen, nil)
+		assert.Error(t, err)
+
+		frozenErr, ok := err.(*FrozenContractError)
+		assert.True(t, ok)
+		assert.Equal(t, []pactfile.Change{
+			{Kind: pactfile.Changed, Interaction: "a", Path: "contents $.content.id", Detail: "type changed from number to string"},
+			{Kind: pactfile.Added, Interaction: "c"},
+		}, frozenErr.Changes)
+	})
+}
+
+func TestWriteDelta(t *testing.T) {
+	dir := t.TempDir()
+	previous := filepath.Join(dir, "previous.json")
+	assert.NoError(t, ioutil.WriteFile(previous, []byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "interactions": [
+    {"type": "Asynchronous/Messages", "description": "a", "contents": {"content": {"id": 1}}},
+    {"type": "Asynchronous/Messages", "description": "b", "contents": {"content": {"id": 2}}}
+  ]
+}`), 0644))
+
+	write := func(d string, overwrite bool) error {
+		return ioutil.WriteFile(filepath.Join(d, "consumer-provider.json"), []byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "interactions": [
+    {"type": "Asynchronous/Messages", "description": "a", "contents": {"content": {"id": 1}}},
+    {"type": "Asynchronous/Messages", "description": "c", "contents": {"content": {"id": 3}}}
+  ]
+}`), 0644)
+	}
+
+	output := filepath.Join(dir, "delta", "consumer-provider.json")
+	assert.NoError(t, writeDelta(write, previous, output, nil))
+
+	delta, err := pactfile.Read(output)
+	assert.NoError(t, err)
+	assert.Len(t, delta.Interactions, 1)
+	assert.Equal(t, "c", delta.Interactions[0].Description)
+	assert.Equal(t, []interface{}{map[string]interface{}{"type": "Asynchronous/Messages", "description": "b"}}, delta.Metadata["pactGo"].(map[string]interface{})[pactfile.DeltaRemovedMetadataKey])
+
+	assert.Error(t, writeDelta(write, filepath.Join(dir, "missing.json"), output, nil))
+}
+
+type recordingLogger struct {
+	lines []string
+}
+
+func (l *recordingLogger) Log(level, msg string, args ...interface{}) {
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(msg, args...))
+}
@@ -0,0 +1,82 @@
+package v4
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// VerifyResult is the outcome of verifying a single message
+type VerifyResult struct {
+	// Description of the message
+	Description string
+
+	// Duration of the verification, including the consumer handler
+	Duration time.Duration
+
+	// Error is the reason verification failed, nil if it passed
+	Error error
+}
+
+type junitTestSuite struct {
+	XMLName  xml.Name        `xml:"testsuite"`
+	Name     string          `xml:"name,attr"`
+	Tests    int             `xml:"tests,attr"`
+	Failures int             `xml:"failures,attr"`
+	Time     string          `xml:"time,attr"`
+	Cases    []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// writeJUnitReport writes the results as a JUnit XML test suite, with one test case per message
+func writeJUnitReport(path string, name string, results []VerifyResult) error {
+	suite := junitTestSuite{
+		Name:  name,
+		Tests: len(results),
+	}
+
+	var total time.Duration
+	for _, r := range results {
+		total += r.Duration
+		c := junitTestCase{
+			Name:      r.Description,
+			ClassName: name,
+			Time:      seconds(r.Duration),
+		}
+		if r.Error != nil {
+			suite.Failures++
+			c.Failure = &junitFailure{Message: r.Error.Error(), Text: r.Error.Error()}
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+	suite.Time = seconds(total)
+
+	out, err := xml.MarshalIndent(suite, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, append([]byte(xml.Header), out...), 0644)
+}
+
+func seconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
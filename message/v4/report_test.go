@@ -0,0 +1,35 @@
+package v4
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteJUnitReport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "junit")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "reports", "pact.xml")
+	err = writeJUnitReport(path, "consumer-provider", []VerifyResult{
+		{Description: "a user event", Duration: 1500 * time.Millisecond},
+		{Description: "an order event", Duration: 250 * time.Millisecond, Error: errors.New("$.id: expected an integer")},
+	})
+	assert.NoError(t, err)
+
+	report, err := ioutil.ReadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuite name="consumer-provider" tests="2" failures="1" time="1.750">
+  <testcase name="a user event" classname="consumer-provider" time="1.500"></testcase>
+  <testcase name="an order event" classname="consumer-provider" time="0.250">
+    <failure message="$.id: expected an integer">$.id: expected an integer</failure>
+  </testcase>
+</testsuite>`, string(report))
+}
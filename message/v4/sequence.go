@@ -0,0 +1,131 @@
+package v4
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/models"
+)
+
+// MessageSequence is a scenario in which the consumer receives several messages in order,
+// e.g. an OrderCreated event then an OrderShipped event:
+//
+//	seq := p.AddMessageSequence("an order is placed and shipped")
+//	seq.ExpectsToReceive("an OrderCreated event").WithJSONContent(created)
+//	seq.ExpectsToReceive("an OrderShipped event").WithJSONContent(shipped)
+//	err := seq.ConsumedBy(handler).Verify(t)
+//
+// The handler is invoked once per message, in order, and the pact file is written once all
+// of the messages have been consumed.
+type MessageSequence struct {
+	pact     *AsynchronousPact
+	scenario string
+	states   []models.ProviderState
+	messages []*AsynchronousMessageBuilder
+	handler  AsynchronousConsumerCtx
+}
+
+// AddMessageSequence creates a new sequence of asynchronous consumer expectations
+func (p *AsynchronousPact) AddMessageSequence(scenario string) *MessageSequence {
+	return &MessageSequence{
+		pact:     p,
+		scenario: scenario,
+	}
+}
+
+// Given specifies a provider state for the messages added to the sequence after it. Optional.
+func (s *MessageSequence) Given(state string) *MessageSequence {
+	s.states = append(s.states, models.ProviderState{Name: state})
+
+	return s
+}
+
+// GivenWithParameter specifies a provider state with parameters for the messages added
+// to the sequence after it. Optional.
+func (s *MessageSequence) GivenWithParameter(state models.ProviderState) *MessageSequence {
+	s.states = append(s.states, state)
+
+	return s
+}
+
+// ExpectsToReceive adds the next message of the sequence
+func (s *MessageSequence) ExpectsToReceive(description string) *UnconfiguredAsynchronousMessageBuilder {
+	message := s.pact.AddAsynchronousMessage().GivenStates(s.states...)
+	s.messages = append(s.messages, message)
+
+	return message.ExpectsToReceive(description)
+}
+
+// ConsumedBy sets the function that consumes each message of the sequence, unless the
+// message has a handler of its own
+func (s *MessageSequence) ConsumedBy(handler AsynchronousConsumer) *MessageSequence {
+	s.handler = withContext(handler)
+
+	return s
+}
+
+// ConsumedByCtx is ConsumedBy for a handler that is given a context
+func (s *MessageSequence) ConsumedByCtx(handler AsynchronousConsumerCtx) *MessageSequence {
+	s.handler = handler
+
+	return s
+}
+
+// Verify consumes the messages of the sequence in order, failing the test at the first
+// message the handler fails to consume. The pact file is written, with the order of the
+// messages recorded under SequencesMetadataKey, only if every message is consumed.
+func (s *MessageSequence) Verify(t *testing.T) error {
+	return s.VerifyContext(context.Background(), t)
+}
+
+// VerifyContext verifies the sequence like Verify, giving the handler a context derived from ctx
+func (s *MessageSequence) VerifyContext(ctx context.Context, t *testing.T) error {
+	err := s.verify(ctx)
+	if err != nil {
+		t.Errorf("VerifyMessageConsumer failed: %v", err)
+	}
+
+	return err
+}
+
+func (s *MessageSequence) verify(ctx context.Context) error {
+	if len(s.messages) == 0 {
+		return fmt.Errorf("the sequence '%s' has no messages", s.scenario)
+	}
+
+	descriptions := make([]string, len(s.messages))
+	for i, message := range s.messages {
+		handler := message.consumer()
+		if handler == nil {
+			handler = s.handler
+		}
+		if handler == nil {
+			return fmt.Errorf("no handler given for message %d of the sequence '%s'", i+1, s.scenario)
+		}
+
+		if err := s.pact.verifyMessage(ctx, message, handler, false); err != nil {
+			return fmt.Errorf("message %d of the sequence '%s' (%s): %v", i+1, s.scenario, message.description, err)
+		}
+		descriptions[i] = message.description
+	}
+
+	s.pact.recordSequence(s.scenario, descriptions)
+	if s.pact.config.DeferPactWrite {
+		return nil
+	}
+
+	return s.pact.writePact(s.pact.messageserver.WritePactFile)
+}
+
+// recordSequence records the order of the messages of a sequence in the pact file metadata
+func (p *AsynchronousPact) recordSequence(scenario string, descriptions []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.sequences == nil {
+		p.sequences = make(map[string][]string)
+	}
+	p.sequences[scenario] = descriptions
+	p.recordMetadata(SequencesMetadataKey)
+}
@@ -0,0 +1,67 @@
+package v4
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/matchers"
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageSequence(t *testing.T) {
+	dir := t.TempDir()
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "sequenceconsumer",
+		Provider: "sequenceprovider",
+		PactDir:  dir,
+	})
+
+	seq := p.AddMessageSequence("an order is placed and shipped").Given("an order exists")
+	seq.ExpectsToReceive("an OrderCreated event").
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(1), "status": "created"})
+	seq.ExpectsToReceive("an OrderShipped event").
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(1), "status": "shipped"})
+
+	var received []interface{}
+	err := seq.ConsumedBy(func(m AsynchronousMessage) error {
+		received = append(received, m.Body.(map[string]interface{})["status"])
+		return nil
+	}).Verify(t)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{"created", "shipped"}, received)
+
+	pact, err := pactfile.Read(filepath.Join(dir, "sequenceconsumer-sequenceprovider.json"))
+	assert.NoError(t, err)
+	assert.Len(t, pact.AllInteractions(), 2)
+	namespace := pact.Metadata[models.MetadataNamespace].(map[string]interface{})
+	assert.Contains(t, namespace[SequencesMetadataKey], `"an order is placed and shipped":["an OrderCreated event","an OrderShipped event"]`)
+
+	t.Run("stops at the first failure without writing the pact", func(t *testing.T) {
+		dir := t.TempDir()
+		p, _ := NewAsynchronousPact(Config{
+			Consumer: "sequenceconsumer",
+			Provider: "sequenceprovider",
+			PactDir:  dir,
+		})
+		seq := p.AddMessageSequence("a failing sequence")
+		seq.ExpectsToReceive("a first event").WithJSONContent(map[string]interface{}{"id": 1})
+		seq.ExpectsToReceive("a second event").WithJSONContent(map[string]interface{}{"id": 2})
+
+		calls := 0
+		err := seq.ConsumedBy(func(m AsynchronousMessage) error {
+			calls++
+			return errors.New("boom")
+		}).verify(context.Background())
+
+		assert.EqualError(t, err, "message 1 of the sequence 'a failing sequence' (a first event): boom")
+		assert.Equal(t, 1, calls)
+		_, err = os.Stat(filepath.Join(dir, "sequenceconsumer-sequenceprovider.json"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
@@ -0,0 +1,165 @@
+package v4
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/pact-foundation/pact-go/v2/internal/native"
+	logging "github.com/pact-foundation/pact-go/v2/log"
+	"github.com/pact-foundation/pact-go/v2/models"
+)
+
+// sharedPacts are the pacts created with Config.SharedHandle, by the pact file they write,
+// with the number of open handles on each
+var sharedPacts = struct {
+	sync.Mutex
+	pacts map[string]*sharedPact
+}{pacts: make(map[string]*sharedPact)}
+
+type sharedPact struct {
+	pact *asynchronousPact
+
+	// config the pact was created with, that the configuration of further handles must match
+	config Config
+
+	handles int
+}
+
+// sharedPactKey identifies the pact file a configuration writes
+func sharedPactKey(config Config) string {
+	dir := config.PactDir
+	if dir == "" {
+		cwd, _ := os.Getwd()
+		dir = filepath.Join(cwd, "pacts")
+	}
+
+	return fmt.Sprintf("%s|%s|%s|%s|%s", config.Consumer, config.Provider, filepath.Clean(dir), config.PactFileName, config.PactSpecification)
+}
+
+// newSharedAsynchronousPact returns a new handle on the open pact writing the same pact
+// file as the configuration, or creates one to share if there is none. The configuration
+// must be the same as that of the pact.
+func newSharedAsynchronousPact(config Config) (*AsynchronousPact, error) {
+	sharedPacts.Lock()
+	defer sharedPacts.Unlock()
+
+	key := sharedPactKey(config)
+	if s, ok := sharedPacts.pacts[key]; ok {
+		if field := configDifference(s.config, config); field != "" {
+			return nil, fmt.Errorf("the shared pact %s was created with a different %s, the pacts sharing a handle must have the same configuration", key, field)
+		}
+		s.handles++
+		handle := &AsynchronousPact{asynchronousPact: s.pact}
+		handle.logf("DEBUG", "sharing the native message pact %s, handles: %d", key, s.handles)
+		native.Init(string(logging.LogLevel()))
+
+		return handle, nil
+	}
+
+	shared := config
+	shared.DeferPactWrite = true
+	pact, err := newAsynchronousPact(shared)
+	if err != nil {
+		return nil, err
+	}
+	pact.sharedKey = key
+	sharedPacts.pacts[key] = &sharedPact{pact: pact.asynchronousPact, config: config, handles: 1}
+
+	return pact, nil
+}
+
+// releaseSharedPact releases a closed handle on a shared pact. It returns whether the
+// handle was the last, so that the pact should be written and closed.
+func releaseSharedPact(handle *AsynchronousPact) bool {
+	sharedPacts.Lock()
+	defer sharedPacts.Unlock()
+
+	s, ok := sharedPacts.pacts[handle.sharedKey]
+	if !ok || s.pact != handle.asynchronousPact {
+		return true
+	}
+
+	s.handles--
+	if s.handles > 0 {
+		return false
+	}
+	delete(sharedPacts.pacts, handle.sharedKey)
+
+	return true
+}
+
+// configDifference returns the name of the first field that differs between the
+// configurations, or "" if they are the same. Functions, e.g. hooks, are the same if they
+// are the same function, e.g. the same function literal.
+func configDifference(a, b Config) string {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	for i := 0; i < va.NumField(); i++ {
+		fa, fb := va.Field(i), vb.Field(i)
+		if fa.Kind() == reflect.Func {
+			if fa.IsNil() != fb.IsNil() || fa.Pointer() != fb.Pointer() {
+				return va.Type().Field(i).Name
+			}
+			continue
+		}
+		if !reflect.DeepEqual(fa.Interface(), fb.Interface()) {
+			return va.Type().Field(i).Name
+		}
+	}
+
+	return ""
+}
+
+// recordMetadata records a change to pact file metadata derived from the messages, e.g.
+// their field descriptions. With Config.DeferPactWrite the metadata is only sent to the
+// native core when the pact file is written, rather than on each change. The caller must
+// hold the lock of the pact.
+func (p *AsynchronousPact) recordMetadata(key string) {
+	if !p.config.DeferPactWrite {
+		p.writeMetadata(key)
+		return
+	}
+
+	if p.pendingMetadata == nil {
+		p.pendingMetadata = make(map[string]bool)
+	}
+	p.pendingMetadata[key] = true
+}
+
+// flushMetadata sends any pending metadata to the native core. The caller must hold the
+// lock of the pact.
+func (p *AsynchronousPact) flushMetadata() {
+	for key := range p.pendingMetadata {
+		p.writeMetadata(key)
+	}
+	p.pendingMetadata = nil
+}
+
+// writeMetadata sends the metadata of the key to the native core
+func (p *AsynchronousPact) writeMetadata(key string) {
+	var value interface{}
+	switch key {
+	case FieldDescriptionsMetadataKey:
+		value = p.fieldDescriptions
+	case DeprecatedFieldsMetadataKey:
+		value = p.deprecatedFields
+	case IgnoredFieldsMetadataKey:
+		value = p.ignoredFields
+	case MetadataConditionsMetadataKey:
+		value = p.metadataConditions
+	case MetadataLinksMetadataKey:
+		value = p.metadataLinks
+	case DeprecationsMetadataKey:
+		value = p.deprecations
+	case NegativeExamplesMetadataKey:
+		value = p.negativeExamples
+	case SequencesMetadataKey:
+		value = p.sequences
+	}
+
+	b, _ := json.Marshal(value)
+	p.messageserver.WithMetadata(models.MetadataNamespace, key, string(b))
+}
@@ -0,0 +1,159 @@
+package v4
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pact-foundation/pact-go/v2/matchers"
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSharedPactKey(t *testing.T) {
+	config := Config{Consumer: "consumer", Provider: "provider", PactDir: "/tmp/pacts/"}
+
+	assert.Equal(t, sharedPactKey(config), sharedPactKey(Config{Consumer: "consumer", Provider: "provider", PactDir: "/tmp/pacts"}))
+	assert.NotEqual(t, sharedPactKey(config), sharedPactKey(Config{Consumer: "consumer", Provider: "other", PactDir: "/tmp/pacts"}))
+	assert.NotEqual(t, sharedPactKey(config), sharedPactKey(Config{Consumer: "consumer", Provider: "provider", PactDir: "/tmp/pacts", PactFileName: "orders.json"}))
+}
+
+func TestRecordMetadataDeferred(t *testing.T) {
+	p := &AsynchronousPact{asynchronousPact: &asynchronousPact{config: Config{DeferPactWrite: true}}}
+
+	p.recordMetadata(FieldDescriptionsMetadataKey)
+	p.recordMetadata(SequencesMetadataKey)
+	p.recordMetadata(FieldDescriptionsMetadataKey)
+
+	assert.Equal(t, map[string]bool{FieldDescriptionsMetadataKey: true, SequencesMetadataKey: true}, p.pendingMetadata)
+}
+
+func TestAsyncSharedHandle(t *testing.T) {
+	dir := t.TempDir()
+	config := Config{
+		Consumer:     "sharedhandleconsumer",
+		Provider:     "sharedhandleprovider",
+		PactDir:      dir,
+		SharedHandle: true,
+	}
+	file := filepath.Join(dir, "sharedhandleconsumer-sharedhandleprovider.json")
+
+	first, err := NewAsynchronousPact(config)
+	assert.NoError(t, err)
+	second, err := NewAsynchronousPact(config)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, second)
+	assert.Same(t, first.asynchronousPact, second.asynchronousPact)
+
+	for i, p := range []*AsynchronousPact{first, second} {
+		err := p.AddAsynchronousMessage().
+			ExpectsToReceive(fmt.Sprintf("shared handle message %d", i)).
+			WithJSONContent(map[string]interface{}{"id": matchers.Like(i)}).
+			IgnoreContentFields("$.trace").
+			ConsumedBy(func(m AsynchronousMessage) error { return nil }).
+			Verify(t)
+		assert.NoError(t, err)
+	}
+
+	assert.NoError(t, first.Close())
+	assert.NoError(t, first.Close())
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err), "the pact file is written before the last handle is closed")
+
+	assert.NoError(t, second.Close())
+	pact, err := pactfile.Read(file)
+	assert.NoError(t, err)
+	assert.Len(t, pact.AllInteractions(), 2)
+	metadata, _ := pact.Metadata[models.MetadataNamespace].(map[string]interface{})
+	assert.Contains(t, metadata, IgnoredFieldsMetadataKey)
+}
+
+func TestAsyncSharedHandleConfig(t *testing.T) {
+	config := Config{
+		Consumer:     "sharedconfigconsumer",
+		Provider:     "sharedconfigprovider",
+		PactDir:      t.TempDir(),
+		SharedHandle: true,
+		AfterSuite:   func() error { return nil },
+	}
+
+	first, err := NewAsynchronousPact(config)
+	assert.NoError(t, err)
+	defer first.Close()
+
+	other := config
+	other.AfterSuite = func() error { return errors.New("a different hook") }
+	_, err = NewAsynchronousPact(other)
+	assert.ErrorContains(t, err, "was created with a different AfterSuite")
+
+	other = config
+	other.HandlerTimeout = time.Second
+	_, err = NewAsynchronousPact(other)
+	assert.ErrorContains(t, err, "was created with a different HandlerTimeout")
+
+	second, err := NewAsynchronousPact(config)
+	assert.NoError(t, err)
+	assert.NoError(t, second.Close())
+	assert.NoError(t, second.Close())
+	assert.Equal(t, 1, sharedPacts.pacts[sharedPactKey(config)].handles, "closing a handle twice must not release another")
+}
+
+func TestConfigDifference(t *testing.T) {
+	hook := func() error { return nil }
+	a := Config{Consumer: "consumer", Provider: "provider", AfterSuite: hook, LogLevel: "DEBUG"}
+
+	assert.Equal(t, "", configDifference(a, a))
+	b := a
+	b.LogLevel = "INFO"
+	assert.Equal(t, "LogLevel", configDifference(a, b))
+	b = a
+	b.AfterSuite = nil
+	assert.Equal(t, "AfterSuite", configDifference(a, b))
+	b = a
+	b.BeforeEach = func(string) error { return nil }
+	assert.Equal(t, "BeforeEach", configDifference(a, b))
+}
+
+// BenchmarkAsyncVerify compares verifying a suite of messages with a pact file written
+// after each message, with the suite verified with a shared handle, writing the pact
+// file and the pact metadata once
+func BenchmarkAsyncVerify(b *testing.B) {
+	const messages = 100
+
+	verify := func(b *testing.B, p *AsynchronousPact, i int) {
+		message := p.AddAsynchronousMessage().
+			ExpectsToReceive(fmt.Sprintf("benchmark message %d", i)).
+			WithJSONContent(map[string]interface{}{"id": matchers.Described(matchers.Like(i), "the id")})
+		if err := p.verifyMessageConsumerRaw(message.rootBuilder, func(m AsynchronousMessage) error { return nil }); err != nil {
+			b.Fatal(err)
+		}
+	}
+
+	b.Run("write per message", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			p, _ := NewAsynchronousPact(Config{Consumer: "benchconsumer", Provider: "benchprovider", PactDir: b.TempDir()})
+			for i := 0; i < messages; i++ {
+				verify(b, p, i)
+			}
+			p.Close()
+		}
+	})
+
+	b.Run("shared handle", func(b *testing.B) {
+		for n := 0; n < b.N; n++ {
+			config := Config{Consumer: "benchconsumer", Provider: "benchprovider", PactDir: b.TempDir(), SharedHandle: true}
+			pacts := make([]*AsynchronousPact, messages)
+			for i := range pacts {
+				pacts[i], _ = NewAsynchronousPact(config)
+				verify(b, pacts[i], i)
+			}
+			for _, p := range pacts {
+				p.Close()
+			}
+		}
+	})
+}
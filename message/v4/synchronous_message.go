@@ -1,15 +1,19 @@
 package v4
 
 import (
+	"bytes"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/pact-foundation/pact-go/v2/internal/native"
 	mockserver "github.com/pact-foundation/pact-go/v2/internal/native"
 	logging "github.com/pact-foundation/pact-go/v2/log"
+	"github.com/pact-foundation/pact-go/v2/matchers"
 	"github.com/pact-foundation/pact-go/v2/models"
 )
 
@@ -18,6 +22,13 @@ type SynchronousPact struct {
 
 	// Reference to the native rust handle
 	mockserver *native.MessageServer
+
+	// written is set once the pact file has been written, see Config.PactFileWriteMode
+	written bool
+
+	// closed is set once the pact has been closed, guarded by closeMu
+	closed  bool
+	closeMu sync.Mutex
 }
 
 // SynchronousMessage contains a req/res message
@@ -58,6 +69,18 @@ func (m *UnconfiguredSynchronousMessageBuilder) GivenWithParameter(state models.
 	}
 }
 
+// GivenStates specifies several provider states, added to any already given
+func (m *UnconfiguredSynchronousMessageBuilder) GivenStates(states ...models.ProviderState) *UnconfiguredSynchronousMessageBuilder {
+	for _, state := range states {
+		m.messageHandle.GivenWithParameter(state.Name, state.Parameters)
+	}
+
+	return &UnconfiguredSynchronousMessageBuilder{
+		pact:          m.pact,
+		messageHandle: m.messageHandle,
+	}
+}
+
 type UnconfiguredSynchronousMessageBuilder struct {
 	messageHandle *native.Message
 	pact          *SynchronousPact
@@ -65,37 +88,46 @@ type UnconfiguredSynchronousMessageBuilder struct {
 
 // UsingPlugin enables a plugin for use in the current test case
 func (m *UnconfiguredSynchronousMessageBuilder) UsingPlugin(config PluginConfig) *SynchronousMessageWithPlugin {
-	m.pact.mockserver.UsingPlugin(config.Plugin, config.Version)
-
-	return &SynchronousMessageWithPlugin{
+	s := &SynchronousMessageWithPlugin{
 		pact:          m.pact,
 		messageHandle: m.messageHandle,
 	}
+
+	return s.UsingPlugin(config)
 }
 
-// UsingPlugin enables a plugin for use in the current test case
+// UsingPlugin enables a further plugin for use in the current test case
 func (m *SynchronousMessageWithPlugin) UsingPlugin(config PluginConfig) *SynchronousMessageWithPlugin {
-	m.pact.mockserver.UsingPlugin(config.Plugin, config.Version)
+	if err := m.pact.mockserver.UsingPlugin(config.Plugin, config.Version); err != nil && m.err == nil {
+		m.err = fmt.Errorf("unable to load plugin %s %s: %v", config.Plugin, config.Version, err)
+	}
 
 	return m
 }
 
 // AddMessage creates a new asynchronous consumer expectation
 func (m *UnconfiguredSynchronousMessageBuilder) WithRequest(r RequestBuilderFunc) *SynchronousMessageWithRequest {
-	r(&SynchronousMessageWithRequestBuilder{
+	builder := &SynchronousMessageWithRequestBuilder{
 		messageHandle: m.messageHandle,
 		pact:          m.pact,
-	})
+	}
+	r(builder)
 
 	return &SynchronousMessageWithRequest{
 		pact:          m.pact,
 		messageHandle: m.messageHandle,
+		err:           builder.err,
+		request:       builder.content,
 	}
 }
 
 type SynchronousMessageWithRequest struct {
 	messageHandle *native.Message
 	pact          *SynchronousPact
+	err           error
+
+	// The expected request, see SynchronousMessageWithResponse.VerifyClient
+	request interface{}
 }
 
 type RequestBuilderFunc func(*SynchronousMessageWithRequestBuilder)
@@ -103,6 +135,12 @@ type RequestBuilderFunc func(*SynchronousMessageWithRequestBuilder)
 type SynchronousMessageWithRequestBuilder struct {
 	messageHandle *native.Message
 	pact          *SynchronousPact
+
+	// err is the first error encountered while building the request
+	err error
+
+	// The request content, retained so the request a client sends can be compared with it
+	content interface{}
 }
 
 // WithMetadata specifies message-implementation specific metadata
@@ -114,9 +152,20 @@ func (m *SynchronousMessageWithRequestBuilder) WithMetadata(metadata map[string]
 	return m
 }
 
+// WithMetadataMatchers specifies message metadata that is matched by the given matchers,
+// rather than by equality, e.g. matchers.MapMatcher{"topic": matchers.Regex("orders-v1", "orders-.*")}
+func (m *SynchronousMessageWithRequestBuilder) WithMetadataMatchers(metadata matchers.MapMatcher) *SynchronousMessageWithRequestBuilder {
+	for k, v := range metadata {
+		m.messageHandle.WithMetadataMatcher(k, v)
+	}
+
+	return m
+}
+
 // WithContent specifies the payload in bytes that the consumer expects to receive
 func (m *SynchronousMessageWithRequestBuilder) WithContent(contentType string, body []byte) *SynchronousMessageWithRequestBuilder {
 	m.messageHandle.WithContents(native.INTERACTION_PART_REQUEST, contentType, body)
+	m.content = body
 
 	return m
 }
@@ -124,27 +173,55 @@ func (m *SynchronousMessageWithRequestBuilder) WithContent(contentType string, b
 // WithJSONContent specifies the payload as an object (to be marshalled to WithJSONContent) that
 // is expected to be consumed
 func (m *SynchronousMessageWithRequestBuilder) WithJSONContent(content interface{}) *SynchronousMessageWithRequestBuilder {
+	content, err := prepareJSONContent(content)
+	if err != nil && m.err == nil {
+		m.err = fmt.Errorf("invalid request content: %v", err)
+	}
 	m.messageHandle.WithRequestJSONContents(content)
+	m.content = content
+
+	return m
+}
+
+// WithXMLContent specifies the payload as an XML document, whose elements, attributes and
+// text may be matched. See matchers.XMLDocument
+func (m *SynchronousMessageWithRequestBuilder) WithXMLContent(body matchers.XMLBody) *SynchronousMessageWithRequestBuilder {
+	dsl, example, err := prepareXMLContent(body)
+	if err != nil && m.err == nil {
+		m.err = fmt.Errorf("invalid request content: %v", err)
+	}
+	m.messageHandle.WithContents(native.INTERACTION_PART_REQUEST, matchers.XMLContentType, dsl)
+	m.content = example
 
 	return m
 }
 
 // AddMessage creates a new asynchronous consumer expectation
 func (m *SynchronousMessageWithRequest) WithResponse(builder ResponseBuilderFunc) *SynchronousMessageWithResponse {
-	builder(&SynchronousMessageWithResponseBuilder{
+	b := &SynchronousMessageWithResponseBuilder{
 		messageHandle: m.messageHandle,
 		pact:          m.pact,
-	})
+	}
+	builder(b)
+
+	err := m.err
+	if err == nil {
+		err = b.err
+	}
 
 	return &SynchronousMessageWithResponse{
 		pact:          m.pact,
 		messageHandle: m.messageHandle,
+		err:           err,
+		request:       m.request,
 	}
 }
 
 type SynchronousMessageWithResponse struct {
 	messageHandle *native.Message
 	pact          *SynchronousPact
+	err           error
+	request       interface{}
 }
 
 type ResponseBuilderFunc func(*SynchronousMessageWithResponseBuilder)
@@ -152,6 +229,9 @@ type ResponseBuilderFunc func(*SynchronousMessageWithResponseBuilder)
 type SynchronousMessageWithResponseBuilder struct {
 	messageHandle *native.Message
 	pact          *SynchronousPact
+
+	// err is the first error encountered while building the response
+	err error
 }
 
 // WithMetadata specifies message-implementation specific metadata
@@ -163,6 +243,16 @@ func (m *SynchronousMessageWithResponseBuilder) WithMetadata(metadata map[string
 	return m
 }
 
+// WithMetadataMatchers specifies message metadata that is matched by the given matchers,
+// rather than by equality, e.g. matchers.MapMatcher{"topic": matchers.Regex("orders-v1", "orders-.*")}
+func (m *SynchronousMessageWithResponseBuilder) WithMetadataMatchers(metadata matchers.MapMatcher) *SynchronousMessageWithResponseBuilder {
+	for k, v := range metadata {
+		m.messageHandle.WithMetadataMatcher(k, v)
+	}
+
+	return m
+}
+
 // WithContent specifies the payload in bytes that the consumer expects to receive
 // May be called multiple times, with each call appeding a new response to the interaction
 func (m *SynchronousMessageWithResponseBuilder) WithContent(contentType string, body []byte) *SynchronousMessageWithResponseBuilder {
@@ -174,34 +264,77 @@ func (m *SynchronousMessageWithResponseBuilder) WithContent(contentType string,
 // WithJSONContent specifies the payload as an object (to be marshalled to WithJSONContent) that
 // is expected to be consumed
 func (m *SynchronousMessageWithResponseBuilder) WithJSONContent(content interface{}) *SynchronousMessageWithResponseBuilder {
+	content, err := prepareJSONContent(content)
+	if err != nil && m.err == nil {
+		m.err = fmt.Errorf("invalid response content: %v", err)
+	}
 	m.messageHandle.WithResponseJSONContents(content)
 
 	return m
 }
 
+// WithXMLContent specifies the payload as an XML document, whose elements, attributes and
+// text may be matched. See matchers.XMLDocument
+func (m *SynchronousMessageWithResponseBuilder) WithXMLContent(body matchers.XMLBody) *SynchronousMessageWithResponseBuilder {
+	dsl, _, err := prepareXMLContent(body)
+	if err != nil && m.err == nil {
+		m.err = fmt.Errorf("invalid response content: %v", err)
+	}
+	m.messageHandle.WithContents(native.INTERACTION_PART_RESPONSE, matchers.XMLContentType, dsl)
+
+	return m
+}
+
 type SynchronousMessageWithPlugin struct {
 	messageHandle *native.Message
 	pact          *SynchronousPact
+
+	// err is the first error encountered while loading the plugins or setting the contents
+	err error
 }
 
+// WithContents specifies the contents of the message, in the format of the plugin for
+// the content type, e.g. the fields of a protobuf message
 func (s *SynchronousMessageWithPlugin) WithContents(contents string, contentType string) *SynchronousMessageWithPluginContents {
-	s.messageHandle.WithPluginInteractionContents(native.INTERACTION_PART_REQUEST, contentType, contents)
+	if err := s.messageHandle.WithPluginInteractionContents(native.INTERACTION_PART_REQUEST, contentType, contents); err != nil && s.err == nil {
+		s.err = fmt.Errorf("invalid plugin contents: %v", err)
+	}
 
 	return &SynchronousMessageWithPluginContents{
 		pact:          s.pact,
 		messageHandle: s.messageHandle,
+		err:           s.err,
+	}
+}
+
+// WithContentsFromPlugin specifies the contents of the message as the configuration of the
+// plugin for the content type, as a JSON string or a value marshalled to JSON, e.g. the
+// "pact:proto" file and "pact:message-type" of a protobuf message
+func (s *SynchronousMessageWithPlugin) WithContentsFromPlugin(contentType string, config interface{}) *SynchronousMessageWithPluginContents {
+	contents, err := pluginContents(config)
+	if err != nil && s.err == nil {
+		s.err = fmt.Errorf("invalid plugin contents: %v", err)
 	}
+
+	return s.WithContents(contents, contentType)
 }
 
 type SynchronousMessageWithPluginContents struct {
 	messageHandle *native.Message
 	pact          *SynchronousPact
+
+	// err is the first error encountered while loading the plugins or setting the contents
+	err error
 }
 
 // ExecuteTest runs the current test case against a Mock Service.
 // Will cleanup interactions between tests within a suite
 // and write the pact file if successful
 func (m *SynchronousMessageWithPluginContents) ExecuteTest(t *testing.T, integrationTest func(m SynchronousMessage) error) error {
+	if m.err != nil {
+		return m.err
+	}
+
 	message, err := getSynchronousMessageWithContents(m.messageHandle)
 	if err != nil {
 		return err
@@ -213,7 +346,7 @@ func (m *SynchronousMessageWithPluginContents) ExecuteTest(t *testing.T, integra
 		return err
 	}
 
-	return m.pact.mockserver.WritePactFile(m.pact.config.PactDir, false)
+	return m.pact.writePact(m.pact.mockserver.WritePactFile)
 }
 
 func (s *SynchronousMessageWithPluginContents) StartTransport(transport string, address string, config map[string][]interface{}) *SynchronousMessageWithTransport {
@@ -226,6 +359,7 @@ func (s *SynchronousMessageWithPluginContents) StartTransport(transport string,
 	return &SynchronousMessageWithTransport{
 		pact:          s.pact,
 		messageHandle: s.messageHandle,
+		err:           s.err,
 		transport: TransportConfig{
 			Port:    port,
 			Address: address,
@@ -237,9 +371,16 @@ type SynchronousMessageWithTransport struct {
 	messageHandle *native.Message
 	pact          *SynchronousPact
 	transport     TransportConfig
+
+	// err is the first error encountered while configuring the transport
+	err error
 }
 
 func (s *SynchronousMessageWithTransport) ExecuteTest(t *testing.T, integrationTest func(tc TransportConfig, m SynchronousMessage) error) error {
+	if s.err != nil {
+		return s.err
+	}
+
 	message, err := getSynchronousMessageWithContents(s.messageHandle)
 	if err != nil {
 		return err
@@ -263,7 +404,9 @@ func (s *SynchronousMessageWithTransport) ExecuteTest(t *testing.T, integrationT
 
 	s.pact.mockserver.CleanupPlugins()
 
-	return s.pact.mockserver.WritePactFileForServer(s.transport.Port, s.pact.config.PactDir, false)
+	return s.pact.writePact(func(dir string, overwrite bool) error {
+		return s.pact.mockserver.WritePactFileForServer(s.transport.Port, dir, overwrite)
+	})
 }
 
 type PluginConfig struct {
@@ -286,23 +429,74 @@ func NewSynchronousPact(config Config) (*SynchronousPact, error) {
 	return provider, err
 }
 
+// Close releases the native resources held by the pact. Other pacts in the
+// same process are unaffected. The pact must not be used once closed, closing it again
+// has no effect
+func (m *SynchronousPact) Close() error {
+	m.closeMu.Lock()
+	defer m.closeMu.Unlock()
+
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	defer native.Shutdown()
+
+	return m.mockserver.Close()
+}
+
 // validateConfig validates the configuration for the consumer test
+// WriteDelta writes a pact file holding only the interactions that were added or changed
+// since previousPactFile to outputFile, see AsynchronousPact.WriteDelta
+func (m *SynchronousPact) WriteDelta(previousPactFile, outputFile string) error {
+	return writeDelta(m.mockserver.WritePactFile, previousPactFile, outputFile, m.config.Logger)
+}
+
+// writePact writes the pact file, see Config.PactFileWriteMode
+func (m *SynchronousPact) writePact(write pactFileWriter) error {
+	err := writePact(write, m.config, !m.written)
+	if err == nil {
+		m.written = true
+	}
+
+	return err
+}
+
 func (m *SynchronousPact) validateConfig() error {
-	log.Println("[DEBUG] pact synchronous message validate config")
+	if err := m.config.applyLogLevel(); err != nil {
+		return err
+	}
+	logging.Logf(m.config.Logger, "DEBUG", "pact synchronous message validate config")
 	dir, _ := os.Getwd()
 
 	if m.config.PactDir == "" {
 		m.config.PactDir = filepath.Join(dir, "pacts")
 	}
 
+	if err := validateOutputFormat(m.config.OutputFormat); err != nil {
+		return err
+	}
+	if err := validateDedupStrategy(m.config.DedupStrategy); err != nil {
+		return err
+	}
+	if err := validateWriteMode(m.config.PactFileWriteMode); err != nil {
+		return err
+	}
+	if m.config.PactSpecification != "" && m.config.PactSpecification != models.V4 {
+		return fmt.Errorf("unsupported pact specification '%s', synchronous messages require %s", m.config.PactSpecification, models.V4)
+	}
+
 	m.mockserver = native.NewMessageServer(m.config.Consumer, m.config.Provider)
 	m.mockserver.WithSpecificationVersion(mockserver.SPECIFICATION_VERSION_V4)
+	if m.config.Environment != "" {
+		m.mockserver.WithMetadata(models.MetadataNamespace, models.EnvironmentMetadataKey, m.config.Environment)
+	}
 
 	return nil
 }
 
 func (m *SynchronousPact) AddSynchronousMessage(description string) *UnconfiguredSynchronousMessageBuilder {
-	log.Println("[DEBUG] add sync message")
+	logging.Logf(m.config.Logger, "DEBUG", "add sync message")
 
 	message := m.mockserver.NewSyncMessageInteraction(description)
 
@@ -316,6 +510,13 @@ func (m *SynchronousPact) AddSynchronousMessage(description string) *Unconfigure
 // Will cleanup interactions between tests within a suite
 // and write the pact file if successful
 func (m *SynchronousMessageWithResponse) ExecuteTest(t *testing.T, integrationTest func(md SynchronousMessage) error) error {
+	if m.err != nil {
+		return m.err
+	}
+	if err := m.messageHandle.Err(); err != nil {
+		return fmt.Errorf("unable to build the synchronous message: %v", err)
+	}
+
 	message, err := getSynchronousMessageWithContents(m.messageHandle)
 	if err != nil {
 		return err
@@ -327,7 +528,97 @@ func (m *SynchronousMessageWithResponse) ExecuteTest(t *testing.T, integrationTe
 		return err
 	}
 
-	return m.pact.mockserver.WritePactFile(m.pact.config.PactDir, false)
+	return m.pact.writePact(m.pact.mockserver.WritePactFile)
+}
+
+// SynchronousClient is the consumer code under test for a synchronous message. It sends its
+// request with send, which returns the response, and handles the response.
+type SynchronousClient func(send func(request []byte) (MessageContents, error)) error
+
+// VerifyClient verifies the consumer against both halves of the interaction: the request
+// the client sends must satisfy the expected request, and the client must handle the
+// expected response, which send returns, without error. The pact file is written if both
+// halves are verified.
+func (m *SynchronousMessageWithResponse) VerifyClient(t *testing.T, client SynchronousClient) error {
+	err := m.verifyClient(client)
+	if err != nil {
+		t.Errorf("VerifyClient failed: %v", err)
+		return err
+	}
+
+	return m.pact.writePact(m.pact.mockserver.WritePactFile)
+}
+
+func (m *SynchronousMessageWithResponse) verifyClient(client SynchronousClient) error {
+	if m.err != nil {
+		return m.err
+	}
+	if err := m.messageHandle.Err(); err != nil {
+		return fmt.Errorf("unable to build the synchronous message: %v", err)
+	}
+
+	message, err := getSynchronousMessageWithContents(m.messageHandle)
+	if err != nil {
+		return err
+	}
+
+	sent := false
+	var requestErr error
+	send := func(request []byte) (MessageContents, error) {
+		sent = true
+		if requestErr = compareRequest(m.request, request); requestErr != nil {
+			return MessageContents{}, requestErr
+		}
+		if len(message.Response) == 0 {
+			return MessageContents{}, fmt.Errorf("the interaction has no response")
+		}
+
+		return message.Response[0], nil
+	}
+
+	err = client(send)
+	if requestErr != nil {
+		return requestErr
+	}
+	if err != nil {
+		return err
+	}
+	if !sent {
+		return fmt.Errorf("the client did not send a request")
+	}
+
+	return nil
+}
+
+// compareRequest checks a request sent by the client against the expected request content
+func compareRequest(expected interface{}, request []byte) error {
+	var mismatches []matchers.Mismatch
+	if raw, ok := expected.([]byte); ok {
+		if !bytes.Equal(raw, request) {
+			mismatches = append(mismatches, matchers.Mismatch{
+				Path:     "$",
+				Expected: raw,
+				Actual:   request,
+				Mismatch: "expected the binary request to be equal",
+			})
+		}
+	} else {
+		res, err := matchers.Compare(expected, request)
+		if err != nil {
+			return err
+		}
+		mismatches = res
+	}
+
+	if len(mismatches) > 0 {
+		descriptions := make([]string, len(mismatches))
+		for i, mismatch := range mismatches {
+			descriptions[i] = mismatch.String()
+		}
+		return fmt.Errorf("the request sent by the client does not satisfy the contract: %s", strings.Join(descriptions, "; "))
+	}
+
+	return nil
 }
 
 func getSynchronousMessageWithContents(message *native.Message) (SynchronousMessage, error) {
@@ -1,12 +1,14 @@
 package v4
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
 	"testing"
 
 	"github.com/pact-foundation/pact-go/v2/log"
+	"github.com/pact-foundation/pact-go/v2/matchers"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -45,10 +47,12 @@ func TestSyncTypeSystem(t *testing.T) {
 		WithRequest(func(r *SynchronousMessageWithRequestBuilder) {
 			r.WithJSONContent(map[string]string{"foo": "bar"})
 			r.WithMetadata(map[string]string{})
+			r.WithMetadataMatchers(matchers.MapMatcher{"topic": matchers.Regex("orders-v1", "orders-.*")})
 		}).
 		WithResponse(func(r *SynchronousMessageWithResponseBuilder) {
 			r.WithJSONContent(map[string]string{"foo": "bar"})
 			r.WithMetadata(map[string]string{})
+			r.WithMetadataMatchers(matchers.MapMatcher{"correlationId": matchers.UUID()})
 		}).
 		ExecuteTest(t, func(m SynchronousMessage) error {
 			// In this scenario, we have no real transport, so we need to mock/handle both directions
@@ -140,3 +144,78 @@ func TestSyncTypeSystem(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func TestSyncUsingPlugins(t *testing.T) {
+	p, _ := NewSynchronousPact(Config{
+		Consumer: "consumer",
+		Provider: "provider",
+		PactDir:  t.TempDir(),
+	})
+
+	called := false
+	err := p.AddSynchronousMessage("a message of plugins that aren't installed").
+		UsingPlugin(PluginConfig{Plugin: "not-a-plugin", Version: "0.0.1"}).
+		UsingPlugin(PluginConfig{Plugin: "nor-a-plugin", Version: "0.0.2"}).
+		WithContentsFromPlugin("application/protobuf", `{"pact:proto": "plugin.proto"}`).
+		ExecuteTest(t, func(m SynchronousMessage) error {
+			called = true
+			return nil
+		})
+
+	assert.ErrorContains(t, err, "unable to load plugin not-a-plugin 0.0.1")
+	assert.False(t, called)
+}
+
+func TestSyncVerifyClient(t *testing.T) {
+	p, _ := NewSynchronousPact(Config{
+		Consumer: "consumer",
+		Provider: "provider",
+		PactDir:  t.TempDir(),
+	})
+
+	message := p.AddSynchronousMessage("a price request").
+		WithRequest(func(r *SynchronousMessageWithRequestBuilder) {
+			r.WithJSONContent(map[string]interface{}{"sku": matchers.Like("abc")})
+		}).
+		WithResponse(func(r *SynchronousMessageWithResponseBuilder) {
+			r.WithJSONContent(map[string]interface{}{"price": matchers.Decimal(9.99)})
+		})
+
+	var price struct {
+		Price float64 `json:"price"`
+	}
+	err := message.VerifyClient(t, func(send func([]byte) (MessageContents, error)) error {
+		res, err := send([]byte(`{"sku": "xyz"}`))
+		if err != nil {
+			return err
+		}
+		return json.Unmarshal(res.Contents, &price)
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 9.99, price.Price)
+
+	err = message.verifyClient(func(send func([]byte) (MessageContents, error)) error {
+		_, _ = send([]byte(`{"sku": 1}`))
+		return nil
+	})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "$.sku")
+
+	err = message.verifyClient(func(send func([]byte) (MessageContents, error)) error {
+		return nil
+	})
+	assert.EqualError(t, err, "the client did not send a request")
+}
+
+func TestSyncCloseTwice(t *testing.T) {
+	p, err := NewSynchronousPact(Config{
+		Consumer: "closeconsumer",
+		Provider: "closeprovider",
+		PactDir:  t.TempDir(),
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, p.Close())
+	assert.NoError(t, p.Close())
+	assert.True(t, p.closed)
+}
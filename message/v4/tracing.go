@@ -0,0 +1,50 @@
+package v4
+
+import "context"
+
+// TracerProvider creates the Tracer used to record spans around message verification.
+//
+// Its shape mirrors the OpenTelemetry API, so an OpenTelemetry TracerProvider can be
+// used with a small adapter, without pact-go depending on a particular SDK.
+type TracerProvider interface {
+	Tracer(name string) Tracer
+}
+
+// Tracer starts spans
+type Tracer interface {
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span is a single timed operation
+type Span interface {
+	// RecordError records an error against the span
+	RecordError(err error)
+
+	// End completes the span
+	End()
+}
+
+const tracerName = "github.com/pact-foundation/pact-go/v2/message/v4"
+
+// startSpan starts a span if a TracerProvider is configured, otherwise a no-op span is returned
+func startSpan(ctx context.Context, provider TracerProvider, name string) (context.Context, Span) {
+	if provider == nil {
+		return ctx, noopSpan{}
+	}
+
+	return provider.Tracer(tracerName).Start(ctx, name)
+}
+
+// endSpan records any error against the span and ends it
+func endSpan(span Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+	}
+	span.End()
+}
+
+type noopSpan struct{}
+
+func (noopSpan) RecordError(error) {}
+
+func (noopSpan) End() {}
@@ -0,0 +1,57 @@
+package v4
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type recordingTracer struct {
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	name  string
+	err   error
+	ended bool
+}
+
+func (r *recordingTracer) Tracer(string) Tracer {
+	return r
+}
+
+func (r *recordingTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	s := &recordingSpan{name: name}
+	r.spans = append(r.spans, s)
+
+	return ctx, s
+}
+
+func (s *recordingSpan) RecordError(err error) {
+	s.err = err
+}
+
+func (s *recordingSpan) End() {
+	s.ended = true
+}
+
+func TestStartSpan(t *testing.T) {
+	t.Run("no provider", func(t *testing.T) {
+		_, span := startSpan(context.Background(), nil, "pact.handler")
+		assert.Equal(t, noopSpan{}, span)
+		endSpan(span, fmt.Errorf("ignored"))
+	})
+
+	t.Run("with a provider", func(t *testing.T) {
+		tracer := &recordingTracer{}
+		_, span := startSpan(context.Background(), tracer, "pact.handler")
+		endSpan(span, fmt.Errorf("handler failed"))
+
+		assert.Len(t, tracer.spans, 1)
+		assert.Equal(t, "pact.handler", tracer.spans[0].name)
+		assert.True(t, tracer.spans[0].ended)
+		assert.EqualError(t, tracer.spans[0].err, "handler failed")
+	})
+}
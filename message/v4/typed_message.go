@@ -0,0 +1,141 @@
+package v4
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/pact-foundation/pact-go/v2/matchers"
+	"github.com/pact-foundation/pact-go/v2/models"
+)
+
+// TypedMessage builds an asynchronous message whose content is given to the consumer
+// as a T, removing the need for AsType, e.g.
+//
+//	AddTypedAsynchronousMessage[User](p).
+//		ExpectsToReceive("a user").
+//		WithJSONContent(matchers.MatchV2(User{})).
+//		ConsumedBy(func(u User, md Metadata) error { ... }).
+//		Verify(t)
+type TypedMessage[T any] struct {
+	rootBuilder *AsynchronousMessageBuilder
+}
+
+// AddTypedAsynchronousMessage creates a new asynchronous consumer expectation, with a
+// handler that receives the content unmarshalled into a T
+func AddTypedAsynchronousMessage[T any](p *AsynchronousPact) *TypedMessage[T] {
+	return &TypedMessage[T]{
+		rootBuilder: p.AddAsynchronousMessage(),
+	}
+}
+
+// AsType gives the content of an existing message to its consumer as a T, e.g.
+//
+//	AsType[User](p.AddAsynchronousMessage().
+//		ExpectsToReceive("a user").
+//		WithJSONContent(matchers.MatchV2(User{}))).
+//		ConsumedBy(func(u User, md Metadata) error { ... })
+func AsType[T any](m *AsynchronousMessageWithContents) *TypedMessage[T] {
+	m.rootBuilder.pact.logf("DEBUG", "setting Message decoding to type: %v", reflect.TypeOf((*T)(nil)).Elem())
+
+	return &TypedMessage[T]{
+		rootBuilder: m.rootBuilder,
+	}
+}
+
+// Given specifies a provider state. Optional.
+func (m *TypedMessage[T]) Given(state string) *TypedMessage[T] {
+	m.rootBuilder.Given(state)
+
+	return m
+}
+
+// GivenWithParameter specifies a provider state with parameters. Optional.
+func (m *TypedMessage[T]) GivenWithParameter(state models.ProviderState) *TypedMessage[T] {
+	m.rootBuilder.GivenWithParameter(state)
+
+	return m
+}
+
+// GivenStates specifies several provider states, added to any already given. Optional.
+func (m *TypedMessage[T]) GivenStates(states ...models.ProviderState) *TypedMessage[T] {
+	m.rootBuilder.GivenStates(states...)
+
+	return m
+}
+
+// ExpectsToReceive specifies the content it is expecting to be
+// given from the Provider
+func (m *TypedMessage[T]) ExpectsToReceive(description string) *TypedMessage[T] {
+	m.rootBuilder.ExpectsToReceive(description)
+
+	return m
+}
+
+// WithMetadata specifies message-implementation specific metadata
+// to go with the content
+func (m *TypedMessage[T]) WithMetadata(metadata map[string]string) *TypedMessage[T] {
+	m.unconfigured().WithMetadata(metadata)
+
+	return m
+}
+
+// WithMetadataMatchers specifies message metadata that is matched by the given matchers
+func (m *TypedMessage[T]) WithMetadataMatchers(metadata matchers.MapMatcher) *TypedMessage[T] {
+	m.unconfigured().WithMetadataMatchers(metadata)
+
+	return m
+}
+
+// WithContent specifies the payload in bytes that the consumer expects to receive.
+// Unless a decoder is registered for the content type in Config.Codecs, the payload
+// is unmarshalled from JSON.
+func (m *TypedMessage[T]) WithContent(contentType string, body []byte) *TypedMessage[T] {
+	m.unconfigured().WithContent(contentType, body)
+
+	return m
+}
+
+// WithJSONContent specifies the payload as an object (to be marshalled to JSON) that
+// is expected to be consumed
+func (m *TypedMessage[T]) WithJSONContent(content interface{}) *TypedMessage[T] {
+	m.unconfigured().WithJSONContent(content)
+
+	return m
+}
+
+// ConsumedBy sets the function that will consume the message, along with its metadata
+func (m *TypedMessage[T]) ConsumedBy(handler func(T, Metadata) error) *AsynchronousMessageWithConsumer {
+	m.rootBuilder.handler = typedConsumer(m.rootBuilder, handler)
+
+	return &AsynchronousMessageWithConsumer{
+		rootBuilder: m.rootBuilder,
+	}
+}
+
+func (m *TypedMessage[T]) unconfigured() *UnconfiguredAsynchronousMessageBuilder {
+	return &UnconfiguredAsynchronousMessageBuilder{
+		rootBuilder: m.rootBuilder,
+	}
+}
+
+// typedConsumer adapts a typed handler to an AsynchronousConsumer. The content is
+// unmarshalled into a T, or must already be a T if a decoder is registered for it.
+func typedConsumer[T any](message *AsynchronousMessageBuilder, handler func(T, Metadata) error) AsynchronousConsumer {
+	return func(m AsynchronousMessage) error {
+		var value T
+		t := reflect.TypeOf((*T)(nil)).Elem()
+
+		if message.decoder() != nil {
+			v, ok := m.Body.(T)
+			if !ok {
+				return fmt.Errorf("the decoder for %s produced a %T, but the handler expects a %v", message.declaredContentType(), m.Body, t)
+			}
+			value = v
+		} else if err := json.Unmarshal(m.Contents, &value); err != nil {
+			return fmt.Errorf("unable to unmarshal the message contents into %v: %v, contents: %s", t, err, m.Contents)
+		}
+
+		return handler(value, m.Metadata)
+	}
+}
@@ -0,0 +1,81 @@
+package v4
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/matchers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTypedMessage(t *testing.T) {
+	type user struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	}
+
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	var received user
+	var topic interface{}
+	message := AddTypedAsynchronousMessage[user](p).
+		Given("a user exists").
+		ExpectsToReceive("a typed user").
+		WithMetadata(map[string]string{"topic": "users"}).
+		WithJSONContent(map[string]interface{}{
+			"id":   matchers.Integer(1),
+			"name": matchers.Like("billy"),
+		}).
+		ConsumedBy(func(u user, md Metadata) error {
+			received = u
+			topic = md["topic"]
+			return nil
+		})
+
+	assert.NoError(t, message.Verify(t))
+	assert.Equal(t, user{ID: 1, Name: "billy"}, received)
+	assert.Equal(t, "users", topic)
+
+	handler := typedConsumer(message.rootBuilder, func(u user, md Metadata) error { return nil })
+	err := handler(AsynchronousMessage{Contents: []byte(`{"id": "1"}`)})
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "v4.user")
+	assert.Contains(t, err.Error(), `{"id": "1"}`)
+
+	failing := typedConsumer(message.rootBuilder, func(u user, md Metadata) error { return errors.New("boom") })
+	assert.EqualError(t, failing(AsynchronousMessage{Contents: []byte(`{"id": 1}`)}), "boom")
+}
+
+func TestAsType(t *testing.T) {
+	type user struct {
+		ID int `json:"id"`
+	}
+
+	p, _ := NewAsynchronousPact(Config{
+		Consumer: "asyncconsumer",
+		Provider: "asyncprovider",
+		PactDir:  "/tmp/",
+	})
+
+	var received user
+	err := AsType[user](p.AddAsynchronousMessage().
+		ExpectsToReceive("an existing message as a user").
+		WithJSONContent(map[string]interface{}{"id": matchers.Integer(1)})).
+		ConsumedBy(func(u user, md Metadata) error {
+			received = u
+			return nil
+		}).
+		Verify(t)
+
+	assert.NoError(t, err)
+	assert.Equal(t, user{ID: 1}, received)
+
+	// A value given to the untyped AsType is decoded into that type, not a map
+	m, err := decodeContents(AsynchronousMessage{Contents: []byte(`{"id": 2}`)}, user{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, user{ID: 2}, m.Body)
+}
@@ -89,7 +89,7 @@ func CreateMessageHandler(messageHandlers Handlers) proxy.Middleware {
 				res, metadata, handlerErr := f(message.States)
 
 				if handlerErr != nil {
-					log.Printf("[ERROR] error executive message handler %s", err)
+					log.Printf("[ERROR] error executing message handler %s", handlerErr)
 					w.WriteHeader(http.StatusServiceUnavailable)
 					return
 				}
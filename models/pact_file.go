@@ -13,3 +13,43 @@ const (
 	// V4 spec
 	V4 = "4.0.0"
 )
+
+// MetadataNamespace is the namespace of the pact file metadata written by pact-go
+const MetadataNamespace = "pactGo"
+
+// NativeVersionMetadataKey records the version of the native library that wrote the pact
+const NativeVersionMetadataKey = "nativeVersion"
+
+// EnvironmentMetadataKey records the environment the pact was generated in, e.g. "staging"
+const EnvironmentMetadataKey = "environment"
+
+// ConsumerGroupMetadataKey is the message metadata key recording the consumer group
+// (e.g. a Kafka consumer group) that expects the message
+const ConsumerGroupMetadataKey = "consumerGroup"
+
+// OrderingKeyMetadataKey is the message metadata key recording the JSON path of the
+// content field messages are ordered (e.g. partitioned) by
+const OrderingKeyMetadataKey = "orderingKey"
+
+// SeverityMetadataKey is the message metadata key recording how verification
+// failures of the message are treated, see Severity
+const SeverityMetadataKey = "severity"
+
+// MaxInFlightMetadataKey is the message metadata key recording the most messages
+// the consumer processes concurrently
+const MaxInFlightMetadataKey = "maxInFlight"
+
+// MetadataOrderMetadataKey is the message metadata key recording the order of the metadata
+// keys of the message, for transports where the order of headers is significant
+const MetadataOrderMetadataKey = "metadataOrder"
+
+// Severity determines whether verification failures of an interaction fail the build
+type Severity string
+
+const (
+	// SeverityCritical failures fail verification (the default)
+	SeverityCritical Severity = "critical"
+
+	// SeverityWarning failures are reported but do not fail verification
+	SeverityWarning Severity = "warning"
+)
@@ -0,0 +1,292 @@
+package pactfile
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// IncompatibleChange is a change to a contract that breaks backwards compatibility
+type IncompatibleChange struct {
+	// Interaction is the description of the affected interaction
+	Interaction string `json:"interaction"`
+
+	// Path is the location of the change, e.g. "response.body $.items[0].id".
+	// It is empty if the whole interaction was affected
+	Path string `json:"path,omitempty"`
+
+	// Reason describes the change
+	Reason string `json:"reason"`
+}
+
+func (c IncompatibleChange) String() string {
+	if c.Path == "" {
+		return fmt.Sprintf("%s: %s", c.Interaction, c.Reason)
+	}
+
+	return fmt.Sprintf("%s: %s: %s", c.Interaction, c.Path, c.Reason)
+}
+
+// CompatibilityReport is the result of a compatibility check between two versions of a contract
+type CompatibilityReport struct {
+	Changes []IncompatibleChange `json:"changes"`
+}
+
+// Compatible is true if no incompatible changes were found
+func (r CompatibilityReport) Compatible() bool {
+	return len(r.Changes) == 0
+}
+
+// CheckCompatibility determines whether the contract in the newPact file is
+// backwards compatible with the contract in the oldPact file. A change is
+// incompatible if the new contract removes an interaction or a field, changes
+// the type of a field, or narrows a matcher (e.g. from a type match to an exact value).
+func CheckCompatibility(oldPact, newPact string) (CompatibilityReport, error) {
+	var report CompatibilityReport
+
+	o, err := Read(oldPact)
+	if err != nil {
+		return report, err
+	}
+	n, err := Read(newPact)
+	if err != nil {
+		return report, err
+	}
+
+	return Compatibility(o, n), nil
+}
+
+// Compatibility is the parsed form of CheckCompatibility
+func Compatibility(oldPact, newPact *Pact) CompatibilityReport {
+	var report CompatibilityReport
+
+	newInteractions := make(map[string]*Interaction)
+	for _, i := range newPact.AllInteractions() {
+		newInteractions[i.Key()] = i
+	}
+
+	for _, o := range oldPact.AllInteractions() {
+		n, ok := newInteractions[o.Key()]
+		if !ok {
+			report.Changes = append(report.Changes, IncompatibleChange{
+				Interaction: o.Description,
+				Reason:      "interaction was removed",
+			})
+			continue
+		}
+
+		newParts := make(map[string]Part)
+		for _, p := range n.Parts() {
+			newParts[p.Name] = p
+		}
+
+		for _, op := range o.Parts() {
+			np, ok := newParts[op.Name]
+			if !ok {
+				if op.Content != nil {
+					report.Changes = append(report.Changes, IncompatibleChange{
+						Interaction: o.Description,
+						Path:        op.Name,
+						Reason:      "content was removed",
+					})
+				}
+				continue
+			}
+
+			for _, c := range compareParts("$", op.Content, np.Content, op, np) {
+				c.Interaction = o.Description
+				c.Path = op.Name + " " + c.Path
+				report.Changes = append(report.Changes, c)
+			}
+		}
+	}
+
+	return report
+}
+
+func compareParts(path string, o, n interface{}, op, np Part) []IncompatibleChange {
+	var changes []IncompatibleChange
+
+	if kind(o) != kind(n) {
+		return []IncompatibleChange{{
+			Path:   path,
+			Reason: fmt.Sprintf("type changed from %s to %s", kind(o), kind(n)),
+		}}
+	}
+
+	if reason := narrowed(op.RulesFor(path), np.RulesFor(path), o, n); reason != "" {
+		changes = append(changes, IncompatibleChange{Path: path, Reason: reason})
+	}
+
+	switch ov := o.(type) {
+	case map[string]interface{}:
+		nv := n.(map[string]interface{})
+		keys := make([]string, 0, len(ov))
+		for k := range ov {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		for _, k := range keys {
+			childPath := objectPath(path, k)
+			child, ok := nv[k]
+			if !ok {
+				changes = append(changes, IncompatibleChange{Path: childPath, Reason: "field was removed"})
+				continue
+			}
+			changes = append(changes, compareParts(childPath, ov[k], child, op, np)...)
+		}
+	case []interface{}:
+		nv := n.([]interface{})
+		for i := range ov {
+			if i >= len(nv) {
+				// array matchers only require an example of the first element
+				if len(nv) > 0 && len(np.RulesFor(path)) > 0 {
+					break
+				}
+				changes = append(changes, IncompatibleChange{Path: fmt.Sprintf("%s[%d]", path, i), Reason: "array item was removed"})
+				continue
+			}
+			changes = append(changes, compareParts(fmt.Sprintf("%s[%d]", path, i), ov[i], nv[i], op, np)...)
+		}
+	}
+
+	return changes
+}
+
+// strictness orders rules from the most to the least permissive
+func strictness(rules []Rule) int {
+	if len(rules) == 0 {
+		return 2
+	}
+
+	level := 0
+	for _, r := range rules {
+		switch r.Type() {
+		case "type", "values", "arrayContains", "notEmpty":
+		case "equality", "":
+			level = 2
+		default:
+			if level < 1 {
+				level = 1
+			}
+		}
+	}
+
+	return level
+}
+
+func narrowed(oldRules, newRules []Rule, o, n interface{}) string {
+	oldLevel, newLevel := strictness(oldRules), strictness(newRules)
+
+	switch {
+	case newLevel < oldLevel:
+		return ""
+	case newLevel > oldLevel:
+		return fmt.Sprintf("matcher was narrowed from %s to %s", describeRules(oldRules), describeRules(newRules))
+	case newLevel == 2:
+		if fmt.Sprint(o) != fmt.Sprint(n) && kind(o) != "object" && kind(o) != "array" {
+			return fmt.Sprintf("expected value changed from %v to %v", o, n)
+		}
+		return ""
+	}
+
+	for _, r := range newRules {
+		switch r.Type() {
+		case "regex":
+			if !hasRule(oldRules, "regex", r["regex"]) {
+				return fmt.Sprintf("regex was changed to %v", r["regex"])
+			}
+		case "type":
+			if kind(o) != "array" {
+				continue
+			}
+			if min, ok := r["min"].(float64); ok && min > minOf(oldRules) {
+				return fmt.Sprintf("minimum array length was increased to %v", min)
+			}
+			if max, ok := r["max"].(float64); ok && (maxOf(oldRules) < 0 || max < maxOf(oldRules)) {
+				return fmt.Sprintf("maximum array length was decreased to %v", max)
+			}
+		}
+		if newLevel == 1 && r.Type() != "regex" && !hasRule(oldRules, r.Type(), nil) {
+			return fmt.Sprintf("matcher was narrowed from %s to %s", describeRules(oldRules), describeRules(newRules))
+		}
+	}
+
+	return ""
+}
+
+func hasRule(rules []Rule, t string, regex interface{}) bool {
+	for _, r := range rules {
+		if r.Type() == t && (regex == nil || r["regex"] == regex) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func minOf(rules []Rule) float64 {
+	for _, r := range rules {
+		if min, ok := r["min"].(float64); ok {
+			return min
+		}
+	}
+
+	return 0
+}
+
+func maxOf(rules []Rule) float64 {
+	for _, r := range rules {
+		if max, ok := r["max"].(float64); ok {
+			return max
+		}
+	}
+
+	return -1
+}
+
+func describeRules(rules []Rule) string {
+	if len(rules) == 0 {
+		return "an exact value"
+	}
+
+	desc := ""
+	for i, r := range rules {
+		if i > 0 {
+			desc += ", "
+		}
+		desc += r.Type()
+	}
+
+	return desc
+}
+
+func kind(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case map[string]interface{}:
+		return "object"
+	case []interface{}:
+		return "array"
+	case string:
+		return "string"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	}
+
+	return fmt.Sprintf("%T", v)
+}
+
+var identifierRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+func objectPath(path string, key string) string {
+	if identifierRegex.MatchString(key) {
+		return path + "." + key
+	}
+
+	return fmt.Sprintf("%s['%s']", path, key)
+}
@@ -0,0 +1,282 @@
+package pactfile
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const compatibilityV3Pact = `{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "messages": [
+    {
+      "description": "a user event",
+      "contents": {"id": 1, "name": "billy", "tags": ["a"], "kind": "created"},
+      "matchingRules": {
+        "body": {
+          "$.id": {"combine": "AND", "matchers": [{"match": "integer"}]},
+          "$.name": {"combine": "AND", "matchers": [{"match": "type"}]},
+          "$.tags": {"combine": "AND", "matchers": [{"match": "type", "min": 1}]}
+        }
+      },
+      "metadata": {"contentType": "application/json"}
+    },
+    {
+      "description": "a deleted event",
+      "contents": {"id": 1}
+    }
+  ],
+  "metadata": {"pactSpecification": {"version": "3.0.0"}}
+}`
+
+func writePact(t *testing.T, dir, name, content string) string {
+	path := filepath.Join(dir, name)
+	err := ioutil.WriteFile(path, []byte(content), 0644)
+	assert.NoError(t, err)
+
+	return path
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compatibility")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	old := writePact(t, dir, "old.json", compatibilityV3Pact)
+
+	t.Run("identical contracts are compatible", func(t *testing.T) {
+		report, err := CheckCompatibility(old, old)
+		assert.NoError(t, err)
+		assert.True(t, report.Compatible())
+	})
+
+	t.Run("widened contracts are compatible", func(t *testing.T) {
+		widened := writePact(t, dir, "widened.json", `{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "messages": [
+    {
+      "description": "a user event",
+      "contents": {"id": 2, "name": "sally", "tags": ["b"], "kind": "created", "extra": true},
+      "matchingRules": {
+        "body": {
+          "$.id": {"combine": "AND", "matchers": [{"match": "type"}]},
+          "$.name": {"combine": "AND", "matchers": [{"match": "type"}]},
+          "$.tags": {"combine": "AND", "matchers": [{"match": "type", "min": 1}]}
+        }
+      },
+      "metadata": {"contentType": "application/json"}
+    },
+    {
+      "description": "a deleted event",
+      "contents": {"id": 1}
+    }
+  ]
+}`)
+		report, err := CheckCompatibility(old, widened)
+		assert.NoError(t, err)
+		assert.True(t, report.Compatible(), "%v", report.Changes)
+	})
+
+	t.Run("breaking changes are reported with their path", func(t *testing.T) {
+		broken := writePact(t, dir, "broken.json", `{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "messages": [
+    {
+      "description": "a user event",
+      "contents": {"id": "1", "name": "billy", "tags": ["a", "b"], "kind": "updated"},
+      "matchingRules": {
+        "body": {
+          "$.id": {"combine": "AND", "matchers": [{"match": "type"}]},
+          "$.tags": {"combine": "AND", "matchers": [{"match": "type", "min": 2}]}
+        }
+      }
+    }
+  ]
+}`)
+		report, err := CheckCompatibility(old, broken)
+		assert.NoError(t, err)
+		assert.False(t, report.Compatible())
+
+		paths := make([]string, len(report.Changes))
+		for i, c := range report.Changes {
+			paths[i] = c.Path
+		}
+		assert.ElementsMatch(t, []string{
+			"",
+			"contents $.id",
+			"contents $.kind",
+			"contents $.name",
+			"contents $.tags",
+			"metadata",
+		}, paths)
+	})
+
+	t.Run("missing files return an error", func(t *testing.T) {
+		_, err := CheckCompatibility(old, filepath.Join(dir, "missing.json"))
+		assert.Error(t, err)
+	})
+}
+
+func TestCompatibility_V2ExamplePact(t *testing.T) {
+	p, err := Read("../examples/pacts/PactGoProductAPIConsumer-PactGoProductAPI.json")
+	assert.NoError(t, err)
+	assert.Equal(t, "2.0.0", p.SpecificationVersion())
+
+	parts := p.Interactions[0].Parts()
+	assert.Len(t, parts, 1)
+	assert.Equal(t, "response.body", parts[0].Name)
+	assert.Equal(t, "type", parts[0].RulesFor("$.id")[0].Type())
+
+	assert.True(t, Compatibility(p, p).Compatible())
+}
+
+func TestDiff(t *testing.T) {
+	o, err := Parse([]byte(compatibilityV3Pact))
+	assert.NoError(t, err)
+	n, err := Parse([]byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "messages": [
+    {
+      "description": "a user event",
+      "contents": {"id": "1", "name": "billy", "tags": ["a", "b"], "kind": "created", "extra": true},
+      "matchingRules": {
+        "body": {
+          "$.name": {"combine": "AND", "matchers": [{"match": "type"}]},
+          "$.tags": {"combine": "AND", "matchers": [{"match": "type", "min": 1}]}
+        }
+      },
+      "metadata": {"contentType": "application/json"}
+    },
+    {
+      "description": "a new event",
+      "contents": {"id": 1}
+    }
+  ]
+}`))
+	assert.NoError(t, err)
+
+	changes := Diff(o, n)
+	descriptions := make([]string, len(changes))
+	for i, c := range changes {
+		descriptions[i] = c.String()
+	}
+	assert.Equal(t, []string{
+		"a user event: contents $.extra added",
+		"a user event: contents $.id changed: type changed from number to string",
+		"a user event: contents $.tags[1] added",
+		"a deleted event: removed",
+		"a new event: added",
+	}, descriptions)
+
+	assert.Empty(t, Diff(o, o))
+}
+
+func TestBuildCompatibilityMatrix(t *testing.T) {
+	dir, err := ioutil.TempDir("", "matrix")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	web := writePact(t, dir, "web.json", `{
+  "consumer": {"name": "web"},
+  "provider": {"name": "users"},
+  "messages": [
+    {
+      "description": "a user event",
+      "contents": {"id": 1, "name": "billy", "kind": "created"},
+      "matchingRules": {"body": {"$.id": {"matchers": [{"match": "integer"}]}, "$.name": {"matchers": [{"match": "type"}]}}}
+    },
+    {"description": "a web only event", "contents": {"id": 1}}
+  ]
+}`)
+	mobile := writePact(t, dir, "mobile.json", `{
+  "consumer": {"name": "mobile"},
+  "provider": {"name": "users"},
+  "messages": [
+    {
+      "description": "a user event",
+      "contents": {"id": "u-1", "name": "bob", "kind": "created", "email": "bob@example.com"},
+      "matchingRules": {"body": {"$.name": {"matchers": [{"match": "regex", "regex": "^b.*"}]}}}
+    }
+  ]
+}`)
+	billing := writePact(t, dir, "billing.json", `{
+  "consumer": {"name": "billing"},
+  "provider": {"name": "users"},
+  "messages": [
+    {
+      "description": "a user event",
+      "contents": {"id": 2, "name": "billy", "kind": "deleted"},
+      "matchingRules": {"body": {"$.id": {"matchers": [{"match": "integer"}]}}}
+    }
+  ]
+}`)
+
+	matrix, err := BuildCompatibilityMatrix([]string{web, mobile, billing})
+	assert.NoError(t, err)
+	assert.Equal(t, "users", matrix.Provider)
+	assert.Equal(t, []string{"web", "mobile", "billing"}, matrix.Consumers)
+	assert.Equal(t, []Overlap{{Interaction: "a user event", Consumers: []string{"web", "mobile", "billing"}}}, matrix.Overlaps)
+	assert.False(t, matrix.Consistent())
+
+	descriptions := make([]string, len(matrix.Conflicts))
+	for i, c := range matrix.Conflicts {
+		descriptions[i] = c.String()
+	}
+	assert.Equal(t, []string{
+		`a user event: contents $.id: billing expects any number (integer), mobile expects exactly "u-1", web expects any number (integer)`,
+		`a user event: contents $.kind: billing expects exactly "deleted", mobile expects exactly "created", web expects exactly "created"`,
+	}, descriptions)
+
+	other := writePact(t, dir, "other.json", `{"consumer": {"name": "web"}, "provider": {"name": "orders"}}`)
+	_, err = BuildCompatibilityMatrix([]string{web, other})
+	assert.Error(t, err)
+}
+
+func TestDelta(t *testing.T) {
+	o, err := Parse([]byte(compatibilityV3Pact))
+	assert.NoError(t, err)
+	n, err := Parse([]byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "messages": [
+    {
+      "description": "a user event",
+      "contents": {"id": 1, "name": "billy", "tags": ["a"], "kind": "updated"},
+      "matchingRules": {
+        "body": {
+          "$.id": {"combine": "AND", "matchers": [{"match": "integer"}]},
+          "$.name": {"combine": "AND", "matchers": [{"match": "type"}]},
+          "$.tags": {"combine": "AND", "matchers": [{"match": "type", "min": 1}]}
+        }
+      },
+      "metadata": {"contentType": "application/json"}
+    },
+    {
+      "description": "a new event",
+      "contents": {"id": 1}
+    }
+  ],
+  "metadata": {"pactSpecification": {"version": "3.0.0"}}
+}`))
+	assert.NoError(t, err)
+
+	delta := Delta(o, n)
+	descriptions := make([]string, len(delta.AllInteractions()))
+	for i, d := range delta.AllInteractions() {
+		descriptions[i] = d.Description
+	}
+	assert.Equal(t, []string{"a user event", "a new event"}, descriptions)
+	assert.Equal(t, "3.0.0", delta.SpecificationVersion())
+	assert.Equal(t, []RemovedInteraction{{Description: "a deleted event"}}, delta.Metadata["pactGo"].(map[string]interface{})[DeltaRemovedMetadataKey])
+
+	unchanged := Delta(n, n)
+	assert.Empty(t, unchanged.AllInteractions())
+	assert.Empty(t, unchanged.Metadata["pactGo"].(map[string]interface{})[DeltaRemovedMetadataKey])
+}
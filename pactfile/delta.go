@@ -0,0 +1,72 @@
+package pactfile
+
+import (
+	"reflect"
+
+	"github.com/pact-foundation/pact-go/v2/models"
+)
+
+// DeltaRemovedMetadataKey records the interactions removed since the previous pact in the
+// pact-go metadata of a delta pact, as a list of their type, description and provider states
+const DeltaRemovedMetadataKey = "deltaRemoved"
+
+// RemovedInteraction identifies an interaction of the previous pact that is not in a delta
+type RemovedInteraction struct {
+	Type           string                 `json:"type,omitempty"`
+	Description    string                 `json:"description"`
+	ProviderStates []models.ProviderState `json:"providerStates,omitempty"`
+}
+
+// Delta returns a pact holding only the interactions of newPact that were added or changed
+// since oldPact, with the interactions it removed listed under DeltaRemovedMetadataKey.
+// Applying the delta to oldPact, replacing interactions by type, description and provider
+// states, gives newPact.
+func Delta(oldPact, newPact *Pact) *Pact {
+	delta := &Pact{
+		Consumer: newPact.Consumer,
+		Provider: newPact.Provider,
+		Metadata: make(map[string]interface{}, len(newPact.Metadata)+1),
+	}
+	for k, v := range newPact.Metadata {
+		delta.Metadata[k] = v
+	}
+
+	old := make(map[string]*Interaction)
+	for _, i := range oldPact.AllInteractions() {
+		old[i.Key()] = i
+	}
+	changed := func(interactions []*Interaction) []*Interaction {
+		var res []*Interaction
+		for _, i := range interactions {
+			if o, ok := old[i.Key()]; !ok || !reflect.DeepEqual(o.Raw, i.Raw) {
+				res = append(res, i)
+			}
+		}
+
+		return res
+	}
+	delta.Interactions = changed(newPact.Interactions)
+	delta.Messages = changed(newPact.Messages)
+
+	current := make(map[string]bool)
+	for _, i := range newPact.AllInteractions() {
+		current[i.Key()] = true
+	}
+	removed := []RemovedInteraction{}
+	for _, i := range oldPact.AllInteractions() {
+		if !current[i.Key()] {
+			removed = append(removed, RemovedInteraction{Type: i.Type, Description: i.Description, ProviderStates: i.ProviderStates})
+		}
+	}
+
+	namespace := make(map[string]interface{})
+	if existing, ok := delta.Metadata[models.MetadataNamespace].(map[string]interface{}); ok {
+		for k, v := range existing {
+			namespace[k] = v
+		}
+	}
+	namespace[DeltaRemovedMetadataKey] = removed
+	delta.Metadata[models.MetadataNamespace] = namespace
+
+	return delta
+}
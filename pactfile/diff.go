@@ -0,0 +1,183 @@
+package pactfile
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// ChangeKind classifies a Change between two versions of a contract
+type ChangeKind string
+
+const (
+	// Added is an interaction, part or field only present in the new contract
+	Added ChangeKind = "added"
+
+	// Removed is an interaction, part or field only present in the old contract
+	Removed ChangeKind = "removed"
+
+	// Changed is a field whose example or matching rules differ
+	Changed ChangeKind = "changed"
+)
+
+// Change is a single difference between two versions of a contract
+type Change struct {
+	Kind ChangeKind `json:"kind"`
+
+	// Interaction is the description of the affected interaction
+	Interaction string `json:"interaction"`
+
+	// Path is the location of the change, e.g. "contents $.user.name".
+	// It is empty if the whole interaction was added or removed
+	Path string `json:"path,omitempty"`
+
+	// Detail describes a changed field, e.g. "type changed from string to number"
+	Detail string `json:"detail,omitempty"`
+}
+
+func (c Change) String() string {
+	s := fmt.Sprintf("%s: %s", c.Interaction, c.Kind)
+	if c.Path != "" {
+		s = fmt.Sprintf("%s: %s %s", c.Interaction, c.Path, c.Kind)
+	}
+	if c.Detail != "" {
+		s += ": " + c.Detail
+	}
+
+	return s
+}
+
+// Diff lists every difference between two versions of a contract, in the order of the
+// interactions in the old contract followed by any added interactions. Unlike
+// Compatibility, backwards compatible changes such as added fields are included.
+func Diff(oldPact, newPact *Pact) []Change {
+	var changes []Change
+
+	oldInteractions := make(map[string]bool)
+	newInteractions := make(map[string]*Interaction)
+	for _, i := range newPact.AllInteractions() {
+		newInteractions[i.Key()] = i
+	}
+
+	for _, o := range oldPact.AllInteractions() {
+		oldInteractions[o.Key()] = true
+		n, ok := newInteractions[o.Key()]
+		if !ok {
+			changes = append(changes, Change{Kind: Removed, Interaction: o.Description})
+			continue
+		}
+
+		for _, c := range diffInteraction(o, n) {
+			c.Interaction = o.Description
+			changes = append(changes, c)
+		}
+	}
+
+	for _, n := range newPact.AllInteractions() {
+		if !oldInteractions[n.Key()] {
+			changes = append(changes, Change{Kind: Added, Interaction: n.Description})
+		}
+	}
+
+	return changes
+}
+
+func diffInteraction(o, n *Interaction) []Change {
+	var changes []Change
+
+	newParts := make(map[string]Part)
+	for _, p := range n.Parts() {
+		newParts[p.Name] = p
+	}
+
+	seen := make(map[string]bool)
+	for _, op := range o.Parts() {
+		seen[op.Name] = true
+		np, ok := newParts[op.Name]
+		if !ok {
+			changes = append(changes, Change{Kind: Removed, Path: op.Name})
+			continue
+		}
+
+		for _, c := range diffValues("$", op.Content, np.Content, op, np) {
+			c.Path = op.Name + " " + c.Path
+			changes = append(changes, c)
+		}
+	}
+
+	for _, np := range n.Parts() {
+		if !seen[np.Name] {
+			changes = append(changes, Change{Kind: Added, Path: np.Name})
+		}
+	}
+
+	return changes
+}
+
+func diffValues(path string, o, n interface{}, op, np Part) []Change {
+	if kind(o) != kind(n) {
+		return []Change{{Kind: Changed, Path: path, Detail: fmt.Sprintf("type changed from %s to %s", kind(o), kind(n))}}
+	}
+
+	var changes []Change
+	oldRules, newRules := op.rulesMatching(path, true), np.rulesMatching(path, true)
+	if !reflect.DeepEqual(oldRules, newRules) {
+		changes = append(changes, Change{
+			Kind:   Changed,
+			Path:   path,
+			Detail: fmt.Sprintf("matcher changed from %s to %s", describeRules(op.RulesFor(path)), describeRules(np.RulesFor(path))),
+		})
+	}
+
+	switch ov := o.(type) {
+	case map[string]interface{}:
+		nv := n.(map[string]interface{})
+		for _, k := range unionKeys(ov, nv) {
+			childPath := objectPath(path, k)
+			oc, inOld := ov[k]
+			nc, inNew := nv[k]
+			switch {
+			case !inNew:
+				changes = append(changes, Change{Kind: Removed, Path: childPath})
+			case !inOld:
+				changes = append(changes, Change{Kind: Added, Path: childPath})
+			default:
+				changes = append(changes, diffValues(childPath, oc, nc, op, np)...)
+			}
+		}
+	case []interface{}:
+		nv := n.([]interface{})
+		for i := 0; i < len(ov) || i < len(nv); i++ {
+			childPath := fmt.Sprintf("%s[%d]", path, i)
+			switch {
+			case i >= len(nv):
+				changes = append(changes, Change{Kind: Removed, Path: childPath})
+			case i >= len(ov):
+				changes = append(changes, Change{Kind: Added, Path: childPath})
+			default:
+				changes = append(changes, diffValues(childPath, ov[i], nv[i], op, np)...)
+			}
+		}
+	default:
+		if !reflect.DeepEqual(o, n) {
+			changes = append(changes, Change{Kind: Changed, Path: path, Detail: fmt.Sprintf("example changed from %v to %v", o, n)})
+		}
+	}
+
+	return changes
+}
+
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
@@ -0,0 +1,265 @@
+package pactfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Matrix describes the interactions the consumers of a provider expect in common,
+// and where their expectations of those interactions conflict
+type Matrix struct {
+	Provider  string   `json:"provider"`
+	Consumers []string `json:"consumers"`
+
+	// Overlaps are the interactions expected by more than one consumer
+	Overlaps []Overlap `json:"overlaps"`
+
+	// Conflicts are the fields of overlapping interactions that no single
+	// implementation of the provider could satisfy for every consumer
+	Conflicts []Conflict `json:"conflicts"`
+}
+
+// Consistent is true if the expectations of the consumers don't conflict
+func (m Matrix) Consistent() bool {
+	return len(m.Conflicts) == 0
+}
+
+// Overlap is an interaction expected by several consumers
+type Overlap struct {
+	// Interaction is the description of the interaction
+	Interaction string   `json:"interaction"`
+	Consumers   []string `json:"consumers"`
+}
+
+// Conflict is a field of an interaction where consumers have incompatible expectations
+type Conflict struct {
+	// Interaction is the description of the interaction
+	Interaction string `json:"interaction"`
+
+	// Path is the location of the field, e.g. "contents $.user.id"
+	Path string `json:"path"`
+
+	// Expectations describe what each consumer expects of the field, by consumer name
+	Expectations map[string]string `json:"expectations"`
+}
+
+func (c Conflict) String() string {
+	consumers := make([]string, 0, len(c.Expectations))
+	for consumer := range c.Expectations {
+		consumers = append(consumers, consumer)
+	}
+	sort.Strings(consumers)
+
+	expectations := make([]string, len(consumers))
+	for i, consumer := range consumers {
+		expectations[i] = fmt.Sprintf("%s expects %s", consumer, c.Expectations[consumer])
+	}
+
+	return fmt.Sprintf("%s: %s: %s", c.Interaction, c.Path, strings.Join(expectations, ", "))
+}
+
+// BuildCompatibilityMatrix compares the pacts of several consumers of one provider,
+// finding the interactions they have in common and the fields of those interactions
+// where their expectations conflict, e.g. two consumers expecting a field of the same
+// event as a number and as a string. Interactions are matched by type and description,
+// regardless of their provider states. Fields expected by only one consumer never conflict.
+func BuildCompatibilityMatrix(pactFiles []string) (Matrix, error) {
+	var matrix Matrix
+
+	pacts := make([]*Pact, 0, len(pactFiles))
+	for _, file := range pactFiles {
+		p, err := Read(file)
+		if err != nil {
+			return matrix, err
+		}
+		if matrix.Provider == "" {
+			matrix.Provider = p.Provider.Name
+		} else if p.Provider.Name != matrix.Provider {
+			return matrix, fmt.Errorf("%s is a pact with provider %s, expected %s", file, p.Provider.Name, matrix.Provider)
+		}
+		pacts = append(pacts, p)
+		matrix.Consumers = append(matrix.Consumers, p.Consumer.Name)
+	}
+
+	return compatibilityMatrix(matrix, pacts), nil
+}
+
+// consumerInteraction is an interaction expected by a consumer
+type consumerInteraction struct {
+	consumer    string
+	interaction *Interaction
+}
+
+func compatibilityMatrix(matrix Matrix, pacts []*Pact) Matrix {
+	var keys []string
+	shared := make(map[string][]consumerInteraction)
+	for _, p := range pacts {
+		for _, i := range p.AllInteractions() {
+			key := i.Type + "|" + i.Description
+			if _, ok := shared[key]; !ok {
+				keys = append(keys, key)
+			}
+			shared[key] = append(shared[key], consumerInteraction{p.Consumer.Name, i})
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		interactions := shared[key]
+		if len(interactions) < 2 {
+			continue
+		}
+
+		description := interactions[0].interaction.Description
+		overlap := Overlap{Interaction: description}
+		for _, i := range interactions {
+			overlap.Consumers = append(overlap.Consumers, i.consumer)
+		}
+		matrix.Overlaps = append(matrix.Overlaps, overlap)
+
+		var paths []string
+		conflicts := make(map[string]Conflict)
+		for a := 0; a < len(interactions); a++ {
+			for b := a + 1; b < len(interactions); b++ {
+				for _, c := range conflictingParts(interactions[a], interactions[b]) {
+					existing, ok := conflicts[c.Path]
+					if !ok {
+						paths = append(paths, c.Path)
+						existing = Conflict{Interaction: description, Path: c.Path, Expectations: make(map[string]string)}
+					}
+					for consumer, expectation := range c.Expectations {
+						existing.Expectations[consumer] = expectation
+					}
+					conflicts[c.Path] = existing
+				}
+			}
+		}
+
+		for _, path := range paths {
+			matrix.Conflicts = append(matrix.Conflicts, conflicts[path])
+		}
+	}
+
+	return matrix
+}
+
+func conflictingParts(a, b consumerInteraction) []Conflict {
+	var conflicts []Conflict
+
+	bParts := make(map[string]Part)
+	for _, p := range b.interaction.Parts() {
+		bParts[p.Name] = p
+	}
+
+	for _, ap := range a.interaction.Parts() {
+		bp, ok := bParts[ap.Name]
+		if !ok {
+			continue
+		}
+		for _, c := range conflictingValues("$", ap.Content, bp.Content, ap, bp, a.consumer, b.consumer) {
+			c.Path = ap.Name + " " + c.Path
+			conflicts = append(conflicts, c)
+		}
+	}
+
+	return conflicts
+}
+
+func conflictingValues(path string, a, b interface{}, ap, bp Part, aConsumer, bConsumer string) []Conflict {
+	aRules, bRules := ap.RulesFor(path), bp.RulesFor(path)
+	conflict := func() []Conflict {
+		return []Conflict{{
+			Path: path,
+			Expectations: map[string]string{
+				aConsumer: expectation(a, aRules),
+				bConsumer: expectation(b, bRules),
+			},
+		}}
+	}
+
+	if kind(a) != kind(b) {
+		return conflict()
+	}
+
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv := b.(map[string]interface{})
+		var conflicts []Conflict
+		for _, k := range unionKeys(av, bv) {
+			ac, inA := av[k]
+			bc, inB := bv[k]
+			if inA && inB {
+				conflicts = append(conflicts, conflictingValues(objectPath(path, k), ac, bc, ap, bp, aConsumer, bConsumer)...)
+			}
+		}
+		return conflicts
+	case []interface{}:
+		bv := b.([]interface{})
+		if len(aRules) == 0 && len(bRules) == 0 && len(av) != len(bv) {
+			return conflict()
+		}
+		var conflicts []Conflict
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			conflicts = append(conflicts, conflictingValues(fmt.Sprintf("%s[%d]", path, i), av[i], bv[i], ap, bp, aConsumer, bConsumer)...)
+		}
+		return conflicts
+	}
+
+	if !satisfies(a, bRules, b) && !satisfies(b, aRules, a) {
+		return conflict()
+	}
+	if isExact(aRules) && isExact(bRules) && fmt.Sprint(a) != fmt.Sprint(b) {
+		return conflict()
+	}
+	if (hasRule(aRules, "integer", nil) && hasRule(bRules, "decimal", nil)) || (hasRule(aRules, "decimal", nil) && hasRule(bRules, "integer", nil)) {
+		return conflict()
+	}
+
+	return nil
+}
+
+// satisfies reports whether a scalar value satisfies the rules of another consumer,
+// whose own example is expected
+func satisfies(v interface{}, rules []Rule, expected interface{}) bool {
+	if isExact(rules) {
+		return fmt.Sprint(v) == fmt.Sprint(expected)
+	}
+
+	for _, r := range rules {
+		if r.Type() != "regex" {
+			continue
+		}
+		pattern, _ := r["regex"].(string)
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			continue
+		}
+		if !re.MatchString(fmt.Sprint(v)) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func isExact(rules []Rule) bool {
+	return len(rules) == 0 || hasRule(rules, "equality", nil)
+}
+
+func expectation(v interface{}, rules []Rule) string {
+	if isExact(rules) {
+		example, _ := json.Marshal(v)
+		return fmt.Sprintf("exactly %s", example)
+	}
+
+	for _, r := range rules {
+		if r.Type() == "regex" {
+			return fmt.Sprintf("a string matching %v", r["regex"])
+		}
+	}
+
+	return fmt.Sprintf("any %s (%s)", kind(v), describeRules(rules))
+}
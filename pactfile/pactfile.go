@@ -0,0 +1,501 @@
+// Package pactfile reads pact files written by the consumer DSLs, so that
+// contracts can be inspected and compared without the native library.
+package pactfile
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/v2/models"
+)
+
+// Pacticipant is a consumer or provider in a pact
+type Pacticipant struct {
+	Name string `json:"name"`
+}
+
+// Pact is a parsed pact file. Both V2/V3 (interactions and messages) and
+// V4 (interactions with a type) layouts are supported.
+type Pact struct {
+	Consumer     Pacticipant            `json:"consumer"`
+	Provider     Pacticipant            `json:"provider"`
+	Interactions []*Interaction         `json:"interactions,omitempty"`
+	Messages     []*Interaction         `json:"messages,omitempty"`
+	Metadata     map[string]interface{} `json:"metadata,omitempty"`
+}
+
+// Interaction is a single HTTP or message interaction. The complete JSON
+// document is retained in Raw so that no detail is lost when re-serialised.
+type Interaction struct {
+	Description    string
+	Type           string
+	ProviderStates []models.ProviderState
+
+	Raw map[string]interface{}
+}
+
+// UnmarshalJSON retains the raw document and extracts the common fields
+func (i *Interaction) UnmarshalJSON(data []byte) error {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var common struct {
+		Description    string                 `json:"description"`
+		Type           string                 `json:"type"`
+		ProviderState  string                 `json:"providerState"`
+		ProviderStates []models.ProviderState `json:"providerStates"`
+	}
+	if err := json.Unmarshal(data, &common); err != nil {
+		return err
+	}
+
+	i.Description = common.Description
+	i.Type = common.Type
+	i.ProviderStates = common.ProviderStates
+	if common.ProviderState != "" {
+		i.ProviderStates = append(i.ProviderStates, models.ProviderState{Name: common.ProviderState})
+	}
+	i.Raw = raw
+
+	return nil
+}
+
+// MarshalJSON writes back the raw document
+func (i *Interaction) MarshalJSON() ([]byte, error) {
+	return json.Marshal(i.Raw)
+}
+
+// Key uniquely identifies an interaction within a pact by its type, description and provider states
+func (i *Interaction) Key() string {
+	states := make([]string, len(i.ProviderStates))
+	for n, s := range i.ProviderStates {
+		states[n] = s.Name
+	}
+
+	return fmt.Sprintf("%s|%s|%s", i.Type, i.Description, strings.Join(states, ","))
+}
+
+// Part is a matchable section of an interaction, such as a response body
+// or the contents of a message, along with the matching rules that apply to it
+type Part struct {
+	// Name of the part, e.g. "response.body" or "contents"
+	Name string
+
+	// Content is the example content, or nil if the part has no content
+	Content interface{}
+
+	// Rules are the matching rules for the part, keyed by JSON path from the root
+	// of the content (e.g. $.items[*].id)
+	Rules map[string][]Rule
+
+	// Generators produce the values of the content when it is reified, e.g.
+	// {"type": "RandomInt", "min": 0}, keyed by JSON path like Rules
+	Generators map[string]Rule
+}
+
+// Rule is a single matching rule, e.g. {"match": "type", "min": 1}
+type Rule map[string]interface{}
+
+// Type returns the type of the rule, e.g. "type" or "regex"
+func (r Rule) Type() string {
+	t, _ := r["match"].(string)
+
+	return t
+}
+
+// Read parses the pact file at the given path
+func Read(path string) (*Pact, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read pact file: %v", err)
+	}
+
+	p, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse pact file %s: %v", path, err)
+	}
+
+	return p, nil
+}
+
+// Parse parses a pact file from its JSON representation
+func Parse(data []byte) (*Pact, error) {
+	var p Pact
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, err
+	}
+
+	return &p, nil
+}
+
+// SpecificationVersion returns the pact specification version recorded in the metadata
+func (p *Pact) SpecificationVersion() string {
+	for _, key := range []string{"pactSpecification", "pact-specification"} {
+		if spec, ok := p.Metadata[key].(map[string]interface{}); ok {
+			if v, ok := spec["version"].(string); ok {
+				return v
+			}
+		}
+	}
+
+	return ""
+}
+
+// Environment returns the environment the pact was generated in, if recorded
+func (p *Pact) Environment() string {
+	if ns, ok := p.Metadata[models.MetadataNamespace].(map[string]interface{}); ok {
+		env, _ := ns[models.EnvironmentMetadataKey].(string)
+		return env
+	}
+
+	return ""
+}
+
+// AllInteractions returns the HTTP interactions and messages in the pact
+func (p *Pact) AllInteractions() []*Interaction {
+	all := make([]*Interaction, 0, len(p.Interactions)+len(p.Messages))
+	all = append(all, p.Interactions...)
+
+	return append(all, p.Messages...)
+}
+
+// Parts returns the bodies and message contents of the interaction that can be
+// matched, with their matching rules
+func (i *Interaction) Parts() []Part {
+	var parts []Part
+
+	if contents, ok := i.Raw["contents"]; ok {
+		parts = append(parts, newPart("contents", contents, i.Raw["matchingRules"], i.Raw["generators"], "body"))
+		if metadata, ok := i.Raw["metadata"]; ok {
+			parts = append(parts, newPart("metadata", metadata, i.Raw["matchingRules"], i.Raw["generators"], "metadata"))
+		}
+	}
+
+	for _, name := range []string{"request", "response"} {
+		switch section := i.Raw[name].(type) {
+		case map[string]interface{}:
+			parts = append(parts, sectionParts(name, section)...)
+		case []interface{}:
+			// V4 synchronous messages may have multiple responses
+			for n, s := range section {
+				if m, ok := s.(map[string]interface{}); ok {
+					parts = append(parts, sectionParts(fmt.Sprintf("%s[%d]", name, n), m)...)
+				}
+			}
+		}
+	}
+
+	return parts
+}
+
+func sectionParts(name string, section map[string]interface{}) []Part {
+	var parts []Part
+
+	if body, ok := section["body"]; ok {
+		parts = append(parts, newPart(name+".body", body, section["matchingRules"], section["generators"], "body"))
+	}
+	if contents, ok := section["contents"]; ok {
+		parts = append(parts, newPart(name+".contents", contents, section["matchingRules"], section["generators"], "body"))
+	}
+	if metadata, ok := section["metadata"]; ok {
+		parts = append(parts, newPart(name+".metadata", metadata, section["matchingRules"], section["generators"], "metadata"))
+	}
+
+	return parts
+}
+
+func newPart(name string, content interface{}, rules interface{}, generators interface{}, category string) Part {
+	// V4 bodies are wrapped with their content type
+	if wrapped, ok := content.(map[string]interface{}); ok && category == "body" {
+		if c, ok := wrapped["content"]; ok {
+			if _, ok := wrapped["contentType"]; ok {
+				content = c
+			}
+		}
+	}
+
+	return Part{
+		Name:       name,
+		Content:    content,
+		Rules:      parseRules(rules, category),
+		Generators: parseGenerators(generators, category),
+	}
+}
+
+// parseGenerators reads the generators of a category, e.g. "body": {"$.id": {"type": "RandomInt"}}.
+// V2 pacts have no generators.
+func parseGenerators(generators interface{}, category string) map[string]Rule {
+	res := make(map[string]Rule)
+	m, _ := generators.(map[string]interface{})
+	c, _ := m[category].(map[string]interface{})
+
+	for path, v := range c {
+		if g, ok := v.(map[string]interface{}); ok {
+			// metadata generators are keyed by name rather than path
+			if !strings.HasPrefix(path, "$") {
+				path = "$." + path
+			}
+			res[path] = Rule(g)
+		}
+	}
+
+	return res
+}
+
+// parseRules handles both the V2 ("$.body.id": {"match": "type"}) and
+// V3+ ("body": {"$.id": {"matchers": [{"match": "type"}]}}) layouts
+func parseRules(rules interface{}, category string) map[string][]Rule {
+	res := make(map[string][]Rule)
+	m, ok := rules.(map[string]interface{})
+	if !ok {
+		return res
+	}
+
+	if c, ok := m[category].(map[string]interface{}); ok {
+		for path, v := range c {
+			def, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			// metadata and header rules are keyed by name rather than path
+			if !strings.HasPrefix(path, "$") {
+				path = "$." + path
+			}
+			matchers, _ := def["matchers"].([]interface{})
+			for _, matcher := range matchers {
+				if r, ok := matcher.(map[string]interface{}); ok {
+					res[path] = append(res[path], Rule(r))
+				}
+			}
+		}
+
+		return res
+	}
+
+	prefix := "$." + category
+	for path, v := range m {
+		if !strings.HasPrefix(path, prefix) {
+			continue
+		}
+		if r, ok := v.(map[string]interface{}); ok {
+			key := "$" + strings.TrimPrefix(path, prefix)
+			res[key] = append(res[key], Rule(r))
+		}
+	}
+
+	return res
+}
+
+// RulesFor returns the rules that apply to the given path, i.e. those defined
+// by the most specific rule path matching the path itself or one of its parents
+func (p Part) RulesFor(path string) []Rule {
+	return p.rulesMatching(path, false)
+}
+
+// rulesMatching finds the most specific rules for the path. If exact is set only
+// rules defined for the path itself (possibly through wildcards) are considered
+func (p Part) rulesMatching(path string, exact bool) []Rule {
+	segments := splitPath(path)
+	var best []Rule
+	bestScore := -1
+
+	for rulePath, rules := range p.Rules {
+		ruleSegments := splitPath(rulePath)
+		if len(ruleSegments) > len(segments) || (exact && len(ruleSegments) != len(segments)) {
+			continue
+		}
+
+		score := 0
+		for n, segment := range ruleSegments {
+			switch {
+			case segment == segments[n]:
+				score += 2
+			case segment == "*":
+				score++
+			default:
+				score = -1
+			}
+			if score < 0 {
+				break
+			}
+		}
+		if score > bestScore {
+			best, bestScore = rules, score
+		}
+	}
+
+	return best
+}
+
+// Template converts the example content and matching rules of the part back into
+// matcher decorated content (see the matchers package), so that concrete values can
+// be checked against it with matchers.Compare. Where several rules apply to a path
+// only the first is used.
+func (p Part) Template() interface{} {
+	return p.template("$", p.Content)
+}
+
+func (p Part) template(path string, v interface{}) interface{} {
+	var value interface{}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, child := range t {
+			m[k] = p.template(objectPath(path, k), child)
+		}
+		value = m
+	case []interface{}:
+		items := make([]interface{}, len(t))
+		for i, child := range t {
+			items[i] = p.template(fmt.Sprintf("%s[%d]", path, i), child)
+		}
+		value = items
+	default:
+		value = t
+	}
+
+	rules := p.rulesMatching(path, true)
+	if len(rules) == 0 {
+		return value
+	}
+
+	return ruleToMatcher(rules[0], value)
+}
+
+// GeneratorFor returns the generator of the value at the path, or nil if it has none
+func (p Part) GeneratorFor(path string) Rule {
+	generators := Part{Rules: make(map[string][]Rule, len(p.Generators))}
+	for k, g := range p.Generators {
+		generators.Rules[k] = []Rule{g}
+	}
+
+	if rules := generators.rulesMatching(path, true); len(rules) > 0 {
+		return rules[0]
+	}
+
+	return nil
+}
+
+// GeneratorTemplate converts the example content and generators of the part into
+// matcher decorated content, so that matchers.Generate produces the content with each
+// generator applied, as the native core does when it reifies the part
+func (p Part) GeneratorTemplate() interface{} {
+	return p.generatorTemplate("$", p.Content)
+}
+
+func (p Part) generatorTemplate(path string, v interface{}) interface{} {
+	var value interface{}
+
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, child := range t {
+			m[k] = p.generatorTemplate(objectPath(path, k), child)
+		}
+		value = m
+	case []interface{}:
+		items := make([]interface{}, len(t))
+		for i, child := range t {
+			items[i] = p.generatorTemplate(fmt.Sprintf("%s[%d]", path, i), child)
+		}
+		value = items
+	default:
+		value = t
+	}
+
+	g := p.GeneratorFor(path)
+	if g == nil {
+		return value
+	}
+
+	m := map[string]interface{}{
+		"pact:matcher:type":   "type",
+		"pact:generator:type": g["type"],
+		"value":               value,
+	}
+	for k, v := range g {
+		if k != "type" {
+			m[k] = v
+		}
+	}
+
+	return m
+}
+
+// dateFormatKeys are the rule attributes that may hold the format of a date/time matcher
+var dateFormatKeys = []string{"format", "date", "time", "timestamp"}
+
+func ruleToMatcher(r Rule, value interface{}) map[string]interface{} {
+	m := map[string]interface{}{
+		"pact:matcher:type": r.Type(),
+		"value":             value,
+	}
+	if r.Type() == "" {
+		// V2 regex rules may omit the type
+		m["pact:matcher:type"] = "type"
+		if _, ok := r["regex"]; ok {
+			m["pact:matcher:type"] = "regex"
+		}
+	}
+
+	for k, v := range r {
+		switch k {
+		case "match":
+		case "value":
+			// the include matcher stores the expected substring as its value
+			if r.Type() == "include" {
+				m["value"] = v
+			}
+		default:
+			m[k] = v
+		}
+	}
+	for _, k := range dateFormatKeys {
+		if f, ok := r[k].(string); ok {
+			m["format"] = f
+			break
+		}
+	}
+
+	return m
+}
+
+// splitPath splits a JSON path such as $.a['b-c'][0] into its segments: $, a, b-c, 0
+func splitPath(path string) []string {
+	var segments []string
+	var current strings.Builder
+
+	flush := func() {
+		if current.Len() > 0 {
+			segments = append(segments, current.String())
+			current.Reset()
+		}
+	}
+
+	for i := 0; i < len(path); i++ {
+		switch c := path[i]; c {
+		case '.':
+			flush()
+		case '[':
+			flush()
+			end := strings.IndexByte(path[i:], ']')
+			if end < 0 {
+				current.WriteString(path[i+1:])
+				i = len(path)
+				continue
+			}
+			segments = append(segments, strings.Trim(path[i+1:i+end], `'"`))
+			i += end
+		default:
+			current.WriteByte(c)
+		}
+	}
+	flush()
+
+	return segments
+}
@@ -0,0 +1,65 @@
+package pactfile
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	p, err := Parse([]byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "provider"},
+  "interactions": [
+    {
+      "type": "Synchronous/HTTP",
+      "description": "a request",
+      "providerStates": [{"name": "a user exists", "params": {"id": 1}}],
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {
+        "status": 200,
+        "body": {"content": {"id": 1, "name": "billy"}, "contentType": "application/json", "encoded": false},
+        "matchingRules": {"body": {"$.name": {"combine": "AND", "matchers": [{"match": "type"}]}}}
+      }
+    }
+  ],
+  "metadata": {
+    "pactSpecification": {"version": "4.0"},
+    "pactGo": {"environment": "staging"}
+  }
+}`))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "consumer", p.Consumer.Name)
+	assert.Equal(t, "4.0", p.SpecificationVersion())
+	assert.Equal(t, "staging", p.Environment())
+
+	i := p.AllInteractions()[0]
+	assert.Equal(t, "a request", i.Description)
+	assert.Equal(t, "a user exists", i.ProviderStates[0].Name)
+	assert.Equal(t, "Synchronous/HTTP|a request|a user exists", i.Key())
+
+	parts := i.Parts()
+	assert.Len(t, parts, 1)
+	assert.Equal(t, map[string]interface{}{"id": float64(1), "name": "billy"}, parts[0].Content)
+	assert.Equal(t, "type", parts[0].RulesFor("$.name")[0].Type())
+	assert.Nil(t, parts[0].RulesFor("$.id"))
+}
+
+func TestSplitPath(t *testing.T) {
+	assert.Equal(t, []string{"$", "a", "b-c", "0", "*"}, splitPath("$.a['b-c'][0].*"))
+}
+
+func TestPart_Template(t *testing.T) {
+	p, err := Parse([]byte(compatibilityV3Pact))
+	assert.NoError(t, err)
+
+	parts := p.Messages[0].Parts()
+	assert.Equal(t, "contents", parts[0].Name)
+	assert.Equal(t, map[string]interface{}{
+		"id":   map[string]interface{}{"pact:matcher:type": "integer", "value": float64(1)},
+		"name": map[string]interface{}{"pact:matcher:type": "type", "value": "billy"},
+		"tags": map[string]interface{}{"pact:matcher:type": "type", "min": float64(1), "value": []interface{}{"a"}},
+		"kind": "created",
+	}, parts[0].Template())
+}
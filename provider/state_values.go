@@ -0,0 +1,76 @@
+package provider
+
+import (
+	"sync"
+
+	"github.com/pact-foundation/pact-go/v2/message"
+	"github.com/pact-foundation/pact-go/v2/models"
+)
+
+// stateValues holds the values returned by the state handlers of the states set up for
+// the current interaction, by state name, for the expressions of FromProviderState
+// matchers in messages
+type stateValues struct {
+	mu     sync.Mutex
+	values map[string]models.ProviderStateResponse
+}
+
+// set records the values returned by the setup of a state
+func (s *stateValues) set(state string, values models.ProviderStateResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.values == nil {
+		s.values = make(map[string]models.ProviderStateResponse)
+	}
+	s.values[state] = values
+}
+
+// remove forgets the values of a state that has been torn down
+func (s *stateValues) remove(state string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.values, state)
+}
+
+// apply adds the values returned by the state handlers to the parameters of the states,
+// taking precedence over parameters of the same name given by the pact
+func (s *stateValues) apply(states []models.ProviderState) []models.ProviderState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res := make([]models.ProviderState, len(states))
+	for i, state := range states {
+		res[i] = state
+		values, ok := s.values[state.Name]
+		if !ok {
+			continue
+		}
+
+		params := make(map[string]interface{}, len(state.Parameters)+len(values))
+		for k, v := range state.Parameters {
+			params[k] = v
+		}
+		for k, v := range values {
+			params[k] = v
+		}
+		res[i].Parameters = params
+	}
+
+	return res
+}
+
+// withStateValues gives the message handlers the values returned by the state handlers,
+// e.g. the id of an order created by the state setup, as parameters of their states
+func withStateValues(handlers message.Handlers, values *stateValues) message.Handlers {
+	res := make(message.Handlers, len(handlers))
+	for description, handler := range handlers {
+		handler := handler
+		res[description] = func(states []models.ProviderState) (message.Body, message.Metadata, error) {
+			return handler(values.apply(states))
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,35 @@
+package provider
+
+import (
+	"testing"
+
+	"github.com/pact-foundation/pact-go/v2/message"
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWithStateValues(t *testing.T) {
+	values := &stateValues{}
+	var received []models.ProviderState
+	handlers := withStateValues(message.Handlers{
+		"an order shipped event": func(states []models.ProviderState) (message.Body, message.Metadata, error) {
+			received = states
+			return nil, nil, nil
+		},
+	}, values)
+
+	states := []models.ProviderState{
+		{Name: "an order exists", Parameters: map[string]interface{}{"orderId": "from the pact", "status": "shipped"}},
+		{Name: "a customer exists"},
+	}
+
+	values.set("an order exists", models.ProviderStateResponse{"orderId": "1234"})
+	handlers["an order shipped event"](states)
+	assert.Equal(t, map[string]interface{}{"orderId": "1234", "status": "shipped"}, received[0].Parameters)
+	assert.Nil(t, received[1].Parameters)
+	assert.Equal(t, "from the pact", states[0].Parameters["orderId"])
+
+	values.remove("an order exists")
+	handlers["an order shipped event"](states)
+	assert.Equal(t, states, received)
+}
@@ -0,0 +1,206 @@
+package provider
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+)
+
+// GenerateProviderStub writes a provider verification test for the pact to outDir, as
+// <provider>_provider_test.go, to start the provider's verification code from the
+// consumer's contract. The test verifies the pact with a state handler stub for each
+// provider state and a producer stub for each asynchronous message, named after them,
+// each with a TODO to implement. The package is named after outDir.
+func GenerateProviderStub(pactFile, outDir string) error {
+	p, err := pactfile.Read(pactFile)
+	if err != nil {
+		return err
+	}
+	path, err := filepath.Abs(pactFile)
+	if err != nil {
+		return err
+	}
+	dir, err := filepath.Abs(outDir)
+	if err != nil {
+		return err
+	}
+
+	source, err := providerStub(p, filepath.ToSlash(path), packageName(filepath.Base(dir)))
+	if err != nil {
+		return err
+	}
+
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	file := filepath.Join(dir, strings.ToLower(identifier(p.Provider.Name, "provider"))+"_provider_test.go")
+	log.Println("[DEBUG] writing provider verification stub", file)
+
+	return ioutil.WriteFile(file, source, 0644)
+}
+
+// stubHandler is a generated state handler or message producer
+type stubHandler struct {
+	Key  string
+	Name string
+}
+
+type stubData struct {
+	Package  string
+	PactFile string
+	Provider string
+	HTTP     bool
+	States   []stubHandler
+	Messages []stubHandler
+}
+
+var providerStubTemplate = template.Must(template.New("stub").Funcs(template.FuncMap{"quote": strconv.Quote}).Parse(`package {{.Package}}
+
+// Provider verification of {{.PactFile}}, generated by provider.GenerateProviderStub.
+// Implement each TODO to verify the provider against the contract.
+
+import (
+	"testing"
+{{if .Messages}}
+	"github.com/pact-foundation/pact-go/v2/message"{{end}}{{if or .States .Messages}}
+	"github.com/pact-foundation/pact-go/v2/models"{{end}}
+	"github.com/pact-foundation/pact-go/v2/provider"
+)
+
+func TestProvider(t *testing.T) {
+	provider.NewVerifier().VerifyProvider(t, provider.VerifyRequest{
+		Provider: {{quote .Provider}},
+{{- if .HTTP}}
+		// TODO: start the provider and set its URL
+		ProviderBaseURL: "http://localhost:8080",
+{{- end}}
+		PactFiles: []string{ {{- quote .PactFile -}} },
+{{- if .States}}
+		StateHandlers: models.StateHandlers{
+{{- range .States}}
+			{{quote .Key}}: {{.Name}},
+{{- end}}
+		},
+{{- end}}
+{{- if .Messages}}
+		MessageHandlers: message.Handlers{
+{{- range .Messages}}
+			{{quote .Key}}: {{.Name}},
+{{- end}}
+		},
+{{- end}}
+	})
+}
+{{range .States}}
+// {{.Name}} sets up the provider state {{quote .Key}}
+func {{.Name}}(setup bool, state models.ProviderState) (models.ProviderStateResponse, error) {
+	// TODO: set up the state, or tear it down if setup is false
+	return nil, nil
+}
+{{end}}
+{{- range .Messages}}
+// {{.Name}} produces the message {{quote .Key}}
+func {{.Name}}(states []models.ProviderState) (message.Body, message.Metadata, error) {
+	// TODO: return the message the provider sends, with its metadata
+	return nil, nil, nil
+}
+{{end}}`))
+
+// providerStub renders the provider verification test for the pact
+func providerStub(p *pactfile.Pact, pactFile string, pkg string) ([]byte, error) {
+	data := stubData{
+		Package:  pkg,
+		PactFile: pactFile,
+		Provider: p.Provider.Name,
+	}
+
+	names := make(map[string]bool)
+	name := func(prefix, key string) string {
+		base := prefix + identifier(key, "")
+		n := base
+		for i := 2; names[n]; i++ {
+			n = fmt.Sprintf("%s%d", base, i)
+		}
+		names[n] = true
+
+		return n
+	}
+
+	states := make(map[string]bool)
+	for _, i := range p.AllInteractions() {
+		for _, s := range i.ProviderStates {
+			if !states[s.Name] {
+				states[s.Name] = true
+				data.States = append(data.States, stubHandler{Key: s.Name, Name: name("state", s.Name)})
+			}
+		}
+
+		_, hasContents := i.Raw["contents"]
+		_, hasRequest := i.Raw["request"]
+		switch {
+		case i.Type == "Asynchronous/Messages" || (i.Type == "" && hasContents && !hasRequest):
+			data.Messages = append(data.Messages, stubHandler{Key: i.Description, Name: name("produce", i.Description)})
+		case i.Type == "Synchronous/HTTP" || (i.Type == "" && hasRequest):
+			data.HTTP = true
+		}
+	}
+
+	var out bytes.Buffer
+	if err := providerStubTemplate.Execute(&out, data); err != nil {
+		return nil, err
+	}
+
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("unable to generate the provider stub: %v", err)
+	}
+
+	return source, nil
+}
+
+// identifier converts a description such as "a user event" into a Go identifier such
+// as "AUserEvent", or fallback if it has no letters or digits
+func identifier(s string, fallback string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range s {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if r > unicode.MaxASCII {
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+
+	if b.Len() == 0 {
+		return fallback
+	}
+
+	return b.String()
+}
+
+// packageName converts a directory name into a Go package name
+func packageName(dir string) string {
+	name := strings.ToLower(identifier(dir, ""))
+	if name == "" || unicode.IsDigit(rune(name[0])) || name == "main" {
+		return "provider"
+	}
+
+	return name
+}
@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGenerateProviderStub(t *testing.T) {
+	dir := t.TempDir()
+	pactFile := filepath.Join(dir, "consumer-user service.json")
+	err := ioutil.WriteFile(pactFile, []byte(`{
+  "consumer": {"name": "consumer"},
+  "provider": {"name": "user service"},
+  "interactions": [
+    {
+      "type": "Asynchronous/Messages",
+      "description": "a user event",
+      "providerStates": [{"name": "a user exists"}, {"name": "the account is active"}],
+      "contents": {"content": {"id": 1}}
+    },
+    {
+      "type": "Asynchronous/Messages",
+      "description": "a user-event",
+      "providerStates": [{"name": "a user exists"}],
+      "contents": {"content": {"id": 2}}
+    },
+    {
+      "type": "Synchronous/HTTP",
+      "description": "a request for a user",
+      "request": {"method": "GET", "path": "/users/1"},
+      "response": {"status": 200}
+    }
+  ]
+}`), 0644)
+	assert.NoError(t, err)
+
+	outDir := filepath.Join(dir, "userservice")
+	assert.NoError(t, GenerateProviderStub(pactFile, outDir))
+
+	file := filepath.Join(outDir, "userservice_provider_test.go")
+	source, err := ioutil.ReadFile(file)
+	assert.NoError(t, err)
+
+	f, err := parser.ParseFile(token.NewFileSet(), file, source, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, "userservice", f.Name.Name)
+
+	var funcs []string
+	for _, obj := range f.Scope.Objects {
+		funcs = append(funcs, obj.Name)
+	}
+	assert.ElementsMatch(t, []string{
+		"TestProvider",
+		"stateAUserExists",
+		"stateTheAccountIsActive",
+		"produceAUserEvent",
+		"produceAUserEvent2",
+	}, funcs)
+	assert.Contains(t, string(source), `"a user-event": produceAUserEvent2,`)
+	assert.Contains(t, string(source), `ProviderBaseURL: "http://localhost:8080",`)
+
+	assert.Error(t, GenerateProviderStub(filepath.Join(dir, "missing.json"), outDir))
+}
@@ -102,12 +102,16 @@ func (v *Verifier) verifyProviderRaw(request VerifyRequest, writer outputWriter)
 		m = append(m, beforeEachMiddleware(request.BeforeEach))
 	}
 
-	if len(request.StateHandlers) > 0 {
-		m = append(m, stateHandlerMiddleware(request.StateHandlers, request.AfterEach))
+	// The hooks are run on the state change requests, so these are answered by the proxy
+	// whenever a hook is given
+	values := &stateValues{}
+	handlesStates := len(request.StateHandlers) > 0 || request.BeforeEach != nil || request.AfterEach != nil
+	if handlesStates {
+		m = append(m, stateHandlerMiddleware(request.StateHandlers, request.AfterEach, values))
 	}
 
 	if len(request.MessageHandlers) > 0 {
-		m = append(m, message.CreateMessageHandler(request.MessageHandlers))
+		m = append(m, message.CreateMessageHandler(withStateValues(request.MessageHandlers, values)))
 	}
 
 	if request.RequestFilter != nil {
@@ -145,7 +149,7 @@ func (v *Verifier) verifyProviderRaw(request VerifyRequest, writer outputWriter)
 
 	// Backwards compatibility, setup old provider states URL if given
 	// Otherwise point to proxy
-	if request.ProviderStatesSetupURL == "" && len(request.StateHandlers) > 0 {
+	if request.ProviderStatesSetupURL == "" && handlesStates {
 		request.ProviderStatesSetupURL = fmt.Sprintf("http://localhost:%d%s", port, providerStatesSetupPath)
 	}
 
@@ -167,7 +171,19 @@ func (v *Verifier) verifyProviderRaw(request VerifyRequest, writer outputWriter)
 
 	log.Println("[DEBUG] pact provider verification")
 
-	return request.Verify(v.handle, writer)
+	err = request.Verify(v.handle, writer)
+
+	if request.AfterSuite != nil {
+		log.Println("[DEBUG] executing after suite hook")
+		if hookErr := request.AfterSuite(); hookErr != nil {
+			log.Println("[ERROR] error executing after suite hook:", hookErr)
+			if err == nil {
+				err = fmt.Errorf("the AfterSuite hook failed: %v", hookErr)
+			}
+		}
+	}
+
+	return err
 }
 
 // VerifyProvider accepts an instance of `*testing.T`
@@ -245,8 +261,9 @@ func getStateFromRequest(r *http.Request) (stateHandlerAction, error) {
 //
 // statehandler accepts a state object from the verifier and executes
 // any state handlers associated with the provider.
-// It will not execute further middleware if it is the designted "state" request
-func stateHandlerMiddleware(stateHandlers models.StateHandlers, afterEach Hook) proxy.Middleware {
+// It will not execute further middleware if it is the designted "state" request.
+// The values returned by the state handlers are recorded in values for message handlers.
+func stateHandlerMiddleware(stateHandlers models.StateHandlers, afterEach Hook, values *stateValues) proxy.Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			if r.URL.Path == providerStatesSetupPath {
@@ -290,6 +307,9 @@ func stateHandlerMiddleware(stateHandlers models.StateHandlers, afterEach Hook)
 
 				if !stateFound {
 					log.Printf("[WARN] no state handler found for state: %v", state.State)
+					if !runAfterEach(w, state, afterEach) {
+						return
+					}
 				} else {
 					// Execute state handler
 					res, err := sf(state.Action == "setup", models.ProviderState{Name: state.State, Parameters: state.Params})
@@ -300,14 +320,14 @@ func stateHandlerMiddleware(stateHandlers models.StateHandlers, afterEach Hook)
 						return
 					}
 
-					if state.Action == "teardown" && afterEach != nil {
-						err := afterEach()
+					if state.Action == "setup" && res != nil {
+						values.set(state.State, res)
+					} else if state.Action == "teardown" {
+						values.remove(state.State)
+					}
 
-						if err != nil {
-							log.Printf("[ERROR] after each hook for test errored: %v", err)
-							w.WriteHeader(http.StatusInternalServerError)
-							return
-						}
+					if !runAfterEach(w, state, afterEach) {
+						return
 					}
 
 					// Return provider state values for generator
@@ -342,6 +362,22 @@ func stateHandlerMiddleware(stateHandlers models.StateHandlers, afterEach Hook)
 	}
 }
 
+// runAfterEach runs the AfterEach hook on the teardown of a state, answering the request
+// with an error and returning false if it fails
+func runAfterEach(w http.ResponseWriter, state stateHandlerAction, afterEach Hook) bool {
+	if state.Action != "teardown" || afterEach == nil {
+		return true
+	}
+
+	if err := afterEach(); err != nil {
+		log.Printf("[ERROR] after each hook for test errored: %v", err)
+		w.WriteHeader(http.StatusInternalServerError)
+		return false
+	}
+
+	return true
+}
+
 // Use this to wait for a port to be running prior
 // to running tests.
 func WaitForPort(port int, network string, address string, timeoutDuration time.Duration, message string) error {
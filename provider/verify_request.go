@@ -136,10 +136,14 @@ type VerifyRequest struct {
 	// e.g. setup temporary tokens, prepare data
 	BeforeEach Hook
 
-	// AfterEach allows you to configure your provider prior to the test execution
+	// AfterEach allows you to configure your provider after the individual test execution
 	// e.g. reset the database state
 	AfterEach Hook
 
+	// AfterSuite is run once verification is complete, whether or not it passed
+	// e.g. stop services started for the verification
+	AfterSuite Hook
+
 	// RequestFilter is a piece of middleware that will intercept requests/responses
 	// from the provider in order to modify it. This is useful in situations where
 	// you need to override a value due to time sensitivity - such as a OAuth Bearer
@@ -203,7 +207,10 @@ func (v *VerifyRequest) validate(handle *native.Verifier) error {
 
 	handle.SetVerificationOptions(v.DisableSSLVerification, v.RequestTimeout.Milliseconds())
 
-	if v.PublishVerificationResults && v.ProviderVersion != "" {
+	if v.PublishVerificationResults {
+		if v.ProviderVersion == "" {
+			return errors.New("'ProviderVersion' must be supplied if 'PublishVerificationResults' is set")
+		}
 		handle.SetPublishOptions(v.ProviderVersion, v.BuildURL, v.ProviderTags, v.ProviderBranch)
 	}
 
@@ -25,6 +25,20 @@ func TestVerifyRequestValidate(t *testing.T) {
 				ProviderStatesSetupURL: "http://localhost:8080/setup",
 				ProviderVersion:        "1.0.0",
 			}, err: false},
+			{name: "publishing results without a version", request: &VerifyRequest{
+				PactURLs:                   []string{"http://localhost:1234/path/to/pact"},
+				ProviderBaseURL:            "http://localhost:8080",
+				PublishVerificationResults: true,
+			}, err: true},
+			{name: "publishing results", request: &VerifyRequest{
+				PactURLs:                   []string{"http://localhost:1234/path/to/pact"},
+				ProviderBaseURL:            "http://localhost:8080",
+				PublishVerificationResults: true,
+				ProviderVersion:            "1.0.0",
+				ProviderBranch:             "main",
+				ProviderTags:               []string{"prod"},
+				BuildURL:                   "https://ci.example.com/builds/1",
+			}, err: false},
 			{name: "no base URL provided", request: &VerifyRequest{
 				PactURLs: []string{"http://localhost:1234/path/to/pact"},
 			}, err: true, panic: true},
@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"context"
 	"crypto/tls"
 	"fmt"
 	"log"
@@ -55,6 +56,16 @@ func loggingMiddleware(next http.Handler) http.Handler {
 	})
 }
 
+type originalHostKey struct{}
+
+// hostMiddleware records the host of the incoming request, so that a host set by
+// the other middleware (e.g. a RequestFilter) is sent to the target in its place
+func hostMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), originalHostKey{}, r.Host)))
+	})
+}
+
 // chainHandlers takes a set of middleware and joins them together
 // into a single Middleware, making it much simpler to compose middleware
 // together
@@ -94,7 +105,7 @@ func HTTPReverseProxy(options Options) (int, error) {
 		}
 	}
 
-	wrapper := chainHandlers(append(options.Middleware, loggingMiddleware)...)
+	wrapper := chainHandlers(append(append([]Middleware{hostMiddleware}, options.Middleware...), loggingMiddleware)...)
 
 	log.Println("[DEBUG] starting reverse proxy on port", port)
 	go http.ListenAndServe(fmt.Sprintf(":%d", port), wrapper(proxy))
@@ -155,7 +166,9 @@ func createProxy(target *url.URL, ignorePrefix string) *httputil.ReverseProxy {
 			log.Println("[DEBUG] incoming request", req.URL)
 			req.URL.Scheme = target.Scheme
 			req.URL.Host = target.Host
-			req.Host = target.Host
+			if original, ok := req.Context().Value(originalHostKey{}).(string); !ok || original == req.Host {
+				req.Host = target.Host
+			}
 
 			req.URL.Path = singleJoiningSlash(target.Path, req.URL.Path)
 			log.Println("[DEBUG] outgoing request to target", req.URL)
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"testing"
 )
 
@@ -89,3 +90,32 @@ func TestHTTPReverseProxy(t *testing.T) {
 		t.Errorf("want non-zero port, got %v", port)
 	}
 }
+
+func TestCreateProxy_Host(t *testing.T) {
+	var hosts []string
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts = append(hosts, r.Host)
+	}))
+	defer target.Close()
+
+	u, _ := url.Parse(target.URL)
+	proxy := createProxy(u, "/__setup")
+	rewrite := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/rewritten" {
+				r.Host = "api.example.com"
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+	handler := chainHandlers(hostMiddleware, rewrite)(proxy)
+
+	for _, path := range []string{"/users", "/rewritten"} {
+		req := httptest.NewRequest("GET", "http://localhost:8080"+path, nil)
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	if len(hosts) != 2 || hosts[0] != u.Host || hosts[1] != "api.example.com" {
+		t.Errorf("want hosts [%s api.example.com], got %v", u.Host, hosts)
+	}
+}
@@ -0,0 +1,353 @@
+package stub
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/pact-foundation/pact-go/v2/matchers"
+	"github.com/pact-foundation/pact-go/v2/message"
+	"github.com/pact-foundation/pact-go/v2/models"
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+)
+
+// serveHTTP answers the request with the response of the first interaction it matches
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	r.Body.Close()
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	for _, i := range s.interactions {
+		request, _ := i.Raw["request"].(map[string]interface{})
+		if !matches(i, request, r, body) {
+			continue
+		}
+
+		log.Printf("[DEBUG] stub request %s %s matched '%s'", r.Method, r.URL.Path, i.Description)
+		if err = writeResponse(w, i.Raw["response"]); err != nil {
+			log.Printf("[ERROR] unable to write the response of '%s': %v", i.Description, err)
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+		return
+	}
+
+	unmatched := fmt.Sprintf("%s %s", r.Method, r.URL.RequestURI())
+	log.Printf("[INFO] stub request %s did not match any interaction", unmatched)
+	s.mu.Lock()
+	s.requests = append(s.requests, unmatched)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error": fmt.Sprintf("no interaction matched the request %s", unmatched),
+	})
+}
+
+// matches reports whether the request matches the request of the interaction
+func matches(i *pactfile.Interaction, expected map[string]interface{}, r *http.Request, body []byte) bool {
+	if method, _ := expected["method"].(string); !strings.EqualFold(method, r.Method) {
+		return false
+	}
+
+	rules, _ := expected["matchingRules"].(map[string]interface{})
+	if rules == nil {
+		rules, _ = i.Raw["matchingRules"].(map[string]interface{})
+	}
+
+	path, _ := expected["path"].(string)
+	if !matchesValue(path, r.URL.Path, rulesFor(rules, "path", "")) {
+		return false
+	}
+
+	for name, values := range queryValues(expected["query"]) {
+		if !matchesValues(values, r.URL.Query()[name], rulesFor(rules, "query", name)) {
+			return false
+		}
+	}
+
+	if headers, ok := expected["headers"].(map[string]interface{}); ok {
+		for name, v := range headers {
+			if !matchesValues(stringValues(v), r.Header.Values(name), rulesFor(rules, "header", name)) {
+				return false
+			}
+		}
+	}
+
+	for _, part := range i.Parts() {
+		if part.Name == "request.body" {
+			return matchesBody(part, body)
+		}
+	}
+
+	return true
+}
+
+// matchesBody compares the body of the request with the expected body and its matching rules
+func matchesBody(part pactfile.Part, body []byte) bool {
+	if text, ok := part.Content.(string); ok && !json.Valid(body) {
+		return text == string(body)
+	}
+
+	mismatches, err := matchers.Compare(part.Template(), body)
+	if err != nil {
+		return false
+	}
+
+	return len(mismatches) == 0
+}
+
+// matchesValues compares the values of a query parameter or header with the expected values,
+// which must all be matched in order
+func matchesValues(expected []string, actual []string, rules []map[string]interface{}) bool {
+	if len(actual) < len(expected) {
+		return false
+	}
+
+	for n, e := range expected {
+		if !matchesValue(e, actual[n], rules) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// matchesValue compares a value with its expected value, or with a regex matching rule
+func matchesValue(expected string, actual string, rules []map[string]interface{}) bool {
+	for _, rule := range rules {
+		if pattern, ok := rule["regex"].(string); ok {
+			matched, err := regexp.MatchString(pattern, actual)
+			return err == nil && matched
+		}
+		if rule["match"] == "type" {
+			return true
+		}
+	}
+
+	// header values may carry parameters, e.g. application/json; charset=utf-8
+	return expected == actual || strings.HasPrefix(actual, expected+";")
+}
+
+// rulesFor returns the matching rules of a request path, query parameter or header,
+// supporting both the V2 ("$.query.name") and V3+ ("query": {"name": {"matchers": ...}})
+// layouts
+func rulesFor(rules map[string]interface{}, category string, name string) []map[string]interface{} {
+	var res []map[string]interface{}
+
+	if c, ok := rules[category].(map[string]interface{}); ok {
+		def := c
+		if name != "" {
+			// header names are case insensitive
+			def = nil
+			for k, v := range c {
+				if strings.EqualFold(k, name) {
+					def, _ = v.(map[string]interface{})
+				}
+			}
+		}
+		matchers, _ := def["matchers"].([]interface{})
+		for _, m := range matchers {
+			if r, ok := m.(map[string]interface{}); ok {
+				res = append(res, r)
+			}
+		}
+
+		return res
+	}
+
+	key := "$." + category
+	if category == "header" {
+		key = "$.headers"
+	}
+	if name != "" {
+		key += "." + name
+	}
+	for k, v := range rules {
+		if strings.EqualFold(k, key) {
+			if r, ok := v.(map[string]interface{}); ok {
+				res = append(res, r)
+			}
+		}
+	}
+
+	return res
+}
+
+// queryValues returns the expected query parameters, given as a string by V2 pacts
+func queryValues(query interface{}) map[string][]string {
+	res := make(map[string][]string)
+
+	switch q := query.(type) {
+	case string:
+		values, _ := url.ParseQuery(q)
+		for k, v := range values {
+			res[k] = v
+		}
+	case map[string]interface{}:
+		for k, v := range q {
+			res[k] = stringValues(v)
+		}
+	}
+
+	return res
+}
+
+// stringValues returns a header or query value, which may be a single value or a list
+func stringValues(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		values := make([]string, 0, len(t))
+		for _, item := range t {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	default:
+		return []string{fmt.Sprint(t)}
+	}
+}
+
+// writeResponse writes the response of an interaction
+func writeResponse(w http.ResponseWriter, response interface{}) error {
+	res, _ := response.(map[string]interface{})
+
+	contentType := ""
+	if headers, ok := res["headers"].(map[string]interface{}); ok {
+		for name, v := range headers {
+			for _, value := range stringValues(v) {
+				w.Header().Add(name, value)
+			}
+			if strings.EqualFold(name, "Content-Type") {
+				contentType = stringValues(v)[0]
+			}
+		}
+	}
+
+	status := http.StatusOK
+	if s, ok := res["status"].(float64); ok {
+		status = int(s)
+	}
+
+	b, bodyContentType, err := content(res["body"], contentType)
+	if err != nil {
+		return err
+	}
+	if contentType == "" && bodyContentType != "" {
+		w.Header().Set("Content-Type", bodyContentType)
+	}
+
+	w.WriteHeader(status)
+	w.Write(b)
+
+	return nil
+}
+
+// content returns the bytes and content type of a body or message contents. V4 pacts
+// wrap their bodies with their content type and encoding.
+func content(body interface{}, contentType string) ([]byte, string, error) {
+	if body == nil {
+		return nil, "", nil
+	}
+
+	encoded := interface{}(false)
+	if wrapped, ok := body.(map[string]interface{}); ok {
+		if c, ok := wrapped["content"]; ok {
+			if ct, ok := wrapped["contentType"].(string); ok {
+				body, contentType, encoded = c, ct, wrapped["encoded"]
+			}
+		}
+	}
+
+	text, isText := body.(string)
+	switch {
+	case isText && encoded == "base64":
+		b, err := base64.StdEncoding.DecodeString(text)
+		return b, contentType, err
+	case isText && (encoded == "json" || !isJSON(contentType)):
+		if contentType == "" {
+			contentType = "text/plain"
+		}
+		return []byte(text), contentType, nil
+	}
+
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	b, err := json.Marshal(body)
+
+	return b, contentType, err
+}
+
+// isJSON reports whether the content type is JSON, or unknown
+func isJSON(contentType string) bool {
+	return contentType == "" || strings.Contains(contentType, "json")
+}
+
+// messageHandlers reproduce the contents and metadata of the messages
+func messageHandlers(messages []*pactfile.Interaction) message.Handlers {
+	handlers := make(message.Handlers, len(messages))
+
+	for _, m := range messages {
+		m := m
+		if _, ok := handlers[m.Description]; ok {
+			log.Printf("[WARN] the message '%s' is defined more than once, only the first is served", m.Description)
+			continue
+		}
+
+		handlers[m.Description] = func([]models.ProviderState) (message.Body, message.Metadata, error) {
+			metadata := message.Metadata{}
+			if md, ok := m.Raw["metadata"].(map[string]interface{}); ok {
+				for k, v := range md {
+					metadata[k] = v
+				}
+			}
+
+			contentType, _ := metadata["contentType"].(string)
+			b, contentType, err := content(m.Raw["contents"], contentType)
+			if err != nil {
+				return nil, nil, err
+			}
+			if _, ok := metadata["contentType"]; !ok && contentType != "" {
+				metadata["contentType"] = contentType
+			}
+
+			return b, metadata, nil
+		}
+	}
+
+	return handlers
+}
+
+// cors allows the stub to be called from any origin, answering preflight requests
+func cors(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			origin = "*"
+		}
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Set("Access-Control-Allow-Methods", "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS")
+			if headers := r.Header.Get("Access-Control-Request-Headers"); headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
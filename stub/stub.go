@@ -0,0 +1,187 @@
+// Package stub serves the interactions of pact files as a stub API, so that frontends
+// and integration environments can run against the contracts defined by their
+// consumer tests, outside of test execution.
+//
+// Each HTTP request is answered with the response of the first interaction whose
+// request it matches, using the matching rules of the pact. Messages are served with
+// the message protocol used for provider verification: a POST to /__messages with the
+// description of the message returns its contents, with its metadata given in the
+// Pact-Message-Metadata header.
+package stub
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"path/filepath"
+	"sync"
+
+	"github.com/pact-foundation/pact-go/v2/broker"
+	"github.com/pact-foundation/pact-go/v2/message"
+	"github.com/pact-foundation/pact-go/v2/pactfile"
+)
+
+// Config describes the pacts to serve and the stub server they are served by
+type Config struct {
+	// PactFiles are individual pact files to serve
+	PactFiles []string
+
+	// PactDirs are directories whose *.json pact files are served
+	PactDirs []string
+
+	// Brokers are contracts to fetch from a broker, see broker.FetchLatest
+	Brokers []broker.Config
+
+	// Host the stub server listens on. Defaults to 127.0.0.1
+	Host string
+
+	// Port the stub server listens on. Leave blank to have one assigned
+	// automatically by the OS
+	Port int
+
+	// CORS allows the stub to be called from a browser on any origin, answering
+	// its preflight requests
+	CORS bool
+}
+
+// Server is a running stub server
+type Server struct {
+	config       Config
+	interactions []*pactfile.Interaction
+	server       *http.Server
+	listener     net.Listener
+
+	mu       sync.Mutex
+	requests []string
+}
+
+// Start loads the pacts, and serves them until the server is closed
+func Start(config Config) (*Server, error) {
+	if config.Host == "" {
+		config.Host = "127.0.0.1"
+	}
+
+	pacts, err := load(config)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Server{
+		config: config,
+	}
+	var messages []*pactfile.Interaction
+	for _, p := range pacts {
+		for _, i := range p.AllInteractions() {
+			switch {
+			case isMessage(i):
+				messages = append(messages, i)
+			case isHTTP(i):
+				s.interactions = append(s.interactions, i)
+			default:
+				log.Printf("[DEBUG] skipping interaction '%s' of type %s", i.Description, i.Type)
+			}
+		}
+	}
+
+	s.listener, err = net.Listen("tcp", fmt.Sprintf("%s:%d", config.Host, config.Port))
+	if err != nil {
+		return nil, fmt.Errorf("unable to start the stub server: %v", err)
+	}
+	s.config.Port = s.listener.Addr().(*net.TCPAddr).Port
+
+	var handler http.Handler = http.HandlerFunc(s.serveHTTP)
+	if len(messages) > 0 {
+		handler = message.CreateMessageHandler(messageHandlers(messages))(handler)
+	}
+	if config.CORS {
+		handler = cors(handler)
+	}
+	s.server = &http.Server{Handler: handler}
+
+	log.Printf("[INFO] serving %d interaction(s) and %d message(s) from %d pact(s) on %s", len(s.interactions), len(messages), len(pacts), s.URL())
+	go s.server.Serve(s.listener)
+
+	return s, nil
+}
+
+// URL of the stub server
+func (s *Server) URL() string {
+	return fmt.Sprintf("http://%s", s.listener.Addr())
+}
+
+// Port the stub server is listening on
+func (s *Server) Port() int {
+	return s.config.Port
+}
+
+// Unmatched returns the requests, as "<method> <path>", that did not match any interaction
+func (s *Server) Unmatched() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string{}, s.requests...)
+}
+
+// Close stops the stub server
+func (s *Server) Close() error {
+	return s.server.Close()
+}
+
+// load reads the pact files and fetches the contracts from the brokers
+func load(config Config) ([]*pactfile.Pact, error) {
+	files := append([]string{}, config.PactFiles...)
+	for _, dir := range config.PactDirs {
+		matches, err := filepath.Glob(filepath.Join(dir, "*.json"))
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no pact files found in %s", dir)
+		}
+		files = append(files, matches...)
+	}
+
+	var pacts []*pactfile.Pact
+	for _, file := range files {
+		p, err := pactfile.Read(file)
+		if err != nil {
+			return nil, err
+		}
+		pacts = append(pacts, p)
+	}
+
+	for _, b := range config.Brokers {
+		p, err := broker.FetchLatest(b)
+		if err != nil {
+			return nil, err
+		}
+		pacts = append(pacts, p)
+	}
+
+	if len(pacts) == 0 {
+		return nil, fmt.Errorf("no pacts to serve, PactFiles, PactDirs or Brokers must be specified")
+	}
+
+	return pacts, nil
+}
+
+// isMessage reports whether the interaction is an asynchronous message
+func isMessage(i *pactfile.Interaction) bool {
+	if i.Type != "" {
+		return i.Type == "Asynchronous/Messages"
+	}
+	_, ok := i.Raw["contents"]
+
+	return ok
+}
+
+// isHTTP reports whether the interaction is an HTTP request and response
+func isHTTP(i *pactfile.Interaction) bool {
+	if i.Type != "" {
+		return i.Type == "Synchronous/HTTP"
+	}
+	_, ok := i.Raw["request"].(map[string]interface{})
+
+	return ok
+}
@@ -0,0 +1,190 @@
+package stub
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var v3Pact = `{
+  "consumer": {"name": "web"},
+  "provider": {"name": "users"},
+  "interactions": [
+    {
+      "description": "a request for a user",
+      "request": {
+        "method": "GET",
+        "path": "/users/10",
+        "query": {"fields": ["name"]},
+        "headers": {"Accept": "application/json"},
+        "matchingRules": {
+          "path": {"matchers": [{"match": "regex", "regex": "^/users/[0-9]+$"}]}
+        }
+      },
+      "response": {
+        "status": 200,
+        "headers": {"Content-Type": "application/json"},
+        "body": {"id": 10, "name": "billy"}
+      }
+    },
+    {
+      "description": "a request to create a user",
+      "request": {
+        "method": "POST",
+        "path": "/users",
+        "body": {"name": "billy"},
+        "matchingRules": {
+          "body": {"$.name": {"matchers": [{"match": "type"}]}}
+        }
+      },
+      "response": {
+        "status": 201
+      }
+    }
+  ],
+  "metadata": {"pactSpecification": {"version": "3.0.0"}}
+}`
+
+var v4Pact = `{
+  "consumer": {"name": "web"},
+  "provider": {"name": "events"},
+  "interactions": [
+    {
+      "type": "Synchronous/HTTP",
+      "description": "a health check",
+      "request": {"method": "GET", "path": "/health"},
+      "response": {
+        "status": 200,
+        "body": {"content": "ok", "contentType": "text/plain", "encoded": false}
+      }
+    },
+    {
+      "type": "Asynchronous/Messages",
+      "description": "a user created event",
+      "contents": {"content": {"id": 10}, "contentType": "application/json", "encoded": false},
+      "metadata": {"topic": "users"}
+    }
+  ],
+  "metadata": {"pactSpecification": {"version": "4.0"}}
+}`
+
+func startStub(t *testing.T, config Config) *Server {
+	dir := t.TempDir()
+	for name, pact := range map[string]string{"web-users.json": v3Pact, "web-events.json": v4Pact} {
+		assert.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(pact), 0644))
+	}
+	config.PactDirs = []string{dir}
+
+	s, err := Start(config)
+	assert.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+func do(t *testing.T, method string, url string, body string, headers map[string]string) (*http.Response, string) {
+	req, err := http.NewRequest(method, url, strings.NewReader(body))
+	assert.NoError(t, err)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer res.Body.Close()
+	b, _ := ioutil.ReadAll(res.Body)
+
+	return res, string(b)
+}
+
+func TestStub_HTTP(t *testing.T) {
+	s := startStub(t, Config{})
+
+	res, body := do(t, "GET", s.URL()+"/users/42?fields=name", "", map[string]string{"Accept": "application/json"})
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+	assert.JSONEq(t, `{"id": 10, "name": "billy"}`, body)
+
+	res, _ = do(t, "GET", s.URL()+"/users/42", "", map[string]string{"Accept": "application/json"})
+	assert.Equal(t, 404, res.StatusCode)
+
+	res, _ = do(t, "POST", s.URL()+"/users", `{"name": "sally"}`, nil)
+	assert.Equal(t, 201, res.StatusCode)
+
+	res, _ = do(t, "POST", s.URL()+"/users", `{"name": 1}`, nil)
+	assert.Equal(t, 404, res.StatusCode)
+
+	res, body = do(t, "GET", s.URL()+"/health", "", nil)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "text/plain", res.Header.Get("Content-Type"))
+	assert.Equal(t, "ok", body)
+
+	assert.Equal(t, []string{"GET /users/42", "POST /users"}, s.Unmatched())
+}
+
+func TestStub_Messages(t *testing.T) {
+	s := startStub(t, Config{})
+
+	res, body := do(t, "POST", s.URL()+"/__messages", `{"description": "a user created event"}`, nil)
+	assert.Equal(t, 200, res.StatusCode)
+	assert.JSONEq(t, `{"id": 10}`, body)
+	assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+
+	metadata, err := base64.StdEncoding.DecodeString(res.Header.Get("Pact-Message-Metadata"))
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"topic": "users", "contentType": "application/json"}`, string(metadata))
+
+	res, _ = do(t, "POST", s.URL()+"/__messages", `{"description": "an unknown event"}`, nil)
+	assert.Equal(t, 404, res.StatusCode)
+}
+
+func TestStub_CORS(t *testing.T) {
+	s := startStub(t, Config{CORS: true})
+
+	res, _ := do(t, "OPTIONS", s.URL()+"/users/10", "", map[string]string{
+		"Origin":                         "http://localhost:3000",
+		"Access-Control-Request-Method":  "GET",
+		"Access-Control-Request-Headers": "accept",
+	})
+	assert.Equal(t, 204, res.StatusCode)
+	assert.Equal(t, "http://localhost:3000", res.Header.Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "accept", res.Header.Get("Access-Control-Allow-Headers"))
+
+	res, _ = do(t, "GET", s.URL()+"/health", "", map[string]string{"Origin": "http://localhost:3000"})
+	assert.Equal(t, 200, res.StatusCode)
+	assert.Equal(t, "http://localhost:3000", res.Header.Get("Access-Control-Allow-Origin"))
+}
+
+func TestContent(t *testing.T) {
+	b, contentType, err := content(map[string]interface{}{"content": base64.StdEncoding.EncodeToString([]byte{1, 2}), "contentType": "application/octet-stream", "encoded": "base64"}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, b)
+	assert.Equal(t, "application/octet-stream", contentType)
+
+	b, contentType, err = content(map[string]interface{}{"id": 1}, "")
+	assert.NoError(t, err)
+	assert.True(t, json.Valid(b))
+	assert.True(t, bytes.Contains(b, []byte(`"id":1`)))
+	assert.Equal(t, "application/json", contentType)
+
+	b, contentType, err = content("hello", "text/plain")
+	assert.NoError(t, err)
+	assert.Equal(t, "hello", string(b))
+	assert.Equal(t, "text/plain", contentType)
+}
+
+func TestStart_NoPacts(t *testing.T) {
+	_, err := Start(Config{})
+	assert.Error(t, err)
+
+	_, err = Start(Config{PactDirs: []string{t.TempDir()}})
+	assert.Error(t, err)
+}
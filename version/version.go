@@ -4,6 +4,7 @@ import (
 	"log"
 
 	"github.com/pact-foundation/pact-go/v2/internal/checker"
+	"github.com/pact-foundation/pact-go/v2/internal/native"
 )
 
 // CheckVersion checks if the currently installed version is within semver range
@@ -16,3 +17,9 @@ func CheckVersion() {
 
 	log.Println("[DEBUG] version check completed")
 }
+
+// NativeVersion returns the version of the native pact library in use. It is also
+// recorded in the metadata of each pact file written
+func NativeVersion() string {
+	return native.Version()
+}